@@ -0,0 +1,518 @@
+// Package agent runs the collectors on a host and ships their events to a
+// central server
+package agent
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config configures an agent
+type Config struct {
+	ID                string // unique agent ID, the host name by default
+	Host              string // host name events are stored under
+	Labels            models.Labels
+	ServerURL         string
+	Token             string // bearer token expected by the server, if any
+	BatchInterval     time.Duration
+	HeartbeatInterval time.Duration
+	SpoolDir          string
+	SpoolMaxBytes     int64
+	OpensFailedOnly   bool
+	RunqlatPerPIDNS   bool
+	RunqlatCgroup     string
+	OffCPUInterval    time.Duration
+	ListenInterval    time.Duration
+	FSSlowerThreshold time.Duration
+}
+
+// Agent collects events into batches and ships them to the server,
+// spooling them on disk while the server is unreachable
+type Agent struct {
+	config     Config
+	client     *Client
+	spool      *Spool
+	attributor enrich.Attributor
+
+	processes       *collector.ProcessCollector
+	connections     *collector.NetworkCollector
+	disk            *collector.DiskCollector
+	cpuProfiles     *collector.CPUProfileCollector
+	tcpSessions     *collector.TCPLifeCollector
+	syscalls        *collector.SyscallCollector
+	processSyscalls *collector.ProcessSyscallCollector
+	exits           *collector.ExitCollector
+	opens           *collector.OpenCollector
+	runq            *collector.RunqCollector
+	offCPU          *collector.OffCPUCollector
+	tcpRetransmits  *collector.TCPRetransCollector
+	tcpDrops        *collector.TCPDropCollector
+	tcpRTT          *collector.TCPRTTCollector
+	accepts         *collector.TCPAcceptCollector
+	listeners       *collector.ListenCollector
+	tcpStates       *collector.TCPStatesCollector
+	dnsLookups      *collector.GethostlatencyCollector
+	oomKills        *collector.OomkillCollector
+	signals         *collector.KillsnoopCollector
+	fsSlower        []*collector.FSSlowerCollector // one per mounted filesystem type, set up by Start
+	stateAttributor *enrich.StateAttributor
+	hostnames       *enrich.HostnameResolver
+
+	bccVersion string
+
+	mu         sync.Mutex // serializes delivery
+	registered bool
+	batchSeq   int
+	batchBase  string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(config Config, attributor enrich.Attributor) (*Agent, error) {
+	spool, err := NewSpool(config.SpoolDir, config.SpoolMaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Agent{
+		config:          config,
+		client:          NewClient(config.ServerURL, config.Token, 30*time.Second),
+		spool:           spool,
+		attributor:      attributor,
+		processes:       collector.NewProcessCollector(),
+		connections:     collector.NewNetworkCollector(),
+		disk:            collector.NewDiskCollector(),
+		cpuProfiles:     collector.NewCPUProfileCollector(),
+		tcpSessions:     collector.NewTCPLifeCollector(),
+		syscalls:        collector.NewSyscallCollector(),
+		processSyscalls: collector.NewProcessSyscallCollector(),
+		exits:           collector.NewExitCollector(),
+		opens:           collector.NewOpenCollector(config.OpensFailedOnly),
+		runq:            collector.NewRunqCollector(config.RunqlatPerPIDNS, config.RunqlatCgroup),
+		offCPU:          collector.NewOffCPUCollector(config.OffCPUInterval),
+		tcpRetransmits:  collector.NewTCPRetransCollector(),
+		tcpDrops:        collector.NewTCPDropCollector(),
+		tcpRTT:          collector.NewTCPRTTCollector(),
+		accepts:         collector.NewTCPAcceptCollector(),
+		listeners:       collector.NewListenCollector(config.ListenInterval),
+		tcpStates:       collector.NewTCPStatesCollector(),
+		dnsLookups:      collector.NewGethostlatencyCollector(),
+		oomKills:        collector.NewOomkillCollector(),
+		signals:         collector.NewKillsnoopCollector(),
+		stateAttributor: enrich.NewStateAttributor(attributor),
+		hostnames:       enrich.NewHostnameResolver(),
+		batchBase:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// Start starts the collectors and the delivery and heartbeat loops
+func (a *Agent) Start() {
+	collectors := []struct {
+		name  string
+		start func() error
+	}{
+		{"execsnoop", a.processes.Start},
+		{"tcpconnect", a.connections.Start},
+		{"biolatency", a.disk.Start},
+		{"profile", a.cpuProfiles.Start},
+		{"tcplife", a.tcpSessions.Start},
+		{"syscount", a.syscalls.Start},
+		{"syscount per process", a.processSyscalls.Start},
+		{"exitsnoop", a.exits.Start},
+		{"opensnoop", a.opens.Start},
+		{"runqlat", a.runq.Start},
+		{"offcputime", a.offCPU.Start},
+		{"tcpretrans", a.tcpRetransmits.Start},
+		{"tcpdrop", a.tcpDrops.Start},
+		{"tcprtt", a.tcpRTT.Start},
+		{"tcpaccept", a.accepts.Start},
+		{"listen", a.listeners.Start},
+		{"tcpstates", a.tcpStates.Start},
+		{"gethostlatency", a.dnsLookups.Start},
+		{"oomkill", a.oomKills.Start},
+		{"killsnoop", a.signals.Start},
+	}
+	for _, c := range collectors {
+		if err := c.start(); err != nil {
+			log.Printf("Failed to start %s collector: %v", c.name, err)
+		}
+	}
+
+	filesystems, err := collector.DetectFilesystems()
+	if err != nil {
+		log.Printf("Failed to detect mounted filesystems: %v", err)
+	}
+	for _, fs := range filesystems {
+		c := collector.NewFSSlowerCollector(fs, a.config.FSSlowerThreshold)
+		if err := c.Start(); err != nil {
+			log.Printf("Failed to start %sslower collector: %v", fs, err)
+			continue
+		}
+		a.fsSlower = append(a.fsSlower, c)
+	}
+
+	a.bccVersion = collector.BCCVersion()
+
+	// Batches are spooled until registration succeeds, which is retried on
+	// every delivery
+	a.mu.Lock()
+	err = a.register(a.ctx)
+	a.mu.Unlock()
+	if err != nil {
+		log.Printf("Agent %s not registered yet: %v", a.config.ID, err)
+	} else {
+		// Report the collector status right away rather than with the first
+		// periodic heartbeat
+		a.heartbeat(a.ctx)
+	}
+
+	a.wg.Add(2)
+	go a.shipPeriodically()
+	go a.heartbeatPeriodically()
+
+	log.Printf("Agent %s started, shipping to %s every %s", a.config.ID, a.config.ServerURL, a.config.BatchInterval)
+}
+
+// Stop stops the collectors and ships or spools what they collected last
+func (a *Agent) Stop() {
+	a.cancel()
+	a.wg.Wait()
+
+	a.processes.Stop()
+	a.connections.Stop()
+	a.disk.Stop()
+	a.cpuProfiles.Stop()
+	a.tcpSessions.Stop()
+	a.syscalls.Stop()
+	a.processSyscalls.Stop()
+	a.exits.Stop()
+	a.opens.Stop()
+	a.runq.Stop()
+	a.offCPU.Stop()
+	a.tcpRetransmits.Stop()
+	a.tcpDrops.Stop()
+	a.tcpRTT.Stop()
+	a.accepts.Stop()
+	a.listeners.Stop()
+	a.tcpStates.Stop()
+	a.dnsLookups.Stop()
+	a.oomKills.Stop()
+	a.signals.Stop()
+	for _, c := range a.fsSlower {
+		c.Stop()
+	}
+
+	// The server may be gone already, in which case the batch is spooled
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	a.ship(ctx, a.collect(time.Now()))
+	log.Printf("Agent %s stopped", a.config.ID)
+}
+
+func (a *Agent) shipPeriodically() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.ship(a.ctx, a.collect(now))
+		}
+	}
+}
+
+func (a *Agent) heartbeatPeriodically() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.heartbeat(a.ctx)
+		}
+	}
+}
+
+// collect drains the collectors into a batch, attributing and timestamping
+// events as the local services do
+func (a *Agent) collect(now time.Time) models.Batch {
+	a.batchSeq++
+	batch := models.Batch{
+		ID:              a.batchBase + "-" + strconv.Itoa(a.batchSeq),
+		AgentID:         a.config.ID,
+		CreatedAt:       now,
+		Processes:       a.processes.GetEvents(),
+		Connections:     a.connections.GetEvents(),
+		DiskLatency:     a.disk.GetEvents(),
+		CPUProfiles:     a.cpuProfiles.GetEvents(),
+		TCPSessions:     a.tcpSessions.GetEvents(),
+		Syscalls:        a.syscalls.GetEvents(),
+		ProcessSyscalls: a.processSyscalls.GetEvents(),
+		Exits:           a.exits.GetEvents(),
+		FileOpens:       a.opens.GetEvents(),
+		RunqLatency:     a.runq.GetEvents(),
+		OffCPUStacks:    a.offCPU.GetEvents(),
+		TCPRetransmits:  a.tcpRetransmits.GetEvents(),
+		TCPDrops:        a.tcpDrops.GetEvents(),
+		TCPRTT:          a.tcpRTT.GetEvents(),
+		Inbound:         a.accepts.GetEvents(),
+		TCPStates:       a.tcpStates.GetEvents(),
+		DNSLookups:      a.dnsLookups.GetEvents(),
+		OOMKills:        a.oomKills.GetEvents(),
+		Signals:         a.signals.GetEvents(),
+	}
+	if inventory, ok := a.listeners.GetEvents(); ok {
+		batch.Listeners = &inventory
+	}
+	for _, c := range a.fsSlower {
+		batch.SlowFileOps = append(batch.SlowFileOps, c.GetEvents()...)
+	}
+
+	for i := range batch.Processes {
+		e := &batch.Processes[i]
+		pid, _ := strconv.Atoi(e.PID)
+		ppid, _ := strconv.Atoi(e.PPID)
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(pid, ppid)
+	}
+	// Lookups go first, so the connections of the batch are named after them
+	for i := range batch.DNSLookups {
+		e := &batch.DNSLookups[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	a.hostnames.AddLookups(batch.DNSLookups)
+	for i := range batch.Connections {
+		e := &batch.Connections[i]
+		pid, _ := strconv.Atoi(e.PID)
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(pid, 0)
+	}
+	a.hostnames.ResolveConnections(batch.Connections)
+	for i := range batch.DiskLatency {
+		batch.DiskLatency[i].Timestamp = now
+	}
+	for i := range batch.CPUProfiles {
+		e := &batch.CPUProfiles[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	for i := range batch.TCPSessions {
+		e := &batch.TCPSessions[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	a.hostnames.ResolveSessions(batch.TCPSessions)
+	for i := range batch.Syscalls {
+		batch.Syscalls[i].Timestamp = now
+	}
+	for i := range batch.ProcessSyscalls {
+		batch.ProcessSyscalls[i].Timestamp = now
+	}
+	for i := range batch.Exits {
+		e := &batch.Exits[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, e.PPID)
+	}
+	for i := range batch.OOMKills {
+		e := &batch.OOMKills[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+		e.TriggerCgroup = a.attributor.Attribute(e.TriggerPID, 0).CgroupPath
+	}
+	for i := range batch.Signals {
+		e := &batch.Signals[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	for i := range batch.SlowFileOps {
+		e := &batch.SlowFileOps[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	for i := range batch.FileOpens {
+		e := &batch.FileOpens[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	for i := range batch.RunqLatency {
+		batch.RunqLatency[i].Timestamp = now
+	}
+	for i := range batch.TCPRTT {
+		batch.TCPRTT[i].Timestamp = now
+	}
+	for i := range batch.Inbound {
+		e := &batch.Inbound[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+	}
+	if batch.Listeners != nil {
+		for i := range batch.Listeners.Sockets {
+			s := &batch.Listeners.Sockets[i]
+			s.Attribution = a.attributor.Attribute(s.PID, 0)
+		}
+	}
+	for i := range batch.OffCPUStacks {
+		e := &batch.OffCPUStacks[i]
+		e.Timestamp = now
+		e.Attribution = a.attributor.Attribute(0, 0)
+	}
+
+	// Retransmits and drops are attributed to the processes owning their sockets
+	enrich.AttributeRetransmits(a.attributor, batch.TCPRetransmits)
+	for i := range batch.TCPRetransmits {
+		batch.TCPRetransmits[i].Timestamp = now
+	}
+	enrich.AttributeDrops(a.attributor, batch.TCPDrops)
+	for i := range batch.TCPDrops {
+		batch.TCPDrops[i].Timestamp = now
+	}
+	a.stateAttributor.Attribute(batch.TCPStates)
+	for i := range batch.TCPStates {
+		batch.TCPStates[i].Timestamp = now
+	}
+	return batch
+}
+
+// ship delivers the spooled batches and then batch, in order. Whatever
+// cannot be delivered stays in or goes to the spool.
+func (a *Agent) ship(ctx context.Context, batch models.Batch) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delivered := a.flushSpool(ctx)
+	if batch.Len() == 0 {
+		return
+	}
+
+	payload, err := EncodeBatch(batch)
+	if err != nil {
+		log.Printf("Error encoding batch: %v", err)
+		return
+	}
+
+	if delivered {
+		err = a.send(ctx, payload)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, ErrBatchRejected) {
+			log.Printf("Dropping batch of %d events: %v", batch.Len(), err)
+			return
+		}
+		log.Printf("Error shipping batch of %d events, spooling it: %v", batch.Len(), err)
+	}
+	if err := a.spool.Put(payload); err != nil {
+		log.Printf("Error spooling batch of %d events: %v", batch.Len(), err)
+	}
+}
+
+// flushSpool sends spooled batches oldest first and reports whether the
+// spool is empty afterwards. Batches the server rejects are dropped, so
+// they do not hold up the ones behind them. Callers hold a.mu.
+func (a *Agent) flushSpool(ctx context.Context) bool {
+	sent := 0
+	defer func() {
+		if sent > 0 {
+			log.Printf("Delivered %d spooled batches", sent)
+		}
+	}()
+
+	for {
+		name, payload, ok, err := a.spool.Oldest()
+		if err != nil {
+			log.Printf("Error reading spool: %v", err)
+			return false
+		}
+		if !ok {
+			return true
+		}
+		err = a.send(ctx, payload)
+		if errors.Is(err, ErrBatchRejected) {
+			log.Printf("Dropping spooled batch %s: %v", name, err)
+		} else if err != nil {
+			return false
+		}
+		if err := a.spool.Remove(name); err != nil {
+			log.Printf("Error removing spooled batch %s: %v", name, err)
+			return false
+		}
+		if err == nil {
+			sent++
+		}
+	}
+}
+
+// send delivers an encoded batch, registering first if needed. Callers hold a.mu.
+func (a *Agent) send(ctx context.Context, payload []byte) error {
+	if !a.registered {
+		if err := a.register(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := a.client.Send(ctx, payload)
+	if errors.Is(err, ErrNotRegistered) {
+		// The server lost its registration, e.g. with its database
+		a.registered = false
+		if err := a.register(ctx); err != nil {
+			return err
+		}
+		err = a.client.Send(ctx, payload)
+	}
+	return err
+}
+
+// register announces the agent to the server. Callers hold a.mu.
+func (a *Agent) register(ctx context.Context) error {
+	err := a.client.Register(ctx, models.AgentRegistration{
+		ID:         a.config.ID,
+		Host:       a.config.Host,
+		Labels:     a.config.Labels,
+		Kernel:     collector.KernelRelease(),
+		BCCVersion: a.bccVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("registration failed: %w", err)
+	}
+	a.registered = true
+	log.Printf("Agent %s registered with %s", a.config.ID, a.config.ServerURL)
+	return nil
+}
+
+func (a *Agent) heartbeat(ctx context.Context) {
+	err := a.client.Heartbeat(ctx, a.config.ID, models.AgentHeartbeat{
+		Spooled:    a.spool.Len(),
+		Collectors: collector.Statuses(),
+	})
+	if errors.Is(err, ErrNotRegistered) {
+		a.mu.Lock()
+		a.registered = false
+		err = a.register(ctx)
+		a.mu.Unlock()
+	}
+	if err != nil {
+		log.Printf("Heartbeat failed: %v", err)
+	}
+}
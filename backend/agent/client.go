@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"ebpf-dashboard/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotRegistered is returned when the server does not know the agent
+var ErrNotRegistered = errors.New("agent not registered")
+
+// ErrBatchRejected is returned when the server refuses a batch for good,
+// e.g. because it is too large or malformed, so resending it cannot succeed
+var ErrBatchRejected = errors.New("batch rejected")
+
+// statusError is a response outside 2xx other than 404
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "unexpected status " + e.status
+}
+
+// Client talks to the server
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Register announces the agent to the server
+func (c *Client) Register(ctx context.Context, reg models.AgentRegistration) error {
+	body, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/api/agents/register", body, "")
+}
+
+// Heartbeat tells the server the agent is alive
+func (c *Client) Heartbeat(ctx context.Context, id string, hb models.AgentHeartbeat) error {
+	body, err := json.Marshal(hb)
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/api/agents/"+url.PathEscape(id)+"/heartbeat", body, "")
+}
+
+// Send delivers a batch encoded by EncodeBatch. Network errors, 5xx and
+// 429 are worth retrying; a 400, 413 or 422 is wrapped in ErrBatchRejected.
+func (c *Client) Send(ctx context.Context, payload []byte) error {
+	err := c.post(ctx, "/api/agents/batches", payload, "gzip")
+	var se *statusError
+	if errors.As(err, &se) {
+		switch se.code {
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+			return fmt.Errorf("%w: %v", ErrBatchRejected, err)
+		}
+	}
+	return err
+}
+
+func (c *Client) post(ctx context.Context, path string, body []byte, encoding string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotRegistered
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+	return nil
+}
+
+// EncodeBatch encodes a batch as gzip-compressed JSON
+func EncodeBatch(batch models.Batch) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(batch); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
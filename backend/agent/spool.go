@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const spoolSuffix = ".batch.gz"
+
+// Spool keeps encoded batches on disk while the server is unreachable.
+// Batches are replayed oldest first; once the spool exceeds its size limit
+// the oldest batches are dropped.
+type Spool struct {
+	dir      string
+	maxBytes int64
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewSpool creates dir if needed and returns a spool of at most maxBytes
+func NewSpool(dir string, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Spool{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// Put writes an encoded batch to the spool
+func (s *Spool) Put(payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Names sort in the order batches were spooled
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, spoolSuffix)
+	tmp := filepath.Join(s.dir, "."+name)
+	if err := os.WriteFile(tmp, payload, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+
+	s.trim()
+	return nil
+}
+
+// Oldest returns the name and content of the oldest spooled batch. ok is
+// false if the spool is empty.
+func (s *Spool) Oldest() (name string, payload []byte, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names, err := s.names()
+	if err != nil || len(names) == 0 {
+		return "", nil, false, err
+	}
+	payload, err = os.ReadFile(filepath.Join(s.dir, names[0]))
+	if err != nil {
+		return "", nil, false, err
+	}
+	return names[0], payload, true, nil
+}
+
+// Remove deletes a spooled batch once it was delivered
+func (s *Spool) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.Remove(filepath.Join(s.dir, name))
+}
+
+// Len returns the number of spooled batches
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names, _ := s.names()
+	return len(names)
+}
+
+// names returns the spooled batches, oldest first. Callers hold s.mu.
+func (s *Spool) names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, spoolSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// trim drops the oldest batches while the spool is larger than its limit.
+// Callers hold s.mu.
+func (s *Spool) trim() {
+	names, err := s.names()
+	if err != nil {
+		return
+	}
+
+	sizes := make([]int64, len(names))
+	var total int64
+	for i, name := range names {
+		if info, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	dropped := 0
+	for i := 0; total > s.maxBytes && i < len(names)-1; i++ {
+		if err := os.Remove(filepath.Join(s.dir, names[i])); err != nil {
+			continue
+		}
+		total -= sizes[i]
+		dropped++
+	}
+	if dropped > 0 {
+		log.Printf("Spool is full, dropped %d oldest batches", dropped)
+	}
+}
@@ -47,12 +47,14 @@ func (c *DiskCollector) Start() error {
 	}
 
 	if err := c.cmd.Start(); err != nil {
+		markFailed(NameBiolatency, err)
 		log.Printf("Failed to start biolatency: %v", err)
 		return err
 	}
 
 	c.running = true
 	log.Println("biolatency collector started")
+	markStarted(NameBiolatency)
 
 	// Read output in a goroutine
 	go func() {
@@ -61,6 +63,7 @@ func (c *DiskCollector) Start() error {
 			c.running = false
 			c.mu.Unlock()
 			log.Println("biolatency collector stopped")
+			markStopped(NameBiolatency)
 		}()
 
 		reader := bufio.NewReader(stdout)
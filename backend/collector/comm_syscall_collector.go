@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// commSyscallProgram counts syscalls by command name and syscall tracepoint
+// and prints the counts every 5 seconds
+const commSyscallProgram = `tracepoint:syscalls:sys_enter_* { @[comm, probe] = count(); }
+interval:s:5 { print(@); clear(@); }`
+
+const syscallProbePrefix = "tracepoint:syscalls:sys_enter_"
+
+// CommSyscallCollector runs bpftrace to record which syscalls each command
+// makes. syscount can only break counts down by syscall or by process, not both.
+type CommSyscallCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.CommSyscallStat
+	mu      sync.Mutex
+	running bool
+}
+
+func NewCommSyscallCollector() *CommSyscallCollector {
+	return &CommSyscallCollector{
+		// One interval prints a line per command and syscall, which easily
+		// exceeds the 100 events the other collectors buffer
+		events: make(chan models.CommSyscallStat, 5000),
+	}
+}
+
+func (c *CommSyscallCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "bpftrace", "-e", commSyscallProgram)
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameCommSyscalls, err)
+		log.Printf("Failed to start bpftrace: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("bpftrace syscall collector started")
+	markStarted(NameCommSyscalls)
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("bpftrace syscall collector stopped")
+			markStopped(NameCommSyscalls)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("bpftrace read error: %v", err)
+				}
+				break
+			}
+
+			stat, ok := parseCommSyscallLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- stat:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseCommSyscallLine parses a map entry of the form
+// "@[COMM, tracepoint:syscalls:sys_enter_NAME]: COUNT". The comm may contain
+// commas, so the probe is split off at the last one.
+func parseCommSyscallLine(line string) (models.CommSyscallStat, bool) {
+	if !strings.HasPrefix(line, "@[") {
+		return models.CommSyscallStat{}, false
+	}
+
+	closing := strings.LastIndex(line, "]: ")
+	if closing < 0 {
+		return models.CommSyscallStat{}, false
+	}
+	key := line[2:closing]
+	count, err := strconv.Atoi(strings.TrimSpace(line[closing+3:]))
+	if err != nil {
+		return models.CommSyscallStat{}, false
+	}
+
+	sep := strings.LastIndex(key, ", ")
+	if sep < 0 || !strings.HasPrefix(key[sep+2:], syscallProbePrefix) {
+		return models.CommSyscallStat{}, false
+	}
+
+	return models.CommSyscallStat{
+		Comm:    key[:sep],
+		Syscall: strings.TrimPrefix(key[sep+2:], syscallProbePrefix),
+		Count:   count,
+	}, true
+}
+
+func (c *CommSyscallCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *CommSyscallCollector) GetEvents() []models.CommSyscallStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.CommSyscallStat
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
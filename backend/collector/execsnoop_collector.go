@@ -36,7 +36,7 @@ func (c *ProcessCollector) Start() error {
 	c.cancel = cancel
 
 	// Start execsnoop in continuous mode (no sudo needed, app runs with sudo)
-	c.cmd = exec.CommandContext(ctx, "execsnoop", "-T")
+	c.cmd = exec.CommandContext(ctx, "execsnoop", "-T", "-U")
 
 	stdout, err := c.cmd.StdoutPipe()
 	if err != nil {
@@ -44,11 +44,13 @@ func (c *ProcessCollector) Start() error {
 	}
 
 	if err := c.cmd.Start(); err != nil {
+		markFailed(NameExecsnoop, err)
 		return err
 	}
 
 	c.running = true
 	log.Println("execsnoop collector started")
+	markStarted(NameExecsnoop)
 
 	// Read output line by line in a goroutine
 	go func() {
@@ -64,13 +66,16 @@ func (c *ProcessCollector) Start() error {
 				continue
 			}
 
+			// execsnoop -T -U output format: TIME UID PCOMM PID PPID RET ARGS
 			fields := strings.Fields(line)
-			if len(fields) >= 5 {
+			if len(fields) >= 6 {
 				event := models.ProcessEvent{
 					Time: fields[0],
-					PID:  fields[2],
-					Comm: fields[1],
-					Args: strings.Join(fields[5:], " "),
+					UID:  fields[1],
+					PID:  fields[3],
+					PPID: fields[4],
+					Comm: fields[2],
+					Args: strings.Join(fields[6:], " "),
 				}
 
 				// Send to channel (non-blocking)
@@ -86,6 +91,7 @@ func (c *ProcessCollector) Start() error {
 		c.running = false
 		c.mu.Unlock()
 		log.Println("execsnoop collector stopped")
+		markStopped(NameExecsnoop)
 	}()
 
 	return nil
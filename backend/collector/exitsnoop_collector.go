@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type ExitCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.ProcessExit
+	mu      sync.Mutex
+	running bool
+}
+
+// exitsnoop -t output format: TIME PCOMM PID PPID TID AGE(s) EXIT_CODE
+// PCOMM may contain spaces, so it is matched lazily up to the numeric columns.
+var exitLineRe = regexp.MustCompile(`^(\S+)\s+(.+?)\s+(\d+)\s+(\d+)\s+(\d+)\s+([\d.]+)\s+(.+)$`)
+
+// EXIT_CODE is "0", "code N" or "signal N (NAME)" optionally followed by ", core dumped"
+var exitSignalRe = regexp.MustCompile(`signal (\d+) \((\w+)\)`)
+
+func NewExitCollector() *ExitCollector {
+	return &ExitCollector{
+		events: make(chan models.ProcessExit, 100),
+	}
+}
+
+func (c *ExitCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	// Start exitsnoop in continuous mode with timestamps
+	// Use stdbuf to disable output buffering
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "exitsnoop", "-t")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameExitsnoop, err)
+		log.Printf("Failed to start exitsnoop: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("exitsnoop collector started")
+	markStarted(NameExitsnoop)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("exitsnoop collector stopped")
+			markStopped(NameExitsnoop)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("exitsnoop read error: %v", err)
+				}
+				break
+			}
+
+			line = strings.TrimSpace(line)
+
+			// Skip header and empty lines
+			if line == "" || strings.HasPrefix(line, "TIME") {
+				continue
+			}
+
+			event, ok := parseExitLine(line)
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseExitLine(line string) (models.ProcessExit, bool) {
+	matches := exitLineRe.FindStringSubmatch(line)
+	if len(matches) != 8 {
+		return models.ProcessExit{}, false
+	}
+
+	pid, _ := strconv.Atoi(matches[3])
+	ppid, _ := strconv.Atoi(matches[4])
+	tid, _ := strconv.Atoi(matches[5])
+	age, _ := strconv.ParseFloat(matches[6], 64)
+
+	event := models.ProcessExit{
+		Time:       matches[1],
+		Comm:       matches[2],
+		PID:        pid,
+		PPID:       ppid,
+		TID:        tid,
+		AgeSeconds: age,
+	}
+
+	status := strings.TrimSpace(matches[7])
+	switch {
+	case strings.HasPrefix(status, "code "):
+		event.ExitCode, _ = strconv.Atoi(strings.TrimPrefix(status, "code "))
+	case strings.HasPrefix(status, "signal "):
+		if sig := exitSignalRe.FindStringSubmatch(status); len(sig) == 3 {
+			event.Signal, _ = strconv.Atoi(sig[1])
+			event.SignalName = sig[2]
+		}
+		event.CoreDumped = strings.Contains(status, "core dumped")
+	}
+
+	return event, true
+}
+
+func (c *ExitCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *ExitCollector) GetEvents() []models.ProcessExit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.ProcessExit
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
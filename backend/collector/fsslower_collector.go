@@ -0,0 +1,201 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FSSlowerCollector struct {
+	fs        string
+	tool      string
+	threshold time.Duration
+	cmd       *exec.Cmd
+	cancel    context.CancelFunc
+	events    chan models.SlowFileOp
+	mu        sync.Mutex
+	running   bool
+}
+
+// fsslowerTools are the tools tracing the operations of each filesystem
+// type, which are reported as their collector names
+var fsslowerTools = map[string]string{
+	"ext4":  NameExt4slower,
+	"xfs":   NameXFSslower,
+	"btrfs": NameBtrfsslower,
+}
+
+// ext4slower, xfsslower and btrfsslower output format:
+// TIME COMM PID T BYTES OFF_KB LAT(ms) FILENAME
+// COMM may contain spaces, so it is matched lazily up to the PID and the
+// single letter operation type. FILENAME is the rest of the line.
+var fsslowerLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\s+(.*?)\s+(\d+)\s+([RWOS])\s+(\d+)\s+(\d+)\s+([\d.]+)\s(.*)$`)
+
+// DetectFilesystems returns the mounted filesystem types that have a tool
+// tracing their slow operations, sorted by name
+func DetectFilesystems() ([]string, error) {
+	mounted, err := procfs.ReadMountTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var filesystems []string
+	for fs := range fsslowerTools {
+		if mounted[fs] {
+			filesystems = append(filesystems, fs)
+		}
+	}
+	sort.Strings(filesystems)
+	return filesystems, nil
+}
+
+// NewFSSlowerCollector returns a collector of the operations on filesystems
+// of type fs taking at least threshold, which is rounded down to
+// milliseconds. fs must be one of those returned by DetectFilesystems.
+func NewFSSlowerCollector(fs string, threshold time.Duration) *FSSlowerCollector {
+	return &FSSlowerCollector{
+		fs:        fs,
+		tool:      fsslowerTools[fs],
+		threshold: threshold,
+		events:    make(chan models.SlowFileOp, 1000),
+	}
+}
+
+func (c *FSSlowerCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	minMS := strconv.FormatInt(c.threshold.Milliseconds(), 10)
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", c.tool, minMS)
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(c.tool, err)
+		log.Printf("Failed to start %s: %v", c.tool, err)
+		return err
+	}
+
+	c.running = true
+	log.Printf("%s collector started", c.tool)
+	markStarted(c.tool)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Printf("%s collector stopped", c.tool)
+			markStopped(c.tool)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("%s read error: %v", c.tool, err)
+				}
+				break
+			}
+
+			event, ok := parseFSSlowerLine(strings.TrimRight(line, "\n"))
+			if !ok {
+				continue
+			}
+			event.Filesystem = c.fs
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseFSSlowerLine(line string) (models.SlowFileOp, bool) {
+	matches := fsslowerLineRe.FindStringSubmatch(line)
+	if len(matches) != 9 {
+		return models.SlowFileOp{}, false
+	}
+
+	pid, _ := strconv.Atoi(matches[3])
+	bytes, _ := strconv.ParseInt(matches[5], 10, 64)
+	offset, _ := strconv.ParseInt(matches[6], 10, 64)
+	latency, _ := strconv.ParseFloat(matches[7], 64)
+
+	return models.SlowFileOp{
+		Time:      matches[1],
+		PID:       pid,
+		Comm:      matches[2],
+		Op:        matches[4],
+		Bytes:     bytes,
+		OffsetKB:  offset,
+		LatencyMS: latency,
+		File:      matches[8],
+	}, true
+}
+
+func (c *FSSlowerCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *FSSlowerCollector) GetEvents() []models.SlowFileOp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.SlowFileOp
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type GethostlatencyCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.DNSLookup
+	mu      sync.Mutex
+	running bool
+}
+
+// gethostlatency output format:
+// TIME PID COMM LATms HOST
+// COMM may contain spaces, so the fields after it are anchored at the end.
+var hostLatencyLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\s+(\d+)\s+(.*?)\s+([\d.]+)\s+(\S+)$`)
+
+func NewGethostlatencyCollector() *GethostlatencyCollector {
+	return &GethostlatencyCollector{
+		events: make(chan models.DNSLookup, 1000),
+	}
+}
+
+func (c *GethostlatencyCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "gethostlatency")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameGethostlatency, err)
+		log.Printf("Failed to start gethostlatency: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("gethostlatency collector started")
+	markStarted(NameGethostlatency)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("gethostlatency collector stopped")
+			markStopped(NameGethostlatency)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("gethostlatency read error: %v", err)
+				}
+				break
+			}
+
+			event, ok := parseHostLatencyLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseHostLatencyLine(line string) (models.DNSLookup, bool) {
+	matches := hostLatencyLineRe.FindStringSubmatch(line)
+	if len(matches) != 6 {
+		return models.DNSLookup{}, false
+	}
+
+	pid, _ := strconv.Atoi(matches[2])
+	latency, _ := strconv.ParseFloat(matches[4], 64)
+
+	return models.DNSLookup{
+		Time:      matches[1],
+		PID:       pid,
+		Comm:      matches[3],
+		Hostname:  strings.ToLower(strings.TrimSuffix(matches[5], ".")),
+		LatencyMS: latency,
+	}, true
+}
+
+func (c *GethostlatencyCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *GethostlatencyCollector) GetEvents() []models.DNSLookup {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.DNSLookup
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
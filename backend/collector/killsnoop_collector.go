@@ -0,0 +1,184 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+type KillsnoopCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.Signal
+	mu      sync.Mutex
+	running bool
+}
+
+// killsnoop output format: TIME PID COMM SIG TPID RESULT
+// COMM may contain spaces, so the fields after it are anchored at the end.
+// TPID is negative for a process group, RESULT is 0 or the negated errno.
+var killsnoopLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\s+(\d+)\s+(.*?)\s+(\d+)\s+(-?\d+)\s+(-?\d+)$`)
+
+func NewKillsnoopCollector() *KillsnoopCollector {
+	return &KillsnoopCollector{
+		events: make(chan models.Signal, 1000),
+	}
+}
+
+func (c *KillsnoopCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "killsnoop")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameKillsnoop, err)
+		log.Printf("Failed to start killsnoop: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("killsnoop collector started")
+	markStarted(NameKillsnoop)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("killsnoop collector stopped")
+			markStopped(NameKillsnoop)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("killsnoop read error: %v", err)
+				}
+				break
+			}
+
+			event, ok := parseKillsnoopLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Name the target right away, a killed process is gone by the
+			// time the event is processed
+			if event.PID > 0 {
+				if stat, err := procfs.ReadStat(event.PID); err == nil {
+					event.Comm = stat.Comm
+				}
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseKillsnoopLine(line string) (models.Signal, bool) {
+	matches := killsnoopLineRe.FindStringSubmatch(line)
+	if len(matches) != 7 {
+		return models.Signal{}, false
+	}
+
+	senderPID, _ := strconv.Atoi(matches[2])
+	sig, _ := strconv.Atoi(matches[4])
+	pid, _ := strconv.Atoi(matches[5])
+	result, _ := strconv.Atoi(matches[6])
+
+	// Signal 0 only checks whether the target exists, which many programs
+	// do constantly
+	if sig == 0 {
+		return models.Signal{}, false
+	}
+
+	event := models.Signal{
+		Time:       matches[1],
+		PID:        pid,
+		SenderPID:  senderPID,
+		SenderComm: matches[3],
+		Signal:     sig,
+		SignalName: unix.SignalName(syscall.Signal(sig)),
+		Errno:      -result,
+	}
+	if event.Errno != 0 {
+		event.ErrnoName = unix.ErrnoName(syscall.Errno(event.Errno))
+		if event.ErrnoName == "" {
+			event.ErrnoName = strconv.Itoa(event.Errno)
+		}
+	}
+	return event, true
+}
+
+func (c *KillsnoopCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *KillsnoopCollector) GetEvents() []models.Signal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.Signal
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
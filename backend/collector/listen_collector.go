@@ -0,0 +1,164 @@
+package collector
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ListenCollector takes an inventory of the listening TCP sockets from
+// /proc/net/tcp and tcp6 at every interval
+type ListenCollector struct {
+	cancel   context.CancelFunc
+	interval time.Duration
+	latest   *models.ListenInventory
+	mu       sync.Mutex
+	running  bool
+}
+
+// NewListenCollector returns a collector scanning the listening sockets
+// every interval
+func NewListenCollector(interval time.Duration) *ListenCollector {
+	return &ListenCollector{interval: interval}
+}
+
+func (c *ListenCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	// Fail early if the socket tables cannot be read at all
+	if _, err := procfs.ReadTCPSockets(); err != nil {
+		markFailed(NameListen, err)
+		log.Printf("Failed to read listening sockets: %v", err)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.running = true
+	log.Println("listen collector started")
+	markStarted(NameListen)
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("listen collector stopped")
+			markStopped(NameListen)
+		}()
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.scan()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *ListenCollector) scan() {
+	sockets, err := scanListeners()
+	if err != nil {
+		log.Printf("Error reading listening sockets: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest = &models.ListenInventory{ScannedAt: time.Now(), Sockets: sockets}
+}
+
+// scanListeners returns one socket per listening address and port, with the
+// PID and command of a process holding it, ordered by port and address
+func scanListeners() ([]models.ListeningSocket, error) {
+	sockets, err := procfs.ReadTCPSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	type listenKey struct {
+		addr string
+		port int
+	}
+
+	var owners map[uint64]int
+	seen := make(map[listenKey]bool)
+	var listeners []models.ListeningSocket
+	for _, s := range sockets {
+		key := listenKey{s.LocalAddr.String(), s.LocalPort}
+		if s.State != procfs.TCPListen || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// Only walk the file descriptors of every process once something listens
+		if owners == nil {
+			if owners, err = procfs.SocketInodes(); err != nil {
+				return nil, err
+			}
+		}
+
+		listener := models.ListeningSocket{Addr: key.addr, Port: key.port, UID: s.UID}
+		if pid, ok := owners[s.Inode]; ok {
+			listener.PID = pid
+			if stat, err := procfs.ReadStat(pid); err == nil {
+				listener.Comm = stat.Comm
+			}
+		}
+		listeners = append(listeners, listener)
+	}
+
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Addr < listeners[j].Addr
+	})
+	return listeners, nil
+}
+
+func (c *ListenCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.running = false
+}
+
+// GetEvents returns the inventory taken since the last call, if any
+func (c *ListenCollector) GetEvents() (models.ListenInventory, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.latest == nil {
+		return models.ListenInventory{}, false
+	}
+	inventory := *c.latest
+	c.latest = nil
+	return inventory, true
+}
@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// offcpuRestartDelay is the delay before restarting offcputime after a
+	// run that ended early, doubled for every further one
+	offcpuRestartDelay = time.Second
+	// offcpuMaxQuickExits is how many runs in a row may end early before the
+	// collector gives up
+	offcpuMaxQuickExits = 5
+)
+
+// OffCPUCollector runs offcputime for one interval at a time, since it only
+// prints its stacks when it exits
+type OffCPUCollector struct {
+	cmd      *exec.Cmd
+	ctx      context.Context
+	cancel   context.CancelFunc
+	events   chan models.OffCPUStack
+	interval time.Duration
+	mu       sync.Mutex
+	running  bool
+}
+
+// NewOffCPUCollector returns a collector of off-CPU stacks, summed up over
+// interval by offcputime
+func NewOffCPUCollector(interval time.Duration) *OffCPUCollector {
+	return &OffCPUCollector{
+		// Every interval prints all distinct stacks at once
+		events:   make(chan models.OffCPUStack, 10000),
+		interval: interval,
+	}
+}
+
+func (c *OffCPUCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	stdout, err := c.startRun()
+	if err != nil {
+		markFailed(NameOffcputime, err)
+		log.Printf("Failed to start offcputime: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("offcputime collector started")
+	markStarted(NameOffcputime)
+
+	// Read the output of each run and start the next one
+	ctx := c.ctx
+	go func() {
+		var failed error
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			if failed != nil {
+				log.Printf("offcputime collector failed: %v", failed)
+				markFailed(NameOffcputime, failed)
+				return
+			}
+			log.Println("offcputime collector stopped")
+			markStopped(NameOffcputime)
+		}()
+
+		quickExits := 0
+		delay := offcpuRestartDelay
+		for {
+			started := time.Now()
+			c.read(stdout)
+
+			c.mu.Lock()
+			cmd := c.cmd
+			c.mu.Unlock()
+			waitErr := cmd.Wait()
+			if ctx.Err() != nil {
+				return
+			}
+
+			// A run lasts the whole interval, so one ending early failed,
+			// e.g. on a kernel offcputime does not support. Wait longer
+			// after every such run and give up after a few in a row.
+			if time.Since(started) < c.interval/2 {
+				quickExits++
+				if quickExits >= offcpuMaxQuickExits {
+					failed = fmt.Errorf("offcputime exited early %d times in a row", quickExits)
+					return
+				}
+				log.Printf("offcputime exited after %v (%v), restarting in %v", time.Since(started).Round(time.Millisecond), waitErr, delay)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(delay):
+				}
+				delay *= 2
+			} else {
+				quickExits = 0
+				delay = offcpuRestartDelay
+			}
+
+			c.mu.Lock()
+			if ctx.Err() != nil {
+				c.mu.Unlock()
+				return
+			}
+			stdout, err = c.startRun()
+			c.mu.Unlock()
+			if err != nil {
+				failed = fmt.Errorf("restarting offcputime: %w", err)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// startRun starts offcputime for one interval. c.mu must be held.
+func (c *OffCPUCollector) startRun() (io.Reader, error) {
+	// Folded stacks with a delimiter between user and kernel frames
+	seconds := strconv.Itoa(int(c.interval / time.Second))
+	c.cmd = exec.CommandContext(c.ctx, "offcputime", "-f", "-d", seconds)
+	// offcputime is a Python tool, which buffers output written to a pipe
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return stdout, nil
+}
+
+func (c *OffCPUCollector) read(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("offcputime read error: %v", err)
+			}
+			return
+		}
+
+		stack, ok := parseFoldedLine(strings.TrimRight(line, "\r\n"))
+		if !ok {
+			continue
+		}
+
+		// Send to channel (non-blocking)
+		select {
+		case c.events <- stack:
+		default:
+			// Channel full, skip this event
+		}
+	}
+}
+
+// parseFoldedLine parses a line of folded output, e.g.
+// "sshd;__libc_read;-;entry_SYSCALL_64;...;schedule 4200": the thread name,
+// the frames from the root to the leaf and the off-CPU time in microseconds.
+// Frames may contain spaces, so the value is split off at the last one.
+func parseFoldedLine(line string) (models.OffCPUStack, bool) {
+	i := strings.LastIndexByte(line, ' ')
+	if i <= 0 {
+		return models.OffCPUStack{}, false
+	}
+	total, err := strconv.ParseInt(line[i+1:], 10, 64)
+	if err != nil || total <= 0 {
+		return models.OffCPUStack{}, false
+	}
+
+	comm, stack, _ := strings.Cut(line[:i], ";")
+	return models.OffCPUStack{Comm: comm, Stack: stack, TotalUS: total}, true
+}
+
+func (c *OffCPUCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *OffCPUCollector) GetEvents() []models.OffCPUStack {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.OffCPUStack
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type OomkillCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.OOMKill
+	mu      sync.Mutex
+	running bool
+}
+
+// oomkill output format:
+// TIME Triggered by PID N ("COMM"), OOM kill of PID N ("COMM"), N pages, loadavg: ...
+var oomkillLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2}) Triggered by PID (\d+) \("(.*?)"\), OOM kill of PID (\d+) \("(.*?)"\), (\d+) pages`)
+
+func NewOomkillCollector() *OomkillCollector {
+	return &OomkillCollector{
+		events: make(chan models.OOMKill, 1000),
+	}
+}
+
+func (c *OomkillCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "oomkill")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameOomkill, err)
+		log.Printf("Failed to start oomkill: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("oomkill collector started")
+	markStarted(NameOomkill)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("oomkill collector stopped")
+			markStopped(NameOomkill)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("oomkill read error: %v", err)
+				}
+				break
+			}
+
+			event, ok := parseOomkillLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseOomkillLine(line string) (models.OOMKill, bool) {
+	matches := oomkillLineRe.FindStringSubmatch(line)
+	if len(matches) != 7 {
+		return models.OOMKill{}, false
+	}
+
+	triggerPID, _ := strconv.Atoi(matches[2])
+	pid, _ := strconv.Atoi(matches[4])
+	pages, _ := strconv.ParseInt(matches[6], 10, 64)
+
+	return models.OOMKill{
+		Time:        matches[1],
+		PID:         pid,
+		Comm:        matches[5],
+		TriggerPID:  triggerPID,
+		TriggerComm: matches[3],
+		Pages:       pages,
+	}, true
+}
+
+func (c *OomkillCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *OomkillCollector) GetEvents() []models.OOMKill {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.OOMKill
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
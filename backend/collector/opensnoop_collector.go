@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+type OpenCollector struct {
+	cmd        *exec.Cmd
+	cancel     context.CancelFunc
+	events     chan models.FileOpen
+	failedOnly bool
+	mu         sync.Mutex
+	running    bool
+}
+
+// opensnoop -T -U -e output format: TIME(s) UID PID COMM FD ERR FLAGS PATH
+// COMM may contain spaces, so it is matched lazily up to the numeric columns.
+// FLAGS are octal, PATH is the rest of the line.
+var openLineRe = regexp.MustCompile(`^([\d.]+)\s+(\d+)\s+(\d+)\s+(.+?)\s+(-?\d+)\s+(\d+)\s+([0-7]+)\s(.*)$`)
+
+// openFlags are the open flags reported by name, in the order opensnoop
+// users know them from strace. O_TMPFILE includes O_DIRECTORY and is
+// decoded before it.
+var openFlags = []struct {
+	flag int
+	name string
+}{
+	{unix.O_CREAT, "O_CREAT"},
+	{unix.O_EXCL, "O_EXCL"},
+	{unix.O_NOCTTY, "O_NOCTTY"},
+	{unix.O_TRUNC, "O_TRUNC"},
+	{unix.O_APPEND, "O_APPEND"},
+	{unix.O_NONBLOCK, "O_NONBLOCK"},
+	{unix.O_SYNC, "O_SYNC"}, // includes O_DSYNC
+	{unix.O_DSYNC, "O_DSYNC"},
+	{unix.O_DIRECT, "O_DIRECT"},
+	{kernelLargeFile, "O_LARGEFILE"},
+	{unix.O_DIRECTORY, "O_DIRECTORY"},
+	{unix.O_NOFOLLOW, "O_NOFOLLOW"},
+	{unix.O_NOATIME, "O_NOATIME"},
+	{unix.O_CLOEXEC, "O_CLOEXEC"},
+	{unix.O_PATH, "O_PATH"},
+}
+
+// kernelLargeFile is O_LARGEFILE as the kernel reports it. Go defines it as
+// 0 on architectures where it is implied, but the kernel still sets the
+// generic bit for every open of a 64-bit process.
+var kernelLargeFile = func() int {
+	if unix.O_LARGEFILE != 0 {
+		return unix.O_LARGEFILE
+	}
+	return 0o100000
+}()
+
+// NewOpenCollector returns a collector of file opens, or only of failed
+// ones if failedOnly is set
+func NewOpenCollector(failedOnly bool) *OpenCollector {
+	return &OpenCollector{
+		// Opens are far more frequent than execs or exits
+		events:     make(chan models.FileOpen, 10000),
+		failedOnly: failedOnly,
+	}
+}
+
+func (c *OpenCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	// Timestamps, UIDs and open flags, optionally failed opens only
+	args := []string{"-oL", "opensnoop", "-T", "-U", "-e"}
+	if c.failedOnly {
+		args = append(args, "-x")
+	}
+	c.cmd = exec.CommandContext(ctx, "stdbuf", args...)
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameOpensnoop, err)
+		log.Printf("Failed to start opensnoop: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("opensnoop collector started")
+	markStarted(NameOpensnoop)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("opensnoop collector stopped")
+			markStopped(NameOpensnoop)
+		}()
+
+		reader := bufio.NewReader(stdout)
+		self := os.Getpid()
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("opensnoop read error: %v", err)
+				}
+				break
+			}
+
+			// Paths may end in spaces, so only the newline is trimmed
+			line = strings.TrimRight(line, "\r\n")
+
+			// Skip header and empty lines
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "TIME") {
+				continue
+			}
+
+			event, ok := parseOpenLine(line)
+			if !ok {
+				continue
+			}
+
+			// Attributing and storing opens makes this process open files
+			// itself, which must not be traced in turn
+			if event.PID == self {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseOpenLine(line string) (models.FileOpen, bool) {
+	matches := openLineRe.FindStringSubmatch(line)
+	if len(matches) != 9 {
+		return models.FileOpen{}, false
+	}
+
+	uid, _ := strconv.Atoi(matches[2])
+	pid, _ := strconv.Atoi(matches[3])
+	fd, _ := strconv.Atoi(matches[5])
+	errno, _ := strconv.Atoi(matches[6])
+	flags, err := strconv.ParseInt(matches[7], 8, 64)
+	if err != nil {
+		return models.FileOpen{}, false
+	}
+
+	event := models.FileOpen{
+		Time:      matches[1],
+		UID:       uid,
+		PID:       pid,
+		Comm:      strings.TrimSpace(matches[4]),
+		FD:        fd,
+		Errno:     errno,
+		Flags:     int(flags),
+		FlagNames: openFlagNames(int(flags)),
+		Path:      matches[8],
+	}
+	if errno != 0 {
+		event.ErrnoName = unix.ErrnoName(syscall.Errno(errno))
+		if event.ErrnoName == "" {
+			event.ErrnoName = strconv.Itoa(errno)
+		}
+	}
+
+	return event, true
+}
+
+// openFlagNames decodes open flags like strace does, e.g.
+// O_WRONLY|O_CREAT|O_TRUNC
+func openFlagNames(flags int) string {
+	var names []string
+	switch flags & unix.O_ACCMODE {
+	case unix.O_WRONLY:
+		names = append(names, "O_WRONLY")
+	case unix.O_RDWR:
+		names = append(names, "O_RDWR")
+	default:
+		names = append(names, "O_RDONLY")
+	}
+	rest := flags &^ unix.O_ACCMODE
+
+	if rest&unix.O_TMPFILE == unix.O_TMPFILE {
+		names = append(names, "O_TMPFILE")
+		rest &^= unix.O_TMPFILE
+	}
+	for _, f := range openFlags {
+		if rest&f.flag == f.flag {
+			names = append(names, f.name)
+			rest &^= f.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, "0"+strconv.FormatInt(int64(rest), 8))
+	}
+	return strings.Join(names, "|")
+}
+
+func (c *OpenCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *OpenCollector) GetEvents() []models.FileOpen {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.FileOpen
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -13,6 +13,9 @@ import (
 	"sync"
 )
 
+// ProfileFrequency is the rate profile-bpfcc samples stacks at, in Hz
+const ProfileFrequency = 99
+
 type CPUProfileCollector struct {
 	cmd     *exec.Cmd
 	cancel  context.CancelFunc
@@ -39,7 +42,7 @@ func (c *CPUProfileCollector) Start() error {
 	c.cancel = cancel
 
 	// Start profile-bpfcc: sample at 99 Hz, continuous mode with 5 second intervals
-	c.cmd = exec.CommandContext(ctx, "sudo", "profile-bpfcc", "-F", "99", "5")
+	c.cmd = exec.CommandContext(ctx, "sudo", "profile-bpfcc", "-F", strconv.Itoa(ProfileFrequency), "5")
 
 	stdout, err := c.cmd.StdoutPipe()
 	if err != nil {
@@ -47,12 +50,14 @@ func (c *CPUProfileCollector) Start() error {
 	}
 
 	if err := c.cmd.Start(); err != nil {
+		markFailed(NameProfile, err)
 		log.Printf("Failed to start profile-bpfcc: %v", err)
 		return err
 	}
 
 	c.running = true
 	log.Println("profile-bpfcc collector started")
+	markStarted(NameProfile)
 
 	// Read output in a goroutine
 	go func() {
@@ -61,6 +66,7 @@ func (c *CPUProfileCollector) Start() error {
 			c.running = false
 			c.mu.Unlock()
 			log.Println("profile-bpfcc collector stopped")
+			markStopped(NameProfile)
 		}()
 
 		reader := bufio.NewReader(stdout)
@@ -114,9 +120,12 @@ func (c *CPUProfileCollector) Start() error {
 					stackTrace := strings.Join(stackLines, "\n")
 
 					count, _ := strconv.Atoi(line)
+					comm, pid := parseProfileProcess(processName)
 
 					profile := models.CPUProfile{
 						ProcessName: processName,
+						Comm:        comm,
+						PID:         pid,
 						StackTrace:  stackTrace,
 						SampleCount: count,
 					}
@@ -143,6 +152,19 @@ func (c *CPUProfileCollector) Start() error {
 	return nil
 }
 
+// profileProcessRe matches the process line profile-bpfcc prints below a stack, e.g. "-  bash (1234)"
+var profileProcessRe = regexp.MustCompile(`^-?\s*(.*?)\s*\((\d+)\)$`)
+
+// parseProfileProcess extracts the command name and PID from a process line
+func parseProfileProcess(processName string) (string, int) {
+	matches := profileProcessRe.FindStringSubmatch(processName)
+	if len(matches) != 3 {
+		return "", 0
+	}
+	pid, _ := strconv.Atoi(matches[2])
+	return matches[1], pid
+}
+
 func (c *CPUProfileCollector) Stop() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,203 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type RunqCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	perNS   bool
+	cgroup  string
+	mu      sync.Mutex
+	running bool
+
+	// The buckets of the intervals since the last GetEvents, summed up per
+	// namespace as they are read
+	bucketsMu sync.Mutex
+	buckets   []models.RunqLatency
+	index     map[runqBucketKey]int
+}
+
+type runqBucketKey struct {
+	pidns    int64
+	rangeMin int
+}
+
+// maxRunqBuckets bounds the buckets kept between two calls of GetEvents
+const maxRunqBuckets = 10000
+
+// runqlat prints a histogram per interval, preceded by a "pidns = <inode>"
+// line per namespace with --pidnss. Buckets are given as "min -> max : count".
+var (
+	runqBucketRe = regexp.MustCompile(`(\d+)\s*->\s*(\d+)\s*:\s*(\d+)`)
+	runqPIDNSRe  = regexp.MustCompile(`^pidns\s*=\s*(\d+)`)
+)
+
+// NewRunqCollector returns a collector of run queue latency histograms,
+// one per PID namespace if perNS is set. A non-empty cgroup limits tracing
+// to the threads of that cgroup (a path below /sys/fs/cgroup).
+func NewRunqCollector(perNS bool, cgroup string) *RunqCollector {
+	return &RunqCollector{
+		perNS:  perNS,
+		cgroup: cgroup,
+		index:  make(map[runqBucketKey]int),
+	}
+}
+
+func (c *RunqCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	// Histograms in microseconds at 1 second intervals
+	args := []string{"-oL", "runqlat"}
+	if c.perNS {
+		args = append(args, "--pidnss")
+	}
+	if c.cgroup != "" {
+		args = append(args, "-c", c.cgroup)
+	}
+	c.cmd = exec.CommandContext(ctx, "stdbuf", append(args, "1")...)
+	// stdbuf does not reach the buffering of the Python tools
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameRunqlat, err)
+		log.Printf("Failed to start runqlat: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("runqlat collector started")
+	markStarted(NameRunqlat)
+
+	// Read output in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("runqlat collector stopped")
+			markStopped(NameRunqlat)
+		}()
+
+		reader := bufio.NewReader(stdout)
+		var pidns int64
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("runqlat read error: %v", err)
+				}
+				break
+			}
+
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			// The buckets that follow belong to this namespace
+			if matches := runqPIDNSRe.FindStringSubmatch(line); matches != nil {
+				pidns, _ = strconv.ParseInt(matches[1], 10, 64)
+				continue
+			}
+
+			matches := runqBucketRe.FindStringSubmatch(line)
+			if len(matches) != 4 {
+				continue
+			}
+			count, _ := strconv.Atoi(matches[3])
+			// Empty buckets would only multiply the rows per namespace
+			if count == 0 {
+				continue
+			}
+			rangeMin, _ := strconv.Atoi(matches[1])
+			rangeMax, _ := strconv.Atoi(matches[2])
+
+			c.add(models.RunqLatency{
+				PIDNS:    pidns,
+				Cgroup:   c.cgroup,
+				RangeMin: rangeMin,
+				RangeMax: rangeMax,
+				Count:    count,
+			})
+		}
+	}()
+
+	return nil
+}
+
+func (c *RunqCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+// add sums a bucket into the histogram of its namespace. Every namespace
+// adds a histogram per interval, so summing as they are read keeps one per
+// namespace.
+func (c *RunqCollector) add(latency models.RunqLatency) {
+	c.bucketsMu.Lock()
+	defer c.bucketsMu.Unlock()
+
+	key := runqBucketKey{latency.PIDNS, latency.RangeMin}
+	if i, ok := c.index[key]; ok {
+		c.buckets[i].Count += latency.Count
+		return
+	}
+	if len(c.buckets) >= maxRunqBuckets {
+		// Too many namespaces, skip this one
+		return
+	}
+	c.index[key] = len(c.buckets)
+	c.buckets = append(c.buckets, latency)
+}
+
+// GetEvents returns the buckets of the intervals since the last call, one
+// histogram per namespace
+func (c *RunqCollector) GetEvents() []models.RunqLatency {
+	c.bucketsMu.Lock()
+	defer c.bucketsMu.Unlock()
+
+	events := c.buckets
+	c.buckets = nil
+	c.index = make(map[runqBucketKey]int)
+	return events
+}
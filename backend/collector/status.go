@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collector names as reported by Statuses
+const (
+	NameExecsnoop       = "execsnoop"
+	NameTCPConnect      = "tcpconnect"
+	NameBiolatency      = "biolatency"
+	NameProfile         = "profile"
+	NameTCPLife         = "tcplife"
+	NameSyscount        = "syscount"
+	NameSyscountProcess = "syscount_process"
+	NameExitsnoop       = "exitsnoop"
+	NameOpensnoop       = "opensnoop"
+	NameRunqlat         = "runqlat"
+	NameOffcputime      = "offcputime"
+	NameTCPRetrans      = "tcpretrans"
+	NameTCPDrop         = "tcpdrop"
+	NameTCPRTT          = "tcprtt"
+	NameTCPAccept       = "tcpaccept"
+	NameListen          = "listen"
+	NameTCPStates       = "tcpstates"
+	NameGethostlatency  = "gethostlatency"
+	NameOomkill         = "oomkill"
+	NameKillsnoop       = "killsnoop"
+	NameExt4slower      = "ext4slower"
+	NameXFSslower       = "xfsslower"
+	NameBtrfsslower     = "btrfsslower"
+	NameCommSyscalls    = "bpftrace_syscalls"
+)
+
+var (
+	statusMu sync.Mutex
+	statuses = make(map[string]models.CollectorStatus)
+)
+
+func markStarted(name string) {
+	setStatus(models.CollectorStatus{Name: name, Running: true})
+}
+
+func markStopped(name string) {
+	setStatus(models.CollectorStatus{Name: name})
+}
+
+func markFailed(name string, err error) {
+	setStatus(models.CollectorStatus{Name: name, Error: err.Error()})
+}
+
+func setStatus(status models.CollectorStatus) {
+	status.Since = time.Now()
+
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statuses[status.Name] = status
+}
+
+// Statuses returns the state of every collector that was started in this
+// process, ordered by name
+func Statuses() []models.CollectorStatus {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	list := make([]models.CollectorStatus, 0, len(statuses))
+	for _, status := range statuses {
+		list = append(list, status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// BCCVersion returns the version of the BCC Python bindings the tools run
+// on, or "" if it cannot be determined
+func BCCVersion() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "python3", "-c", "import bcc; print(bcc.__version__)").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// KernelRelease returns the release of the running kernel, or "" if it
+// cannot be read
+func KernelRelease() string {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(release))
+}
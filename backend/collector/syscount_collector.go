@@ -37,8 +37,10 @@ func (c *SyscallCollector) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	c.cancel = cancel
 
-	// Start syscount-bpfcc: 5 second intervals, continuous mode
-	c.cmd = exec.CommandContext(ctx, "sudo", "syscount-bpfcc", "-i", "5")
+	// Start syscount-bpfcc: 5 second intervals, continuous mode. -T 500 lists
+	// every syscall made in the interval instead of the top 10, so rare ones
+	// such as ptrace are not hidden behind busy ones.
+	c.cmd = exec.CommandContext(ctx, "sudo", "syscount-bpfcc", "-T", "500", "-i", "5")
 
 	stdout, err := c.cmd.StdoutPipe()
 	if err != nil {
@@ -46,12 +48,14 @@ func (c *SyscallCollector) Start() error {
 	}
 
 	if err := c.cmd.Start(); err != nil {
+		markFailed(NameSyscount, err)
 		log.Printf("Failed to start syscount-bpfcc: %v", err)
 		return err
 	}
 
 	c.running = true
 	log.Println("syscount-bpfcc collector started")
+	markStarted(NameSyscount)
 
 	// Read output in a goroutine
 	go func() {
@@ -60,6 +64,7 @@ func (c *SyscallCollector) Start() error {
 			c.running = false
 			c.mu.Unlock()
 			log.Println("syscount-bpfcc collector stopped")
+			markStopped(NameSyscount)
 		}()
 
 		reader := bufio.NewReader(stdout)
@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProcessSyscallCollector runs syscount in per-process mode, counting the
+// system calls of each process per interval
+type ProcessSyscallCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.ProcessSyscallStat
+	mu      sync.Mutex
+	running bool
+}
+
+func NewProcessSyscallCollector() *ProcessSyscallCollector {
+	return &ProcessSyscallCollector{
+		events: make(chan models.ProcessSyscallStat, 100),
+	}
+}
+
+func (c *ProcessSyscallCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	// -P counts by process instead of by syscall, top 50 per 5 second interval
+	c.cmd = exec.CommandContext(ctx, "sudo", "syscount-bpfcc", "-P", "-T", "50", "-i", "5")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameSyscountProcess, err)
+		log.Printf("Failed to start syscount-bpfcc -P: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("syscount-bpfcc per-process collector started")
+	markStarted(NameSyscountProcess)
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("syscount-bpfcc per-process collector stopped")
+			markStopped(NameSyscountProcess)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("syscount-bpfcc -P read error: %v", err)
+				}
+				break
+			}
+
+			stat, ok := parseProcessSyscallLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- stat:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseProcessSyscallLine parses a line of the form "PID COMM COUNT". The
+// comm may contain spaces, so it spans everything between PID and COUNT.
+func parseProcessSyscallLine(line string) (models.ProcessSyscallStat, bool) {
+	// Skip empty lines, headers, and tracing messages
+	if line == "" || strings.HasPrefix(line, "PID") ||
+		strings.Contains(line, "Tracing") || strings.HasPrefix(line, "[") {
+		return models.ProcessSyscallStat{}, false
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return models.ProcessSyscallStat{}, false
+	}
+
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return models.ProcessSyscallStat{}, false
+	}
+	count, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return models.ProcessSyscallStat{}, false
+	}
+
+	return models.ProcessSyscallStat{
+		PID:   pid,
+		Comm:  strings.Join(fields[1:len(fields)-1], " "),
+		Count: count,
+	}, true
+}
+
+func (c *ProcessSyscallCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *ProcessSyscallCollector) GetEvents() []models.ProcessSyscallStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.ProcessSyscallStat
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
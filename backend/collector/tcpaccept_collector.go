@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"net/netip"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type TCPAcceptCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.InboundConnection
+	mu      sync.Mutex
+	running bool
+}
+
+func NewTCPAcceptCollector() *TCPAcceptCollector {
+	return &TCPAcceptCollector{
+		events: make(chan models.InboundConnection, 1000),
+	}
+}
+
+func (c *TCPAcceptCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "tcpaccept")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPAccept, err)
+		log.Printf("Failed to start tcpaccept: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("tcpaccept collector started")
+	markStarted(NameTCPAccept)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("tcpaccept collector stopped")
+			markStopped(NameTCPAccept)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("tcpaccept read error: %v", err)
+				}
+				break
+			}
+
+			event, ok := parseAcceptLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseAcceptLine parses a line of tcpaccept output:
+// PID COMM IP RADDR RPORT LADDR LPORT. Older versions do not print RPORT.
+// COMM may contain spaces, so the fields after it are taken from the end.
+func parseAcceptLine(line string) (models.InboundConnection, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return models.InboundConnection{}, false
+	}
+
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return models.InboundConnection{}, false
+	}
+
+	n := len(fields)
+	localPort, err := strconv.Atoi(fields[n-1])
+	if err != nil {
+		return models.InboundConnection{}, false
+	}
+	localAddr := fields[n-2]
+	if _, err := netip.ParseAddr(localAddr); err != nil {
+		return models.InboundConnection{}, false
+	}
+
+	// The field before LADDR is RPORT if it is a number, else RADDR
+	rest := fields[1 : n-2]
+	remotePort := 0
+	if port, err := strconv.Atoi(rest[len(rest)-1]); err == nil && len(rest) >= 4 {
+		remotePort = port
+		rest = rest[:len(rest)-1]
+	}
+	if len(rest) < 3 {
+		return models.InboundConnection{}, false
+	}
+	remoteAddr := rest[len(rest)-1]
+	if _, err := netip.ParseAddr(remoteAddr); err != nil {
+		return models.InboundConnection{}, false
+	}
+	ipVersion, err := strconv.Atoi(rest[len(rest)-2])
+	if err != nil {
+		return models.InboundConnection{}, false
+	}
+
+	return models.InboundConnection{
+		PID:        pid,
+		Comm:       strings.Join(rest[:len(rest)-2], " "),
+		IPVersion:  ipVersion,
+		RemoteAddr: unmapAddr(remoteAddr),
+		RemotePort: remotePort,
+		LocalAddr:  unmapAddr(localAddr),
+		LocalPort:  localPort,
+	}, true
+}
+
+func (c *TCPAcceptCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *TCPAcceptCollector) GetEvents() []models.InboundConnection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.InboundConnection
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -46,12 +46,14 @@ func (c *NetworkCollector) Start() error {
 	}
 
 	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPConnect, err)
 		log.Printf("Failed to start tcpconnect: %v", err)
 		return err
 	}
 
 	c.running = true
 	log.Println("tcpconnect collector started")
+	markStarted(NameTCPConnect)
 
 	// Read output line by line in a goroutine
 	go func() {
@@ -60,6 +62,7 @@ func (c *NetworkCollector) Start() error {
 			c.running = false
 			c.mu.Unlock()
 			log.Println("tcpconnect collector stopped")
+			markStopped(NameTCPConnect)
 		}()
 
 		reader := bufio.NewReader(stdout)
@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type TCPDropCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.TCPDrop
+	mu      sync.Mutex
+	running bool
+}
+
+// tcpdrop output format: TIME PID IP SADDR:SPORT > DADDR:DPORT STATE (FLAGS),
+// followed by the drop reason on kernels with skb drop reasons, and then by
+// the kernel stack, one indented frame per line, up to a blank line
+var dropLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\s+(\d+)\s+([46])\s+(\S+):(\d+)\s+>\s+(\S+):(\d+)\s+(\S+)\s+\(([^)]*)\)\s*(.*)$`)
+
+func NewTCPDropCollector() *TCPDropCollector {
+	return &TCPDropCollector{
+		events: make(chan models.TCPDrop, 1000),
+	}
+}
+
+func (c *TCPDropCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "tcpdrop")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPDrop, err)
+		log.Printf("Failed to start tcpdrop: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("tcpdrop collector started")
+	markStarted(NameTCPDrop)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("tcpdrop collector stopped")
+			markStopped(NameTCPDrop)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		// A drop is complete once its stack ends, which is only known from
+		// the line after it
+		var (
+			current models.TCPDrop
+			pending bool
+			frames  []string
+		)
+		flush := func() {
+			if !pending {
+				return
+			}
+			current.Stack = strings.Join(frames, "\n")
+			pending = false
+			frames = nil
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- current:
+			default:
+				// Channel full, skip this event
+			}
+		}
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("tcpdrop read error: %v", err)
+				}
+				break
+			}
+
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				flush()
+				continue
+			}
+
+			// Stack frames are indented, headers and drops are not
+			if pending && (line[0] == ' ' || line[0] == '\t') {
+				frames = append(frames, trimmed)
+				continue
+			}
+
+			event, ok := parseDropLine(trimmed)
+			if !ok {
+				continue
+			}
+			flush()
+			current = event
+			pending = true
+		}
+		flush()
+	}()
+
+	return nil
+}
+
+func parseDropLine(line string) (models.TCPDrop, bool) {
+	matches := dropLineRe.FindStringSubmatch(line)
+	if len(matches) != 11 {
+		return models.TCPDrop{}, false
+	}
+
+	pid, _ := strconv.Atoi(matches[2])
+	ipVersion, _ := strconv.Atoi(matches[3])
+	sourcePort, _ := strconv.Atoi(matches[5])
+	destPort, _ := strconv.Atoi(matches[7])
+
+	return models.TCPDrop{
+		Time:       matches[1],
+		PID:        pid,
+		IPVersion:  ipVersion,
+		SourceAddr: unmapAddr(matches[4]),
+		SourcePort: sourcePort,
+		DestAddr:   unmapAddr(matches[6]),
+		DestPort:   destPort,
+		State:      matches[8],
+		Flags:      matches[9],
+		Reason:     strings.TrimSpace(matches[10]),
+	}, true
+}
+
+func (c *TCPDropCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *TCPDropCollector) GetEvents() []models.TCPDrop {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.TCPDrop
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -47,12 +47,14 @@ func (c *TCPLifeCollector) Start() error {
 	}
 
 	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPLife, err)
 		log.Printf("Failed to start tcplife: %v", err)
 		return err
 	}
 
 	c.running = true
 	log.Println("tcplife collector started")
+	markStarted(NameTCPLife)
 
 	// Read output line by line in a goroutine
 	go func() {
@@ -61,6 +63,7 @@ func (c *TCPLifeCollector) Start() error {
 			c.running = false
 			c.mu.Unlock()
 			log.Println("tcplife collector stopped")
+			markStopped(NameTCPLife)
 		}()
 
 		scanner := bufio.NewScanner(stdout)
@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"net/netip"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type TCPRetransCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.TCPRetransmit
+	mu      sync.Mutex
+	running bool
+}
+
+// tcpretrans -l output format: TIME PID IP LADDR:LPORT T> RADDR:RPORT STATE
+// where T is R for a retransmit and L for a tail loss probe. IPv6 addresses
+// contain colons, so the port is what follows the last one.
+var retransLineRe = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\s+(\d+)\s+([46])\s+(\S+):(\d+)\s+([RL])>\s+(\S+):(\d+)\s+(\S+)`)
+
+func NewTCPRetransCollector() *TCPRetransCollector {
+	return &TCPRetransCollector{
+		events: make(chan models.TCPRetransmit, 1000),
+	}
+}
+
+func (c *TCPRetransCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	// Include tail loss probes, which tell a lossy path from a slow peer
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "tcpretrans", "-l")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPRetrans, err)
+		log.Printf("Failed to start tcpretrans: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("tcpretrans collector started")
+	markStarted(NameTCPRetrans)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("tcpretrans collector stopped")
+			markStopped(NameTCPRetrans)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("tcpretrans read error: %v", err)
+				}
+				break
+			}
+
+			event, ok := parseRetransLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseRetransLine(line string) (models.TCPRetransmit, bool) {
+	matches := retransLineRe.FindStringSubmatch(line)
+	if len(matches) != 10 {
+		return models.TCPRetransmit{}, false
+	}
+
+	pid, _ := strconv.Atoi(matches[2])
+	ipVersion, _ := strconv.Atoi(matches[3])
+	localPort, _ := strconv.Atoi(matches[5])
+	remotePort, _ := strconv.Atoi(matches[8])
+
+	event := models.TCPRetransmit{
+		Time:       matches[1],
+		PID:        pid,
+		IPVersion:  ipVersion,
+		LocalAddr:  unmapAddr(matches[4]),
+		LocalPort:  localPort,
+		RemoteAddr: unmapAddr(matches[7]),
+		RemotePort: remotePort,
+		State:      matches[9],
+		Type:       models.RetransmitRTO,
+	}
+	if matches[6] == "L" {
+		event.Type = models.RetransmitTLP
+	}
+	return event, true
+}
+
+// unmapAddr returns IPv4-mapped IPv6 addresses, which dual-stack sockets
+// report, in their IPv4 form so that connections aggregate the same either way
+func unmapAddr(addr string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return addr
+	}
+	return ip.Unmap().String()
+}
+
+func (c *TCPRetransCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *TCPRetransCollector) GetEvents() []models.TCPRetransmit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.TCPRetransmit
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
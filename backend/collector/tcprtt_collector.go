@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type TCPRTTCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	running bool
+
+	// The buckets of the intervals since the last GetEvents, summed up per
+	// peer as they are read
+	bucketsMu sync.Mutex
+	buckets   []models.TCPRTT
+	index     map[tcprttBucketKey]int
+}
+
+type tcprttBucketKey struct {
+	remoteAddr string
+	rangeMin   int
+}
+
+// maxTCPRTTBuckets bounds the buckets kept between two calls of GetEvents
+const maxTCPRTTBuckets = 10000
+
+// tcprtt -B prints a histogram per remote address and interval, each
+// preceded by a "Remote Address = <addr>" line (spelled "Remote Addres" by
+// some versions). Buckets are given as "min -> max : count".
+var tcprttAddrRe = regexp.MustCompile(`^Remote Addres+:?\s*=\s*(\S+)`)
+
+func NewTCPRTTCollector() *TCPRTTCollector {
+	return &TCPRTTCollector{
+		index: make(map[tcprttBucketKey]int),
+	}
+}
+
+func (c *TCPRTTCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	// Histograms in microseconds per remote address at 1 second intervals
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "tcprtt", "-B", "-i", "1")
+	// stdbuf does not reach the buffering of the Python tools
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPRTT, err)
+		log.Printf("Failed to start tcprtt: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("tcprtt collector started")
+	markStarted(NameTCPRTT)
+
+	// Read output in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("tcprtt collector stopped")
+			markStopped(NameTCPRTT)
+		}()
+
+		reader := bufio.NewReader(stdout)
+		var remoteAddr string
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("tcprtt read error: %v", err)
+				}
+				break
+			}
+
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			// The buckets that follow belong to this peer
+			if matches := tcprttAddrRe.FindStringSubmatch(line); matches != nil {
+				remoteAddr = unmapAddr(matches[1])
+				continue
+			}
+
+			// Buckets before any address line would be the histogram of
+			// all peers, which tcprtt -B does not print
+			matches := runqBucketRe.FindStringSubmatch(line)
+			if len(matches) != 4 || remoteAddr == "" {
+				continue
+			}
+			count, _ := strconv.Atoi(matches[3])
+			// Empty buckets would only multiply the rows per peer
+			if count == 0 {
+				continue
+			}
+			rangeMin, _ := strconv.Atoi(matches[1])
+			rangeMax, _ := strconv.Atoi(matches[2])
+
+			c.add(models.TCPRTT{
+				RemoteAddr: remoteAddr,
+				RangeMin:   rangeMin,
+				RangeMax:   rangeMax,
+				Count:      count,
+			})
+		}
+	}()
+
+	return nil
+}
+
+func (c *TCPRTTCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+// add sums a bucket into the histogram of its peer. Every peer adds a
+// histogram per interval, so summing as they are read keeps one per peer.
+func (c *TCPRTTCollector) add(rtt models.TCPRTT) {
+	c.bucketsMu.Lock()
+	defer c.bucketsMu.Unlock()
+
+	key := tcprttBucketKey{rtt.RemoteAddr, rtt.RangeMin}
+	if i, ok := c.index[key]; ok {
+		c.buckets[i].Count += rtt.Count
+		return
+	}
+	if len(c.buckets) >= maxTCPRTTBuckets {
+		// Too many peers, skip this one
+		return
+	}
+	c.index[key] = len(c.buckets)
+	c.buckets = append(c.buckets, rtt)
+}
+
+// GetEvents returns the buckets of the intervals since the last call, one
+// histogram per peer
+func (c *TCPRTTCollector) GetEvents() []models.TCPRTT {
+	c.bucketsMu.Lock()
+	defer c.bucketsMu.Unlock()
+
+	events := c.buckets
+	c.buckets = nil
+	c.index = make(map[tcprttBucketKey]int)
+	return events
+}
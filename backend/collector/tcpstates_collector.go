@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"ebpf-dashboard/models"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type TCPStatesCollector struct {
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc
+	events  chan models.TCPStateChange
+	mu      sync.Mutex
+	running bool
+}
+
+// tcpstates output format:
+// SKADDR C-PID C-COMM LADDR LPORT RADDR RPORT OLDSTATE -> NEWSTATE MS
+// C-COMM may contain spaces, so the fields after it are anchored at the end.
+var statesLineRe = regexp.MustCompile(`^([0-9a-f]+)\s+(\d+)\s+(.*?)\s+(\S+)\s+(\d+)\s+(\S+)\s+(\d+)\s+([A-Z_0-9]+)\s+->\s+([A-Z_0-9]+)\s+([\d.]+)$`)
+
+func NewTCPStatesCollector() *TCPStatesCollector {
+	return &TCPStatesCollector{
+		// Every connection goes through several states
+		events: make(chan models.TCPStateChange, 5000),
+	}
+}
+
+func (c *TCPStatesCollector) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.cmd = exec.CommandContext(ctx, "stdbuf", "-oL", "tcpstates")
+	c.cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cmd.Start(); err != nil {
+		markFailed(NameTCPStates, err)
+		log.Printf("Failed to start tcpstates: %v", err)
+		return err
+	}
+
+	c.running = true
+	log.Println("tcpstates collector started")
+	markStarted(NameTCPStates)
+
+	// Read output line by line in a goroutine
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.running = false
+			c.mu.Unlock()
+			log.Println("tcpstates collector stopped")
+			markStopped(NameTCPStates)
+		}()
+
+		reader := bufio.NewReader(stdout)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("tcpstates read error: %v", err)
+				}
+				break
+			}
+
+			event, ok := parseStatesLine(strings.TrimSpace(line))
+			if !ok {
+				continue
+			}
+
+			// Send to channel (non-blocking)
+			select {
+			case c.events <- event:
+			default:
+				// Channel full, skip this event
+			}
+		}
+	}()
+
+	return nil
+}
+
+func parseStatesLine(line string) (models.TCPStateChange, bool) {
+	matches := statesLineRe.FindStringSubmatch(line)
+	if len(matches) != 11 {
+		return models.TCPStateChange{}, false
+	}
+
+	pid, _ := strconv.Atoi(matches[2])
+	localPort, _ := strconv.Atoi(matches[5])
+	remotePort, _ := strconv.Atoi(matches[7])
+	duration, _ := strconv.ParseFloat(matches[10], 64)
+
+	return models.TCPStateChange{
+		SKAddr:     matches[1],
+		PID:        pid,
+		Comm:       matches[3],
+		LocalAddr:  unmapAddr(matches[4]),
+		LocalPort:  localPort,
+		RemoteAddr: unmapAddr(matches[6]),
+		RemotePort: remotePort,
+		OldState:   matches[8],
+		NewState:   matches[9],
+		DurationMS: duration,
+	}, true
+}
+
+func (c *TCPStatesCollector) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	if c.cmd != nil && c.cmd.Process != nil {
+		c.cmd.Process.Kill()
+	}
+
+	c.running = false
+}
+
+func (c *TCPStatesCollector) GetEvents() []models.TCPStateChange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var events []models.TCPStateChange
+
+	// Drain the channel
+	for {
+		select {
+		case event := <-c.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -4,9 +4,27 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+)
+
+// Run modes
+const (
+	ModeStandalone = "standalone" // collect, store and serve the API on one host
+	ModeAgent      = "agent"      // collect and ship batches to a server
+	ModeServer     = "server"     // standalone, plus storing batches shipped by agents
+)
+
+// Storage backends
+const (
+	StorageSQLite   = "sqlite"   // tables in the database file at DB_PATH
+	StorageMemory   = "memory"   // ring buffers of the newest events, lost on restart
+	StoragePostgres = "postgres" // tables in the PostgreSQL database at POSTGRES_URL
 )
 
 type Config struct {
+	Mode         string
+	HostName     string
+	HostLabels   string // comma-separated key=value pairs, e.g. env=prod,region=eu
 	Port         string
 	DBPath       string
 	LogPath      string
@@ -14,11 +32,80 @@ type Config struct {
 	DefaultLimit int
 	CORSEnabled  bool
 	CORSOrigins  string
+
+	StorageBackend string
+	MemoryCapacity int // events kept per table by the memory backend
+
+	SQLiteWriteQueue     int // inserts waiting for the SQLite writer before collectors block
+	SQLiteWriteBatchRows int // rows after which the SQLite writer commits a transaction
+
+	PostgresURL                 string
+	PostgresMaxConns            int
+	TimescaleEnabled            bool
+	TimescaleCompressAfterHours int // 0 disables compression
+
+	LineageRetentionMinutes int
+
+	KubeEnabled            bool
+	KubeSource             string
+	KubeletURL             string
+	KubeAPIURL             string
+	KubeTokenPath          string
+	KubeCAPath             string
+	KubeInsecureSkipVerify bool
+	KubeNodeName           string
+	KubeRefreshSeconds     int
+
+	TimelineDiskSpikeMS        int
+	TimelineSyscallSpikeFactor float64
+	TimelineSyscallSpikeMin    int
+	TimelineCPUHotStackSamples int
+
+	AlertWebhookURL            string
+	AlertWebhookTimeoutSeconds int
+	AlertEvalIntervalSeconds   int
+
+	BaselineBucketSeconds int
+	BaselineAlpha         float64
+	BaselineZThreshold    float64
+	BaselineMinSamples    int
+
+	SecurityMinerPorts       string
+	SecurityWebServers       string
+	SecurityWebServerAllowed string
+	SecuritySetuidAllowed    string
+
+	ProfilesEnabled  bool
+	ProfileAutoLearn bool
+	ProfileSyscalls  bool
+
+	OpensFailedOnly bool // trace only opens that fail, e.g. on busy hosts
+
+	RunqlatPerPIDNS bool   // one run queue latency histogram per PID namespace
+	RunqlatCgroup   string // cgroup path runqlat is limited to, all threads if empty
+
+	OffCPUIntervalSeconds int // how long each offcputime run sums up stacks
+
+	ListenScanIntervalSeconds int // how often listening sockets are inventoried
+
+	FSSlowerThresholdMS int // minimum latency of traced filesystem operations, 0 traces all
+
+	ServerURL             string
+	AgentID               string
+	AgentToken            string
+	AgentBatchSeconds     int
+	AgentHeartbeatSeconds int
+	AgentSpoolDir         string
+	AgentSpoolMaxMB       int
+	AgentOfflineSeconds   int
 }
 
 // Load loads configuration from environment variables with defaults
 func Load() *Config {
 	return &Config{
+		Mode:         getEnv("MODE", ModeStandalone),
+		HostName:     getEnv("HOST_NAME", hostname()),
+		HostLabels:   getEnv("HOST_LABELS", ""),
 		Port:         getEnv("PORT", "8080"),
 		DBPath:       getEnv("DB_PATH", "./metrics.db"),
 		LogPath:      getEnv("LOG_PATH", "./logs/app.log"),
@@ -26,7 +113,95 @@ func Load() *Config {
 		DefaultLimit: getEnvInt("DEFAULT_LIMIT", 100),
 		CORSEnabled:  getEnvBool("CORS_ENABLED", true),
 		CORSOrigins:  getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:5173"),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", StorageSQLite),
+		MemoryCapacity: getEnvInt("MEMORY_CAPACITY", 100000),
+
+		SQLiteWriteQueue:     getEnvInt("SQLITE_WRITE_QUEUE", 1024),
+		SQLiteWriteBatchRows: getEnvInt("SQLITE_WRITE_BATCH_ROWS", 5000),
+
+		PostgresURL:                 getEnv("POSTGRES_URL", ""),
+		PostgresMaxConns:            getEnvInt("POSTGRES_MAX_CONNS", 10),
+		TimescaleEnabled:            getEnvBool("TIMESCALE_ENABLED", false),
+		TimescaleCompressAfterHours: getEnvInt("TIMESCALE_COMPRESS_AFTER_HOURS", 24),
+
+		LineageRetentionMinutes: getEnvInt("LINEAGE_RETENTION_MINUTES", 60),
+
+		KubeEnabled:            getEnvBool("KUBE_ENABLED", false),
+		KubeSource:             getEnv("KUBE_SOURCE", "kubelet"),
+		KubeletURL:             getEnv("KUBELET_URL", "https://127.0.0.1:10250"),
+		KubeAPIURL:             getEnv("KUBE_API_URL", "https://kubernetes.default.svc"),
+		KubeTokenPath:          getEnv("KUBE_TOKEN_PATH", "/var/run/secrets/kubernetes.io/serviceaccount/token"),
+		KubeCAPath:             getEnv("KUBE_CA_PATH", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"),
+		KubeInsecureSkipVerify: getEnvBool("KUBE_INSECURE_SKIP_VERIFY", false),
+		KubeNodeName:           getEnv("KUBE_NODE_NAME", hostname()),
+		KubeRefreshSeconds:     getEnvInt("KUBE_REFRESH_SECONDS", 30),
+
+		TimelineDiskSpikeMS:        getEnvInt("TIMELINE_DISK_SPIKE_MS", 50),
+		TimelineSyscallSpikeFactor: getEnvFloat("TIMELINE_SYSCALL_SPIKE_FACTOR", 3),
+		TimelineSyscallSpikeMin:    getEnvInt("TIMELINE_SYSCALL_SPIKE_MIN", 1000),
+		TimelineCPUHotStackSamples: getEnvInt("TIMELINE_CPU_HOT_SAMPLES", 100),
+
+		AlertWebhookURL:            getEnv("ALERT_WEBHOOK_URL", ""),
+		AlertWebhookTimeoutSeconds: getEnvInt("ALERT_WEBHOOK_TIMEOUT_SECONDS", 10),
+		AlertEvalIntervalSeconds:   getEnvInt("ALERT_EVAL_INTERVAL_SECONDS", 5),
+
+		BaselineBucketSeconds: getEnvInt("BASELINE_BUCKET_SECONDS", 60),
+		BaselineAlpha:         getEnvFloat("BASELINE_ALPHA", 0.1),
+		BaselineZThreshold:    getEnvFloat("BASELINE_Z_THRESHOLD", 3),
+		BaselineMinSamples:    getEnvInt("BASELINE_MIN_SAMPLES", 10),
+
+		SecurityMinerPorts:       getEnv("SECURITY_MINER_PORTS", "3333,4444,5555,6666,7777,8888,9999,14433,14444,45560,45700"),
+		SecurityWebServers:       getEnv("SECURITY_WEB_SERVERS", "nginx,apache2,httpd,lighttpd,caddy,php-fpm"),
+		SecurityWebServerAllowed: getEnv("SECURITY_WEB_SERVER_ALLOWED_CIDRS", "127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"),
+		SecuritySetuidAllowed:    getEnv("SECURITY_SETUID_ALLOWED", "sudo,su,passwd,chsh,chfn,newgrp,gpasswd,mount,umount,fusermount,fusermount3,ping,unix_chkpwd,ssh-keysign"),
+
+		ProfilesEnabled:  getEnvBool("PROFILES_ENABLED", false),
+		ProfileAutoLearn: getEnvBool("PROFILE_AUTO_LEARN", true),
+		ProfileSyscalls:  getEnvBool("PROFILE_SYSCALLS", true),
+
+		OpensFailedOnly: getEnvBool("OPENSNOOP_FAILED_ONLY", false),
+
+		RunqlatPerPIDNS: getEnvBool("RUNQLAT_PIDNSS", false),
+		RunqlatCgroup:   getEnv("RUNQLAT_CGROUP", ""),
+
+		OffCPUIntervalSeconds: getEnvInt("OFFCPU_INTERVAL_SECONDS", 30),
+
+		ListenScanIntervalSeconds: getEnvInt("LISTEN_SCAN_INTERVAL_SECONDS", 30),
+
+		FSSlowerThresholdMS: getEnvInt("FSSLOWER_THRESHOLD_MS", 10),
+
+		ServerURL:             getEnv("SERVER_URL", ""),
+		AgentID:               getEnv("AGENT_ID", hostname()),
+		AgentToken:            getEnv("AGENT_TOKEN", ""),
+		AgentBatchSeconds:     getEnvInt("AGENT_BATCH_SECONDS", 5),
+		AgentHeartbeatSeconds: getEnvInt("AGENT_HEARTBEAT_SECONDS", 15),
+		AgentSpoolDir:         getEnv("AGENT_SPOOL_DIR", "./spool"),
+		AgentSpoolMaxMB:       getEnvInt("AGENT_SPOOL_MAX_MB", 100),
+		AgentOfflineSeconds:   getEnvInt("AGENT_OFFLINE_SECONDS", 60),
+	}
+}
+
+// ParseHostLabels returns the labels of HOST_LABELS
+func (c *Config) ParseHostLabels() (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(c.HostLabels, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid host label %q, expected key=value", pair)
+		}
+		labels[key] = strings.TrimSpace(value)
 	}
+	return labels, nil
+}
+
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
 }
 
 func getEnv(key, defaultValue string) string {
@@ -45,6 +220,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
@@ -56,11 +240,60 @@ func getEnvBool(key string, defaultValue bool) bool {
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
+	switch c.Mode {
+	case ModeStandalone, ModeServer:
+		if c.AgentOfflineSeconds <= 0 {
+			return fmt.Errorf("AGENT_OFFLINE_SECONDS must be positive")
+		}
+	case ModeAgent:
+		if c.ServerURL == "" {
+			return fmt.Errorf("SERVER_URL is required in agent mode")
+		}
+		if c.AgentID == "" {
+			return fmt.Errorf("AGENT_ID cannot be empty")
+		}
+		if c.AgentBatchSeconds <= 0 || c.AgentHeartbeatSeconds <= 0 {
+			return fmt.Errorf("AGENT_BATCH_SECONDS and AGENT_HEARTBEAT_SECONDS must be positive")
+		}
+		if c.AgentSpoolMaxMB <= 0 {
+			return fmt.Errorf("AGENT_SPOOL_MAX_MB must be positive")
+		}
+	default:
+		return fmt.Errorf("MODE must be standalone, agent or server")
+	}
+	if c.HostName == "" {
+		return fmt.Errorf("HOST_NAME cannot be empty")
+	}
+	if _, err := c.ParseHostLabels(); err != nil {
+		return fmt.Errorf("HOST_LABELS: %w", err)
+	}
 	if c.Port == "" {
 		return fmt.Errorf("PORT cannot be empty")
 	}
-	if c.DBPath == "" {
-		return fmt.Errorf("DB_PATH cannot be empty")
+	switch c.StorageBackend {
+	case StorageSQLite:
+		if c.DBPath == "" {
+			return fmt.Errorf("DB_PATH cannot be empty")
+		}
+		if c.SQLiteWriteQueue <= 0 || c.SQLiteWriteBatchRows <= 0 {
+			return fmt.Errorf("SQLITE_WRITE_QUEUE and SQLITE_WRITE_BATCH_ROWS must be positive")
+		}
+	case StorageMemory:
+		if c.MemoryCapacity <= 0 {
+			return fmt.Errorf("MEMORY_CAPACITY must be positive")
+		}
+	case StoragePostgres:
+		if c.PostgresURL == "" {
+			return fmt.Errorf("POSTGRES_URL is required for the postgres storage backend")
+		}
+		if c.PostgresMaxConns <= 0 {
+			return fmt.Errorf("POSTGRES_MAX_CONNS must be positive")
+		}
+		if c.TimescaleCompressAfterHours < 0 {
+			return fmt.Errorf("TIMESCALE_COMPRESS_AFTER_HOURS cannot be negative")
+		}
+	default:
+		return fmt.Errorf("STORAGE_BACKEND must be sqlite, memory or postgres")
 	}
 	if c.MaxLimit <= 0 {
 		return fmt.Errorf("MAX_LIMIT must be positive")
@@ -68,5 +301,55 @@ func (c *Config) Validate() error {
 	if c.DefaultLimit <= 0 || c.DefaultLimit > c.MaxLimit {
 		return fmt.Errorf("DEFAULT_LIMIT must be between 1 and MAX_LIMIT")
 	}
+	if c.LineageRetentionMinutes <= 0 {
+		return fmt.Errorf("LINEAGE_RETENTION_MINUTES must be positive")
+	}
+	if c.TimelineDiskSpikeMS <= 0 {
+		return fmt.Errorf("TIMELINE_DISK_SPIKE_MS must be positive")
+	}
+	if c.TimelineSyscallSpikeFactor <= 1 {
+		return fmt.Errorf("TIMELINE_SYSCALL_SPIKE_FACTOR must be greater than 1")
+	}
+	if c.TimelineCPUHotStackSamples <= 0 {
+		return fmt.Errorf("TIMELINE_CPU_HOT_SAMPLES must be positive")
+	}
+	if c.AlertWebhookTimeoutSeconds <= 0 {
+		return fmt.Errorf("ALERT_WEBHOOK_TIMEOUT_SECONDS must be positive")
+	}
+	if c.AlertEvalIntervalSeconds <= 0 {
+		return fmt.Errorf("ALERT_EVAL_INTERVAL_SECONDS must be positive")
+	}
+	if c.BaselineBucketSeconds <= 0 {
+		return fmt.Errorf("BASELINE_BUCKET_SECONDS must be positive")
+	}
+	if c.BaselineAlpha <= 0 || c.BaselineAlpha > 1 {
+		return fmt.Errorf("BASELINE_ALPHA must be between 0 and 1")
+	}
+	if c.BaselineZThreshold <= 0 {
+		return fmt.Errorf("BASELINE_Z_THRESHOLD must be positive")
+	}
+	if c.BaselineMinSamples <= 0 {
+		return fmt.Errorf("BASELINE_MIN_SAMPLES must be positive")
+	}
+	if c.KubeEnabled {
+		if c.KubeSource != "kubelet" && c.KubeSource != "apiserver" {
+			return fmt.Errorf("KUBE_SOURCE must be kubelet or apiserver")
+		}
+		if c.KubeRefreshSeconds <= 0 {
+			return fmt.Errorf("KUBE_REFRESH_SECONDS must be positive")
+		}
+	}
+	if c.OffCPUIntervalSeconds <= 0 {
+		return fmt.Errorf("OFFCPU_INTERVAL_SECONDS must be positive")
+	}
+	if c.ListenScanIntervalSeconds <= 0 {
+		return fmt.Errorf("LISTEN_SCAN_INTERVAL_SECONDS must be positive")
+	}
+	if c.FSSlowerThresholdMS < 0 {
+		return fmt.Errorf("FSSLOWER_THRESHOLD_MS must not be negative")
+	}
+	if c.RunqlatCgroup != "" && !strings.HasPrefix(c.RunqlatCgroup, "/") {
+		return fmt.Errorf("RUNQLAT_CGROUP must be an absolute cgroup path, e.g. /sys/fs/cgroup/system.slice")
+	}
 	return nil
 }
@@ -0,0 +1,671 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TimescaleConfig turns the event tables into TimescaleDB hypertables
+type TimescaleConfig struct {
+	Enabled       bool
+	CompressAfter time.Duration // chunks older than this are compressed, 0 to never compress
+}
+
+// InitPostgres connects a pool of at most maxConns connections to the
+// PostgreSQL database at url and brings its schema up to date
+func InitPostgres(url string, maxConns int, timescale TimescaleConfig) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxConns = int32(maxConns)
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		// Return timestamps in UTC like SQLite does, whatever the time zone
+		// of the session
+		conn.TypeMap().RegisterType(&pgtype.Type{
+			Name:  "timestamptz",
+			OID:   pgtype.TimestamptzOID,
+			Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC},
+		})
+		return nil
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test the connection
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	if err := migratePostgres(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	if timescale.Enabled {
+		if err := createHypertables(ctx, pool, timescale.CompressAfter); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	log.Println("PostgreSQL database initialized successfully")
+	return pool, nil
+}
+
+// postgresMigrations are applied in order, each once. Append new migrations
+// rather than changing released ones.
+var postgresMigrations = []string{
+	// 1: initial schema. Event tables have a composite primary key including
+	// the timestamp, which TimescaleDB requires of hypertables.
+	`CREATE TABLE processes (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid TEXT NOT NULL DEFAULT '',
+		ppid TEXT NOT NULL DEFAULT '',
+		uid TEXT NOT NULL DEFAULT '',
+		comm TEXT NOT NULL DEFAULT '',
+		args TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE network_connections (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		pid TEXT NOT NULL DEFAULT '',
+		comm TEXT NOT NULL DEFAULT '',
+		ip_version TEXT NOT NULL DEFAULT '',
+		source_addr TEXT NOT NULL DEFAULT '',
+		source_port TEXT NOT NULL DEFAULT '',
+		dest_addr TEXT NOT NULL DEFAULT '',
+		dest_port TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE disk_latency (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		range_min INTEGER NOT NULL,
+		range_max INTEGER NOT NULL,
+		count INTEGER NOT NULL,
+		host TEXT NOT NULL DEFAULT '',
+		host_labels JSONB,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE cpu_profiles (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		process_name TEXT NOT NULL DEFAULT '',
+		comm TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		stack_trace TEXT NOT NULL DEFAULT '',
+		sample_count INTEGER NOT NULL DEFAULT 0,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE tcp_lifecycle (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		local_addr TEXT NOT NULL DEFAULT '',
+		local_port INTEGER NOT NULL DEFAULT 0,
+		remote_addr TEXT NOT NULL DEFAULT '',
+		remote_port INTEGER NOT NULL DEFAULT 0,
+		tx_kb DOUBLE PRECISION NOT NULL DEFAULT 0,
+		rx_kb DOUBLE PRECISION NOT NULL DEFAULT 0,
+		duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE syscall_stats (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		syscall_name TEXT NOT NULL,
+		count INTEGER NOT NULL,
+		host TEXT NOT NULL DEFAULT '',
+		host_labels JSONB,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE process_syscall_stats (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		pid INTEGER NOT NULL,
+		comm TEXT NOT NULL DEFAULT '',
+		count INTEGER NOT NULL,
+		host TEXT NOT NULL DEFAULT '',
+		host_labels JSONB,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE process_exits (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL,
+		ppid INTEGER NOT NULL DEFAULT 0,
+		tid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		age_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+		exit_code INTEGER NOT NULL DEFAULT 0,
+		signal INTEGER NOT NULL DEFAULT 0,
+		signal_name TEXT NOT NULL DEFAULT '',
+		core_dumped BOOLEAN NOT NULL DEFAULT false,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE anomalies (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL,
+		metric TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		comm TEXT NOT NULL DEFAULT '',
+		value DOUBLE PRECISION NOT NULL,
+		expected DOUBLE PRECISION NOT NULL,
+		stddev DOUBLE PRECISION NOT NULL,
+		score DOUBLE PRECISION NOT NULL,
+		method TEXT NOT NULL,
+		slot INTEGER NOT NULL,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE findings (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL,
+		rule TEXT NOT NULL,
+		title TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		techniques TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		ppid INTEGER NOT NULL DEFAULT 0,
+		uid TEXT NOT NULL DEFAULT '',
+		comm TEXT NOT NULL DEFAULT '',
+		detail TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE alert_rules (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		match JSONB NOT NULL DEFAULT '{}',
+		group_by TEXT NOT NULL DEFAULT '',
+		window_seconds INTEGER NOT NULL,
+		operator TEXT NOT NULL,
+		threshold DOUBLE PRECISION NOT NULL,
+		for_seconds INTEGER NOT NULL DEFAULT 0,
+		severity TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL,
+		webhook_url TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE alerts (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		rule_id BIGINT NOT NULL,
+		rule_name TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		group_key TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		summary TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMPTZ NOT NULL,
+		fired_at TIMESTAMPTZ,
+		resolved_at TIMESTAMPTZ,
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE baselines (
+		metric TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		slot INTEGER NOT NULL,
+		mean DOUBLE PRECISION NOT NULL,
+		stddev DOUBLE PRECISION NOT NULL,
+		samples INTEGER NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (metric, subject, slot)
+	);
+
+	CREATE TABLE baseline_dest_ports (
+		comm TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		first_seen TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (comm, port)
+	);
+
+	CREATE TABLE behavior_profiles (
+		comm TEXT PRIMARY KEY,
+		mode TEXT NOT NULL,
+		children JSONB,
+		connect JSONB,
+		syscalls JSONB,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE agents (
+		id TEXT PRIMARY KEY,
+		host TEXT NOT NULL,
+		labels JSONB,
+		kernel TEXT NOT NULL DEFAULT '',
+		bcc_version TEXT NOT NULL DEFAULT '',
+		registered_at TIMESTAMPTZ NOT NULL,
+		last_heartbeat TIMESTAMPTZ NOT NULL,
+		last_batch_at TIMESTAMPTZ,
+		batches INTEGER NOT NULL DEFAULT 0,
+		events BIGINT NOT NULL DEFAULT 0,
+		spooled INTEGER NOT NULL DEFAULT 0,
+		collectors JSONB
+	);
+
+	CREATE INDEX idx_processes_timestamp ON processes (timestamp);
+	CREATE INDEX idx_processes_pid ON processes (pid);
+	CREATE INDEX idx_processes_host ON processes (host);
+	CREATE INDEX idx_processes_pod ON processes (pod_namespace, pod_name);
+	CREATE INDEX idx_network_timestamp ON network_connections (timestamp);
+	CREATE INDEX idx_network_pid ON network_connections (pid);
+	CREATE INDEX idx_network_pod ON network_connections (pod_namespace, pod_name);
+	CREATE INDEX idx_disk_timestamp ON disk_latency (timestamp);
+	CREATE INDEX idx_disk_host ON disk_latency (host);
+	CREATE INDEX idx_cpu_timestamp ON cpu_profiles (timestamp);
+	CREATE INDEX idx_cpu_pod ON cpu_profiles (pod_namespace, pod_name);
+	CREATE INDEX idx_tcp_timestamp ON tcp_lifecycle (timestamp);
+	CREATE INDEX idx_tcp_pid ON tcp_lifecycle (pid);
+	CREATE INDEX idx_tcp_pod ON tcp_lifecycle (pod_namespace, pod_name);
+	CREATE INDEX idx_syscall_timestamp ON syscall_stats (timestamp);
+	CREATE INDEX idx_syscall_host ON syscall_stats (host);
+	CREATE INDEX idx_process_syscall_timestamp ON process_syscall_stats (timestamp);
+	CREATE INDEX idx_exits_timestamp ON process_exits (timestamp);
+	CREATE INDEX idx_exits_pid ON process_exits (pid);
+	CREATE INDEX idx_exits_comm ON process_exits (comm);
+	CREATE INDEX idx_exits_pod ON process_exits (pod_namespace, pod_name);
+	CREATE INDEX idx_anomalies_timestamp ON anomalies (timestamp);
+	CREATE INDEX idx_findings_timestamp ON findings (timestamp);
+	CREATE INDEX idx_findings_rule ON findings (rule);
+	CREATE INDEX idx_alerts_state ON alerts (state);
+	CREATE INDEX idx_alerts_started ON alerts (started_at);`,
+
+	// 2: file opens
+	`CREATE TABLE file_opens (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		uid INTEGER NOT NULL DEFAULT 0,
+		pid INTEGER NOT NULL,
+		comm TEXT NOT NULL DEFAULT '',
+		fd INTEGER NOT NULL DEFAULT 0,
+		errno INTEGER NOT NULL DEFAULT 0,
+		errno_name TEXT NOT NULL DEFAULT '',
+		flags INTEGER NOT NULL DEFAULT 0,
+		flag_names TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_opens_timestamp ON file_opens (timestamp);
+	CREATE INDEX idx_opens_pid ON file_opens (pid);
+	CREATE INDEX idx_opens_comm ON file_opens (comm);
+	CREATE INDEX idx_opens_path ON file_opens (path);
+	CREATE INDEX idx_opens_pod ON file_opens (pod_namespace, pod_name);`,
+
+	// 3: run queue latency
+	`CREATE TABLE runq_latency (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		pidns BIGINT NOT NULL DEFAULT 0,
+		cgroup TEXT NOT NULL DEFAULT '',
+		range_min INTEGER NOT NULL,
+		range_max INTEGER NOT NULL,
+		count INTEGER NOT NULL,
+		host TEXT NOT NULL DEFAULT '',
+		host_labels JSONB,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_runq_timestamp ON runq_latency (timestamp);
+	CREATE INDEX idx_runq_host ON runq_latency (host);`,
+
+	// 4: off-CPU stacks
+	`CREATE TABLE offcpu_stacks (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		comm TEXT NOT NULL DEFAULT '',
+		stack TEXT NOT NULL DEFAULT '',
+		total_us BIGINT NOT NULL,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_offcpu_timestamp ON offcpu_stacks (timestamp);
+	CREATE INDEX idx_offcpu_comm ON offcpu_stacks (comm);`,
+
+	// 5: TCP retransmits and drops
+	`CREATE TABLE tcp_retransmits (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		ip_version INTEGER NOT NULL,
+		local_addr TEXT NOT NULL,
+		local_port INTEGER NOT NULL,
+		remote_addr TEXT NOT NULL,
+		remote_port INTEGER NOT NULL,
+		state TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE tcp_drops (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		ip_version INTEGER NOT NULL,
+		source_addr TEXT NOT NULL,
+		source_port INTEGER NOT NULL,
+		dest_addr TEXT NOT NULL,
+		dest_port INTEGER NOT NULL,
+		state TEXT NOT NULL DEFAULT '',
+		flags TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL DEFAULT '',
+		stack TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_retrans_timestamp ON tcp_retransmits (timestamp);
+	CREATE INDEX idx_retrans_remote ON tcp_retransmits (remote_addr, remote_port);
+	CREATE INDEX idx_drops_timestamp ON tcp_drops (timestamp);`,
+
+	// 6: TCP round-trip times
+	`CREATE TABLE tcp_rtt (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		remote_addr TEXT NOT NULL,
+		range_min INTEGER NOT NULL,
+		range_max INTEGER NOT NULL,
+		count INTEGER NOT NULL,
+		host TEXT NOT NULL DEFAULT '',
+		host_labels JSONB,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_rtt_timestamp ON tcp_rtt (timestamp);
+	CREATE INDEX idx_rtt_remote ON tcp_rtt (remote_addr);`,
+
+	// 7: inbound connections and listening sockets. listening_sockets holds
+	// the latest inventory of every host and is not a hypertable.
+	`CREATE TABLE inbound_connections (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		ip_version INTEGER NOT NULL,
+		remote_addr TEXT NOT NULL,
+		remote_port INTEGER NOT NULL DEFAULT 0,
+		local_addr TEXT NOT NULL,
+		local_port INTEGER NOT NULL,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE TABLE listening_sockets (
+		addr TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		uid INTEGER NOT NULL DEFAULT 0,
+		first_seen TIMESTAMPTZ NOT NULL,
+		last_seen TIMESTAMPTZ NOT NULL,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (host, addr, port)
+	);
+
+	CREATE TABLE listen_events (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		kind TEXT NOT NULL,
+		addr TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		uid INTEGER NOT NULL DEFAULT 0,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_inbound_timestamp ON inbound_connections (timestamp);
+	CREATE INDEX idx_inbound_local_port ON inbound_connections (local_port);
+	CREATE INDEX idx_listen_events_timestamp ON listen_events (timestamp);`,
+
+	// 8: TCP state transitions
+	`CREATE TABLE tcp_state_changes (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		skaddr TEXT NOT NULL,
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		local_addr TEXT NOT NULL,
+		local_port INTEGER NOT NULL,
+		remote_addr TEXT NOT NULL,
+		remote_port INTEGER NOT NULL,
+		old_state TEXT NOT NULL,
+		new_state TEXT NOT NULL,
+		duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_states_timestamp ON tcp_state_changes (timestamp);
+	CREATE INDEX idx_states_skaddr ON tcp_state_changes (skaddr);`,
+
+	// 9: DNS lookups, and the hostnames connections were resolved from
+	`CREATE TABLE dns_lookups (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		hostname TEXT NOT NULL,
+		latency_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_dns_timestamp ON dns_lookups (timestamp);
+	CREATE INDEX idx_dns_hostname ON dns_lookups (hostname);
+
+	ALTER TABLE network_connections ADD COLUMN dest_host TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tcp_lifecycle ADD COLUMN remote_host TEXT NOT NULL DEFAULT '';`,
+
+	// 10: OOM kills and signals
+	`CREATE TABLE oom_kills (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL,
+		comm TEXT NOT NULL DEFAULT '',
+		trigger_pid INTEGER NOT NULL DEFAULT 0,
+		trigger_comm TEXT NOT NULL DEFAULT '',
+		pages BIGINT NOT NULL DEFAULT 0,
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_oom_timestamp ON oom_kills (timestamp);
+
+	CREATE TABLE signals (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL,
+		comm TEXT NOT NULL DEFAULT '',
+		sender_pid INTEGER NOT NULL DEFAULT 0,
+		sender_comm TEXT NOT NULL DEFAULT '',
+		signal INTEGER NOT NULL,
+		signal_name TEXT NOT NULL DEFAULT '',
+		errno INTEGER NOT NULL DEFAULT 0,
+		errno_name TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_signals_timestamp ON signals (timestamp);
+	CREATE INDEX idx_signals_pid ON signals (pid);`,
+
+	// 11: slow filesystem operations
+	`CREATE TABLE fs_slow_ops (
+		id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+		timestamp TIMESTAMPTZ NOT NULL DEFAULT now(),
+		time TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		comm TEXT NOT NULL DEFAULT '',
+		filesystem TEXT NOT NULL,
+		op TEXT NOT NULL,
+		bytes BIGINT NOT NULL DEFAULT 0,
+		offset_kb BIGINT NOT NULL DEFAULT 0,
+		latency_ms DOUBLE PRECISION NOT NULL,
+		file TEXT NOT NULL DEFAULT '',
+		` + pgAttributionColumns + `,
+		PRIMARY KEY (id, timestamp)
+	);
+
+	CREATE INDEX idx_fs_slow_timestamp ON fs_slow_ops (timestamp);`,
+
+	// 12: the cgroup of the process that triggered an OOM kill
+	`ALTER TABLE oom_kills ADD COLUMN trigger_cgroup TEXT NOT NULL DEFAULT '';`,
+}
+
+// pgAttributionColumns are the attribution columns of every attributed
+// table, in the order of models.Attribution
+const pgAttributionColumns = `cgroup_path TEXT NOT NULL DEFAULT '',
+		systemd_unit TEXT NOT NULL DEFAULT '',
+		container_id TEXT NOT NULL DEFAULT '',
+		container_runtime TEXT NOT NULL DEFAULT '',
+		pod_name TEXT NOT NULL DEFAULT '',
+		pod_namespace TEXT NOT NULL DEFAULT '',
+		pod_uid TEXT NOT NULL DEFAULT '',
+		pod_labels JSONB,
+		node_name TEXT NOT NULL DEFAULT '',
+		host TEXT NOT NULL DEFAULT '',
+		host_labels JSONB`
+
+// migratePostgres applies the migrations the database has not seen yet. An
+// advisory lock keeps servers starting at the same time from racing.
+func migratePostgres(ctx context.Context, pool *pgxpool.Pool) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('ebpf-dashboard migrations'))`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`); err != nil {
+		return err
+	}
+
+	var version int
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(postgresMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this binary (%d)", version, len(postgresMigrations))
+	}
+
+	for i := version; i < len(postgresMigrations); i++ {
+		if _, err := tx.Exec(ctx, postgresMigrations[i]); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			return err
+		}
+		log.Printf("Applied database migration %d", i+1)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// hypertables are the event tables partitioned by time with TimescaleDB
+var hypertables = []string{
+	"processes", "network_connections", "disk_latency", "cpu_profiles", "tcp_lifecycle",
+	"syscall_stats", "process_syscall_stats", "process_exits", "anomalies", "findings", "file_opens",
+	"runq_latency", "offcpu_stacks", "tcp_retransmits", "tcp_drops", "tcp_rtt",
+	"inbound_connections", "listen_events", "tcp_state_changes", "dns_lookups",
+	"oom_kills", "signals", "fs_slow_ops",
+}
+
+// createHypertables converts the event tables into hypertables and sets up
+// compression of old chunks. Tables converted before are left as they are.
+func createHypertables(ctx context.Context, pool *pgxpool.Pool, compressAfter time.Duration) error {
+	if _, err := pool.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS timescaledb`); err != nil {
+		return fmt.Errorf("failed to enable TimescaleDB: %w", err)
+	}
+
+	for _, table := range hypertables {
+		if _, err := pool.Exec(ctx,
+			`SELECT create_hypertable($1::regclass, 'timestamp', if_not_exists => TRUE, migrate_data => TRUE)`,
+			table,
+		); err != nil {
+			return fmt.Errorf("failed to create hypertable %s: %w", table, err)
+		}
+		if compressAfter <= 0 {
+			continue
+		}
+
+		var compressed bool
+		if err := pool.QueryRow(ctx,
+			`SELECT compression_enabled FROM timescaledb_information.hypertables WHERE hypertable_name = $1`,
+			table,
+		).Scan(&compressed); err != nil {
+			return err
+		}
+		if !compressed {
+			// Settings cannot be changed once chunks are compressed, so they
+			// are only applied the first time
+			if _, err := pool.Exec(ctx, `ALTER TABLE `+table+` SET (timescaledb.compress, timescaledb.compress_orderby = 'timestamp DESC, id DESC')`); err != nil {
+				return fmt.Errorf("failed to enable compression on %s: %w", table, err)
+			}
+		}
+		if _, err := pool.Exec(ctx,
+			`SELECT add_compression_policy($1::regclass, $2::interval, if_not_exists => TRUE)`,
+			table, fmt.Sprintf("%d seconds", int(compressAfter.Seconds())),
+		); err != nil {
+			return fmt.Errorf("failed to add compression policy on %s: %w", table, err)
+		}
+	}
+
+	log.Printf("TimescaleDB hypertables ready")
+	return nil
+}
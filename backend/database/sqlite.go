@@ -3,13 +3,26 @@ package database
 import (
 	"database/sql"
 	"log"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// sqlitePragmas put the database in WAL mode, so the API reads while the
+// writer commits, and wait for the lock instead of failing with SQLITE_BUSY.
+// Writing transactions take the lock up front rather than on their first
+// insert, where a deadlock with another writer could no longer be resolved
+// by waiting.
+const sqlitePragmas = "_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000&_txlock=immediate&_cache_size=-16000"
+
 // InitDB initializes the SQLite database and creates all necessary tables
 func InitDB(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	dsn := dbPath + "?" + sqlitePragmas
+	if strings.Contains(dbPath, "?") {
+		dsn = dbPath + "&" + sqlitePragmas
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +37,16 @@ func InitDB(dbPath string) (*sql.DB, error) {
 		return nil, err
 	}
 
+	// Bring tables created by older versions up to date
+	if err := migrateTables(db); err != nil {
+		return nil, err
+	}
+
+	// Indexes may cover migrated columns, so create them last
+	if err := createIndexes(db); err != nil {
+		return nil, err
+	}
+
 	log.Println("Database initialized successfully")
 	return db, nil
 }
@@ -36,6 +59,8 @@ func createTables(db *sql.DB) error {
 			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
 			time TEXT,
 			pid TEXT,
+			ppid TEXT,
+			uid TEXT,
 			comm TEXT,
 			args TEXT
 		);`,
@@ -50,24 +75,19 @@ func createTables(db *sql.DB) error {
 			source_addr TEXT,
 			source_port TEXT,
 			dest_addr TEXT,
-			dest_port TEXT
+			dest_port TEXT,
+			dest_host TEXT
 		);`,
 
-		// Indexes for performance
-		`CREATE INDEX IF NOT EXISTS idx_processes_timestamp ON processes(timestamp);`,
-		`CREATE INDEX IF NOT EXISTS idx_network_timestamp ON network_connections(timestamp);`,
-		`CREATE INDEX IF NOT EXISTS idx_disk_timestamp ON disk_latency(timestamp);`,
-		`CREATE INDEX IF NOT EXISTS idx_cpu_timestamp ON cpu_profiles(timestamp);`,
-		`CREATE INDEX IF NOT EXISTS idx_tcp_timestamp ON tcp_lifecycle(timestamp);`,
-		`CREATE INDEX IF NOT EXISTS idx_syscall_timestamp ON syscall_stats(timestamp);`,
-
 		// Disk latency table
 		`CREATE TABLE IF NOT EXISTS disk_latency (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
 			range_min INTEGER,
 			range_max INTEGER,
-			count INTEGER
+			count INTEGER,
+			host TEXT,
+			host_labels TEXT
 		);`,
 
 		// CPU profiles table
@@ -75,6 +95,7 @@ func createTables(db *sql.DB) error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
 			process_name TEXT,
+			pid INTEGER,
 			stack_trace TEXT,
 			sample_count INTEGER
 		);`,
@@ -91,7 +112,8 @@ func createTables(db *sql.DB) error {
 			remote_port INTEGER,
 			tx_kb REAL,
 			rx_kb REAL,
-			duration_ms REAL
+			duration_ms REAL,
+			remote_host TEXT
 		);`,
 
 		// Syscall statistics table
@@ -99,7 +121,338 @@ func createTables(db *sql.DB) error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
 			syscall_name TEXT,
-			count INTEGER
+			count INTEGER,
+			host TEXT,
+			host_labels TEXT
+		);`,
+
+		// Per-process syscall counts table
+		`CREATE TABLE IF NOT EXISTS process_syscall_stats (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			pid INTEGER,
+			comm TEXT,
+			count INTEGER,
+			host TEXT,
+			host_labels TEXT
+		);`,
+
+		// Alert rules table
+		`CREATE TABLE IF NOT EXISTS alert_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			description TEXT,
+			source TEXT NOT NULL,
+			match TEXT,
+			group_by TEXT,
+			window_seconds INTEGER,
+			operator TEXT,
+			threshold REAL,
+			for_seconds INTEGER,
+			severity TEXT,
+			enabled INTEGER,
+			webhook_url TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// Alerts table, one row per alert from pending until resolved
+		`CREATE TABLE IF NOT EXISTS alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER,
+			rule_name TEXT,
+			severity TEXT,
+			group_key TEXT,
+			state TEXT,
+			value REAL,
+			summary TEXT,
+			started_at DATETIME,
+			fired_at DATETIME,
+			resolved_at DATETIME,
+			updated_at DATETIME
+		);`,
+
+		// Baselines table, one row per metric, subject and hour of the week
+		`CREATE TABLE IF NOT EXISTS baselines (
+			metric TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			slot INTEGER NOT NULL,
+			mean REAL,
+			stddev REAL,
+			samples INTEGER,
+			updated_at DATETIME,
+			PRIMARY KEY (metric, subject, slot)
+		);`,
+
+		// Destination ports each command has connected to
+		`CREATE TABLE IF NOT EXISTS baseline_dest_ports (
+			comm TEXT NOT NULL,
+			port INTEGER NOT NULL,
+			first_seen DATETIME,
+			PRIMARY KEY (comm, port)
+		);`,
+
+		// Anomalies table
+		`CREATE TABLE IF NOT EXISTS anomalies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME,
+			metric TEXT,
+			subject TEXT,
+			comm TEXT,
+			value REAL,
+			expected REAL,
+			stddev REAL,
+			score REAL,
+			method TEXT,
+			slot INTEGER
+		);`,
+
+		// Security findings table
+		`CREATE TABLE IF NOT EXISTS findings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME,
+			rule TEXT,
+			title TEXT,
+			severity TEXT,
+			techniques TEXT,
+			pid INTEGER,
+			ppid INTEGER,
+			uid TEXT,
+			comm TEXT,
+			detail TEXT
+		);`,
+
+		// Behavior profiles table, one allowlist per command name
+		`CREATE TABLE IF NOT EXISTS behavior_profiles (
+			comm TEXT PRIMARY KEY,
+			mode TEXT NOT NULL,
+			children TEXT,
+			connect TEXT,
+			syscalls TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		);`,
+
+		// Agents shipping events to this server
+		`CREATE TABLE IF NOT EXISTS agents (
+			id TEXT PRIMARY KEY,
+			host TEXT NOT NULL,
+			kernel TEXT,
+			registered_at DATETIME,
+			last_heartbeat DATETIME,
+			last_batch_at DATETIME,
+			batches INTEGER DEFAULT 0,
+			events INTEGER DEFAULT 0,
+			spooled INTEGER DEFAULT 0,
+			labels TEXT,
+			bcc_version TEXT,
+			collectors TEXT
+		);`,
+
+		// Process exits table
+		`CREATE TABLE IF NOT EXISTS process_exits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			ppid INTEGER,
+			tid INTEGER,
+			comm TEXT,
+			age_seconds REAL,
+			exit_code INTEGER,
+			signal INTEGER,
+			signal_name TEXT,
+			core_dumped INTEGER
+		);`,
+
+		// File opens table
+		`CREATE TABLE IF NOT EXISTS file_opens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			uid INTEGER,
+			pid INTEGER,
+			comm TEXT,
+			fd INTEGER,
+			errno INTEGER,
+			errno_name TEXT,
+			flags INTEGER,
+			flag_names TEXT,
+			path TEXT
+		);`,
+
+		// Off-CPU stacks table
+		`CREATE TABLE IF NOT EXISTS offcpu_stacks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			comm TEXT,
+			stack TEXT,
+			total_us INTEGER
+		);`,
+
+		// TCP retransmits table
+		`CREATE TABLE IF NOT EXISTS tcp_retransmits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			comm TEXT,
+			ip_version INTEGER,
+			local_addr TEXT,
+			local_port INTEGER,
+			remote_addr TEXT,
+			remote_port INTEGER,
+			state TEXT,
+			type TEXT
+		);`,
+
+		// TCP drops table
+		`CREATE TABLE IF NOT EXISTS tcp_drops (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			comm TEXT,
+			ip_version INTEGER,
+			source_addr TEXT,
+			source_port INTEGER,
+			dest_addr TEXT,
+			dest_port INTEGER,
+			state TEXT,
+			flags TEXT,
+			reason TEXT,
+			stack TEXT
+		);`,
+
+		// TCP round-trip time table
+		`CREATE TABLE IF NOT EXISTS tcp_rtt (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			remote_addr TEXT,
+			range_min INTEGER,
+			range_max INTEGER,
+			count INTEGER,
+			host TEXT,
+			host_labels TEXT
+		);`,
+
+		// TCP state transitions table, from tcpstates
+		`CREATE TABLE IF NOT EXISTS tcp_state_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			skaddr TEXT,
+			pid INTEGER,
+			comm TEXT,
+			local_addr TEXT,
+			local_port INTEGER,
+			remote_addr TEXT,
+			remote_port INTEGER,
+			old_state TEXT,
+			new_state TEXT,
+			duration_ms REAL
+		);`,
+
+		// DNS lookups table, from gethostlatency
+		`CREATE TABLE IF NOT EXISTS dns_lookups (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			comm TEXT,
+			hostname TEXT,
+			latency_ms REAL
+		);`,
+
+		// OOM kills table, from oomkill
+		`CREATE TABLE IF NOT EXISTS oom_kills (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			comm TEXT,
+			trigger_pid INTEGER,
+			trigger_comm TEXT,
+			pages INTEGER,
+			trigger_cgroup TEXT
+		);`,
+
+		// Signals table, from killsnoop
+		`CREATE TABLE IF NOT EXISTS signals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			comm TEXT,
+			sender_pid INTEGER,
+			sender_comm TEXT,
+			signal INTEGER,
+			signal_name TEXT,
+			errno INTEGER,
+			errno_name TEXT
+		);`,
+
+		// Slow filesystem operations table, from ext4slower, xfsslower and btrfsslower
+		`CREATE TABLE IF NOT EXISTS fs_slow_ops (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			time TEXT,
+			pid INTEGER,
+			comm TEXT,
+			filesystem TEXT,
+			op TEXT,
+			bytes INTEGER,
+			offset_kb INTEGER,
+			latency_ms REAL,
+			file TEXT
+		);`,
+
+		// Inbound connections table, from tcpaccept
+		`CREATE TABLE IF NOT EXISTS inbound_connections (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			pid INTEGER,
+			comm TEXT,
+			ip_version INTEGER,
+			remote_addr TEXT,
+			remote_port INTEGER,
+			local_addr TEXT,
+			local_port INTEGER
+		);`,
+
+		// Listening sockets table, the latest inventory of every host
+		`CREATE TABLE IF NOT EXISTS listening_sockets (
+			addr TEXT,
+			port INTEGER,
+			pid INTEGER,
+			comm TEXT,
+			uid INTEGER,
+			first_seen DATETIME,
+			last_seen DATETIME
+		);`,
+
+		// Listen events table, listening sockets opened and closed
+		`CREATE TABLE IF NOT EXISTS listen_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			kind TEXT,
+			addr TEXT,
+			port INTEGER,
+			pid INTEGER,
+			comm TEXT,
+			uid INTEGER
+		);`,
+
+		// Run queue latency table
+		`CREATE TABLE IF NOT EXISTS runq_latency (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			pidns INTEGER,
+			cgroup TEXT,
+			range_min INTEGER,
+			range_max INTEGER,
+			count INTEGER,
+			host TEXT,
+			host_labels TEXT
 		);`,
 	}
 
@@ -111,3 +464,161 @@ func createTables(db *sql.DB) error {
 
 	return nil
 }
+
+func createIndexes(db *sql.DB) error {
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_processes_timestamp ON processes(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_network_timestamp ON network_connections(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_disk_timestamp ON disk_latency(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_cpu_timestamp ON cpu_profiles(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_tcp_timestamp ON tcp_lifecycle(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_syscall_timestamp ON syscall_stats(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_process_syscall_timestamp ON process_syscall_stats(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_network_pid ON network_connections(pid);`,
+		`CREATE INDEX IF NOT EXISTS idx_tcp_pid ON tcp_lifecycle(pid);`,
+		`CREATE INDEX IF NOT EXISTS idx_exits_pid ON process_exits(pid);`,
+		`CREATE INDEX IF NOT EXISTS idx_exits_timestamp ON process_exits(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_exits_comm ON process_exits(comm);`,
+		`CREATE INDEX IF NOT EXISTS idx_processes_pid ON processes(pid);`,
+		`CREATE INDEX IF NOT EXISTS idx_processes_unit ON processes(systemd_unit);`,
+		`CREATE INDEX IF NOT EXISTS idx_processes_container ON processes(container_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_network_container ON network_connections(container_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_tcp_container ON tcp_lifecycle(container_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_cpu_container ON cpu_profiles(container_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_processes_pod ON processes(pod_namespace, pod_name);`,
+		`CREATE INDEX IF NOT EXISTS idx_network_pod ON network_connections(pod_namespace, pod_name);`,
+		`CREATE INDEX IF NOT EXISTS idx_tcp_pod ON tcp_lifecycle(pod_namespace, pod_name);`,
+		`CREATE INDEX IF NOT EXISTS idx_cpu_pod ON cpu_profiles(pod_namespace, pod_name);`,
+		`CREATE INDEX IF NOT EXISTS idx_alerts_state ON alerts(state);`,
+		`CREATE INDEX IF NOT EXISTS idx_alerts_started ON alerts(started_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_anomalies_timestamp ON anomalies(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_findings_timestamp ON findings(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_findings_rule ON findings(rule);`,
+		`CREATE INDEX IF NOT EXISTS idx_exits_pod ON process_exits(pod_namespace, pod_name);`,
+		`CREATE INDEX IF NOT EXISTS idx_processes_host ON processes(host);`,
+		`CREATE INDEX IF NOT EXISTS idx_disk_host ON disk_latency(host);`,
+		`CREATE INDEX IF NOT EXISTS idx_syscall_host ON syscall_stats(host);`,
+		`CREATE INDEX IF NOT EXISTS idx_opens_timestamp ON file_opens(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_opens_pid ON file_opens(pid);`,
+		`CREATE INDEX IF NOT EXISTS idx_opens_comm ON file_opens(comm);`,
+		`CREATE INDEX IF NOT EXISTS idx_opens_path ON file_opens(path);`,
+		`CREATE INDEX IF NOT EXISTS idx_opens_pod ON file_opens(pod_namespace, pod_name);`,
+		`CREATE INDEX IF NOT EXISTS idx_runq_timestamp ON runq_latency(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_runq_host ON runq_latency(host);`,
+		`CREATE INDEX IF NOT EXISTS idx_offcpu_timestamp ON offcpu_stacks(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_offcpu_comm ON offcpu_stacks(comm);`,
+		`CREATE INDEX IF NOT EXISTS idx_retrans_timestamp ON tcp_retransmits(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_retrans_remote ON tcp_retransmits(remote_addr, remote_port);`,
+		`CREATE INDEX IF NOT EXISTS idx_drops_timestamp ON tcp_drops(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_rtt_timestamp ON tcp_rtt(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_rtt_remote ON tcp_rtt(remote_addr);`,
+		`CREATE INDEX IF NOT EXISTS idx_inbound_timestamp ON inbound_connections(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_inbound_local_port ON inbound_connections(local_port);`,
+		`CREATE INDEX IF NOT EXISTS idx_listeners_host ON listening_sockets(host);`,
+		`CREATE INDEX IF NOT EXISTS idx_listen_events_timestamp ON listen_events(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_states_timestamp ON tcp_state_changes(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_states_skaddr ON tcp_state_changes(skaddr);`,
+		`CREATE INDEX IF NOT EXISTS idx_dns_timestamp ON dns_lookups(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_dns_hostname ON dns_lookups(hostname);`,
+		`CREATE INDEX IF NOT EXISTS idx_oom_timestamp ON oom_kills(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_signals_timestamp ON signals(timestamp);`,
+		`CREATE INDEX IF NOT EXISTS idx_signals_pid ON signals(pid);`,
+		`CREATE INDEX IF NOT EXISTS idx_fs_slow_timestamp ON fs_slow_ops(timestamp);`,
+	}
+
+	for _, index := range indexes {
+		if _, err := db.Exec(index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attributedTables are the tables whose rows are attributed to a cgroup,
+// systemd unit, container, Kubernetes pod and host
+var attributedTables = []string{"processes", "network_connections", "tcp_lifecycle", "cpu_profiles", "process_exits", "findings", "file_opens", "offcpu_stacks", "tcp_retransmits", "tcp_drops", "inbound_connections", "listening_sockets", "listen_events", "tcp_state_changes", "dns_lookups", "oom_kills", "signals", "fs_slow_ops"}
+
+// attributionColumns are added to every attributed table, in the order of models.Attribution
+var attributionColumns = []string{
+	"cgroup_path", "systemd_unit", "container_id", "container_runtime",
+	"pod_name", "pod_namespace", "pod_uid", "pod_labels", "node_name", "host",
+	"host_labels",
+}
+
+// columnMigration describes a column added to a table after its first release
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// migrateTables adds columns introduced after a table was first created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so databases
+// from older versions need the new columns added explicitly.
+func migrateTables(db *sql.DB) error {
+	columns := []columnMigration{
+		{"processes", "ppid", "TEXT"},
+		{"processes", "uid", "TEXT"},
+		{"cpu_profiles", "pid", "INTEGER"},
+		{"cpu_profiles", "comm", "TEXT"},
+		{"disk_latency", "host", "TEXT"},
+		{"syscall_stats", "host", "TEXT"},
+		{"process_syscall_stats", "host", "TEXT"},
+		{"disk_latency", "host_labels", "TEXT"},
+		{"syscall_stats", "host_labels", "TEXT"},
+		{"process_syscall_stats", "host_labels", "TEXT"},
+		{"agents", "labels", "TEXT"},
+		{"agents", "bcc_version", "TEXT"},
+		{"agents", "collectors", "TEXT"},
+		{"network_connections", "dest_host", "TEXT"},
+		{"tcp_lifecycle", "remote_host", "TEXT"},
+		{"oom_kills", "trigger_cgroup", "TEXT"},
+	}
+
+	// Every table with per-process rows carries the same attribution columns
+	for _, table := range attributedTables {
+		for _, column := range attributionColumns {
+			columns = append(columns, columnMigration{table, column, "TEXT"})
+		}
+	}
+
+	for _, c := range columns {
+		if err := addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	return err
+}
@@ -0,0 +1,337 @@
+package detect
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// downloadWindow is how long after a download by a parent process a chmod or
+// execution from a temporary directory counts as download and execute
+const downloadWindow = time.Minute
+
+// dedupWindow suppresses repeated findings of the same rule and subject
+const dedupWindow = 10 * time.Minute
+
+// maxSeenEntries triggers a sweep of expired dedup and download entries once exceeded
+const maxSeenEntries = 10000
+
+var (
+	reverseShellRes = []*regexp.Regexp{
+		regexp.MustCompile(`/dev/(tcp|udp)/`),
+		regexp.MustCompile(`(?i)\b(nc|ncat|netcat)(\.\w+)?\s.*\s-[a-z]*[ec]\s`),
+		regexp.MustCompile(`(?i)\bsocat\b.*\bexec:`),
+		regexp.MustCompile(`(?i)\bmkfifo\b.*\b(nc|ncat|netcat|openssl|telnet)\b`),
+		regexp.MustCompile(`(?i)\b(python[0-9.]*|perl|ruby|php|lua)\b.*\bsocket\b.*(pty\.spawn|dup2|/bin/(ba)?sh|subprocess|exec)`),
+		regexp.MustCompile(`(?i)\bphp\b.*\bfsockopen\b`),
+	}
+	downloadPipeRes = []*regexp.Regexp{
+		regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(\S*/)?(ba|da|z|k)?sh\b`),
+		regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(\S*/)?(python[0-9.]*|perl|ruby)\b`),
+		regexp.MustCompile(`\b(curl|wget)\b.*(&&|;)\s*(chmod\s+[+0-7ugoa]*x|(\S*/)?(ba)?sh\s+\S|\./)`),
+	}
+	chmodExecRe = regexp.MustCompile(`\bchmod\s+[+0-7ugoa]*x`)
+	minerRe     = regexp.MustCompile(`(?i)stratum\+(tcp|ssl|tls)://|--donate-level|\bxmrig\b`)
+)
+
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "ash": true, "mksh": true}
+
+var tmpDirs = []string{"/tmp/", "/var/tmp/", "/dev/shm/"}
+
+// binDirs are searched for setuid binaries started by name only
+var binDirs = []string{"/usr/local/sbin", "/usr/local/bin", "/usr/sbin", "/usr/bin", "/sbin", "/bin"}
+
+// Config tunes the detections. Lists are comma-separated.
+type Config struct {
+	MinerPorts       string // destination ports of mining pools
+	WebServers       string // command names, or prefixes like php-fpm, of web servers
+	WebServerAllowed string // CIDRs web servers may connect to
+	SetuidAllowed    string // setuid binaries non-root users may run
+}
+
+// Detector matches exec and connect events against the built-in rules
+type Detector struct {
+	minerPorts    map[int]bool
+	webServers    []string
+	webAllowed    []*net.IPNet
+	setuidAllowed map[string]bool
+
+	mu        sync.Mutex
+	downloads map[string]download // by parent PID
+	seen      map[string]time.Time
+}
+
+type download struct {
+	at   time.Time
+	args string
+}
+
+// New returns a Detector for cfg
+func New(cfg Config) (*Detector, error) {
+	d := &Detector{
+		minerPorts:    make(map[int]bool),
+		webServers:    splitList(cfg.WebServers),
+		setuidAllowed: make(map[string]bool),
+		downloads:     make(map[string]download),
+		seen:          make(map[string]time.Time),
+	}
+
+	for _, value := range splitList(cfg.MinerPorts) {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid miner port %q", value)
+		}
+		d.minerPorts[port] = true
+	}
+	for _, value := range splitList(cfg.WebServerAllowed) {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		d.webAllowed = append(d.webAllowed, network)
+	}
+	for _, name := range splitList(cfg.SetuidAllowed) {
+		d.setuidAllowed[name] = true
+	}
+
+	return d, nil
+}
+
+// Exec returns the findings raised by an exec event
+func (d *Detector) Exec(e models.ProcessEvent, now time.Time) []models.Finding {
+	pid, _ := strconv.Atoi(e.PID)
+	args := e.Args
+	var findings []models.Finding
+
+	add := func(rule, detail string) {
+		if d.firstSeen(rule+"|"+e.PID+"|"+detail, now) {
+			findings = append(findings, newExecFinding(rule, e, pid, detail, now))
+		}
+	}
+
+	if matchAny(reverseShellRes, args) {
+		add(RuleReverseShell, args)
+	} else if shells[e.Comm] {
+		if target := socketStdio(pid); target != "" {
+			add(RuleReverseShell, fmt.Sprintf("%s with %s (%s)", e.Comm, target, args))
+		}
+	}
+
+	if matchAny(downloadPipeRes, args) {
+		add(RuleDownloadExecute, args)
+	}
+
+	path := d.executable(pid, args)
+	inTmp := hasAnyPrefix(path, tmpDirs)
+	if inTmp {
+		add(RuleTmpExecution, path)
+	}
+
+	// A download by the same parent shortly before a chmod +x or an execution
+	// from a temporary directory
+	if e.Comm == "curl" || e.Comm == "wget" {
+		d.mu.Lock()
+		d.downloads[e.PPID] = download{at: now, args: args}
+		if len(d.downloads) > maxSeenEntries {
+			for ppid, dl := range d.downloads {
+				if now.Sub(dl.at) > downloadWindow {
+					delete(d.downloads, ppid)
+				}
+			}
+		}
+		d.mu.Unlock()
+	} else if inTmp || chmodExecRe.MatchString(args) {
+		d.mu.Lock()
+		dl, ok := d.downloads[e.PPID]
+		d.mu.Unlock()
+		if ok && now.Sub(dl.at) <= downloadWindow {
+			add(RuleDownloadExecute, fmt.Sprintf("%s, then %s", dl.args, args))
+		}
+	}
+
+	if minerRe.MatchString(args) {
+		add(RuleCryptoMiner, args)
+	}
+
+	if e.UID != "" && e.UID != "0" {
+		if binary := d.setuidBinary(path, args); binary != "" {
+			add(RuleSetuidNonRoot, fmt.Sprintf("uid %s ran %s", e.UID, binary))
+		}
+	}
+
+	return findings
+}
+
+// Connect returns the findings raised by a connect event
+func (d *Detector) Connect(e models.NetworkConnection, now time.Time) []models.Finding {
+	port, _ := strconv.Atoi(e.DestPort)
+	dest := net.JoinHostPort(e.DestAddr, e.DestPort)
+	var findings []models.Finding
+
+	add := func(rule, detail string) {
+		// Repeated connections of a command to the same destination are reported once
+		if d.firstSeen(rule+"|"+e.Comm+"|"+dest, now) {
+			findings = append(findings, newConnectFinding(rule, e, detail, now))
+		}
+	}
+
+	if d.minerPorts[port] {
+		add(RuleCryptoMiner, fmt.Sprintf("%s connected to mining pool port %s", e.Comm, dest))
+	}
+
+	if d.isWebServer(e.Comm) && !d.webServerAllowed(e.DestAddr) {
+		add(RuleWebServerOutbound, fmt.Sprintf("%s connected to %s", e.Comm, dest))
+	}
+
+	return findings
+}
+
+// firstSeen reports whether key was not seen within the dedup window and
+// records it
+func (d *Detector) firstSeen(key string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if at, ok := d.seen[key]; ok && now.Sub(at) < dedupWindow {
+		return false
+	}
+	d.seen[key] = now
+
+	if len(d.seen) > maxSeenEntries {
+		for k, at := range d.seen {
+			if now.Sub(at) >= dedupWindow {
+				delete(d.seen, k)
+			}
+		}
+	}
+	return true
+}
+
+// executable returns the path of the executed binary: the first argument if
+// it is absolute, else the exe link of the process if it is still running
+func (d *Detector) executable(pid int, args string) string {
+	if fields := strings.Fields(args); len(fields) > 0 && strings.HasPrefix(fields[0], "/") {
+		return fields[0]
+	}
+	exe, err := procfs.ReadExe(pid)
+	if err != nil {
+		return ""
+	}
+	return exe
+}
+
+// setuidBinary returns the path of the executed binary if it has the setuid
+// bit and is not allowed, else ""
+func (d *Detector) setuidBinary(path, args string) string {
+	if path == "" {
+		fields := strings.Fields(args)
+		if len(fields) == 0 || strings.Contains(fields[0], "/") {
+			return ""
+		}
+		for _, dir := range binDirs {
+			candidate := filepath.Join(dir, fields[0])
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path == "" || d.setuidAllowed[filepath.Base(path)] {
+		return ""
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSetuid == 0 {
+		return ""
+	}
+	return path
+}
+
+func (d *Detector) isWebServer(comm string) bool {
+	for _, name := range d.webServers {
+		if strings.HasPrefix(comm, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Detector) webServerAllowed(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return true
+	}
+	for _, network := range d.webAllowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// socketStdio returns a description of stdin or stdout of pid if it is a socket
+func socketStdio(pid int) string {
+	for fd, name := range []string{"stdin", "stdout"} {
+		target, err := procfs.ReadFD(pid, fd)
+		if err == nil && strings.HasPrefix(target, "socket:") {
+			return name + " on " + target
+		}
+	}
+	return ""
+}
+
+func newExecFinding(rule string, e models.ProcessEvent, pid int, detail string, now time.Time) models.Finding {
+	finding := NewFinding(rule, now)
+	finding.PID = pid
+	finding.PPID, _ = strconv.Atoi(e.PPID)
+	finding.UID = e.UID
+	finding.Comm = e.Comm
+	finding.Detail = detail
+	finding.Attribution = e.Attribution
+	return finding
+}
+
+func newConnectFinding(rule string, e models.NetworkConnection, detail string, now time.Time) models.Finding {
+	finding := NewFinding(rule, now)
+	finding.PID, _ = strconv.Atoi(e.PID)
+	finding.Comm = e.Comm
+	finding.Detail = detail
+	finding.Attribution = e.Attribution
+	return finding
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,88 @@
+package detect
+
+import (
+	"ebpf-dashboard/models"
+	"time"
+)
+
+// Rule IDs of the built-in detections
+const (
+	RuleReverseShell      = "reverse_shell"
+	RuleDownloadExecute   = "download_execute"
+	RuleTmpExecution      = "tmp_execution"
+	RuleCryptoMiner       = "crypto_miner"
+	RuleWebServerOutbound = "web_server_outbound"
+	RuleSetuidNonRoot     = "setuid_non_root"
+	RuleProfileViolation  = "profile_violation"
+)
+
+var rules = []models.DetectionRule{
+	{
+		ID:          RuleReverseShell,
+		Title:       "Reverse shell",
+		Description: "A shell whose stdin or stdout is a socket, or a command line redirecting a shell over the network (bash -i >& /dev/tcp, nc -e, socat exec, scripting language sockets)",
+		Severity:    models.SeverityCritical,
+		Techniques:  []string{"T1059.004", "T1095"},
+	},
+	{
+		ID:          RuleDownloadExecute,
+		Title:       "Download and execute",
+		Description: "Output of curl or wget piped into an interpreter, or a download followed by chmod +x or execution from a temporary directory",
+		Severity:    models.SeverityHigh,
+		Techniques:  []string{"T1105", "T1059.004"},
+	},
+	{
+		ID:          RuleTmpExecution,
+		Title:       "Execution from a temporary directory",
+		Description: "A binary executed from /tmp, /var/tmp or /dev/shm",
+		Severity:    models.SeverityHigh,
+		Techniques:  []string{"T1059", "T1564"},
+	},
+	{
+		ID:          RuleCryptoMiner,
+		Title:       "Crypto-miner activity",
+		Description: "A connection to a well-known mining pool port or a miner command line (stratum URLs, xmrig options)",
+		Severity:    models.SeverityHigh,
+		Techniques:  []string{"T1496"},
+	},
+	{
+		ID:          RuleWebServerOutbound,
+		Title:       "Unexpected outbound connection from a web server",
+		Description: "A web server process connecting to an address outside the allowed networks, typical for web shells",
+		Severity:    models.SeverityMedium,
+		Techniques:  []string{"T1505.003", "T1071"},
+	},
+	{
+		ID:          RuleSetuidNonRoot,
+		Title:       "Setuid binary executed by a non-root user",
+		Description: "A binary with the setuid bit executed by a user other than root that is not on the allowlist",
+		Severity:    models.SeverityMedium,
+		Techniques:  []string{"T1548.001"},
+	},
+	{
+		ID:          RuleProfileViolation,
+		Title:       "Deviation from behavior profile",
+		Description: "A command with an enforcing behavior profile exec'd a child, connected to a destination or made a syscall its profile does not allow",
+		Severity:    models.SeverityMedium,
+		Techniques:  []string{},
+	},
+}
+
+// Rules returns the built-in detection rules
+func Rules() []models.DetectionRule {
+	return rules
+}
+
+// NewFinding returns a finding of the given rule with its title, severity
+// and techniques filled in
+func NewFinding(rule string, now time.Time) models.Finding {
+	finding := models.Finding{Timestamp: now, Rule: rule}
+	for _, r := range rules {
+		if r.ID == rule {
+			finding.Title = r.Title
+			finding.Severity = r.Severity
+			finding.Techniques = r.Techniques
+		}
+	}
+	return finding
+}
@@ -0,0 +1,196 @@
+package enrich
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attributor resolves the attribution of a process
+type Attributor interface {
+	// Attribute resolves pid, falling back to ppid when pid has already exited.
+	// A ppid of 0 disables the fallback.
+	Attribute(pid, ppid int) models.Attribution
+}
+
+// cacheTTL bounds how long a resolved attribution is trusted, since a
+// process can be moved to a different cgroup while it runs
+const cacheTTL = time.Minute
+
+// maxCacheEntries triggers a sweep of stale entries once exceeded
+const maxCacheEntries = 10000
+
+var containerIDRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// podUIDRe matches the pod segment of a kubepods cgroup. The systemd cgroup
+// driver replaces the dashes of the UID with underscores.
+var podUIDRe = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})(\.slice)?$`)
+
+// containerScopePrefixes maps systemd scope name prefixes to container runtimes
+var containerScopePrefixes = []struct {
+	prefix  string
+	runtime string
+}{
+	{"docker-", "docker"},
+	{"cri-containerd-", "containerd"},
+	{"crio-", "cri-o"},
+	{"libpod-", "podman"},
+}
+
+type cacheEntry struct {
+	attribution models.Attribution
+	startTicks  uint64
+	resolvedAt  time.Time
+}
+
+// CgroupResolver resolves PIDs to cgroup path, systemd unit and container ID
+// by reading /proc. Results are cached per PID and invalidated when the PID
+// is reused by a different process, detected through its start time.
+type CgroupResolver struct {
+	mu    sync.Mutex
+	cache map[int]cacheEntry
+}
+
+func NewCgroupResolver() *CgroupResolver {
+	return &CgroupResolver{
+		cache: make(map[int]cacheEntry),
+	}
+}
+
+// Attribute implements Attributor
+func (r *CgroupResolver) Attribute(pid, ppid int) models.Attribution {
+	if attribution, ok := r.resolve(pid); ok {
+		return attribution
+	}
+	// Short-lived processes may be gone before we get to them, but they
+	// inherit the cgroup of the parent that forked them
+	if ppid > 0 {
+		if attribution, ok := r.resolve(ppid); ok {
+			return attribution
+		}
+	}
+	return models.Attribution{}
+}
+
+func (r *CgroupResolver) resolve(pid int) (models.Attribution, bool) {
+	if pid <= 0 {
+		return models.Attribution{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cached, hit := r.cache[pid]
+
+	st, err := procfs.ReadStat(pid)
+	if err != nil {
+		// The process is gone. The cached entry belongs to the last process
+		// that held this PID, which is the one the event is about.
+		return cached.attribution, hit
+	}
+	if hit && cached.startTicks == st.StartTime && now.Sub(cached.resolvedAt) < cacheTTL {
+		return cached.attribution, true
+	}
+
+	path, err := procfs.ReadCgroup(pid)
+	if err != nil {
+		return cached.attribution, hit
+	}
+
+	attribution := ParseCgroupPath(path)
+	if len(r.cache) >= maxCacheEntries {
+		r.sweep(now)
+	}
+	r.cache[pid] = cacheEntry{
+		attribution: attribution,
+		startTicks:  st.StartTime,
+		resolvedAt:  now,
+	}
+	return attribution, true
+}
+
+// sweep drops expired entries. Callers must hold r.mu.
+func (r *CgroupResolver) sweep(now time.Time) {
+	for pid, entry := range r.cache {
+		if now.Sub(entry.resolvedAt) >= cacheTTL {
+			delete(r.cache, pid)
+		}
+	}
+}
+
+// ParseCgroupPath extracts the systemd unit, container ID and pod UID from a
+// cgroup path.
+// Recognized layouts include:
+//
+//	/system.slice/nginx.service
+//	/system.slice/docker-<id>.scope                       (docker, systemd driver)
+//	/docker/<id>                                          (docker, cgroupfs driver)
+//	/kubepods.slice/.../cri-containerd-<id>.scope         (containerd)
+//	/kubepods.slice/.../crio-<id>.scope                   (cri-o)
+//	/kubepods/burstable/pod<uid>/<id>                     (kubernetes, cgroupfs driver)
+//	/kubepods.slice/kubepods-pod<uid>.slice/...           (kubernetes, systemd driver)
+//	/machine.slice/libpod-<id>.scope                      (podman)
+func ParseCgroupPath(path string) models.Attribution {
+	attribution := models.Attribution{CgroupPath: path}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+
+		if attribution.SystemdUnit == "" && strings.HasSuffix(segment, ".service") {
+			attribution.SystemdUnit = segment
+		}
+
+		if attribution.PodUID == "" {
+			if m := podUIDRe.FindStringSubmatch(segment); m != nil {
+				attribution.PodUID = strings.ReplaceAll(m[1], "_", "-")
+			}
+		}
+
+		if attribution.ContainerID != "" {
+			continue
+		}
+
+		name := strings.TrimSuffix(segment, ".scope")
+		for _, p := range containerScopePrefixes {
+			if id := strings.TrimPrefix(name, p.prefix); id != name && containerIDRe.MatchString(id) {
+				attribution.ContainerID = id
+				attribution.ContainerRuntime = p.runtime
+				break
+			}
+		}
+		if attribution.ContainerID == "" && containerIDRe.MatchString(segment) {
+			attribution.ContainerID = segment
+			attribution.ContainerRuntime = runtimeFromAncestors(segments[:i])
+		}
+	}
+
+	// Without a service, the innermost scope (e.g. a login session or a
+	// container scope) is the unit the process runs in
+	if attribution.SystemdUnit == "" {
+		for i := len(segments) - 1; i >= 0; i-- {
+			if strings.HasSuffix(segments[i], ".scope") {
+				attribution.SystemdUnit = segments[i]
+				break
+			}
+		}
+	}
+
+	return attribution
+}
+
+func runtimeFromAncestors(ancestors []string) string {
+	for _, segment := range ancestors {
+		switch {
+		case segment == "docker":
+			return "docker"
+		case strings.HasPrefix(segment, "kubepods"):
+			return "kubernetes"
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,225 @@
+package enrich
+
+import (
+	"ebpf-dashboard/models"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// lookupWindow is how far apart a lookup and a connect of the same process
+// may be seen for the connect to be taken as one to the resolved host. Both
+// are stamped when collected, which may be a second or so late.
+const lookupWindow = 5 * time.Second
+
+// hostnameTTL is how long the hostname of an address is remembered per
+// process after it was last used, which covers pooled connections opened
+// long after the lookup
+const hostnameTTL = time.Hour
+
+// HostnameResolver names the remote addresses of connections after the
+// hostnames their processes resolved just before. gethostlatency does not
+// print the addresses a lookup returned, so a connect is only named when the
+// same PID resolved exactly one hostname within lookupWindow, and the name is
+// then remembered for that PID and address. Connects of a PID that resolved
+// several hostnames in the window are ambiguous and left unnamed.
+type HostnameResolver struct {
+	mu      sync.Mutex
+	lookups map[int][]recentLookup // by PID, oldest first
+	pending map[int][]pendingAddr  // addresses connected to without a lookup yet, by PID
+	names   map[pidAddr]resolvedName
+}
+
+type recentLookup struct {
+	host string
+	at   time.Time
+}
+
+type pendingAddr struct {
+	addr string
+	at   time.Time
+}
+
+type pidAddr struct {
+	pid  int
+	addr string
+}
+
+type resolvedName struct {
+	host string
+	seen time.Time
+}
+
+func NewHostnameResolver() *HostnameResolver {
+	return &HostnameResolver{
+		lookups: make(map[int][]recentLookup),
+		pending: make(map[int][]pendingAddr),
+		names:   make(map[pidAddr]resolvedName),
+	}
+}
+
+// AddLookups remembers the hostnames resolved by lookups. Collectors are
+// drained independently, so a lookup may arrive after the connect it led to;
+// addresses the same process connected to within lookupWindow are named
+// after the lookup then, for the connections that follow, or forgotten again
+// if the lookup makes them ambiguous.
+func (r *HostnameResolver) AddLookups(lookups []models.DNSLookup) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range lookups {
+		if l.PID == 0 || l.Hostname == "" {
+			continue
+		}
+		at := eventTime(l.Timestamp, now)
+		r.lookups[l.PID] = append(r.lookups[l.PID], recentLookup{host: l.Hostname, at: at})
+		for _, p := range r.pending[l.PID] {
+			if !within(p.at, at) {
+				continue
+			}
+			key := pidAddr{l.PID, p.addr}
+			if host, n := r.lookedUp(l.PID, p.at); n == 1 {
+				r.names[key] = resolvedName{host: host, seen: now}
+			} else {
+				delete(r.names, key)
+			}
+		}
+	}
+	r.expire(now)
+}
+
+// ResolveConnections sets the hostname of the destination of connections
+func (r *HostnameResolver) ResolveConnections(events []models.NetworkConnection) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range events {
+		e := &events[i]
+		pid, _ := strconv.Atoi(e.PID)
+		e.DestHost = r.resolve(pid, NormalizeAddr(e.DestAddr), eventTime(e.Timestamp, now), now)
+	}
+	r.expire(now)
+}
+
+// ResolveSessions sets the hostname of the remote end of TCP sessions, as
+// resolved when they were opened
+func (r *HostnameResolver) ResolveSessions(events []models.TCPLifeEvent) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range events {
+		e := &events[i]
+		opened := eventTime(e.Timestamp, now).Add(-time.Duration(e.DurationMS * float64(time.Millisecond)))
+		e.RemoteHost = r.resolve(e.PID, NormalizeAddr(e.RemoteAddr), opened, now)
+	}
+	r.expire(now)
+}
+
+// resolve returns the hostname pid connected to addr by at, or "" if it is
+// unknown. r.mu must be held.
+func (r *HostnameResolver) resolve(pid int, addr string, at, now time.Time) string {
+	if pid == 0 || addr == "" {
+		return ""
+	}
+
+	key := pidAddr{pid, addr}
+	if name, ok := r.names[key]; ok {
+		name.seen = now
+		r.names[key] = name
+		return name.host
+	}
+
+	host, n := r.lookedUp(pid, at)
+	if n == 1 {
+		r.names[key] = resolvedName{host: host, seen: now}
+	}
+
+	// Lookups may still be on their way, naming the address or making it
+	// ambiguous. Once ambiguous, it stays so.
+	if n <= 1 && within(at, now) {
+		r.pending[pid] = append(r.pending[pid], pendingAddr{addr: addr, at: at})
+	}
+	if n != 1 {
+		return ""
+	}
+	return host
+}
+
+// lookedUp returns the hostname pid resolved within lookupWindow of at and
+// 1 if it resolved only that one, or "" and 0 if it resolved none and 2 if
+// several. r.mu must be held.
+func (r *HostnameResolver) lookedUp(pid int, at time.Time) (string, int) {
+	var (
+		host string
+		n    int
+	)
+	for _, l := range r.lookups[pid] {
+		if within(l.at, at) && l.host != host {
+			if n > 0 {
+				return "", 2
+			}
+			host, n = l.host, 1
+		}
+	}
+	return host, n
+}
+
+// expire drops lookups and pending addresses too old to be matched, and
+// names unused for hostnameTTL once the cache grows large. r.mu must be held.
+func (r *HostnameResolver) expire(now time.Time) {
+	for pid, lookups := range r.lookups {
+		kept := lookups[:0]
+		for _, l := range lookups {
+			if now.Sub(l.at) <= lookupWindow {
+				kept = append(kept, l)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.lookups, pid)
+		} else {
+			r.lookups[pid] = kept
+		}
+	}
+
+	for pid, pending := range r.pending {
+		kept := pending[:0]
+		for _, p := range pending {
+			if now.Sub(p.at) <= lookupWindow {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.pending, pid)
+		} else {
+			r.pending[pid] = kept
+		}
+	}
+
+	if len(r.names) > maxCacheEntries {
+		for key, name := range r.names {
+			if now.Sub(name.seen) > hostnameTTL {
+				delete(r.names, key)
+			}
+		}
+	}
+}
+
+// eventTime is the time an event was collected. Events stamped by the
+// database on insert carry no timestamp yet and are taken as collected now.
+func eventTime(t, now time.Time) time.Time {
+	if t.IsZero() {
+		return now
+	}
+	return t
+}
+
+func within(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d >= -lookupWindow && d <= lookupWindow
+}
@@ -0,0 +1,23 @@
+package enrich
+
+import "ebpf-dashboard/models"
+
+// HostAttributor decorates an Attributor with the name and labels of the
+// host the processes run on
+type HostAttributor struct {
+	base   Attributor
+	host   string
+	labels models.Labels
+}
+
+func NewHostAttributor(base Attributor, host string, labels models.Labels) *HostAttributor {
+	return &HostAttributor{base: base, host: host, labels: labels}
+}
+
+// Attribute implements Attributor
+func (a *HostAttributor) Attribute(pid, ppid int) models.Attribution {
+	attribution := a.base.Attribute(pid, ppid)
+	attribution.Host = a.host
+	attribution.HostLabels = a.labels
+	return attribution
+}
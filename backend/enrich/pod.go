@@ -0,0 +1,36 @@
+package enrich
+
+import (
+	"ebpf-dashboard/kube"
+	"ebpf-dashboard/models"
+)
+
+// PodAttributor decorates an Attributor with Kubernetes pod metadata, looked
+// up by the container ID or pod UID found in the cgroup path
+type PodAttributor struct {
+	base Attributor
+	pods *kube.PodCache
+}
+
+func NewPodAttributor(base Attributor, pods *kube.PodCache) *PodAttributor {
+	return &PodAttributor{base: base, pods: pods}
+}
+
+// Attribute implements Attributor
+func (a *PodAttributor) Attribute(pid, ppid int) models.Attribution {
+	attribution := a.base.Attribute(pid, ppid)
+	if attribution.ContainerID == "" && attribution.PodUID == "" {
+		return attribution
+	}
+
+	pod, ok := a.pods.Lookup(attribution.ContainerID, attribution.PodUID)
+	if !ok {
+		return attribution
+	}
+	attribution.PodName = pod.Name
+	attribution.PodNamespace = pod.Namespace
+	attribution.PodUID = pod.UID
+	attribution.PodLabels = pod.Labels
+	attribution.NodeName = pod.NodeName
+	return attribution
+}
@@ -0,0 +1,220 @@
+package enrich
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"log"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// SocketTuple identifies a TCP connection by its local and remote endpoint.
+// Addresses are normalized by NewSocketTuple so that tuples printed by
+// different tools compare equal.
+type SocketTuple struct {
+	LocalAddr  string
+	LocalPort  int
+	RemoteAddr string
+	RemotePort int
+}
+
+// NewSocketTuple returns the tuple of a connection, with IPv4-mapped IPv6
+// addresses in their IPv4 form
+func NewSocketTuple(localAddr string, localPort int, remoteAddr string, remotePort int) SocketTuple {
+	return SocketTuple{
+		LocalAddr:  NormalizeAddr(localAddr),
+		LocalPort:  localPort,
+		RemoteAddr: NormalizeAddr(remoteAddr),
+		RemotePort: remotePort,
+	}
+}
+
+// NormalizeAddr returns the canonical form of an IP address, unmapping
+// IPv4-mapped IPv6 addresses. Anything else is returned as it is.
+func NormalizeAddr(addr string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return addr
+	}
+	return ip.Unmap().String()
+}
+
+// SocketOwners resolves the PIDs of the processes owning the connections of
+// tuples from /proc/net/tcp and the file descriptors of all processes.
+// Connections closed in the meantime are missing from the result. File
+// descriptors are only scanned if a connection is found, which makes the
+// lookup cheap when there is nothing to resolve.
+func SocketOwners(tuples []SocketTuple) (map[SocketTuple]int, error) {
+	owners := make(map[SocketTuple]int)
+	if len(tuples) == 0 {
+		return owners, nil
+	}
+
+	sockets, err := procfs.ReadTCPSockets()
+	if err != nil {
+		return nil, err
+	}
+	byTuple := make(map[SocketTuple]uint64, len(sockets))
+	for _, s := range sockets {
+		if s.Inode == 0 {
+			continue
+		}
+		byTuple[SocketTuple{s.LocalAddr.String(), s.LocalPort, s.RemoteAddr.String(), s.RemotePort}] = s.Inode
+	}
+
+	wanted := make(map[SocketTuple]uint64)
+	for _, t := range tuples {
+		if inode, ok := byTuple[t]; ok {
+			wanted[t] = inode
+		}
+	}
+	if len(wanted) == 0 {
+		return owners, nil
+	}
+
+	inodes, err := procfs.SocketInodes()
+	if err != nil {
+		return nil, err
+	}
+	for t, inode := range wanted {
+		if pid, ok := inodes[inode]; ok {
+			owners[t] = pid
+		}
+	}
+	return owners, nil
+}
+
+// AttributeRetransmits sets the PID, command and attribution of retransmits
+// to those of the processes owning their sockets. Retransmits of sockets
+// closed in the meantime keep no PID and are attributed to the host only.
+func AttributeRetransmits(attributor Attributor, events []models.TCPRetransmit) {
+	tuples := make([]SocketTuple, len(events))
+	for i, e := range events {
+		tuples[i] = NewSocketTuple(e.LocalAddr, e.LocalPort, e.RemoteAddr, e.RemotePort)
+	}
+	owners := lookupOwners(tuples)
+	for i := range events {
+		e := &events[i]
+		e.PID, e.Comm = owners.owner(tuples[i])
+		e.Attribution = attributor.Attribute(e.PID, 0)
+	}
+}
+
+// AttributeDrops sets the PID, command and attribution of drops like
+// AttributeRetransmits. Dropped packets are mostly incoming ones, so their
+// destination is tried as the local end first.
+func AttributeDrops(attributor Attributor, events []models.TCPDrop) {
+	incoming := make([]SocketTuple, len(events))
+	outgoing := make([]SocketTuple, len(events))
+	for i, e := range events {
+		incoming[i] = NewSocketTuple(e.DestAddr, e.DestPort, e.SourceAddr, e.SourcePort)
+		outgoing[i] = NewSocketTuple(e.SourceAddr, e.SourcePort, e.DestAddr, e.DestPort)
+	}
+	owners := lookupOwners(append(incoming, outgoing...))
+	for i := range events {
+		e := &events[i]
+		e.PID, e.Comm = owners.owner(incoming[i])
+		if e.PID == 0 {
+			e.PID, e.Comm = owners.owner(outgoing[i])
+		}
+		e.Attribution = attributor.Attribute(e.PID, 0)
+	}
+}
+
+// socketOwners are the owners of the sockets of a batch of events, with
+// their commands read once per process
+type socketOwners struct {
+	pids  map[SocketTuple]int
+	comms map[int]string
+}
+
+func lookupOwners(tuples []SocketTuple) socketOwners {
+	pids, err := SocketOwners(tuples)
+	if err != nil {
+		log.Printf("Error resolving socket owners: %v", err)
+	}
+	owners := socketOwners{pids: pids, comms: make(map[int]string)}
+	for _, pid := range pids {
+		if _, ok := owners.comms[pid]; ok {
+			continue
+		}
+		if stat, err := procfs.ReadStat(pid); err == nil {
+			owners.comms[pid] = stat.Comm
+		}
+	}
+	return owners
+}
+
+func (o socketOwners) owner(t SocketTuple) (int, string) {
+	pid := o.pids[t]
+	return pid, o.comms[pid]
+}
+
+// stateOwnerTTL is how long the owner of a socket is remembered after its
+// latest transition, for sockets whose close was never seen
+const stateOwnerTTL = 10 * time.Minute
+
+// StateAttributor attributes TCP state transitions to the processes owning
+// their sockets. Owners are remembered by socket address until the socket
+// closes, as sockets in TIME_WAIT or already closed are gone from /proc.
+type StateAttributor struct {
+	attributor Attributor
+
+	mu     sync.Mutex
+	owners map[string]stateOwner // by socket address
+}
+
+type stateOwner struct {
+	pid  int
+	comm string
+	seen time.Time
+}
+
+func NewStateAttributor(attributor Attributor) *StateAttributor {
+	return &StateAttributor{attributor: attributor, owners: make(map[string]stateOwner)}
+}
+
+// Attribute sets the PID, command and attribution of events to those of the
+// owners of their sockets. tcpstates reports the process it ran in, which is
+// only the owner for transitions out of CLOSE, made by connect and listen.
+// Transitions of sockets whose owner is unknown keep no PID.
+func (a *StateAttributor) Attribute(events []models.TCPStateChange) {
+	tuples := make([]SocketTuple, len(events))
+	for i, e := range events {
+		tuples[i] = NewSocketTuple(e.LocalAddr, e.LocalPort, e.RemoteAddr, e.RemotePort)
+	}
+	found := lookupOwners(tuples)
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i := range events {
+		e := &events[i]
+		owner, known := a.owners[e.SKAddr]
+		if pid, comm := found.owner(tuples[i]); pid != 0 {
+			owner, known = stateOwner{pid: pid, comm: comm}, true
+		} else if !known && e.OldState == "CLOSE" && e.PID != 0 {
+			owner, known = stateOwner{pid: e.PID, comm: e.Comm}, true
+		}
+
+		e.PID, e.Comm = owner.pid, owner.comm
+		e.Attribution = a.attributor.Attribute(e.PID, 0)
+
+		if e.NewState == "CLOSE" {
+			delete(a.owners, e.SKAddr)
+		} else if known {
+			owner.seen = now
+			a.owners[e.SKAddr] = owner
+		}
+	}
+
+	if len(a.owners) > maxCacheEntries {
+		for addr, owner := range a.owners {
+			if now.Sub(owner.seen) > stateOwnerTTL {
+				delete(a.owners, addr)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"crypto/subtle"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"ebpf-dashboard/services"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchBytes bounds the decompressed size of a batch
+const maxBatchBytes = 64 << 20
+
+type AgentHandler struct {
+	service services.IngestService
+}
+
+func NewAgentHandler(service services.IngestService) *AgentHandler {
+	return &AgentHandler{service: service}
+}
+
+// RequireAgentToken rejects requests that do not carry token as bearer
+// token. An empty token disables the check.
+func RequireAgentToken(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if token == "" {
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid agent token"})
+		}
+	}
+}
+
+// Register handles POST /api/agents/register
+func (h *AgentHandler) Register(c *gin.Context) {
+	var reg models.AgentRegistration
+	if err := c.ShouldBindJSON(&reg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	agent, err := h.service.Register(reg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, agent)
+}
+
+// Heartbeat handles POST /api/agents/:id/heartbeat
+func (h *AgentHandler) Heartbeat(c *gin.Context) {
+	var hb models.AgentHeartbeat
+	if err := c.ShouldBindJSON(&hb); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Heartbeat(c.Param("id"), hb); err != nil {
+		respondAgentError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Ingest handles POST /api/agents/batches. The body is a JSON batch,
+// optionally gzip-compressed.
+func (h *AgentHandler) Ingest(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if c.GetHeader("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	var batch models.Batch
+	if err := json.NewDecoder(io.LimitReader(body, maxBatchBytes)).Decode(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if batch.ID == "" || batch.AgentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "batch id and agent_id are required"})
+		return
+	}
+
+	stored, err := h.service.Ingest(batch)
+	if err != nil {
+		respondAgentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":    batch.Len(),
+		"duplicate": !stored,
+	})
+}
+
+// GetAgents handles GET /api/agents
+func (h *AgentHandler) GetAgents(c *gin.Context) {
+	agents, err := h.service.GetAgents()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(agents),
+		"data":  agents,
+	})
+}
+
+func respondAgentError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		// Tells the agent to register again
+		c.JSON(http.StatusNotFound, gin.H{"error": "agent not registered"})
+		return
+	}
+	if errors.Is(err, services.ErrBatchInProgress) {
+		// Tells the agent to resend the batch later
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"ebpf-dashboard/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AlertHandler struct {
+	service services.AlertService
+}
+
+func NewAlertHandler(service services.AlertService) *AlertHandler {
+	return &AlertHandler{service: service}
+}
+
+// GetAlerts handles GET /api/alerts
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	since, _, err := parseWindow(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state := c.Query("state")
+	switch state {
+	case "", models.AlertPending, models.AlertFiring, models.AlertResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid state %q", state)})
+		return
+	}
+
+	alerts, err := h.service.GetAlerts(state, since, parseLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(alerts),
+		"data":  alerts,
+	})
+}
+
+// GetRules handles GET /api/alerts/rules
+func (h *AlertHandler) GetRules(c *gin.Context) {
+	rules, err := h.service.GetRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(rules),
+		"data":  rules,
+	})
+}
+
+// GetRule handles GET /api/alerts/rules/:id
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	id, err := parseRuleID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.GetRule(id)
+	if err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule handles POST /api/alerts/rules
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	rule := models.AlertRule{Enabled: true}
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.CreateRule(rule)
+	if err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateRule handles PUT /api/alerts/rules/:id
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	id, err := parseRuleID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.AlertRule{Enabled: true}
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.service.UpdateRule(id, rule)
+	if err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRule handles DELETE /api/alerts/rules/:id
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id, err := parseRuleID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.DeleteRule(id); err != nil {
+		respondRuleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func parseRuleID(c *gin.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid rule id %q", c.Param("id"))
+	}
+	return id, nil
+}
+
+func respondRuleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"ebpf-dashboard/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attributionGroups maps the accepted values of the "by" parameter to columns
+var attributionGroups = map[string]string{
+	"unit":         "systemd_unit",
+	"systemd_unit": "systemd_unit",
+	"container":    "container_id",
+	"container_id": "container_id",
+	"cgroup":       "cgroup_path",
+	"cgroup_path":  "cgroup_path",
+	"namespace":    "pod_namespace",
+	"pod":          "pod",
+	"host":         "host",
+}
+
+type AttributionHandler struct {
+	service services.AttributionService
+}
+
+func NewAttributionHandler(service services.AttributionService) *AttributionHandler {
+	return &AttributionHandler{service: service}
+}
+
+// GetSummary handles GET /api/attribution/summary
+func (h *AttributionHandler) GetSummary(c *gin.Context) {
+	groupBy, ok := attributionGroups[c.DefaultQuery("by", "unit")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "by must be one of unit, container, cgroup, namespace, pod or host"})
+		return
+	}
+
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.service.GetSummary(groupBy, since, until, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group_by": groupBy,
+		"count":    len(summary),
+		"data":     summary,
+	})
+}
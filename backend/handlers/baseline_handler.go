@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BaselineHandler struct {
+	service services.BaselineService
+}
+
+func NewBaselineHandler(service services.BaselineService) *BaselineHandler {
+	return &BaselineHandler{service: service}
+}
+
+// GetAnomalies handles GET /api/anomalies
+func (h *BaselineHandler) GetAnomalies(c *gin.Context) {
+	since, until, err := parseWindow(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	metric, err := parseMetric(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	anomalies, err := h.service.GetAnomalies(since, until, metric, c.Query("comm"), parseLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(anomalies),
+		"data":  anomalies,
+	})
+}
+
+// GetBaselines handles GET /api/baselines
+func (h *BaselineHandler) GetBaselines(c *gin.Context) {
+	metric, err := parseMetric(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	baselines, err := h.service.GetBaselines(metric, c.Query("subject"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(baselines),
+		"data":  baselines,
+	})
+}
+
+// parseMetric reads the optional baseline metric query parameter
+func parseMetric(c *gin.Context) (string, error) {
+	metric := c.Query("metric")
+	switch metric {
+	case "", models.BaselineSyscallRate, models.BaselineExecCount, models.BaselineNewDestPorts, models.BaselineTCPDuration:
+		return metric, nil
+	}
+	return "", fmt.Errorf("invalid metric %q", metric)
+}
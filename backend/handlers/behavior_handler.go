@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"ebpf-dashboard/repository"
+	"ebpf-dashboard/services"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BehaviorHandler struct {
+	service services.BehaviorService
+}
+
+func NewBehaviorHandler(service services.BehaviorService) *BehaviorHandler {
+	return &BehaviorHandler{service: service}
+}
+
+// GetProfiles handles GET /api/profiles
+func (h *BehaviorHandler) GetProfiles(c *gin.Context) {
+	profiles := h.service.GetProfiles()
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(profiles),
+		"data":  profiles,
+	})
+}
+
+// GetProfile handles GET /api/profiles/:comm
+func (h *BehaviorHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.Param("comm"))
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SetMode handles PUT /api/profiles/:comm/mode
+func (h *BehaviorHandler) SetMode(c *gin.Context) {
+	var body struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.service.SetMode(c.Param("comm"), body.Mode)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// DeleteProfile handles DELETE /api/profiles/:comm
+func (h *BehaviorHandler) DeleteProfile(c *gin.Context) {
+	if err := h.service.DeleteProfile(c.Param("comm")); err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportProfiles handles GET /api/profiles/export
+func (h *BehaviorHandler) ExportProfiles(c *gin.Context) {
+	var comms []string
+	for _, comm := range strings.Split(c.Query("comm"), ",") {
+		if comm = strings.TrimSpace(comm); comm != "" {
+			comms = append(comms, comm)
+		}
+	}
+
+	data, err := h.service.Export(comms)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
+// ImportProfiles handles POST /api/profiles/import
+func (h *BehaviorHandler) ImportProfiles(c *gin.Context) {
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	imported, err := h.service.Import(data, c.Query("merge") == "true")
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
+
+func respondProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "profile not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
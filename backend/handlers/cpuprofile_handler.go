@@ -31,7 +31,13 @@ func (h *CPUProfileHandler) GetCPUProfiles(c *gin.Context) {
 		}
 	}
 
-	profiles, err := h.service.GetRecentProfiles(limit)
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profiles, err := h.service.GetRecentProfiles(limit, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
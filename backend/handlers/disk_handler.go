@@ -27,7 +27,13 @@ func (h *DiskHandler) GetLatestLatency(c *gin.Context) {
 		limit = 1000
 	}
 
-	latencies, err := h.service.GetLatestLatency(limit)
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	latencies, err := h.service.GetLatestLatency(limit, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DNSHandler struct {
+	service services.DNSService
+}
+
+func NewDNSHandler(service services.DNSService) *DNSHandler {
+	return &DNSHandler{service: service}
+}
+
+// GetRecentLookups handles GET /api/metrics/gethostlatency
+func (h *DNSHandler) GetRecentLookups(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lookups, err := h.service.GetRecentLookups(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(lookups),
+		"data":  lookups,
+	})
+}
+
+// GetHostStats handles GET /api/dns/hosts
+func (h *DNSHandler) GetHostStats(c *gin.Context) {
+	q, ok := parseDNSQuery(c, 0)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetHostStats(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(stats),
+		"data":  stats,
+	})
+}
+
+// GetSlowLookups handles GET /api/dns/slow
+func (h *DNSHandler) GetSlowLookups(c *gin.Context) {
+	q, ok := parseDNSQuery(c, 100*time.Millisecond)
+	if !ok {
+		return
+	}
+
+	lookups, err := h.service.GetSlowLookups(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(lookups),
+		"data":  lookups,
+	})
+}
+
+// parseDNSQuery reads the window, hostname, comm, min_latency and filter
+// parameters, writing the response if one is invalid
+func parseDNSQuery(c *gin.Context, defaultMinLatency time.Duration) (models.DNSQuery, bool) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.DNSQuery{}, false
+	}
+
+	// Lookups taking at least min_latency
+	minLatency := defaultMinLatency
+	if v := c.Query("min_latency"); v != "" {
+		minLatency, err = time.ParseDuration(v)
+		if err != nil || minLatency < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid min_latency %q", v)})
+			return models.DNSQuery{}, false
+		}
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.DNSQuery{}, false
+	}
+
+	return models.DNSQuery{
+		Since:        since,
+		Until:        until,
+		Hostname:     strings.ToLower(strings.TrimSuffix(c.Query("hostname"), ".")),
+		Comm:         c.Query("comm"),
+		MinLatencyMS: float64(minLatency) / float64(time.Millisecond),
+		Filter:       filter,
+		Limit:        parseLimit(c),
+	}, true
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"ebpf-dashboard/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExitHandler struct {
+	service services.ExitService
+}
+
+func NewExitHandler(service services.ExitService) *ExitHandler {
+	return &ExitHandler{service: service}
+}
+
+// GetRecentExits handles GET /api/metrics/exits
+func (h *ExitHandler) GetRecentExits(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exits, err := h.service.GetRecentExits(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(exits),
+		"data":  exits,
+	})
+}
+
+// GetLifetimes handles GET /api/lifecycle/lifetimes
+func (h *ExitHandler) GetLifetimes(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lifetimes, err := h.service.GetLifetimes(since, until, c.Query("comm"), parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(lifetimes),
+		"data":  lifetimes,
+	})
+}
+
+// GetShortLivedStorms handles GET /api/lifecycle/short-lived
+func (h *ExitHandler) GetShortLivedStorms(c *gin.Context) {
+	since, until, err := parseWindow(c, 10*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Processes living up to max_age (default: 1s) count towards a storm
+	maxAge := time.Second
+	if v := c.Query("max_age"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid max_age"})
+			return
+		}
+		maxAge = d
+	}
+	minCount := queryInt(c, "min_count", 20)
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	storms, err := h.service.GetShortLivedStorms(since, until, maxAge, minCount, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(storms),
+		"data":  storms,
+	})
+}
+
+// GetCrashLoops handles GET /api/lifecycle/crashloops
+func (h *ExitHandler) GetCrashLoops(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loops, err := h.service.GetCrashLoops(since, until, queryInt(c, "min_failures", 3), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(loops),
+		"data":  loops,
+	})
+}
+
+// GetKilled handles GET /api/lifecycle/killed
+func (h *ExitHandler) GetKilled(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Optional signal number filter, e.g. signal=9
+	signal := 0
+	if v := c.Query("signal"); v != "" {
+		signal, err = strconv.Atoi(v)
+		if err != nil || signal <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signal"})
+			return
+		}
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	killed, err := h.service.GetKilled(since, until, signal, parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(killed),
+		"data":  killed,
+	})
+}
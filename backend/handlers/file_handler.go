@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+	service services.FileService
+}
+
+func NewFileHandler(service services.FileService) *FileHandler {
+	return &FileHandler{service: service}
+}
+
+// GetRecentOpens handles GET /api/metrics/opens
+func (h *FileHandler) GetRecentOpens(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opens, err := h.service.GetRecentOpens(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(opens),
+		"data":  opens,
+	})
+}
+
+// GetOpens handles GET /api/files/opens
+func (h *FileHandler) GetOpens(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opens, err := h.service.GetOpens(models.FileOpenQuery{
+		Since:      since,
+		Until:      until,
+		PID:        queryInt(c, "pid", 0),
+		Comm:       c.Query("comm"),
+		Path:       c.Query("path"),
+		PathPrefix: c.Query("path_prefix"),
+		FailedOnly: c.Query("failed") == "true",
+		Errno:      strings.ToUpper(c.Query("errno")),
+		Filter:     filter,
+		Limit:      parseLimit(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(opens),
+		"data":  opens,
+	})
+}
+
+// GetFailures handles GET /api/files/failures
+func (h *FileHandler) GetFailures(c *gin.Context) {
+	since, until, err := parseWindow(c, 10*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Optional errno name filter, e.g. errno=ENOENT
+	errno := strings.ToUpper(c.Query("errno"))
+	failures, err := h.service.GetFailures(since, until, errno, queryInt(c, "min_count", 1), parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(failures),
+		"data":  failures,
+	})
+}
+
+// GetAccessors handles GET /api/files/accessors
+func (h *FileHandler) GetAccessors(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path is required"})
+		return
+	}
+
+	since, until, err := parseWindow(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accessors, err := h.service.GetAccessors(path, since, until, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(accessors),
+		"data":  accessors,
+	})
+}
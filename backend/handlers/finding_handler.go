@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FindingHandler struct {
+	service services.FindingService
+}
+
+func NewFindingHandler(service services.FindingService) *FindingHandler {
+	return &FindingHandler{service: service}
+}
+
+// GetFindings handles GET /api/findings
+func (h *FindingHandler) GetFindings(c *gin.Context) {
+	since, until, err := parseWindow(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	severity := c.Query("severity")
+	if severity != "" && models.SeverityRank(severity) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid severity %q", severity)})
+		return
+	}
+
+	findings, err := h.service.GetFindings(models.FindingQuery{
+		Since:    since,
+		Until:    until,
+		Severity: severity,
+		Rule:     c.Query("rule"),
+		PID:      queryInt(c, "pid", 0),
+		Comm:     c.Query("comm"),
+		Filter:   filter,
+		Limit:    parseLimit(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(findings),
+		"data":  findings,
+	})
+}
+
+// GetRules handles GET /api/findings/rules
+func (h *FindingHandler) GetRules(c *gin.Context) {
+	rules := h.service.GetRules()
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(rules),
+		"data":  rules,
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FlameGraphHandler struct {
+	service services.FlameGraphService
+}
+
+func NewFlameGraphHandler(service services.FlameGraphService) *FlameGraphHandler {
+	return &FlameGraphHandler{service: service}
+}
+
+// GetFlameGraph handles GET /api/flamegraph. The type selects on-CPU
+// (default), off-CPU or wall-clock stacks; format=folded returns the input
+// of flamegraph.pl instead of a JSON tree.
+func (h *FlameGraphHandler) GetFlameGraph(c *gin.Context) {
+	since, until, err := parseWindow(c, 15*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := c.DefaultQuery("type", models.FlameOnCPU)
+	q := models.StackQuery{
+		Since:  since,
+		Until:  until,
+		PID:    queryInt(c, "pid", 0),
+		Comm:   c.Query("comm"),
+		Filter: filter,
+	}
+
+	if c.Query("format") == "folded" {
+		lines, err := h.service.GetFolded(kind, q)
+		if err != nil {
+			respondFlameGraphError(c, err)
+			return
+		}
+		if len(lines) == 0 {
+			c.String(http.StatusOK, "")
+			return
+		}
+		c.String(http.StatusOK, strings.Join(lines, "\n")+"\n")
+		return
+	}
+
+	graph, err := h.service.GetFlameGraph(kind, q)
+	if err != nil {
+		respondFlameGraphError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+func respondFlameGraphError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidFlameGraph) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
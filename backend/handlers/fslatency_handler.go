@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FSLatencyHandler struct {
+	service services.FSLatencyService
+}
+
+func NewFSLatencyHandler(service services.FSLatencyService) *FSLatencyHandler {
+	return &FSLatencyHandler{service: service}
+}
+
+// GetRecentSlowOps handles GET /api/metrics/fsslower
+func (h *FSLatencyHandler) GetRecentSlowOps(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ops, err := h.service.GetRecentSlowOps(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(ops),
+		"data":  ops,
+	})
+}
+
+// GetSlowFiles handles GET /api/fs/slow-files
+func (h *FSLatencyHandler) GetSlowFiles(c *gin.Context) {
+	q, err := parseSlowFileOpQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	files, err := h.service.GetSlowFiles(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(files),
+		"data":  files,
+	})
+}
+
+// GetProcessLatency handles GET /api/fs/processes
+func (h *FSLatencyHandler) GetProcessLatency(c *gin.Context) {
+	q, err := parseSlowFileOpQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	processes, err := h.service.GetProcessLatency(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(processes),
+		"data":  processes,
+	})
+}
+
+// parseSlowFileOpQuery reads the window, comm, fs (ext4, xfs or btrfs),
+// op (R, W, O or S), filter and limit parameters
+func parseSlowFileOpQuery(c *gin.Context) (models.SlowFileOpQuery, error) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		return models.SlowFileOpQuery{}, err
+	}
+
+	fs := c.Query("fs")
+	switch fs {
+	case "", "ext4", "xfs", "btrfs":
+	default:
+		return models.SlowFileOpQuery{}, fmt.Errorf("invalid fs %q, must be ext4, xfs or btrfs", fs)
+	}
+
+	op := c.Query("op")
+	switch op {
+	case "", models.FSOpRead, models.FSOpWrite, models.FSOpOpen, models.FSOpSync:
+	default:
+		return models.SlowFileOpQuery{}, fmt.Errorf("invalid op %q, must be R, W, O or S", op)
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		return models.SlowFileOpQuery{}, err
+	}
+
+	return models.SlowFileOpQuery{
+		Since:      since,
+		Until:      until,
+		Comm:       c.Query("comm"),
+		Filesystem: fs,
+		Op:         op,
+		Filter:     filter,
+		Limit:      parseLimit(c),
+	}, nil
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"ebpf-dashboard/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HostHandler struct {
+	service services.HostService
+}
+
+func NewHostHandler(service services.HostService) *HostHandler {
+	return &HostHandler{service: service}
+}
+
+// GetHosts handles GET /api/hosts
+func (h *HostHandler) GetHosts(c *gin.Context) {
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hosts, err := h.service.GetHosts(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(hosts),
+		"data":  hosts,
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InboundHandler struct {
+	service services.InboundService
+}
+
+func NewInboundHandler(service services.InboundService) *InboundHandler {
+	return &InboundHandler{service: service}
+}
+
+// GetRecentInbound handles GET /api/metrics/tcpaccept
+func (h *InboundHandler) GetRecentInbound(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.service.GetRecentInbound(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
+
+// GetServiceClients handles GET /api/inbound/clients
+func (h *InboundHandler) GetServiceClients(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clients, err := h.service.GetServiceClients(models.InboundQuery{
+		Since:     since,
+		Until:     until,
+		LocalPort: queryInt(c, "port", 0),
+		Comm:      c.Query("comm"),
+		Filter:    filter,
+		Limit:     parseLimit(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(clients),
+		"data":  clients,
+	})
+}
+
+// GetListeners handles GET /api/inbound/listeners
+func (h *InboundHandler) GetListeners(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sockets, err := h.service.GetListeners(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(sockets),
+		"data":  sockets,
+	})
+}
+
+// GetListenEvents handles GET /api/inbound/listen-events
+func (h *InboundHandler) GetListenEvents(c *gin.Context) {
+	since, until, err := parseWindow(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kind := c.Query("kind")
+	if kind != "" && kind != models.ListenOpened && kind != models.ListenClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be opened or closed"})
+		return
+	}
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.service.GetListenEvents(since, until, kind, parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KillHandler struct {
+	service services.KillService
+}
+
+func NewKillHandler(service services.KillService) *KillHandler {
+	return &KillHandler{service: service}
+}
+
+// GetRecentOOMKills handles GET /api/metrics/oomkill
+func (h *KillHandler) GetRecentOOMKills(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kills, err := h.service.GetRecentOOMKills(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(kills),
+		"data":  kills,
+	})
+}
+
+// GetRecentSignals handles GET /api/metrics/killsnoop
+func (h *KillHandler) GetRecentSignals(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signals, err := h.service.GetRecentSignals(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(signals),
+		"data":  signals,
+	})
+}
+
+// GetOOMKills handles GET /api/lifecycle/oom-kills
+func (h *KillHandler) GetOOMKills(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kills, err := h.service.GetOOMKills(since, until, c.Query("comm"), parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(kills),
+		"data":  kills,
+	})
+}
+
+// GetSignals handles GET /api/lifecycle/signals
+func (h *KillHandler) GetSignals(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Optional target PID, negative for a process group
+	pid := 0
+	if v := c.Query("pid"); v != "" {
+		pid, err = strconv.Atoi(v)
+		if err != nil || pid == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pid"})
+			return
+		}
+	}
+
+	// Optional signal number filter, e.g. signal=9
+	signal := 0
+	if v := c.Query("signal"); v != "" {
+		signal, err = strconv.Atoi(v)
+		if err != nil || signal <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signal"})
+			return
+		}
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signals, err := h.service.GetSignals(models.SignalQuery{
+		Since:      since,
+		Until:      until,
+		PID:        pid,
+		Comm:       c.Query("comm"),
+		SenderComm: c.Query("sender"),
+		Signal:     signal,
+		Filter:     filter,
+		Limit:      parseLimit(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(signals),
+		"data":  signals,
+	})
+}
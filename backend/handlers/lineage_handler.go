@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"ebpf-dashboard/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LineageHandler struct {
+	service services.LineageService
+}
+
+func NewLineageHandler(service services.LineageService) *LineageHandler {
+	return &LineageHandler{service: service}
+}
+
+// GetAncestors handles GET /api/lineage/:pid/ancestors
+func (h *LineageHandler) GetAncestors(c *gin.Context) {
+	pid, err := parsePID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chain, err := h.service.Ancestors(pid)
+	if err != nil {
+		respondLineageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pid":   pid,
+		"chain": services.FormatChain(chain),
+		"count": len(chain),
+		"data":  chain,
+	})
+}
+
+// GetDescendants handles GET /api/lineage/:pid/descendants
+func (h *LineageHandler) GetDescendants(c *gin.Context) {
+	pid, err := parsePID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tree, err := h.service.Descendants(pid)
+	if err != nil {
+		respondLineageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pid":  pid,
+		"data": tree,
+	})
+}
+
+// GetTree handles GET /api/lineage/tree
+func (h *LineageHandler) GetTree(c *gin.Context) {
+	since, until, err := parseWindow(c, 15*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	forest := h.service.TreeBetween(since, until)
+
+	c.JSON(http.StatusOK, gin.H{
+		"since": since,
+		"until": until,
+		"count": len(forest),
+		"data":  forest,
+	})
+}
+
+func respondLineageError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrProcessNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
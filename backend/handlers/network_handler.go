@@ -27,7 +27,13 @@ func (h *NetworkHandler) GetRecentConnections(c *gin.Context) {
 		limit = 1000
 	}
 
-	connections, err := h.service.GetRecentConnections(limit)
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	connections, err := h.service.GetRecentConnections(limit, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"ebpf-dashboard/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OffCPUHandler struct {
+	service services.OffCPUService
+}
+
+func NewOffCPUHandler(service services.OffCPUService) *OffCPUHandler {
+	return &OffCPUHandler{service: service}
+}
+
+// GetRecentStacks handles GET /api/metrics/offcpu
+func (h *OffCPUHandler) GetRecentStacks(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stacks, err := h.service.GetRecentStacks(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(stacks),
+		"data":  stacks,
+	})
+}
@@ -27,7 +27,13 @@ func (h *ProcessHandler) GetRecentProcesses(c *gin.Context) {
 		limit = 1000
 	}
 
-	processes, err := h.service.GetRecentProcesses(limit)
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	processes, err := h.service.GetRecentProcesses(limit, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
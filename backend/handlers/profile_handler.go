@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	service services.ProfileService
+}
+
+func NewProfileHandler(service services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{service: service}
+}
+
+// GetByPID handles GET /api/processes/:pid
+func (h *ProfileHandler) GetByPID(c *gin.Context) {
+	pid, err := parsePID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.respond(c, models.ProcessSelector{PID: pid})
+}
+
+// GetByComm handles GET /api/processes/comm/:comm
+func (h *ProfileHandler) GetByComm(c *gin.Context) {
+	h.respond(c, models.ProcessSelector{Comm: c.Param("comm")})
+}
+
+func (h *ProfileHandler) respond(c *gin.Context, sel models.ProcessSelector) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if sel.Filter, err = parseHostFilter(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.service.GetProfile(sel, since, until, parseLimit(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseLimit reads the limit query parameter (default: 100, max: 1000)
+func parseLimit(c *gin.Context) int {
+	limit := 100
+	if parsedLimit, err := strconv.Atoi(c.Query("limit")); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	return limit
+}
+
+// parseWindow reads the time window of a query. Either an absolute window is
+// given with since/until (RFC3339) or a relative one with window (e.g. 15m),
+// which ends now. Without any of them defaultWindow is used.
+func parseWindow(c *gin.Context, defaultWindow time.Duration) (time.Time, time.Time, error) {
+	now := time.Now()
+	since := now.Add(-defaultWindow)
+	until := now
+
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid window %q", w)
+		}
+		since = now.Add(-d)
+	}
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid since %q", v)
+		}
+		since = t
+	}
+	if v := c.Query("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid until %q", v)
+		}
+		until = t
+	}
+	if until.Before(since) {
+		return time.Time{}, time.Time{}, fmt.Errorf("until must not be before since")
+	}
+
+	return since, until, nil
+}
+
+// parsePID reads a PID path parameter
+func parsePID(c *gin.Context) (int, error) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid %q", c.Param("pid"))
+	}
+	return pid, nil
+}
+
+// parseFilter reads the Kubernetes and host filters of a query: namespace,
+// pod and label, plus those of parseHostFilter. Labels are given as key=value
+// and may be repeated or comma-separated; all of them have to match.
+func parseFilter(c *gin.Context) (models.Filter, error) {
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		return models.Filter{}, err
+	}
+	filter.Namespace = c.Query("namespace")
+	filter.Pod = c.Query("pod")
+	if filter.Labels, err = parseSelectors(c, "label"); err != nil {
+		return models.Filter{}, err
+	}
+	return filter, nil
+}
+
+// parseHostFilter reads the host filters of a query: host and host_label,
+// which is given like label. Host-wide data such as disk latency and syscall
+// counts can only be narrowed down by host.
+func parseHostFilter(c *gin.Context) (models.Filter, error) {
+	labels, err := parseSelectors(c, "host_label")
+	if err != nil {
+		return models.Filter{}, err
+	}
+	return models.Filter{Host: c.Query("host"), HostLabels: labels}, nil
+}
+
+// parseSelectors reads the key=value pairs of a repeatable, comma-separated
+// query parameter
+func parseSelectors(c *gin.Context, param string) (map[string]string, error) {
+	var selectors map[string]string
+	for _, value := range c.QueryArray(param) {
+		for _, selector := range strings.Split(value, ",") {
+			if selector = strings.TrimSpace(selector); selector == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(selector, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid %s %q, expected key=value", param, selector)
+			}
+			// Keys end up quoted in JSON paths, where these cannot be taken literally
+			if strings.ContainsAny(key, `"\`) {
+				return nil, fmt.Errorf("invalid %s key %q, must not contain quotes or backslashes", param, key)
+			}
+			if selectors == nil {
+				selectors = make(map[string]string)
+			}
+			selectors[key] = val
+		}
+	}
+	return selectors, nil
+}
+
+// queryInt reads a positive integer query parameter, falling back to defaultValue
+func queryInt(c *gin.Context, key string, defaultValue int) int {
+	if v, err := strconv.Atoi(c.Query(key)); err == nil && v > 0 {
+		return v
+	}
+	return defaultValue
+}
+
+// maxSteps bounds the number of steps a time series query may return
+const maxSteps = 1000
+
+// parseStep reads the step of a time series over since..until, e.g. 1m.
+// Without one the window is split into about 100 steps, rounded up to a
+// multiple of minStep.
+func parseStep(c *gin.Context, since, until time.Time, minStep time.Duration) (time.Duration, error) {
+	window := until.Sub(since)
+
+	v := c.Query("step")
+	if v == "" {
+		steps := (window/100 + minStep - 1) / minStep
+		return max(steps, 1) * minStep, nil
+	}
+
+	step, err := time.ParseDuration(v)
+	if err != nil || step < time.Second {
+		return 0, fmt.Errorf("invalid step %q, expected a duration of at least 1s", v)
+	}
+	if window/step > maxSteps {
+		return 0, fmt.Errorf("step %s is too small for the window, at most %d steps are allowed", step, maxSteps)
+	}
+	return step, nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runqSnapshotInterval is how often run queue latency histograms are saved,
+// and so the shortest step of their time series
+const runqSnapshotInterval = 5 * time.Second
+
+type RunqHandler struct {
+	service services.RunqService
+}
+
+func NewRunqHandler(service services.RunqService) *RunqHandler {
+	return &RunqHandler{service: service}
+}
+
+// GetLatestRunq handles GET /api/metrics/runqlat
+func (h *RunqHandler) GetLatestRunq(c *gin.Context) {
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	latencies, err := h.service.GetLatestRunq(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(latencies),
+		"data":  latencies,
+	})
+}
+
+// GetPercentiles handles GET /api/runqueue/percentiles
+func (h *RunqHandler) GetPercentiles(c *gin.Context) {
+	q, step, err := parseRunqQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	percentiles, err := h.service.GetPercentiles(q, step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(percentiles),
+		"step":  step.String(),
+		"data":  percentiles,
+	})
+}
+
+// GetHeatmap handles GET /api/runqueue/heatmap
+func (h *RunqHandler) GetHeatmap(c *gin.Context) {
+	q, step, err := parseRunqQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	heatmap, err := h.service.GetHeatmap(q, step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// parseRunqQuery reads the window, step, host filter and PID namespace of a
+// run queue latency query
+func parseRunqQuery(c *gin.Context) (models.RunqQuery, time.Duration, error) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		return models.RunqQuery{}, 0, err
+	}
+	step, err := parseStep(c, since, until, runqSnapshotInterval)
+	if err != nil {
+		return models.RunqQuery{}, 0, err
+	}
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		return models.RunqQuery{}, 0, err
+	}
+
+	q := models.RunqQuery{Since: since, Until: until, Filter: filter}
+	if v := c.Query("pidns"); v != "" {
+		if q.PIDNS, err = strconv.ParseInt(v, 10, 64); err != nil || q.PIDNS <= 0 {
+			return models.RunqQuery{}, 0, fmt.Errorf("invalid pidns %q", v)
+		}
+	}
+	return q, step, nil
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StorageHandler struct {
+	backend string
+	writer  *repository.SQLiteWriter // nil unless the backend is SQLite
+}
+
+func NewStorageHandler(backend string, writer *repository.SQLiteWriter) *StorageHandler {
+	return &StorageHandler{backend: backend, writer: writer}
+}
+
+// GetStats handles GET /api/storage
+func (h *StorageHandler) GetStats(c *gin.Context) {
+	stats := models.StorageStats{Backend: h.backend}
+	if h.writer != nil {
+		writer := h.writer.Stats()
+		stats.Writer = &writer
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -31,7 +31,33 @@ func (h *SyscallHandler) GetSyscallStats(c *gin.Context) {
 		}
 	}
 
-	stats, err := h.service.GetRecentStats(limit)
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.service.GetRecentStats(limit, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(stats),
+		"data":  stats,
+	})
+}
+
+// GetProcessSyscallStats handles GET /api/metrics/syscalls/processes
+func (h *SyscallHandler) GetProcessSyscallStats(c *gin.Context) {
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.service.GetRecentProcessStats(parseLimit(c), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
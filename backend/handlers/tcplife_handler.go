@@ -31,7 +31,13 @@ func (h *TCPLifeHandler) GetTCPLifeEvents(c *gin.Context) {
 		}
 	}
 
-	events, err := h.service.GetRecentEvents(limit)
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.service.GetRecentEvents(limit, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TCPRetransHandler struct {
+	service services.TCPRetransService
+}
+
+func NewTCPRetransHandler(service services.TCPRetransService) *TCPRetransHandler {
+	return &TCPRetransHandler{service: service}
+}
+
+// GetRecentRetransmits handles GET /api/metrics/tcpretrans
+func (h *TCPRetransHandler) GetRecentRetransmits(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.service.GetRecentRetransmits(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
+
+// GetRecentDrops handles GET /api/metrics/tcpdrop
+func (h *TCPRetransHandler) GetRecentDrops(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.service.GetRecentDrops(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
+
+// GetRetransmits handles GET /api/tcp/retransmits
+func (h *TCPRetransHandler) GetRetransmits(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	step, err := parseStep(c, since, until, time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	q := models.RetransmitQuery{
+		Since:      since,
+		Until:      until,
+		RemoteAddr: c.Query("remote_addr"),
+		RemotePort: queryInt(c, "remote_port", 0),
+		Comm:       c.Query("comm"),
+		Filter:     filter,
+	}
+	if q.RemoteAddr != "" {
+		q.RemoteAddr = enrich.NormalizeAddr(q.RemoteAddr)
+	}
+
+	points, err := h.service.GetRetransmitSeries(q, step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(points),
+		"step":  step.String(),
+		"data":  points,
+	})
+}
+
+// GetDrops handles GET /api/tcp/drops
+func (h *TCPRetransHandler) GetDrops(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	drops, err := h.service.GetDropSummary(since, until, parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(drops),
+		"data":  drops,
+	})
+}
+
+// GetLossySessions handles GET /api/tcp/lossy
+func (h *TCPRetransHandler) GetLossySessions(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Sessions open for at least min_duration (default: 0, all sessions)
+	var minDuration time.Duration
+	if v := c.Query("min_duration"); v != "" {
+		minDuration, err = time.ParseDuration(v)
+		if err != nil || minDuration < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid min_duration %q", v)})
+			return
+		}
+	}
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	minDurationMS := float64(minDuration) / float64(time.Millisecond)
+	sessions, err := h.service.GetLossySessions(since, until, minDurationMS, queryInt(c, "min_retransmits", 1), parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(sessions),
+		"data":  sessions,
+	})
+}
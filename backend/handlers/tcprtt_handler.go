@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rttSnapshotInterval is how often round-trip time histograms are saved,
+// and so the shortest step of their time series
+const rttSnapshotInterval = 5 * time.Second
+
+type TCPRTTHandler struct {
+	service services.TCPRTTService
+}
+
+func NewTCPRTTHandler(service services.TCPRTTService) *TCPRTTHandler {
+	return &TCPRTTHandler{service: service}
+}
+
+// GetLatestRTT handles GET /api/metrics/tcprtt
+func (h *TCPRTTHandler) GetLatestRTT(c *gin.Context) {
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rtts, err := h.service.GetLatestRTT(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(rtts),
+		"data":  rtts,
+	})
+}
+
+// GetPercentiles handles GET /api/tcp/rtt
+func (h *TCPRTTHandler) GetPercentiles(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := parseRTTQuery(c, since, until)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	percentiles, err := h.service.GetPercentiles(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(percentiles),
+		"data":  percentiles,
+	})
+}
+
+// GetSeries handles GET /api/tcp/rtt/series
+func (h *TCPRTTHandler) GetSeries(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	step, err := parseStep(c, since, until, rttSnapshotInterval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q, err := parseRTTQuery(c, since, until)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	points, err := h.service.GetSeries(q, step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(points),
+		"step":  step.String(),
+		"data":  points,
+	})
+}
+
+// parseRTTQuery reads the peer and host filter of a round-trip time query
+func parseRTTQuery(c *gin.Context, since, until time.Time) (models.RTTQuery, error) {
+	filter, err := parseHostFilter(c)
+	if err != nil {
+		return models.RTTQuery{}, err
+	}
+
+	q := models.RTTQuery{Since: since, Until: until, Filter: filter}
+	if v := c.Query("remote_addr"); v != "" {
+		q.RemoteAddr = enrich.NormalizeAddr(v)
+	}
+	return q, nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TCPStateHandler struct {
+	service services.TCPStateService
+}
+
+func NewTCPStateHandler(service services.TCPStateService) *TCPStateHandler {
+	return &TCPStateHandler{service: service}
+}
+
+// GetRecentStateChanges handles GET /api/metrics/tcpstates
+func (h *TCPStateHandler) GetRecentStateChanges(c *gin.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := h.service.GetRecentStateChanges(parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
+
+// GetStateSeries handles GET /api/tcp/states
+func (h *TCPStateHandler) GetStateSeries(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	step, err := parseStep(c, since, until, time.Second)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	points, err := h.service.GetStateSeries(models.StateQuery{
+		Since:  since,
+		Until:  until,
+		State:  strings.ToUpper(c.Query("state")),
+		Comm:   c.Query("comm"),
+		Filter: filter,
+	}, step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(points),
+		"step":  step.String(),
+		"data":  points,
+	})
+}
+
+// GetStuckConnections handles GET /api/tcp/stuck
+func (h *TCPStateHandler) GetStuckConnections(c *gin.Context) {
+	since, _, err := parseWindow(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Connections in their state for at least min_age (default: 30s)
+	minAge := 30 * time.Second
+	if v := c.Query("min_age"); v != "" {
+		minAge, err = time.ParseDuration(v)
+		if err != nil || minAge < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid min_age %q", v)})
+			return
+		}
+	}
+
+	// States, e.g. states=CLOSE_WAIT,SYN_SENT
+	var states []string
+	for _, value := range c.QueryArray("states") {
+		for _, state := range strings.Split(value, ",") {
+			if state = strings.TrimSpace(state); state != "" {
+				states = append(states, strings.ToUpper(state))
+			}
+		}
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stuck, err := h.service.GetStuckConnections(since, minAge, states, parseLimit(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(stuck),
+		"data":  stuck,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/services"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TimelineHandler struct {
+	service services.TimelineService
+}
+
+func NewTimelineHandler(service services.TimelineService) *TimelineHandler {
+	return &TimelineHandler{service: service}
+}
+
+// GetTimeline handles GET /api/timeline
+func (h *TimelineHandler) GetTimeline(c *gin.Context) {
+	since, until, err := parseWindow(c, time.Hour)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	q := models.TimelineQuery{
+		Since:  since,
+		Until:  until,
+		PID:    queryInt(c, "pid", 0),
+		Comm:   c.Query("comm"),
+		Filter: filter,
+		Limit:  parseLimit(c),
+	}
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		q.After = &cursor
+	}
+
+	// Event types, e.g. types=exec,connect
+	var types []string
+	for _, value := range c.QueryArray("types") {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	events, next, err := h.service.GetTimeline(q, types)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownEventType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "types": h.service.Types()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"count": len(events),
+		"data":  events,
+	}
+	if next != nil {
+		response["next_cursor"] = encodeCursor(*next)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// encodeCursor serializes a timeline position into an opaque token
+func encodeCursor(cursor models.TimelineCursor) string {
+	raw := fmt.Sprintf("%d|%s|%d", cursor.Time.Unix(), cursor.Type, cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(token string) (models.TimelineCursor, error) {
+	invalid := fmt.Errorf("invalid cursor %q", token)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return models.TimelineCursor{}, invalid
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return models.TimelineCursor{}, invalid
+	}
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return models.TimelineCursor{}, invalid
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return models.TimelineCursor{}, invalid
+	}
+	return models.TimelineCursor{Time: time.Unix(sec, 0).UTC(), Type: parts[1], ID: id}, nil
+}
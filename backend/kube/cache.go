@@ -0,0 +1,141 @@
+package kube
+
+import (
+	"sync"
+	"time"
+)
+
+// deletedGracePeriod keeps deleted pods resolvable for a while, so that events
+// of a terminating pod that are still buffered can be attributed
+const deletedGracePeriod = 5 * time.Minute
+
+// Pod holds the metadata of a pod used to attribute events
+type Pod struct {
+	UID          string
+	Name         string
+	Namespace    string
+	NodeName     string
+	Labels       map[string]string
+	ContainerIDs []string
+}
+
+type cachedPod struct {
+	pod       Pod
+	deletedAt time.Time
+}
+
+// PodCache indexes pods by UID and by container ID
+type PodCache struct {
+	mu          sync.RWMutex
+	byUID       map[string]*cachedPod
+	byContainer map[string]*cachedPod
+}
+
+func NewPodCache() *PodCache {
+	return &PodCache{
+		byUID:       make(map[string]*cachedPod),
+		byContainer: make(map[string]*cachedPod),
+	}
+}
+
+// Lookup finds the pod running containerID, falling back to the pod UID for
+// containers that are not listed in the pod status yet
+func (c *PodCache) Lookup(containerID, podUID string) (Pod, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if containerID != "" {
+		if cached, ok := c.byContainer[containerID]; ok {
+			return cached.pod, true
+		}
+	}
+	if podUID != "" {
+		if cached, ok := c.byUID[podUID]; ok {
+			return cached.pod, true
+		}
+	}
+	return Pod{}, false
+}
+
+// Len returns the number of pods currently cached, including deleted pods
+// within their grace period
+func (c *PodCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.byUID)
+}
+
+// Replace updates the cache to a full pod listing. Pods missing from the
+// listing are treated as deleted.
+func (c *PodCache) Replace(pods []Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		seen[pod.UID] = true
+		c.upsert(pod)
+	}
+	for uid, cached := range c.byUID {
+		if !seen[uid] && cached.deletedAt.IsZero() {
+			cached.deletedAt = now
+		}
+	}
+	c.expire(now)
+}
+
+// Upsert adds or updates a single pod
+func (c *PodCache) Upsert(pod Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.upsert(pod)
+}
+
+// Delete marks a pod as deleted. It stays resolvable for deletedGracePeriod.
+func (c *PodCache) Delete(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if cached, ok := c.byUID[uid]; ok && cached.deletedAt.IsZero() {
+		cached.deletedAt = now
+	}
+	c.expire(now)
+}
+
+// upsert replaces the cached pod. Callers must hold c.mu.
+func (c *PodCache) upsert(pod Pod) {
+	if pod.UID == "" {
+		return
+	}
+	if old, ok := c.byUID[pod.UID]; ok {
+		for _, id := range old.pod.ContainerIDs {
+			if c.byContainer[id] == old {
+				delete(c.byContainer, id)
+			}
+		}
+	}
+
+	cached := &cachedPod{pod: pod}
+	c.byUID[pod.UID] = cached
+	for _, id := range pod.ContainerIDs {
+		c.byContainer[id] = cached
+	}
+}
+
+// expire drops pods deleted longer than deletedGracePeriod ago. Callers must
+// hold c.mu.
+func (c *PodCache) expire(now time.Time) {
+	for uid, cached := range c.byUID {
+		if cached.deletedAt.IsZero() || now.Sub(cached.deletedAt) < deletedGracePeriod {
+			continue
+		}
+		delete(c.byUID, uid)
+		for _, id := range cached.pod.ContainerIDs {
+			if c.byContainer[id] == cached {
+				delete(c.byContainer, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package kube
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// podList and podObject mirror the parts of the Kubernetes Pod API we use
+type podList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []podObject `json:"items"`
+}
+
+type podObject struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		UID             string            `json:"uid"`
+		Labels          map[string]string `json:"labels"`
+		ResourceVersion string            `json:"resourceVersion"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		ContainerStatuses          []containerStatus `json:"containerStatuses"`
+		InitContainerStatuses      []containerStatus `json:"initContainerStatuses"`
+		EphemeralContainerStatuses []containerStatus `json:"ephemeralContainerStatuses"`
+	} `json:"status"`
+}
+
+type containerStatus struct {
+	ContainerID string `json:"containerID"`
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// toPod converts an API object into a Pod, stripping the runtime scheme
+// (docker://, containerd://, cri-o://) from container IDs
+func (o podObject) toPod() Pod {
+	pod := Pod{
+		UID:       o.Metadata.UID,
+		Name:      o.Metadata.Name,
+		Namespace: o.Metadata.Namespace,
+		NodeName:  o.Spec.NodeName,
+		Labels:    o.Metadata.Labels,
+	}
+	for _, statuses := range [][]containerStatus{
+		o.Status.ContainerStatuses,
+		o.Status.InitContainerStatuses,
+		o.Status.EphemeralContainerStatuses,
+	} {
+		for _, s := range statuses {
+			if id := stripRuntimeScheme(s.ContainerID); id != "" {
+				pod.ContainerIDs = append(pod.ContainerIDs, id)
+			}
+		}
+	}
+	return pod
+}
+
+func stripRuntimeScheme(containerID string) string {
+	if i := strings.Index(containerID, "://"); i >= 0 {
+		return containerID[i+3:]
+	}
+	return containerID
+}
+
+// ClientConfig configures access to the kubelet or the API server
+type ClientConfig struct {
+	BaseURL            string
+	TokenPath          string
+	CAPath             string
+	InsecureSkipVerify bool
+}
+
+// client is a minimal HTTP client for the Pod endpoints of the kubelet and
+// the API server, authenticating with a service account bearer token
+type client struct {
+	baseURL   string
+	tokenPath string
+	http      *http.Client
+}
+
+func newClient(cfg ClientConfig) (*client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAPath != "" && !cfg.InsecureSkipVerify {
+		pem, err := os.ReadFile(cfg.CAPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if err == nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", cfg.CAPath)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	return &client{
+		baseURL:   strings.TrimSuffix(cfg.BaseURL, "/"),
+		tokenPath: cfg.TokenPath,
+		http: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:     tlsConfig,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		},
+	}, nil
+}
+
+// get issues an authenticated GET request. The token is read on every request
+// because projected service account tokens are rotated by the kubelet.
+func (c *client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.tokenPath != "" {
+		if token, err := os.ReadFile(c.tokenPath); err == nil {
+			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// listPods fetches a PodList from path
+func (c *client) listPods(ctx context.Context, path string, query url.Values) ([]Pod, string, error) {
+	resp, err := c.get(ctx, path, query)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var list podList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, "", fmt.Errorf("failed to decode pod list: %w", err)
+	}
+
+	pods := make([]Pod, 0, len(list.Items))
+	for _, item := range list.Items {
+		pods = append(pods, item.toPod())
+	}
+	return pods, list.Metadata.ResourceVersion, nil
+}
+
+// watchPods streams watch events from the API server until the server closes
+// the stream, ctx is cancelled or an error occurs. It returns the last
+// resource version seen so the caller can resume from there.
+func (c *client) watchPods(ctx context.Context, query url.Values, handle func(eventType string, pod Pod)) (string, error) {
+	resourceVersion := query.Get("resourceVersion")
+
+	resp, err := c.get(ctx, "/api/v1/pods", query)
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event watchEvent
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return resourceVersion, nil
+			}
+			return resourceVersion, err
+		}
+
+		if event.Type == "ERROR" {
+			// Typically 410 Gone: the resource version is too old, relist
+			return "", fmt.Errorf("watch error: %s", string(event.Object))
+		}
+
+		var obj podObject
+		if err := json.Unmarshal(event.Object, &obj); err != nil {
+			return resourceVersion, fmt.Errorf("failed to decode watch event: %w", err)
+		}
+		if obj.Metadata.ResourceVersion != "" {
+			resourceVersion = obj.Metadata.ResourceVersion
+		}
+		if event.Type != "BOOKMARK" {
+			handle(event.Type, obj.toPod())
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// SourceKubelet polls the /pods endpoint of the local kubelet
+	SourceKubelet = "kubelet"
+	// SourceAPIServer lists and watches the pods of this node on the API server
+	SourceAPIServer = "apiserver"
+)
+
+// WatcherConfig configures where pod metadata is read from
+type WatcherConfig struct {
+	Source   string
+	NodeName string
+	Refresh  time.Duration
+	Client   ClientConfig
+}
+
+// Watcher keeps a PodCache in sync with the pods running on this node
+type Watcher struct {
+	cfg    WatcherConfig
+	client *client
+	cache  *PodCache
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWatcher(cfg WatcherConfig, cache *PodCache) (*Watcher, error) {
+	if cfg.Source != SourceKubelet && cfg.Source != SourceAPIServer {
+		return nil, fmt.Errorf("unknown pod source %q", cfg.Source)
+	}
+	c, err := newClient(cfg.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		cfg:    cfg,
+		client: c,
+		cache:  cache,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start begins syncing pods in the background
+func (w *Watcher) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		if w.cfg.Source == SourceKubelet {
+			w.pollKubelet()
+		} else {
+			w.watchAPIServer()
+		}
+	}()
+}
+
+// Stop stops syncing pods
+func (w *Watcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+func (w *Watcher) pollKubelet() {
+	ticker := time.NewTicker(w.cfg.Refresh)
+	defer ticker.Stop()
+
+	for {
+		pods, _, err := w.client.listPods(w.ctx, "/pods", nil)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error listing pods from kubelet: %v", err)
+		} else {
+			w.cache.Replace(pods)
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchAPIServer lists the pods of this node and then follows changes with a
+// watch, relisting whenever the watch cannot be resumed
+func (w *Watcher) watchAPIServer() {
+	query := url.Values{}
+	if w.cfg.NodeName != "" {
+		query.Set("fieldSelector", "spec.nodeName="+w.cfg.NodeName)
+	}
+
+	resourceVersion := ""
+	for w.ctx.Err() == nil {
+		if resourceVersion == "" {
+			pods, rv, err := w.client.listPods(w.ctx, "/api/v1/pods", query)
+			if err != nil {
+				if w.ctx.Err() == nil {
+					log.Printf("Error listing pods from API server: %v", err)
+				}
+				w.wait()
+				continue
+			}
+			w.cache.Replace(pods)
+			resourceVersion = rv
+		}
+
+		watchQuery := url.Values{}
+		for key, values := range query {
+			watchQuery[key] = values
+		}
+		watchQuery.Set("watch", "1")
+		watchQuery.Set("allowWatchBookmarks", "true")
+		watchQuery.Set("resourceVersion", resourceVersion)
+
+		rv, err := w.client.watchPods(w.ctx, watchQuery, w.handleEvent)
+		resourceVersion = rv
+		if err != nil {
+			if w.ctx.Err() == nil {
+				log.Printf("Pod watch interrupted, relisting: %v", err)
+			}
+			resourceVersion = ""
+			w.wait()
+		}
+	}
+}
+
+func (w *Watcher) handleEvent(eventType string, pod Pod) {
+	switch eventType {
+	case "ADDED", "MODIFIED":
+		w.cache.Upsert(pod)
+	case "DELETED":
+		w.cache.Delete(pod.UID)
+	}
+}
+
+// wait backs off for one refresh interval before retrying
+func (w *Watcher) wait() {
+	select {
+	case <-w.ctx.Done():
+	case <-time.After(w.cfg.Refresh):
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"ebpf-dashboard/agent"
 	"ebpf-dashboard/config"
 	"ebpf-dashboard/database"
+	"ebpf-dashboard/detect"
+	"ebpf-dashboard/enrich"
 	"ebpf-dashboard/handlers"
+	"ebpf-dashboard/kube"
 	"ebpf-dashboard/logger"
+	"ebpf-dashboard/models"
 	"ebpf-dashboard/repository"
 	"ebpf-dashboard/services"
 	"log"
@@ -39,31 +44,166 @@ func main() {
 	}
 	defer logger.Close()
 
-	logger.Info("Starting eBPF Dashboard Backend...")
+	logger.Info("Starting eBPF Dashboard Backend in %s mode...", cfg.Mode)
 
-	// Initialize database
-	db, err := database.InitDB(cfg.DBPath)
+	// Agents only collect and ship, without a database or API
+	if cfg.Mode == config.ModeAgent {
+		runAgent(cfg)
+		return
+	}
+
+	// Initialize storage
+	thresholds := repository.TimelineThresholds{
+		DiskP99:          time.Duration(cfg.TimelineDiskSpikeMS) * time.Millisecond,
+		SyscallFactor:    cfg.TimelineSyscallSpikeFactor,
+		SyscallMinCount:  cfg.TimelineSyscallSpikeMin,
+		CPUHotStackCount: cfg.TimelineCPUHotStackSamples,
+	}
+	var (
+		stores       repository.Stores
+		sqliteWriter *repository.SQLiteWriter
+	)
+	switch cfg.StorageBackend {
+	case config.StorageMemory:
+		stores = repository.NewMemoryStores(cfg.MemoryCapacity, thresholds)
+		logger.Info("Storing up to %d events per table in memory", cfg.MemoryCapacity)
+	case config.StoragePostgres:
+		pool, err := database.InitPostgres(cfg.PostgresURL, cfg.PostgresMaxConns, database.TimescaleConfig{
+			Enabled:       cfg.TimescaleEnabled,
+			CompressAfter: time.Duration(cfg.TimescaleCompressAfterHours) * time.Hour,
+		})
+		if err != nil {
+			logger.Error("Failed to initialize database: %v", err)
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer pool.Close()
+		stores = repository.NewPostgresStores(pool, thresholds)
+	default:
+		db, err := database.InitDB(cfg.DBPath)
+		if err != nil {
+			logger.Error("Failed to initialize database: %v", err)
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer db.Close()
+		// Closed before the database, so queued inserts are still written
+		writer := repository.NewSQLiteWriter(db, repository.WriterConfig{
+			QueueSize: cfg.SQLiteWriteQueue,
+			BatchRows: cfg.SQLiteWriteBatchRows,
+		})
+		defer writer.Close()
+		sqliteWriter = writer
+		stores = repository.NewSQLiteStores(db, writer, thresholds)
+	}
+
+	// Resolve PIDs to cgroup, systemd unit, container and pod before events are stored
+	hostLabels, _ := cfg.ParseHostLabels() // validated with the configuration
+	attributor, podWatcher := newAttributor(cfg, hostLabels)
+
+	// Security detections on exec and connect events
+	detector, err := detect.New(detect.Config{
+		MinerPorts:       cfg.SecurityMinerPorts,
+		WebServers:       cfg.SecurityWebServers,
+		WebServerAllowed: cfg.SecurityWebServerAllowed,
+		SetuidAllowed:    cfg.SecuritySetuidAllowed,
+	})
 	if err != nil {
-		logger.Error("Failed to initialize database: %v", err)
-		log.Fatalf("Failed to initialize database: %v", err)
+		log.Fatalf("Invalid security detection settings: %v", err)
 	}
-	defer db.Close()
 
-	// Initialize repositories
-	processRepo := repository.NewProcessRepository(db)
-	networkRepo := repository.NewNetworkRepository(db)
-	diskRepo := repository.NewDiskRepository(db)
-	cpuProfileRepo := repository.NewCPUProfileRepository(db)
-	tcpLifeRepo := repository.NewTCPLifeRepository(db)
-	syscallRepo := repository.NewSyscallRepository(db)
+	// Names the remote addresses of connections after the hostnames their
+	// processes resolved, fed by the DNS service
+	hostnames := enrich.NewHostnameResolver()
 
 	// Initialize services
-	processService := services.NewProcessService(processRepo)
-	networkService := services.NewNetworkService(networkRepo)
-	diskService := services.NewDiskService(diskRepo)
-	cpuProfileService := services.NewCPUProfileService(cpuProfileRepo)
-	tcpLifeService := services.NewTCPLifeService(tcpLifeRepo)
-	syscallService := services.NewSyscallService(syscallRepo)
+	processService := services.NewProcessService(stores.Processes, attributor)
+	networkService := services.NewNetworkService(stores.Connections, attributor, hostnames)
+	diskService := services.NewDiskService(stores.Disk, cfg.HostName, hostLabels)
+	cpuProfileService := services.NewCPUProfileService(stores.CPUProfiles, attributor)
+	tcpLifeService := services.NewTCPLifeService(stores.TCPSessions, attributor, hostnames)
+	syscallService := services.NewSyscallService(stores.Syscalls, cfg.HostName, hostLabels)
+	exitService := services.NewExitService(stores.Exits, attributor)
+	fileService := services.NewFileService(stores.Files, attributor, cfg.OpensFailedOnly)
+	runqService := services.NewRunqService(stores.Runq, cfg.HostName, hostLabels, cfg.RunqlatPerPIDNS, cfg.RunqlatCgroup)
+	offCPUService := services.NewOffCPUService(stores.OffCPU, attributor, time.Duration(cfg.OffCPUIntervalSeconds)*time.Second)
+	flameGraphService := services.NewFlameGraphService(stores.CPUProfiles, stores.OffCPU)
+	tcpRetransService := services.NewTCPRetransService(stores.TCPRetrans, stores.TCPSessions, attributor)
+	tcpRTTService := services.NewTCPRTTService(stores.TCPRTT, cfg.HostName, hostLabels)
+	tcpStateService := services.NewTCPStateService(stores.TCPStates, attributor)
+	dnsService := services.NewDNSService(stores.DNS, attributor, hostnames)
+	killService := services.NewKillService(stores.Kills, attributor)
+	fsLatencyService := services.NewFSLatencyService(stores.FSLatency, attributor, time.Duration(cfg.FSSlowerThresholdMS)*time.Millisecond)
+	inboundService := services.NewInboundService(stores.Inbound, attributor, cfg.HostName, time.Duration(cfg.ListenScanIntervalSeconds)*time.Second)
+	attributionService := services.NewAttributionService(stores.Attribution)
+	lineageService := services.NewLineageService(time.Duration(cfg.LineageRetentionMinutes) * time.Minute)
+	profileService := services.NewProfileService(stores.Profiles, lineageService, cfg.HostName)
+	timelineService := services.NewTimelineService(stores.Timeline)
+	alertService := services.NewAlertService(
+		stores.Alerts,
+		services.NewWebhookNotifier(time.Duration(cfg.AlertWebhookTimeoutSeconds)*time.Second),
+		cfg.AlertWebhookURL,
+		time.Duration(cfg.AlertEvalIntervalSeconds)*time.Second,
+	)
+	baselineService := services.NewBaselineService(stores.Baselines, services.BaselineConfig{
+		Bucket:     time.Duration(cfg.BaselineBucketSeconds) * time.Second,
+		Alpha:      cfg.BaselineAlpha,
+		Threshold:  cfg.BaselineZThreshold,
+		MinSamples: cfg.BaselineMinSamples,
+	})
+	findingService := services.NewFindingService(stores.Findings, detector)
+	ingestService := services.NewIngestService(stores.Agents, stores.Batches, time.Duration(cfg.AgentOfflineSeconds)*time.Second)
+	hostService := services.NewHostService(cfg.HostName, hostLabels, ingestService)
+	behaviorService := services.NewBehaviorService(stores.Behavior, lineageService, findingService, services.BehaviorConfig{
+		AutoLearn: cfg.ProfileAutoLearn,
+		Syscalls:  cfg.ProfileSyscalls,
+	})
+
+	// Feed exec and exit events into the process tree
+	processService.Subscribe(lineageService.HandleExecs)
+	exitService.Subscribe(lineageService.HandleExits)
+
+	// Feed every event stream into the alert rules engine
+	processService.Subscribe(alertService.HandleExecs)
+	exitService.Subscribe(alertService.HandleExits)
+	networkService.Subscribe(alertService.HandleConnections)
+	tcpLifeService.Subscribe(alertService.HandleTCPSessions)
+	diskService.Subscribe(alertService.HandleDiskLatency)
+	syscallService.Subscribe(alertService.HandleSyscalls)
+	inboundService.SubscribeAccepts(alertService.HandleAccepts)
+	inboundService.SubscribeListenEvents(alertService.HandleListenEvents)
+
+	// Learn baselines of syscall rates, execs, destination ports and TCP durations
+	processService.Subscribe(baselineService.HandleExecs)
+	networkService.Subscribe(baselineService.HandleConnections)
+	tcpLifeService.Subscribe(baselineService.HandleTCPSessions)
+	syscallService.Subscribe(baselineService.HandleSyscalls)
+
+	// Agent batches feed the alert rules and baselines too. Detections,
+	// behavior profiles and the process tree stay local: they read /proc.
+	ingestService.Subscribe(alertService.HandleBatch)
+	ingestService.Subscribe(baselineService.HandleBatch)
+
+	// Run the security detections
+	processService.Subscribe(findingService.HandleExecs)
+	networkService.Subscribe(findingService.HandleConnections)
+
+	// Learn and enforce per-command behavior profiles
+	if cfg.ProfilesEnabled {
+		processService.Subscribe(behaviorService.HandleExecs)
+		networkService.Subscribe(behaviorService.HandleConnections)
+	}
+
+	// Seed the process tree from /proc before exec events start arriving
+	lineageService.Start()
+
+	if err := alertService.Start(); err != nil {
+		logger.Error("Failed to start alert service: %v", err)
+	}
+	if err := baselineService.Start(); err != nil {
+		logger.Error("Failed to start baseline service: %v", err)
+	}
+	if cfg.ProfilesEnabled {
+		behaviorService.Start()
+	}
 
 	// Start background collectors
 	processService.StartCollecting()
@@ -74,6 +214,31 @@ func main() {
 		logger.Error("Failed to start tcplife collector: %v", err)
 	}
 	syscallService.Start()
+	if err := exitService.StartCollecting(); err != nil {
+		logger.Error("Failed to start exitsnoop collector: %v", err)
+	}
+	if err := fileService.StartCollecting(); err != nil {
+		logger.Error("Failed to start opensnoop collector: %v", err)
+	}
+	if err := runqService.StartCollecting(); err != nil {
+		logger.Error("Failed to start runqlat collector: %v", err)
+	}
+	if err := offCPUService.StartCollecting(); err != nil {
+		logger.Error("Failed to start offcputime collector: %v", err)
+	}
+	tcpRetransService.Start()
+	if err := tcpRTTService.StartCollecting(); err != nil {
+		logger.Error("Failed to start tcprtt collector: %v", err)
+	}
+	inboundService.Start()
+	if err := tcpStateService.StartCollecting(); err != nil {
+		logger.Error("Failed to start tcpstates collector: %v", err)
+	}
+	if err := dnsService.StartCollecting(); err != nil {
+		logger.Error("Failed to start gethostlatency collector: %v", err)
+	}
+	killService.Start()
+	fsLatencyService.Start()
 
 	// Initialize handlers
 	processHandler := handlers.NewProcessHandler(processService)
@@ -82,6 +247,29 @@ func main() {
 	cpuProfileHandler := handlers.NewCPUProfileHandler(cpuProfileService)
 	tcpLifeHandler := handlers.NewTCPLifeHandler(tcpLifeService)
 	syscallHandler := handlers.NewSyscallHandler(syscallService)
+	exitHandler := handlers.NewExitHandler(exitService)
+	fileHandler := handlers.NewFileHandler(fileService)
+	runqHandler := handlers.NewRunqHandler(runqService)
+	offCPUHandler := handlers.NewOffCPUHandler(offCPUService)
+	flameGraphHandler := handlers.NewFlameGraphHandler(flameGraphService)
+	tcpRetransHandler := handlers.NewTCPRetransHandler(tcpRetransService)
+	tcpRTTHandler := handlers.NewTCPRTTHandler(tcpRTTService)
+	inboundHandler := handlers.NewInboundHandler(inboundService)
+	tcpStateHandler := handlers.NewTCPStateHandler(tcpStateService)
+	dnsHandler := handlers.NewDNSHandler(dnsService)
+	killHandler := handlers.NewKillHandler(killService)
+	fsLatencyHandler := handlers.NewFSLatencyHandler(fsLatencyService)
+	lineageHandler := handlers.NewLineageHandler(lineageService)
+	attributionHandler := handlers.NewAttributionHandler(attributionService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	timelineHandler := handlers.NewTimelineHandler(timelineService)
+	alertHandler := handlers.NewAlertHandler(alertService)
+	baselineHandler := handlers.NewBaselineHandler(baselineService)
+	findingHandler := handlers.NewFindingHandler(findingService)
+	behaviorHandler := handlers.NewBehaviorHandler(behaviorService)
+	agentHandler := handlers.NewAgentHandler(ingestService)
+	hostHandler := handlers.NewHostHandler(hostService)
+	storageHandler := handlers.NewStorageHandler(cfg.StorageBackend, sqliteWriter)
 	healthHandler := handlers.NewHealthHandler()
 
 	// Setup Gin router
@@ -107,7 +295,116 @@ func main() {
 		api.GET("/cpuprofile", cpuProfileHandler.GetCPUProfiles)
 		api.GET("/tcplife", tcpLifeHandler.GetTCPLifeEvents)
 		api.GET("/syscalls", syscallHandler.GetSyscallStats)
+		api.GET("/syscalls/processes", syscallHandler.GetProcessSyscallStats)
+		api.GET("/exits", exitHandler.GetRecentExits)
+		api.GET("/opens", fileHandler.GetRecentOpens)
+		api.GET("/runqlat", runqHandler.GetLatestRunq)
+		api.GET("/offcpu", offCPUHandler.GetRecentStacks)
+		api.GET("/tcpretrans", tcpRetransHandler.GetRecentRetransmits)
+		api.GET("/tcpdrop", tcpRetransHandler.GetRecentDrops)
+		api.GET("/tcprtt", tcpRTTHandler.GetLatestRTT)
+		api.GET("/tcpaccept", inboundHandler.GetRecentInbound)
+		api.GET("/tcpstates", tcpStateHandler.GetRecentStateChanges)
+		api.GET("/gethostlatency", dnsHandler.GetRecentLookups)
+		api.GET("/oomkill", killHandler.GetRecentOOMKills)
+		api.GET("/killsnoop", killHandler.GetRecentSignals)
+		api.GET("/fsslower", fsLatencyHandler.GetRecentSlowOps)
+	}
+	lifecycle := router.Group("/api/lifecycle")
+	{
+		lifecycle.GET("/lifetimes", exitHandler.GetLifetimes)
+		lifecycle.GET("/short-lived", exitHandler.GetShortLivedStorms)
+		lifecycle.GET("/crashloops", exitHandler.GetCrashLoops)
+		lifecycle.GET("/killed", exitHandler.GetKilled)
+		lifecycle.GET("/oom-kills", killHandler.GetOOMKills)
+		lifecycle.GET("/signals", killHandler.GetSignals)
+	}
+	files := router.Group("/api/files")
+	{
+		files.GET("/opens", fileHandler.GetOpens)
+		files.GET("/failures", fileHandler.GetFailures)
+		files.GET("/accessors", fileHandler.GetAccessors)
+	}
+	fs := router.Group("/api/fs")
+	{
+		fs.GET("/slow-files", fsLatencyHandler.GetSlowFiles)
+		fs.GET("/processes", fsLatencyHandler.GetProcessLatency)
+	}
+	runqueue := router.Group("/api/runqueue")
+	{
+		runqueue.GET("/percentiles", runqHandler.GetPercentiles)
+		runqueue.GET("/heatmap", runqHandler.GetHeatmap)
+	}
+	tcp := router.Group("/api/tcp")
+	{
+		tcp.GET("/retransmits", tcpRetransHandler.GetRetransmits)
+		tcp.GET("/drops", tcpRetransHandler.GetDrops)
+		tcp.GET("/lossy", tcpRetransHandler.GetLossySessions)
+		tcp.GET("/rtt", tcpRTTHandler.GetPercentiles)
+		tcp.GET("/rtt/series", tcpRTTHandler.GetSeries)
+		tcp.GET("/states", tcpStateHandler.GetStateSeries)
+		tcp.GET("/stuck", tcpStateHandler.GetStuckConnections)
+	}
+	inbound := router.Group("/api/inbound")
+	{
+		inbound.GET("/clients", inboundHandler.GetServiceClients)
+		inbound.GET("/listeners", inboundHandler.GetListeners)
+		inbound.GET("/listen-events", inboundHandler.GetListenEvents)
+	}
+	dns := router.Group("/api/dns")
+	{
+		dns.GET("/hosts", dnsHandler.GetHostStats)
+		dns.GET("/slow", dnsHandler.GetSlowLookups)
 	}
+	lineage := router.Group("/api/lineage")
+	{
+		lineage.GET("/tree", lineageHandler.GetTree)
+		lineage.GET("/:pid/ancestors", lineageHandler.GetAncestors)
+		lineage.GET("/:pid/descendants", lineageHandler.GetDescendants)
+	}
+	processes := router.Group("/api/processes")
+	{
+		processes.GET("/:pid", profileHandler.GetByPID)
+		processes.GET("/comm/:comm", profileHandler.GetByComm)
+	}
+	alerts := router.Group("/api/alerts")
+	{
+		alerts.GET("", alertHandler.GetAlerts)
+		alerts.GET("/rules", alertHandler.GetRules)
+		alerts.POST("/rules", alertHandler.CreateRule)
+		alerts.GET("/rules/:id", alertHandler.GetRule)
+		alerts.PUT("/rules/:id", alertHandler.UpdateRule)
+		alerts.DELETE("/rules/:id", alertHandler.DeleteRule)
+	}
+	router.GET("/api/findings", findingHandler.GetFindings)
+	router.GET("/api/findings/rules", findingHandler.GetRules)
+	behaviorProfiles := router.Group("/api/profiles")
+	{
+		behaviorProfiles.GET("", behaviorHandler.GetProfiles)
+		behaviorProfiles.GET("/export", behaviorHandler.ExportProfiles)
+		behaviorProfiles.POST("/import", behaviorHandler.ImportProfiles)
+		behaviorProfiles.GET("/:comm", behaviorHandler.GetProfile)
+		behaviorProfiles.PUT("/:comm/mode", behaviorHandler.SetMode)
+		behaviorProfiles.DELETE("/:comm", behaviorHandler.DeleteProfile)
+	}
+	router.GET("/api/hosts", hostHandler.GetHosts)
+	router.GET("/api/agents", agentHandler.GetAgents)
+	if cfg.Mode == config.ModeServer {
+		// Agents register, send heartbeats and ship batches here
+		agents := router.Group("/api/agents", handlers.RequireAgentToken(cfg.AgentToken))
+		{
+			agents.POST("/register", agentHandler.Register)
+			agents.POST("/batches", agentHandler.Ingest)
+			agents.POST("/:id/heartbeat", agentHandler.Heartbeat)
+		}
+		logger.Info("Accepting batches from agents")
+	}
+	router.GET("/api/anomalies", baselineHandler.GetAnomalies)
+	router.GET("/api/baselines", baselineHandler.GetBaselines)
+	router.GET("/api/timeline", timelineHandler.GetTimeline)
+	router.GET("/api/flamegraph", flameGraphHandler.GetFlameGraph)
+	router.GET("/api/attribution/summary", attributionHandler.GetSummary)
+	router.GET("/api/storage", storageHandler.GetStats)
 	router.GET("/health", healthHandler.GetHealth)
 
 	// Create HTTP server
@@ -116,14 +413,27 @@ func main() {
 		Handler: router,
 	}
 
-	// Graceful shutdown
+	// Graceful shutdown. main waits for it before returning, since the
+	// deferred closes of the writer and database must run last.
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
+
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 		<-sigChan
 
 		logger.Info("Shutting down gracefully...")
 
+		// Stop the HTTP server first, so agent batches being ingested are
+		// stored before the services they are fed to stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Error("Server forced to shutdown: %v", err)
+		}
+
 		// Stop collectors
 		processService.StopCollecting()
 		networkService.StopCollecting()
@@ -131,13 +441,25 @@ func main() {
 		cpuProfileService.Stop()
 		tcpLifeService.StopCollecting()
 		syscallService.Stop()
-
-		// Shutdown HTTP server with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := srv.Shutdown(ctx); err != nil {
-			logger.Error("Server forced to shutdown: %v", err)
+		exitService.StopCollecting()
+		fileService.StopCollecting()
+		runqService.StopCollecting()
+		offCPUService.StopCollecting()
+		tcpRetransService.Stop()
+		tcpRTTService.StopCollecting()
+		inboundService.Stop()
+		tcpStateService.StopCollecting()
+		dnsService.StopCollecting()
+		killService.Stop()
+		fsLatencyService.Stop()
+		lineageService.Stop()
+		alertService.Stop()
+		baselineService.Stop()
+		if cfg.ProfilesEnabled {
+			behaviorService.Stop()
+		}
+		if podWatcher != nil {
+			podWatcher.Stop()
 		}
 
 		logger.Info("Server stopped")
@@ -149,4 +471,85 @@ func main() {
 		logger.Error("Failed to start server: %v", err)
 		log.Fatalf("Failed to start server: %v", err)
 	}
+	<-stopped
+}
+
+// newAttributor returns the attributor that resolves PIDs to their cgroup,
+// systemd unit and container, and optionally their Kubernetes pod, kept in
+// sync by the returned watcher. Events are stamped with the local host name
+// and labels.
+func newAttributor(cfg *config.Config, hostLabels models.Labels) (enrich.Attributor, *kube.Watcher) {
+	var attributor enrich.Attributor = enrich.NewCgroupResolver()
+
+	// Optionally add Kubernetes pod metadata, kept in sync from the kubelet or API server
+	var podWatcher *kube.Watcher
+	if cfg.KubeEnabled {
+		baseURL := cfg.KubeletURL
+		if cfg.KubeSource == kube.SourceAPIServer {
+			baseURL = cfg.KubeAPIURL
+		}
+		podCache := kube.NewPodCache()
+		watcher, err := kube.NewWatcher(kube.WatcherConfig{
+			Source:   cfg.KubeSource,
+			NodeName: cfg.KubeNodeName,
+			Refresh:  time.Duration(cfg.KubeRefreshSeconds) * time.Second,
+			Client: kube.ClientConfig{
+				BaseURL:            baseURL,
+				TokenPath:          cfg.KubeTokenPath,
+				CAPath:             cfg.KubeCAPath,
+				InsecureSkipVerify: cfg.KubeInsecureSkipVerify,
+			},
+		}, podCache)
+		if err != nil {
+			logger.Error("Failed to set up pod watcher: %v", err)
+		} else {
+			podWatcher = watcher
+			podWatcher.Start()
+			attributor = enrich.NewPodAttributor(attributor, podCache)
+			logger.Info("Kubernetes pod enrichment enabled (source: %s, node: %s)", cfg.KubeSource, cfg.KubeNodeName)
+		}
+	}
+
+	return enrich.NewHostAttributor(attributor, cfg.HostName, hostLabels), podWatcher
+}
+
+// runAgent runs the collectors and ships their events to the server until
+// the process is interrupted
+func runAgent(cfg *config.Config) {
+	hostLabels, _ := cfg.ParseHostLabels() // validated with the configuration
+	attributor, podWatcher := newAttributor(cfg, hostLabels)
+
+	a, err := agent.New(agent.Config{
+		ID:                cfg.AgentID,
+		Host:              cfg.HostName,
+		Labels:            hostLabels,
+		ServerURL:         cfg.ServerURL,
+		Token:             cfg.AgentToken,
+		BatchInterval:     time.Duration(cfg.AgentBatchSeconds) * time.Second,
+		HeartbeatInterval: time.Duration(cfg.AgentHeartbeatSeconds) * time.Second,
+		SpoolDir:          cfg.AgentSpoolDir,
+		SpoolMaxBytes:     int64(cfg.AgentSpoolMaxMB) << 20,
+		OpensFailedOnly:   cfg.OpensFailedOnly,
+		RunqlatPerPIDNS:   cfg.RunqlatPerPIDNS,
+		RunqlatCgroup:     cfg.RunqlatCgroup,
+		OffCPUInterval:    time.Duration(cfg.OffCPUIntervalSeconds) * time.Second,
+		ListenInterval:    time.Duration(cfg.ListenScanIntervalSeconds) * time.Second,
+		FSSlowerThreshold: time.Duration(cfg.FSSlowerThresholdMS) * time.Millisecond,
+	}, attributor)
+	if err != nil {
+		logger.Error("Failed to set up agent: %v", err)
+		log.Fatalf("Failed to set up agent: %v", err)
+	}
+	a.Start()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	logger.Info("Shutting down gracefully...")
+	a.Stop()
+	if podWatcher != nil {
+		podWatcher.Stop()
+	}
+	logger.Info("Agent stopped")
 }
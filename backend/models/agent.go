@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Agent states, derived from the time of the last heartbeat
+const (
+	AgentOnline  = "online"
+	AgentOffline = "offline"
+)
+
+// AgentRegistration is sent by an agent when it starts and whenever the
+// server does not know it
+type AgentRegistration struct {
+	ID         string `json:"id" binding:"required"`
+	Host       string `json:"host" binding:"required"`
+	Labels     Labels `json:"labels,omitempty"`
+	Kernel     string `json:"kernel"`
+	BCCVersion string `json:"bcc_version"`
+}
+
+// AgentHeartbeat is sent by an agent periodically
+type AgentHeartbeat struct {
+	Spooled    int               `json:"spooled"` // batches waiting in the spool of the agent
+	Collectors []CollectorStatus `json:"collectors,omitempty"`
+}
+
+// Agent is a host that ships its events to this server
+type Agent struct {
+	ID            string            `json:"id"`
+	Host          string            `json:"host"`
+	Labels        Labels            `json:"labels,omitempty"`
+	Kernel        string            `json:"kernel"`
+	BCCVersion    string            `json:"bcc_version"`
+	Status        string            `json:"status"`
+	RegisteredAt  time.Time         `json:"registered_at"`
+	LastHeartbeat time.Time         `json:"last_heartbeat"`
+	LastBatchAt   time.Time         `json:"last_batch_at"`
+	Batches       int               `json:"batches"`
+	Events        int               `json:"events"`
+	Spooled       int               `json:"spooled"`
+	Collectors    []CollectorStatus `json:"collectors,omitempty"`
+}
+
+// Batch is the set of events an agent collected during one interval
+type Batch struct {
+	ID              string               `json:"id"`
+	AgentID         string               `json:"agent_id"`
+	CreatedAt       time.Time            `json:"created_at"`
+	Processes       []ProcessEvent       `json:"processes,omitempty"`
+	Connections     []NetworkConnection  `json:"connections,omitempty"`
+	DiskLatency     []DiskLatency        `json:"disk_latency,omitempty"`
+	CPUProfiles     []CPUProfile         `json:"cpu_profiles,omitempty"`
+	TCPSessions     []TCPLifeEvent       `json:"tcp_sessions,omitempty"`
+	Syscalls        []SyscallStat        `json:"syscalls,omitempty"`
+	ProcessSyscalls []ProcessSyscallStat `json:"process_syscalls,omitempty"`
+	Exits           []ProcessExit        `json:"exits,omitempty"`
+	FileOpens       []FileOpen           `json:"file_opens,omitempty"`
+	RunqLatency     []RunqLatency        `json:"runq_latency,omitempty"`
+	OffCPUStacks    []OffCPUStack        `json:"offcpu_stacks,omitempty"`
+	TCPRetransmits  []TCPRetransmit      `json:"tcp_retransmits,omitempty"`
+	TCPDrops        []TCPDrop            `json:"tcp_drops,omitempty"`
+	TCPRTT          []TCPRTT             `json:"tcp_rtt,omitempty"`
+	Inbound         []InboundConnection  `json:"inbound_connections,omitempty"`
+	TCPStates       []TCPStateChange     `json:"tcp_state_changes,omitempty"`
+	DNSLookups      []DNSLookup          `json:"dns_lookups,omitempty"`
+	OOMKills        []OOMKill            `json:"oom_kills,omitempty"`
+	Signals         []Signal             `json:"signals,omitempty"`
+	SlowFileOps     []SlowFileOp         `json:"fs_slow_ops,omitempty"`
+	// Listeners is the inventory of listening sockets taken since the last
+	// batch, if any. It replaces the stored inventory of the host.
+	Listeners *ListenInventory `json:"listeners,omitempty"`
+}
+
+// Len returns the number of events in the batch
+func (b Batch) Len() int {
+	n := len(b.Processes) + len(b.Connections) + len(b.DiskLatency) + len(b.CPUProfiles) +
+		len(b.TCPSessions) + len(b.Syscalls) + len(b.ProcessSyscalls) + len(b.Exits) + len(b.FileOpens) +
+		len(b.RunqLatency) + len(b.OffCPUStacks) + len(b.TCPRetransmits) + len(b.TCPDrops) +
+		len(b.TCPRTT) + len(b.Inbound) + len(b.TCPStates) + len(b.DNSLookups) +
+		len(b.OOMKills) + len(b.Signals) + len(b.SlowFileOps)
+	if b.Listeners != nil {
+		n += len(b.Listeners.Sockets)
+	}
+	return n
+}
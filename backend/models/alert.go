@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Alert rule sources. Event sources count matching events within the window,
+// metric sources evaluate an aggregate.
+const (
+	AlertSourceExec        = "exec"
+	AlertSourceExit        = "exit"
+	AlertSourceConnect     = "connect"
+	AlertSourceTCPSession  = "tcp_session"
+	AlertSourceAccept      = "accept" // inbound connections from tcpaccept
+	AlertSourceListen      = "listen" // newly opened listening sockets
+	AlertSourceDiskP99     = "disk_p99_ms"
+	AlertSourceSyscallRate = "syscall_rate"
+)
+
+// Alert states
+const (
+	AlertPending  = "pending"
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// AlertRule is a user-defined condition evaluated against the event streams
+type AlertRule struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description,omitempty"`
+	Source        string     `json:"source"`
+	Match         AlertMatch `json:"match"`
+	GroupBy       string     `json:"group_by,omitempty"` // comm, pid, addr or port; one alert per value
+	WindowSeconds int        `json:"window_seconds"`     // the value is computed over this window
+	Operator      string     `json:"operator"`           // >, >=, <, <=, ==, !=
+	Threshold     float64    `json:"threshold"`
+	ForSeconds    int        `json:"for_seconds"` // how long the condition must hold before firing
+	Severity      string     `json:"severity"`
+	Enabled       bool       `json:"enabled"`
+	WebhookURL    string     `json:"webhook_url,omitempty"` // overrides the default webhook
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AlertMatch narrows down the events a rule counts. Empty fields match
+// everything; all non-empty fields have to match.
+type AlertMatch struct {
+	Comm      []string `json:"comm,omitempty"`
+	ArgsRegex string   `json:"args_regex,omitempty"` // exec arguments
+	Addr      []string `json:"addr,omitempty"`       // destination, remote or listening address, IP or CIDR
+	Port      []int    `json:"port,omitempty"`       // destination, local or listening port
+	Signal    []int    `json:"signal,omitempty"`     // terminating signal of an exit
+	Failed    bool     `json:"failed,omitempty"`     // exits with a non-zero code or a signal
+	Syscall   string   `json:"syscall,omitempty"`    // required for syscall_rate
+}
+
+// Alert is one occurrence of a rule condition, deduplicated per rule and group
+type Alert struct {
+	ID         int        `json:"id"`
+	RuleID     int        `json:"rule_id"`
+	RuleName   string     `json:"rule_name"`
+	Severity   string     `json:"severity"`
+	GroupKey   string     `json:"group_key,omitempty"`
+	State      string     `json:"state"`
+	Value      float64    `json:"value"`
+	Summary    string     `json:"summary"`
+	StartedAt  time.Time  `json:"started_at"`
+	FiredAt    *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// AlertNotification is the body delivered to webhooks on state changes
+type AlertNotification struct {
+	Status string    `json:"status"`
+	Alert  Alert     `json:"alert"`
+	Rule   AlertRule `json:"rule"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// Attribution identifies the cgroup, systemd unit, container and Kubernetes
+// pod a process belongs to, and the host it runs on
+type Attribution struct {
+	CgroupPath       string `json:"cgroup_path"`
+	SystemdUnit      string `json:"systemd_unit"`
+	ContainerID      string `json:"container_id"`
+	ContainerRuntime string `json:"container_runtime"`
+	PodName          string `json:"pod_name,omitempty"`
+	PodNamespace     string `json:"pod_namespace,omitempty"`
+	PodUID           string `json:"pod_uid,omitempty"`
+	PodLabels        Labels `json:"pod_labels,omitempty"`
+	NodeName         string `json:"node_name,omitempty"`
+	Host             string `json:"host"`
+	HostLabels       Labels `json:"host_labels,omitempty"`
+}
+
+// AttributionSummary aggregates activity of all events attributed to one group,
+// e.g. one systemd unit or one container
+type AttributionSummary struct {
+	Group       string  `json:"group"`
+	Execs       int     `json:"execs"`
+	Connects    int     `json:"connects"`
+	TCPSessions int     `json:"tcp_sessions"`
+	TxKB        float64 `json:"tx_kb"`
+	RxKB        float64 `json:"rx_kb"`
+	CPUSamples  int     `json:"cpu_samples"`
+}
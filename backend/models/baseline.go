@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Baseline metrics
+const (
+	BaselineSyscallRate  = "syscall_rate"    // calls per second of one syscall, host-wide
+	BaselineExecCount    = "exec_count"      // execs of one command per bucket
+	BaselineNewDestPorts = "new_dest_ports"  // destination ports a command never connected to before, per bucket
+	BaselineTCPDuration  = "tcp_duration_ms" // mean duration of the TCP sessions of one command
+)
+
+// BaselineOverall is the slot of the baseline that is learned over all hours
+const BaselineOverall = -1
+
+// Anomaly detection methods
+const (
+	AnomalySeasonal = "seasonal" // scored against the same hour of the week
+	AnomalyEWMA     = "ewma"     // scored against the overall moving average
+)
+
+// Baseline holds the exponentially weighted mean and standard deviation of a
+// metric for one subject (a syscall or command name) and hour of the week
+type Baseline struct {
+	Metric    string    `json:"metric"`
+	Subject   string    `json:"subject"`
+	Slot      int       `json:"slot"` // hour of the week, 0 is Sunday 00:00 local time; -1 for all hours
+	Mean      float64   `json:"mean"`
+	StdDev    float64   `json:"stddev"`
+	Samples   int       `json:"samples"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HourOfWeek returns the baseline slot t falls into
+func HourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// Anomaly is a metric value that deviates from its learned baseline
+type Anomaly struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Metric    string    `json:"metric"`
+	Subject   string    `json:"subject"`
+	Comm      string    `json:"comm,omitempty"` // set for per-command metrics
+	Value     float64   `json:"value"`
+	Expected  float64   `json:"expected"`
+	StdDev    float64   `json:"stddev"`
+	Score     float64   `json:"score"` // z-score of the value
+	Method    string    `json:"method"`
+	Slot      int       `json:"slot"`
+}
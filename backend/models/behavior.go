@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Behavior profile modes
+const (
+	ProfileLearning  = "learning"  // observed behavior is added to the profile
+	ProfileEnforcing = "enforcing" // behavior outside the profile raises findings
+)
+
+// BehaviorProfile is the allowlist of what processes with one command name
+// do: the commands they exec, where they connect to and the syscalls they make
+type BehaviorProfile struct {
+	Comm      string        `json:"comm" yaml:"comm"`
+	Mode      string        `json:"mode" yaml:"mode"`
+	Children  []string      `json:"children" yaml:"children"`
+	Connect   []ConnectRule `json:"connect" yaml:"connect"`
+	Syscalls  []string      `json:"syscalls" yaml:"syscalls"`
+	CreatedAt time.Time     `json:"created_at" yaml:"-"`
+	UpdatedAt time.Time     `json:"updated_at" yaml:"-"`
+}
+
+// ConnectRule allows connections to a network, on one port or on any port if 0
+type ConnectRule struct {
+	Network string `json:"network" yaml:"network"`
+	Port    int    `json:"port" yaml:"port"`
+}
+
+// BehaviorProfileSet is the document profiles are exported and imported as
+type BehaviorProfileSet struct {
+	Profiles []BehaviorProfile `json:"profiles" yaml:"profiles"`
+}
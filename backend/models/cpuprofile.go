@@ -7,6 +7,9 @@ type CPUProfile struct {
 	ID          int       `json:"id"`
 	Timestamp   time.Time `json:"timestamp"`
 	ProcessName string    `json:"process_name"`
+	Comm        string    `json:"comm"`
+	PID         int       `json:"pid"`
 	StackTrace  string    `json:"stack_trace"`
 	SampleCount int       `json:"sample_count"`
+	Attribution
 }
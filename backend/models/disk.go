@@ -1,11 +1,55 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 type DiskLatency struct {
-	ID        int       `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	RangeMin  int       `json:"range_min"`
-	RangeMax  int       `json:"range_max"`
-	Count     int       `json:"count"`
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	RangeMin   int       `json:"range_min"`
+	RangeMax   int       `json:"range_max"`
+	Count      int       `json:"count"`
+	Host       string    `json:"host"`
+	HostLabels Labels    `json:"host_labels,omitempty"`
+}
+
+// Bucket returns the range and count of the histogram bucket
+func (l DiskLatency) Bucket() (rangeMin, rangeMax, count int) {
+	return l.RangeMin, l.RangeMax, l.Count
+}
+
+// LatencyBucket is a bucket of a latency histogram
+type LatencyBucket interface {
+	Bucket() (rangeMin, rangeMax, count int)
+}
+
+// LatencyPercentile estimates the q-th quantile (0 < q <= 1) of a latency
+// histogram as the upper bound of the bucket it falls into
+func LatencyPercentile[B LatencyBucket](buckets []B, q float64) int {
+	type bucket struct{ min, max, count int }
+
+	total := 0
+	sorted := make([]bucket, 0, len(buckets))
+	for _, b := range buckets {
+		rangeMin, rangeMax, count := b.Bucket()
+		sorted = append(sorted, bucket{rangeMin, rangeMax, count})
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].min < sorted[j].min })
+
+	threshold := q * float64(total)
+	seen := 0
+	for _, b := range sorted {
+		seen += b.count
+		if float64(seen) >= threshold {
+			return b.max
+		}
+	}
+	return sorted[len(sorted)-1].max
 }
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// DNSLookup is a hostname resolved through getaddrinfo, gethostbyname or
+// gethostbyname2, from gethostlatency. Lookups answered from a resolver
+// cache inside the process, or made without libc, are not seen.
+type DNSLookup struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Time      string    `json:"time"`
+	PID       int       `json:"pid"`
+	Comm      string    `json:"comm"`
+	Hostname  string    `json:"hostname"`
+	LatencyMS float64   `json:"latency_ms"`
+	Attribution
+}
+
+// DNSQuery selects lookups by hostname, command and latency
+type DNSQuery struct {
+	Since        time.Time
+	Until        time.Time
+	Hostname     string
+	Comm         string
+	MinLatencyMS float64
+	Filter       Filter
+	Limit        int
+}
+
+// DNSHostStats summarizes the lookups of one hostname
+type DNSHostStats struct {
+	Hostname     string    `json:"hostname"`
+	Lookups      int       `json:"lookups"`
+	AvgLatencyMS float64   `json:"avg_latency_ms"`
+	MaxLatencyMS float64   `json:"max_latency_ms"`
+	LastSeen     time.Time `json:"last_seen"`
+}
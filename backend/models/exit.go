@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ProcessExit represents a process exit event from exitsnoop
+type ProcessExit struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Time       string    `json:"time"`
+	PID        int       `json:"pid"`
+	PPID       int       `json:"ppid"`
+	TID        int       `json:"tid"`
+	Comm       string    `json:"comm"`
+	AgeSeconds float64   `json:"age_seconds"`
+	ExitCode   int       `json:"exit_code"`
+	Signal     int       `json:"signal"`
+	SignalName string    `json:"signal_name,omitempty"`
+	CoreDumped bool      `json:"core_dumped"`
+	Attribution
+}
+
+// ProcessLifetime is an exit joined with the exec event that started the process
+type ProcessLifetime struct {
+	PID             int       `json:"pid"`
+	PPID            int       `json:"ppid"`
+	Comm            string    `json:"comm"`
+	Args            string    `json:"args"`
+	StartTime       time.Time `json:"start_time"`
+	ExitTime        time.Time `json:"exit_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ExitCode        int       `json:"exit_code"`
+	Signal          int       `json:"signal"`
+	SignalName      string    `json:"signal_name,omitempty"`
+	CoreDumped      bool      `json:"core_dumped"`
+	ExecSeen        bool      `json:"exec_seen"` // false if the process was started before execsnoop was running
+	Attribution
+}
+
+// ShortLivedStorm summarizes a burst of short-lived processes with the same name
+type ShortLivedStorm struct {
+	Comm          string    `json:"comm"`
+	Count         int       `json:"count"`
+	Parents       int       `json:"parents"`
+	AvgAgeSeconds float64   `json:"avg_age_seconds"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// CrashLoop summarizes repeated failing exits of processes with the same name
+type CrashLoop struct {
+	Comm         string    `json:"comm"`
+	Failures     int       `json:"failures"`
+	LastExitCode int       `json:"last_exit_code"`
+	LastSignal   int       `json:"last_signal"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
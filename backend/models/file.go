@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// FileOpen represents a file open traced by opensnoop
+type FileOpen struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Time      string    `json:"time"` // seconds since opensnoop started
+	UID       int       `json:"uid"`
+	PID       int       `json:"pid"`
+	Comm      string    `json:"comm"`
+	FD        int       `json:"fd"`    // -1 if the open failed
+	Errno     int       `json:"errno"` // 0 if the open succeeded
+	ErrnoName string    `json:"errno_name,omitempty"`
+	Flags     int       `json:"flags"`
+	FlagNames string    `json:"flag_names"` // e.g. O_WRONLY|O_CREAT|O_CLOEXEC
+	Path      string    `json:"path"`       // as passed to open, relative paths stay relative
+	Attribution
+}
+
+// Failed reports whether the open returned an error
+func (o FileOpen) Failed() bool {
+	return o.Errno != 0
+}
+
+// FileOpenQuery selects file opens
+type FileOpenQuery struct {
+	Since      time.Time
+	Until      time.Time
+	PID        int
+	Comm       string
+	Path       string
+	PathPrefix string
+	FailedOnly bool
+	Errno      string // errno name, e.g. ENOENT
+	Filter     Filter
+	Limit      int
+}
+
+// OpenFailure summarizes failed opens of one path with the same error
+type OpenFailure struct {
+	Path      string    `json:"path"`
+	Errno     int       `json:"errno"`
+	ErrnoName string    `json:"errno_name"`
+	Count     int       `json:"count"`
+	Commands  int       `json:"commands"` // distinct commands that failed
+	LastComm  string    `json:"last_comm"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// FileAccessor summarizes the opens of one path by a command and user
+type FileAccessor struct {
+	Comm      string    `json:"comm"`
+	UID       int       `json:"uid"`
+	PIDs      int       `json:"pids"`
+	Opens     int       `json:"opens"`
+	Writes    int       `json:"writes"` // opens for writing, successful or not
+	Failures  int       `json:"failures"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// Filter narrows queries down to events attributed to matching Kubernetes
+// pods and hosts
+type Filter struct {
+	Namespace  string
+	Pod        string
+	Labels     map[string]string
+	Host       string
+	HostLabels map[string]string
+}
+
+// IsEmpty reports whether the filter matches every event
+func (f Filter) IsEmpty() bool {
+	return !f.HasPod() && !f.HasHost()
+}
+
+// HasPod reports whether the filter selects pods
+func (f Filter) HasPod() bool {
+	return f.Namespace != "" || f.Pod != "" || len(f.Labels) > 0
+}
+
+// HasHost reports whether the filter selects hosts
+func (f Filter) HasHost() bool {
+	return f.Host != "" || len(f.HostLabels) > 0
+}
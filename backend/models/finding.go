@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Finding severities
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// DetectionRule describes one of the built-in security detections
+type DetectionRule struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Techniques  []string `json:"techniques"` // MITRE ATT&CK technique IDs
+}
+
+// Finding is a security detection raised by an exec or connect event
+type Finding struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Rule       string    `json:"rule"`
+	Title      string    `json:"title"`
+	Severity   string    `json:"severity"`
+	Techniques []string  `json:"techniques"`
+	PID        int       `json:"pid"`
+	PPID       int       `json:"ppid,omitempty"`
+	UID        string    `json:"uid,omitempty"`
+	Comm       string    `json:"comm"`
+	Detail     string    `json:"detail"` // what matched, e.g. the command line or destination
+	Attribution
+}
+
+// FindingQuery selects findings
+type FindingQuery struct {
+	Since    time.Time
+	Until    time.Time
+	Severity string // minimum severity
+	Rule     string
+	PID      int
+	Comm     string
+	Filter   Filter
+	Limit    int
+}
+
+// SeverityRank orders severities from low (1) to critical (4), 0 if unknown
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// File operation types as reported by ext4slower, xfsslower and btrfsslower
+const (
+	FSOpRead  = "R"
+	FSOpWrite = "W"
+	FSOpOpen  = "O"
+	FSOpSync  = "S" // fsync
+)
+
+// SlowFileOp is a filesystem operation that took at least the configured
+// threshold, from ext4slower, xfsslower or btrfsslower. File is the name of
+// the file without its directory, which the tools do not print.
+type SlowFileOp struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Time       string    `json:"time"`
+	PID        int       `json:"pid"`
+	Comm       string    `json:"comm"`
+	Filesystem string    `json:"filesystem"`
+	Op         string    `json:"op"`
+	Bytes      int64     `json:"bytes"`
+	OffsetKB   int64     `json:"offset_kb"`
+	LatencyMS  float64   `json:"latency_ms"`
+	File       string    `json:"file"`
+	Attribution
+}
+
+// SlowFileOpQuery selects slow operations by command, filesystem and type
+type SlowFileOpQuery struct {
+	Since      time.Time
+	Until      time.Time
+	Comm       string
+	Filesystem string
+	Op         string
+	Filter     Filter
+	Limit      int
+}
+
+// SlowFile summarizes the slow operations on one file name
+type SlowFile struct {
+	File           string    `json:"file"`
+	Filesystem     string    `json:"filesystem"`
+	Ops            int       `json:"ops"`
+	Processes      int       `json:"processes"`
+	Bytes          int64     `json:"bytes"`
+	AvgLatencyMS   float64   `json:"avg_latency_ms"`
+	MaxLatencyMS   float64   `json:"max_latency_ms"`
+	TotalLatencyMS float64   `json:"total_latency_ms"`
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+// ProcessFSLatency summarizes the slow operations of one process
+type ProcessFSLatency struct {
+	PID            int       `json:"pid"`
+	Comm           string    `json:"comm"`
+	Ops            int       `json:"ops"`
+	Reads          int       `json:"reads"`
+	Writes         int       `json:"writes"`
+	Opens          int       `json:"opens"`
+	Syncs          int       `json:"syncs"`
+	Files          int       `json:"files"`
+	AvgLatencyMS   float64   `json:"avg_latency_ms"`
+	MaxLatencyMS   float64   `json:"max_latency_ms"`
+	TotalLatencyMS float64   `json:"total_latency_ms"`
+	LastSeen       time.Time `json:"last_seen"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// CollectorStatus is the state of one collector on a host
+type CollectorStatus struct {
+	Name    string    `json:"name"`
+	Running bool      `json:"running"`
+	Error   string    `json:"error,omitempty"` // why the collector failed to start
+	Since   time.Time `json:"since"`
+}
+
+// Host is an entry of the host inventory: the local host or a host that
+// ships its events through an agent
+type Host struct {
+	Name       string            `json:"name"`
+	Labels     Labels            `json:"labels,omitempty"`
+	Kernel     string            `json:"kernel"`
+	BCCVersion string            `json:"bcc_version"`
+	AgentID    string            `json:"agent_id,omitempty"`
+	Local      bool              `json:"local"`
+	Status     string            `json:"status"`
+	LastSeen   time.Time         `json:"last_seen"`
+	Collectors []CollectorStatus `json:"collectors"`
+}
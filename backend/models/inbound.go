@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Listen event kinds
+const (
+	ListenOpened = "opened" // a port started listening
+	ListenClosed = "closed" // a port stopped listening
+)
+
+// InboundConnection is a TCP connection accepted by a local process, from
+// tcpaccept
+type InboundConnection struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	PID        int       `json:"pid"`
+	Comm       string    `json:"comm"`
+	IPVersion  int       `json:"ip_version"`
+	RemoteAddr string    `json:"remote_addr"`
+	RemotePort int       `json:"remote_port,omitempty"` // not printed by older tcpaccept versions
+	LocalAddr  string    `json:"local_addr"`
+	LocalPort  int       `json:"local_port"`
+	Attribution
+}
+
+// ServiceClient counts the connections one remote address made to a local
+// port and command
+type ServiceClient struct {
+	LocalPort   int       `json:"local_port"`
+	Comm        string    `json:"comm"`
+	RemoteAddr  string    `json:"remote_addr"`
+	Connections int       `json:"connections"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// ListeningSocket is a TCP socket in LISTEN state from /proc/net/tcp and
+// tcp6. Sockets sharing an address and port, e.g. with SO_REUSEPORT, are
+// listed once with one of their owners. PID is 0 if no owner was found.
+type ListeningSocket struct {
+	Addr      string    `json:"addr"`
+	Port      int       `json:"port"`
+	PID       int       `json:"pid,omitempty"`
+	Comm      string    `json:"comm,omitempty"`
+	UID       int       `json:"uid"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Attribution
+}
+
+// ListenEvent records a listening socket that appeared or went away between
+// two inventories of a host
+type ListenEvent struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"`
+	Addr      string    `json:"addr"`
+	Port      int       `json:"port"`
+	PID       int       `json:"pid,omitempty"`
+	Comm      string    `json:"comm,omitempty"`
+	UID       int       `json:"uid"`
+	Attribution
+}
+
+// ListenInventory is a scan of the listening sockets of a host
+type ListenInventory struct {
+	ScannedAt time.Time         `json:"scanned_at"`
+	Sockets   []ListeningSocket `json:"sockets"`
+}
+
+// InboundQuery selects the accepted connections counted per client
+type InboundQuery struct {
+	Since     time.Time
+	Until     time.Time
+	LocalPort int
+	Comm      string
+	Filter    Filter
+	Limit     int
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// OOMKill is a process killed by the kernel OOM killer, from oomkill. PID and
+// Comm are those of the victim; the triggering process is the one whose
+// allocation failed, which usually shares the victim's memory cgroup.
+type OOMKill struct {
+	ID          int       `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Time        string    `json:"time"`
+	PID         int       `json:"pid"`
+	Comm        string    `json:"comm"`
+	TriggerPID  int       `json:"trigger_pid"`
+	TriggerComm string    `json:"trigger_comm"`
+	Pages       int64     `json:"pages"`
+	// TriggerCgroup is the cgroup of the triggering process. It shares the
+	// memory cgroup of the victim only if that cgroup ran out of memory,
+	// rather than the whole host, so it is kept apart from the attribution.
+	TriggerCgroup string `json:"trigger_cgroup,omitempty"`
+	Attribution
+}
+
+// Signal is a signal sent with kill(2), from killsnoop. PID and Comm are
+// those of the target, so signals show up next to the exits they cause.
+// A target PID of 0 or below addresses a process group, whose name is not
+// known.
+type Signal struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Time       string    `json:"time"`
+	PID        int       `json:"pid"`
+	Comm       string    `json:"comm,omitempty"`
+	SenderPID  int       `json:"sender_pid"`
+	SenderComm string    `json:"sender_comm"`
+	Signal     int       `json:"signal"`
+	SignalName string    `json:"signal_name,omitempty"`
+	Errno      int       `json:"errno"` // 0 if the signal was sent
+	ErrnoName  string    `json:"errno_name,omitempty"`
+	Attribution
+}
+
+// SignalQuery selects signals by target, sender and signal number
+type SignalQuery struct {
+	Since      time.Time
+	Until      time.Time
+	PID        int    // target PID
+	Comm       string // target command
+	SenderComm string
+	Signal     int
+	Filter     Filter
+	Limit      int
+}
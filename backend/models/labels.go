@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Labels is a set of key/value labels, stored as a JSON object
+type Labels map[string]string
+
+// Value implements driver.Valuer. Empty label sets are stored as NULL.
+func (l Labels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (l *Labels) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into Labels", src)
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]string)(l))
+}
+
+// Matches reports whether l contains every key/value pair of selector
+func (l Labels) Matches(selector map[string]string) bool {
+	for key, value := range selector {
+		if l[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ProcessNode is a single process in the live process tree
+type ProcessNode struct {
+	PID          int        `json:"pid"`
+	PPID         int        `json:"ppid"`                    // parent at exec, or when first seen in /proc
+	ReparentedTo int        `json:"reparented_to,omitempty"` // current parent if the process was reparented since, e.g. to init
+	Comm         string     `json:"comm"`
+	Args         string     `json:"args"`
+	StartTime    time.Time  `json:"start_time"`
+	ExitTime     *time.Time `json:"exit_time,omitempty"`
+	Source       string     `json:"source"` // "proc" when seeded from /proc, "exec" when seen by execsnoop
+}
+
+// ProcessTree is a process together with its descendants
+type ProcessTree struct {
+	ProcessNode
+	Children []*ProcessTree `json:"children"`
+}
@@ -12,4 +12,6 @@ type NetworkConnection struct {
 	SourcePort string    `json:"source_port"`
 	DestAddr   string    `json:"dest_addr"`
 	DestPort   string    `json:"dest_port"`
+	DestHost   string    `json:"dest_host,omitempty"` // hostname the process resolved before connecting, if seen
+	Attribution
 }
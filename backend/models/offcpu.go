@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// OffCPUStack is the time threads of one command spent blocked in one stack
+// during a collection interval, from offcputime -f. The folded stack lists
+// the frames from the root to the leaf, separated by semicolons; user and
+// kernel frames are separated by a "-" frame. offcputime reports the thread
+// name but no PID, so stacks are only attributed to their host.
+type OffCPUStack struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Comm      string    `json:"comm"`
+	Stack     string    `json:"stack"`
+	TotalUS   int64     `json:"total_us"`
+	Attribution
+}
+
+// Flame graph types: on-CPU samples, off-CPU (blocked) time, or both
+// combined into wall-clock time
+const (
+	FlameOnCPU     = "oncpu"
+	FlameOffCPU    = "offcpu"
+	FlameWallClock = "wallclock"
+)
+
+// StackQuery selects the stacks of a flame graph. PID only selects on-CPU
+// stacks, off-CPU ones are recorded per command.
+type StackQuery struct {
+	Since  time.Time
+	Until  time.Time
+	PID    int
+	Comm   string
+	Filter Filter
+}
+
+// StackTotal is the total weight of one stack of one command within a
+// window: samples for on-CPU stacks, microseconds for off-CPU ones
+type StackTotal struct {
+	Comm  string
+	Stack string
+	Total int64
+}
+
+// FlameNode is a frame of a flame graph with the time spent in it and its
+// callees, in microseconds. The root node is named after the graph type.
+type FlameNode struct {
+	Name     string       `json:"name"`
+	Value    int64        `json:"value"`
+	OnCPU    int64        `json:"on_cpu_us,omitempty"`
+	OffCPU   int64        `json:"off_cpu_us,omitempty"`
+	Children []*FlameNode `json:"children,omitempty"`
+}
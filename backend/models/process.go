@@ -7,6 +7,9 @@ type ProcessEvent struct {
 	Timestamp time.Time `json:"timestamp"`
 	Time      string    `json:"time"`
 	PID       string    `json:"pid"`
+	PPID      string    `json:"ppid"`
+	UID       string    `json:"uid"`
 	Comm      string    `json:"comm"`
 	Args      string    `json:"args"`
+	Attribution
 }
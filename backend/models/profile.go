@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// ProcessSelector selects the events of a single PID or, when PID is 0, of
+// all processes with the given command name. Only the host conditions of
+// Filter apply; they tell apart processes of different hosts with the same PID.
+type ProcessSelector struct {
+	PID    int
+	Comm   string
+	Filter Filter
+}
+
+// ProcessProfile joins everything recorded about a process within a window
+type ProcessProfile struct {
+	PID         int                 `json:"pid,omitempty"`
+	Comm        string              `json:"comm"`
+	Since       time.Time           `json:"since"`
+	Until       time.Time           `json:"until"`
+	Ancestors   []ProcessNode       `json:"ancestors,omitempty"`
+	Execs       []ProcessEvent      `json:"execs"`
+	Exits       []ProcessExit       `json:"exits"`
+	OOMKills    []OOMKill           `json:"oom_kills"`
+	Signals     []Signal            `json:"signals"` // signals sent to the process
+	Connections []NetworkConnection `json:"connections"`
+	TCP         TCPSummary          `json:"tcp"`
+	TCPSessions []TCPLifeEvent      `json:"tcp_sessions"`
+	CPUSamples  int                 `json:"cpu_samples"`
+	CPUStacks   []StackSample       `json:"cpu_stacks"`
+	Syscalls    *SyscallSummary     `json:"syscalls"` // nil if no per-process syscall counts were collected
+}
+
+// TCPSummary aggregates TCP sessions over a window
+type TCPSummary struct {
+	Sessions      int     `json:"sessions"`
+	Peers         int     `json:"peers"`
+	TxKB          float64 `json:"tx_kb"`
+	RxKB          float64 `json:"rx_kb"`
+	AvgDurationMS float64 `json:"avg_duration_ms"`
+	MaxDurationMS float64 `json:"max_duration_ms"`
+}
+
+// StackSample is a CPU stack with the number of samples it was seen in
+type StackSample struct {
+	Stack   string `json:"stack"`
+	Samples int    `json:"samples"`
+}
+
+// SyscallSummary holds the syscall counts of a process per collection interval
+type SyscallSummary struct {
+	Total  int            `json:"total"`
+	Series []SyscallPoint `json:"series"`
+}
+
+// SyscallPoint is the number of syscalls made during one collection interval
+type SyscallPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Count     int       `json:"count"`
+}
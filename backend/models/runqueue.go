@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// RunqLatency is one bucket of a run queue latency histogram from runqlat:
+// how long threads waited on a CPU run queue before running, in microseconds.
+// PIDNS is the PID namespace the histogram belongs to when collected per
+// namespace, 0 for the whole host. Cgroup is the cgroup runqlat was limited
+// to, if any.
+type RunqLatency struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	PIDNS      int64     `json:"pidns,omitempty"`
+	Cgroup     string    `json:"cgroup,omitempty"`
+	RangeMin   int       `json:"range_min"`
+	RangeMax   int       `json:"range_max"`
+	Count      int       `json:"count"`
+	Host       string    `json:"host"`
+	HostLabels Labels    `json:"host_labels,omitempty"`
+}
+
+// Bucket returns the range and count of the histogram bucket
+func (l RunqLatency) Bucket() (rangeMin, rangeMax, count int) {
+	return l.RangeMin, l.RangeMax, l.Count
+}
+
+// RunqQuery selects run queue latency buckets. PIDNS 0 matches every namespace.
+type RunqQuery struct {
+	Since  time.Time
+	Until  time.Time
+	PIDNS  int64
+	Filter Filter
+}
+
+// RunqPercentiles summarizes the run queue latency histogram of one host and
+// PID namespace over one step, latencies are in microseconds
+type RunqPercentiles struct {
+	Time   time.Time `json:"time"`
+	Host   string    `json:"host"`
+	PIDNS  int64     `json:"pidns,omitempty"`
+	Cgroup string    `json:"cgroup,omitempty"`
+	Count  int       `json:"count"`
+	P50US  int       `json:"p50_us"`
+	P90US  int       `json:"p90_us"`
+	P99US  int       `json:"p99_us"`
+	MaxUS  int       `json:"max_us"`
+}
+
+// RunqHeatmap counts run queue latencies per step and histogram bucket.
+// Counts of a column line up with Buckets.
+type RunqHeatmap struct {
+	Step    string              `json:"step"`
+	Buckets []RunqHeatmapBucket `json:"buckets"`
+	Columns []RunqHeatmapColumn `json:"columns"`
+}
+
+// RunqHeatmapBucket is the latency range of a heatmap row, in microseconds
+type RunqHeatmapBucket struct {
+	RangeMin int `json:"range_min"`
+	RangeMax int `json:"range_max"`
+}
+
+// RunqHeatmapColumn is the histogram of one step of a heatmap
+type RunqHeatmapColumn struct {
+	Time   time.Time `json:"time"`
+	Counts []int     `json:"counts"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// StorageStats describes the storage backend and, for SQLite, the writer
+// that batches all inserts into shared transactions
+type StorageStats struct {
+	Backend string       `json:"backend"`
+	Writer  *WriterStats `json:"writer,omitempty"`
+}
+
+// WriterStats are the counters of the SQLite writer since startup
+type WriterStats struct {
+	QueueDepth    int        `json:"queue_depth"` // inserts waiting for the writer
+	QueuedRows    int        `json:"queued_rows"`
+	QueueCapacity int        `json:"queue_capacity"`
+	Transactions  int        `json:"transactions"`
+	RowsWritten   int        `json:"rows_written"`
+	RowsFailed    int        `json:"rows_failed"`
+	LastBatchRows int        `json:"last_batch_rows"`
+	LastCommitMS  float64    `json:"last_commit_ms"` // duration of the last transaction
+	AvgLatencyMS  float64    `json:"avg_latency_ms"` // from queueing an insert until its commit
+	MaxLatencyMS  float64    `json:"max_latency_ms"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+}
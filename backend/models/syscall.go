@@ -8,4 +8,26 @@ type SyscallStat struct {
 	Timestamp   time.Time `json:"timestamp"`
 	SyscallName string    `json:"syscall_name"`
 	Count       int       `json:"count"`
+	Host        string    `json:"host"`
+	HostLabels  Labels    `json:"host_labels,omitempty"`
+}
+
+// ProcessSyscallStat represents the number of system calls a process made
+// during one collection interval
+type ProcessSyscallStat struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	PID        int       `json:"pid"`
+	Comm       string    `json:"comm"`
+	Count      int       `json:"count"`
+	Host       string    `json:"host"`
+	HostLabels Labels    `json:"host_labels,omitempty"`
+}
+
+// CommSyscallStat represents how often processes with one command name made
+// one system call during a collection interval
+type CommSyscallStat struct {
+	Comm    string `json:"comm"`
+	Syscall string `json:"syscall"`
+	Count   int    `json:"count"`
 }
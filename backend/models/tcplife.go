@@ -12,7 +12,9 @@ type TCPLifeEvent struct {
 	LocalPort  int       `json:"local_port"`
 	RemoteAddr string    `json:"remote_addr"`
 	RemotePort int       `json:"remote_port"`
+	RemoteHost string    `json:"remote_host,omitempty"` // hostname the process resolved before connecting, if seen
 	TxKB       float64   `json:"tx_kb"`
 	RxKB       float64   `json:"rx_kb"`
 	DurationMS float64   `json:"duration_ms"`
+	Attribution
 }
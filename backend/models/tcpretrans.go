@@ -0,0 +1,104 @@
+package models
+
+import "time"
+
+// Retransmit types as reported by tcpretrans -l
+const (
+	RetransmitRTO = "RTO" // a segment the kernel retransmitted, after a timeout or as fast retransmit
+	RetransmitTLP = "TLP" // a tail loss probe
+)
+
+// TCPRetransmit is a retransmitted TCP segment from tcpretrans. PID and Comm
+// are those of the process owning the socket, if it was still open when the
+// retransmit was processed; tcpretrans itself runs in whatever context the
+// kernel retransmits in.
+type TCPRetransmit struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Time       string    `json:"time"`
+	PID        int       `json:"pid,omitempty"`
+	Comm       string    `json:"comm,omitempty"`
+	IPVersion  int       `json:"ip_version"`
+	LocalAddr  string    `json:"local_addr"`
+	LocalPort  int       `json:"local_port"`
+	RemoteAddr string    `json:"remote_addr"`
+	RemotePort int       `json:"remote_port"`
+	State      string    `json:"state"`
+	Type       string    `json:"type"`
+	Attribution
+}
+
+// TCPDrop is a TCP packet the kernel dropped, from tcpdrop. Source and
+// destination are those of the packet, so the local end of a received packet
+// is its destination. Stack holds the kernel frames, innermost first.
+type TCPDrop struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	Time       string    `json:"time"`
+	PID        int       `json:"pid,omitempty"`
+	Comm       string    `json:"comm,omitempty"`
+	IPVersion  int       `json:"ip_version"`
+	SourceAddr string    `json:"source_addr"`
+	SourcePort int       `json:"source_port"`
+	DestAddr   string    `json:"dest_addr"`
+	DestPort   int       `json:"dest_port"`
+	State      string    `json:"state"`
+	Flags      string    `json:"flags"`
+	Reason     string    `json:"reason,omitempty"`
+	Stack      string    `json:"stack"`
+	Attribution
+}
+
+// RetransmitQuery selects retransmits by remote endpoint and command
+type RetransmitQuery struct {
+	Since      time.Time
+	Until      time.Time
+	RemoteAddr string
+	RemotePort int
+	Comm       string
+	Filter     Filter
+}
+
+// RetransmitCount is the number of retransmits of one type of a connection
+// within one second
+type RetransmitCount struct {
+	Timestamp  time.Time
+	LocalAddr  string
+	LocalPort  int
+	RemoteAddr string
+	RemotePort int
+	Comm       string
+	Type       string
+	Count      int
+}
+
+// RetransmitPoint counts the retransmits to one remote endpoint by one
+// command within one step
+type RetransmitPoint struct {
+	Time        time.Time `json:"time"`
+	RemoteAddr  string    `json:"remote_addr"`
+	RemotePort  int       `json:"remote_port"`
+	Comm        string    `json:"comm"`
+	Retransmits int       `json:"retransmits"`
+	RTO         int       `json:"rto"`
+	TLP         int       `json:"tlp"`
+}
+
+// DropSummary counts the drops with the same reason, state and kernel stack
+type DropSummary struct {
+	Reason   string    `json:"reason"`
+	State    string    `json:"state"`
+	Stack    string    `json:"stack"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// LossySession is a TCP session from tcplife with the retransmits seen on
+// its 4-tuple while it was open
+type LossySession struct {
+	TCPLifeEvent
+	StartedAt   time.Time `json:"started_at"`
+	Retransmits int       `json:"retransmits"`
+	RTO         int       `json:"rto"`
+	TLP         int       `json:"tlp"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// TCPRTT is one bucket of a TCP round-trip time histogram of one remote
+// address from tcprtt, in microseconds
+type TCPRTT struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	RemoteAddr string    `json:"remote_addr"`
+	RangeMin   int       `json:"range_min"`
+	RangeMax   int       `json:"range_max"`
+	Count      int       `json:"count"`
+	Host       string    `json:"host"`
+	HostLabels Labels    `json:"host_labels,omitempty"`
+}
+
+// Bucket returns the range and count of the histogram bucket
+func (r TCPRTT) Bucket() (rangeMin, rangeMax, count int) {
+	return r.RangeMin, r.RangeMax, r.Count
+}
+
+// RTTQuery selects round-trip time buckets. An empty RemoteAddr matches
+// every peer.
+type RTTQuery struct {
+	Since      time.Time
+	Until      time.Time
+	RemoteAddr string
+	Filter     Filter
+}
+
+// RTTPercentiles summarizes the round-trip times from one host to one remote
+// address, in microseconds
+type RTTPercentiles struct {
+	Host       string `json:"host"`
+	RemoteAddr string `json:"remote_addr"`
+	Count      int    `json:"count"`
+	P50US      int    `json:"p50_us"`
+	P90US      int    `json:"p90_us"`
+	P99US      int    `json:"p99_us"`
+	MaxUS      int    `json:"max_us"`
+}
+
+// RTTPoint summarizes the round-trip times to one peer over one step
+type RTTPoint struct {
+	Time time.Time `json:"time"`
+	RTTPercentiles
+}
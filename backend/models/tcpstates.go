@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// TCPStateChange is a TCP state transition from tcpstates. SKAddr is the
+// kernel address of the socket, which identifies it across transitions.
+// PID and Comm are those of the process owning the socket if it could be
+// found; many transitions happen in softirq context rather than in the
+// owner's.
+type TCPStateChange struct {
+	ID         int       `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	SKAddr     string    `json:"skaddr"`
+	PID        int       `json:"pid,omitempty"`
+	Comm       string    `json:"comm,omitempty"`
+	LocalAddr  string    `json:"local_addr"`
+	LocalPort  int       `json:"local_port"`
+	RemoteAddr string    `json:"remote_addr"`
+	RemotePort int       `json:"remote_port"`
+	OldState   string    `json:"old_state"`
+	NewState   string    `json:"new_state"`
+	DurationMS float64   `json:"duration_ms"` // time spent in OldState
+	Attribution
+}
+
+// StateQuery selects the state transitions counted over time
+type StateQuery struct {
+	Since  time.Time
+	Until  time.Time
+	State  string // new state
+	Comm   string
+	Filter Filter
+}
+
+// StateCount is the number of transitions of a command into a state within
+// one second
+type StateCount struct {
+	Timestamp time.Time
+	State     string
+	Comm      string
+	Count     int
+}
+
+// StatePoint is the number of transitions of a command into a state within
+// one step of a time series
+type StatePoint struct {
+	Time  time.Time `json:"time"`
+	State string    `json:"state"`
+	Comm  string    `json:"comm"`
+	Count int       `json:"count"`
+}
+
+// StuckConnection is a socket whose latest transition left it in a state it
+// has not moved on from for StuckSeconds
+type StuckConnection struct {
+	TCPStateChange
+	StuckSeconds float64 `json:"stuck_seconds"`
+}
+
+// StuckQuery selects the sockets whose latest transition within
+// [Since, Before] left them in one of States
+type StuckQuery struct {
+	Since  time.Time
+	Before time.Time
+	States []string
+	Filter Filter
+	Limit  int
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Timeline event types
+const (
+	TimelineAnomaly      = "anomaly"
+	TimelineCPUHotStack  = "cpu_hot_stack"
+	TimelineConnect      = "connect"
+	TimelineDiskSpike    = "disk_spike"
+	TimelineExec         = "exec"
+	TimelineExit         = "exit"
+	TimelineOOMKill      = "oom_kill"
+	TimelineSignal       = "signal"
+	TimelineSyscallSpike = "syscall_spike"
+	TimelineTCPSession   = "tcp_session"
+)
+
+// TimelineEvent is the common envelope of all events on the timeline
+type TimelineEvent struct {
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	PID     int         `json:"pid,omitempty"`
+	Comm    string      `json:"comm,omitempty"`
+	Summary string      `json:"summary"`
+	Payload interface{} `json:"payload"`
+	ID      int         `json:"-"` // row ID within the source table, orders events of the same type and time
+}
+
+// Cursor returns the position of e on the timeline
+func (e TimelineEvent) Cursor() TimelineCursor {
+	return TimelineCursor{Time: e.Time, Type: e.Type, ID: e.ID}
+}
+
+// TimelineCursor is a position on the timeline. Events are ordered newest
+// first, then by type, then by descending ID.
+type TimelineCursor struct {
+	Time time.Time
+	Type string
+	ID   int
+}
+
+// Before reports whether the event at c comes before the event at other in
+// timeline order
+func (c TimelineCursor) Before(other TimelineCursor) bool {
+	if !c.Time.Equal(other.Time) {
+		return c.Time.After(other.Time)
+	}
+	if c.Type != other.Type {
+		return c.Type < other.Type
+	}
+	return c.ID > other.ID
+}
+
+// TimelineQuery selects a page of timeline events
+type TimelineQuery struct {
+	Since  time.Time
+	Until  time.Time
+	PID    int
+	Comm   string
+	Filter Filter
+	After  *TimelineCursor // return events following this position, nil for the first page
+	Limit  int
+}
+
+// TargetsProcess reports whether the query is narrowed down to processes or
+// pods. Host filters apply to host-wide events as well.
+func (q TimelineQuery) TargetsProcess() bool {
+	return q.PID > 0 || q.Comm != "" || q.Filter.HasPod()
+}
+
+// DiskSpike is the payload of a disk_spike event, latencies are in microseconds
+type DiskSpike struct {
+	Host        string `json:"host"`
+	P50US       int    `json:"p50_us"`
+	P99US       int    `json:"p99_us"`
+	MaxUS       int    `json:"max_us"`
+	IOs         int    `json:"ios"`
+	ThresholdUS int    `json:"threshold_us"`
+}
+
+// SyscallSpike is the payload of a syscall_spike event
+type SyscallSpike struct {
+	Host     string  `json:"host"`
+	Syscall  string  `json:"syscall"`
+	Count    int     `json:"count"`
+	Baseline float64 `json:"baseline"`
+	Factor   float64 `json:"factor"`
+}
@@ -0,0 +1,143 @@
+package procfs
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TCP socket states as numbered in /proc/net/tcp
+const (
+	TCPEstablished = 0x01
+	TCPListen      = 0x0A
+)
+
+// TCPSocket is a line of /proc/net/tcp or /proc/net/tcp6. Addresses of IPv6
+// sockets talking to IPv4 peers are unmapped to their IPv4 form.
+type TCPSocket struct {
+	LocalAddr  netip.Addr
+	LocalPort  int
+	RemoteAddr netip.Addr
+	RemotePort int
+	State      int
+	UID        int
+	Inode      uint64
+}
+
+// ReadTCPSockets returns the IPv4 and IPv6 TCP sockets of the network
+// namespace of this process. A missing tcp6 table, e.g. with IPv6 disabled,
+// is not an error.
+func ReadTCPSockets() ([]TCPSocket, error) {
+	sockets, err := readTCPTable(filepath.Join(Root, "net", "tcp"))
+	if err != nil {
+		return nil, err
+	}
+	v6, err := readTCPTable(filepath.Join(Root, "net", "tcp6"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return append(sockets, v6...), nil
+}
+
+func readTCPTable(path string) ([]TCPSocket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sockets []TCPSocket
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		local, localPort, err := parseSocketAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		remote, remotePort, err := parseSocketAddr(fields[2])
+		if err != nil {
+			continue
+		}
+		state, _ := strconv.ParseInt(fields[3], 16, 32)
+		uid, _ := strconv.Atoi(fields[7])
+		inode, _ := strconv.ParseUint(fields[9], 10, 64)
+
+		sockets = append(sockets, TCPSocket{
+			LocalAddr:  local,
+			LocalPort:  localPort,
+			RemoteAddr: remote,
+			RemotePort: remotePort,
+			State:      int(state),
+			UID:        uid,
+			Inode:      inode,
+		})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseSocketAddr parses an address like "0100007F:0050". The address is
+// printed as 32-bit words in host byte order, little-endian on every
+// architecture we run on.
+func parseSocketAddr(s string) (netip.Addr, int, error) {
+	addrHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return netip.Addr{}, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return netip.Addr{}, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return netip.Addr{}, 0, fmt.Errorf("invalid socket port %q", s)
+	}
+
+	addr, _ := netip.AddrFromSlice(raw)
+	return addr.Unmap(), int(port), nil
+}
+
+// SocketInodes maps the inodes of the sockets every process has open to the
+// PID of one of the processes holding it. Processes that vanish or cannot be
+// inspected are skipped.
+func SocketInodes() (map[uint64]int, error) {
+	pids, err := ListPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	inodes := make(map[uint64]int)
+	for _, pid := range pids {
+		dir := filepath.Join(Root, strconv.Itoa(pid), "fd")
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			target, err := os.Readlink(filepath.Join(dir, entry.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, ok := inodes[inode]; !ok {
+				inodes[inode] = pid
+			}
+		}
+	}
+	return inodes, nil
+}
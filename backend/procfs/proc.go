@@ -0,0 +1,188 @@
+package procfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Root is the mount point of the proc filesystem
+const Root = "/proc"
+
+// clockTicks is USER_HZ, the unit of the starttime field in /proc/<pid>/stat.
+// It is 100 on every mainstream Linux architecture.
+const clockTicks = 100
+
+// Stat holds the fields of /proc/<pid>/stat we care about
+type Stat struct {
+	PID       int
+	Comm      string
+	State     string
+	PPID      int
+	StartTime uint64 // clock ticks since boot
+}
+
+var (
+	bootTimeOnce sync.Once
+	bootTime     time.Time
+)
+
+// ListPIDs returns the PIDs of all processes currently visible in /proc
+func ListPIDs() ([]int, error) {
+	entries, err := os.ReadDir(Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// ReadStat parses /proc/<pid>/stat
+func ReadStat(pid int) (Stat, error) {
+	data, err := os.ReadFile(filepath.Join(Root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return Stat{}, err
+	}
+
+	// The comm field is wrapped in parentheses and may itself contain spaces
+	// or parentheses, so split around the last closing parenthesis.
+	line := string(data)
+	open := strings.IndexByte(line, '(')
+	closing := strings.LastIndexByte(line, ')')
+	if open < 0 || closing < open {
+		return Stat{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+
+	// Fields after comm start at field 3 (state)
+	rest := strings.Fields(line[closing+1:])
+	if len(rest) < 20 {
+		return Stat{}, fmt.Errorf("short stat for pid %d", pid)
+	}
+
+	ppid, _ := strconv.Atoi(rest[1])
+	startTime, _ := strconv.ParseUint(rest[19], 10, 64)
+
+	return Stat{
+		PID:       pid,
+		Comm:      line[open+1 : closing],
+		State:     rest[0],
+		PPID:      ppid,
+		StartTime: startTime,
+	}, nil
+}
+
+// ReadCmdline returns the command line of a process with arguments joined by spaces
+func ReadCmdline(pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(Root, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", err
+	}
+	args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	return strings.Join(args, " "), nil
+}
+
+// ReadCgroup returns the cgroup path of a process. On cgroup v2 this is the
+// unified hierarchy path; on cgroup v1 the name=systemd hierarchy is preferred,
+// falling back to the first controller listed.
+func ReadCgroup(pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(Root, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+
+	var fallback string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// Each line is hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		switch {
+		case parts[0] == "0" && parts[1] == "":
+			return parts[2], nil
+		case parts[1] == "name=systemd":
+			return parts[2], nil
+		case fallback == "":
+			fallback = parts[2]
+		}
+	}
+	return fallback, nil
+}
+
+// BootTime returns the system boot time read from /proc/stat
+func BootTime() time.Time {
+	bootTimeOnce.Do(func() {
+		f, err := os.Open(filepath.Join(Root, "stat"))
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[0] == "btime" {
+				if secs, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					bootTime = time.Unix(secs, 0)
+				}
+				return
+			}
+		}
+	})
+	return bootTime
+}
+
+// StartedAt converts the stat starttime into wall-clock time
+func (s Stat) StartedAt() time.Time {
+	boot := BootTime()
+	if boot.IsZero() {
+		return time.Time{}
+	}
+	return boot.Add(time.Duration(s.StartTime) * (time.Second / clockTicks))
+}
+
+// ReadExe returns the path of the executable of a process
+func ReadExe(pid int) (string, error) {
+	return os.Readlink(filepath.Join(Root, strconv.Itoa(pid), "exe"))
+}
+
+// ReadFD returns the target of a file descriptor of a process, e.g. a path,
+// "socket:[12345]" or "pipe:[6789]"
+func ReadFD(pid, fd int) (string, error) {
+	return os.Readlink(filepath.Join(Root, strconv.Itoa(pid), "fd", strconv.Itoa(fd)))
+}
+
+// ReadMountTypes returns the filesystem types of all mounts. The mounts of
+// init are read, which are those of the host when running in a container
+// that shares its PID namespace, falling back to our own mount namespace.
+func ReadMountTypes() (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(Root, "1", "mounts"))
+	if err != nil {
+		if data, err = os.ReadFile(filepath.Join(Root, "self", "mounts")); err != nil {
+			return nil, err
+		}
+	}
+
+	types := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		// Each line is device mount-point type options dump pass
+		if fields := strings.Fields(line); len(fields) >= 3 {
+			types[fields[2]] = true
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"encoding/json"
+	"time"
+)
+
+type AgentRepository interface {
+	Register(reg models.AgentRegistration, at time.Time) error
+	Heartbeat(id string, hb models.AgentHeartbeat, at time.Time) error
+	RecordBatch(id string, events int, at time.Time) error
+	GetAgent(id string) (models.Agent, error)
+	GetAgents() ([]models.Agent, error)
+}
+
+type agentRepository struct {
+	db *sql.DB
+}
+
+func NewAgentRepository(db *sql.DB) AgentRepository {
+	return &agentRepository{db: db}
+}
+
+// Register inserts an agent, or updates the host, labels and versions of a
+// known one
+func (r *agentRepository) Register(reg models.AgentRegistration, at time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO agents (id, host, labels, kernel, bcc_version, registered_at, last_heartbeat)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET host = excluded.host, labels = excluded.labels,
+			kernel = excluded.kernel, bcc_version = excluded.bcc_version,
+			last_heartbeat = excluded.last_heartbeat`,
+		reg.ID, reg.Host, reg.Labels, reg.Kernel, reg.BCCVersion, formatTime(at), formatTime(at),
+	)
+	return err
+}
+
+// Heartbeat records that an agent is alive, along with the state of its
+// collectors
+func (r *agentRepository) Heartbeat(id string, hb models.AgentHeartbeat, at time.Time) error {
+	collectors, err := json.Marshal(hb.Collectors)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(`UPDATE agents SET last_heartbeat = ?, spooled = ?, collectors = ? WHERE id = ?`,
+		formatTime(at), hb.Spooled, string(collectors), id)
+	return requireRow(result, err)
+}
+
+// RecordBatch counts a batch received from an agent
+func (r *agentRepository) RecordBatch(id string, events int, at time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE agents SET last_batch_at = ?, batches = batches + 1, events = events + ?
+		WHERE id = ?`,
+		formatTime(at), events, id)
+	return requireRow(result, err)
+}
+
+const agentColumns = `id, host, labels, COALESCE(kernel, ''), COALESCE(bcc_version, ''),
+	registered_at, last_heartbeat, COALESCE(last_batch_at, ''), batches, events, spooled,
+	COALESCE(collectors, '')`
+
+func (r *agentRepository) GetAgent(id string) (models.Agent, error) {
+	rows, err := r.db.Query(`SELECT `+agentColumns+` FROM agents WHERE id = ?`, id)
+	if err != nil {
+		return models.Agent{}, err
+	}
+	defer rows.Close()
+
+	agents, err := scanAgents(rows)
+	if err != nil {
+		return models.Agent{}, err
+	}
+	if len(agents) == 0 {
+		return models.Agent{}, ErrNotFound
+	}
+	return agents[0], nil
+}
+
+// GetAgents returns all agents ordered by host
+func (r *agentRepository) GetAgents() ([]models.Agent, error) {
+	rows, err := r.db.Query(`SELECT ` + agentColumns + ` FROM agents ORDER BY host, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAgents(rows)
+}
+
+func scanAgents(rows *sql.Rows) ([]models.Agent, error) {
+	var agents []models.Agent
+	for rows.Next() {
+		var (
+			a          models.Agent
+			lastBatch  string
+			collectors string
+		)
+		if err := rows.Scan(&a.ID, &a.Host, &a.Labels, &a.Kernel, &a.BCCVersion, &a.RegisteredAt, &a.LastHeartbeat,
+			&lastBatch, &a.Batches, &a.Events, &a.Spooled, &collectors); err != nil {
+			return nil, err
+		}
+		a.LastBatchAt = parseTimestamp(lastBatch)
+		if collectors != "" {
+			if err := json.Unmarshal([]byte(collectors), &a.Collectors); err != nil {
+				return nil, err
+			}
+		}
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
+
+// requireRow turns an update that matched no row into ErrNotFound
+func requireRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
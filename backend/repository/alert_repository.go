@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a row looked up by ID does not exist
+var ErrNotFound = errors.New("not found")
+
+type AlertRepository interface {
+	CreateRule(rule *models.AlertRule) error
+	UpdateRule(rule *models.AlertRule) error
+	DeleteRule(id int) error
+	GetRule(id int) (models.AlertRule, error)
+	GetRules() ([]models.AlertRule, error)
+	SaveAlert(alert *models.Alert) error
+	DeleteAlert(id int) error
+	GetAlerts(state string, since time.Time, limit int) ([]models.Alert, error)
+	GetActiveAlerts() ([]models.Alert, error)
+}
+
+type alertRepository struct {
+	db *sql.DB
+}
+
+func NewAlertRepository(db *sql.DB) AlertRepository {
+	return &alertRepository{db: db}
+}
+
+const alertRuleColumns = `id, name, COALESCE(description, ''), source, COALESCE(match, '{}'), COALESCE(group_by, ''),
+	window_seconds, operator, threshold, for_seconds, severity, enabled, COALESCE(webhook_url, ''), created_at, updated_at`
+
+func (r *alertRepository) CreateRule(rule *models.AlertRule) error {
+	match, err := json.Marshal(rule.Match)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := r.db.Exec(`
+		INSERT INTO alert_rules (name, description, source, match, group_by, window_seconds, operator,
+			threshold, for_seconds, severity, enabled, webhook_url, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.Name, rule.Description, rule.Source, string(match), rule.GroupBy, rule.WindowSeconds, rule.Operator,
+		rule.Threshold, rule.ForSeconds, rule.Severity, rule.Enabled, rule.WebhookURL, formatTime(now), formatTime(now),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	rule.ID = int(id)
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return nil
+}
+
+func (r *alertRepository) UpdateRule(rule *models.AlertRule) error {
+	match, err := json.Marshal(rule.Match)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	result, err := r.db.Exec(`
+		UPDATE alert_rules SET name = ?, description = ?, source = ?, match = ?, group_by = ?, window_seconds = ?,
+			operator = ?, threshold = ?, for_seconds = ?, severity = ?, enabled = ?, webhook_url = ?, updated_at = ?
+		WHERE id = ?`,
+		rule.Name, rule.Description, rule.Source, string(match), rule.GroupBy, rule.WindowSeconds,
+		rule.Operator, rule.Threshold, rule.ForSeconds, rule.Severity, rule.Enabled, rule.WebhookURL, formatTime(now),
+		rule.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	rule.UpdatedAt = now
+	return nil
+}
+
+func (r *alertRepository) DeleteRule(id int) error {
+	result, err := r.db.Exec(`DELETE FROM alert_rules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *alertRepository) GetRule(id int) (models.AlertRule, error) {
+	rows, err := r.db.Query(`SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = ?`, id)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	defer rows.Close()
+
+	rules, err := scanAlertRules(rows)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	if len(rules) == 0 {
+		return models.AlertRule{}, ErrNotFound
+	}
+	return rules[0], nil
+}
+
+func (r *alertRepository) GetRules() ([]models.AlertRule, error) {
+	rows, err := r.db.Query(`SELECT ` + alertRuleColumns + ` FROM alert_rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAlertRules(rows)
+}
+
+func scanAlertRules(rows rowScanner) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	for rows.Next() {
+		var (
+			rule  models.AlertRule
+			match string
+		)
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Source, &match, &rule.GroupBy,
+			&rule.WindowSeconds, &rule.Operator, &rule.Threshold, &rule.ForSeconds, &rule.Severity, &rule.Enabled,
+			&rule.WebhookURL, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(match), &rule.Match); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// SaveAlert inserts a new alert or updates an existing one
+func (r *alertRepository) SaveAlert(alert *models.Alert) error {
+	values := []interface{}{alert.RuleID, alert.RuleName, alert.Severity, alert.GroupKey, alert.State, alert.Value,
+		alert.Summary, formatTime(alert.StartedAt), nullableTime(alert.FiredAt), nullableTime(alert.ResolvedAt),
+		formatTime(alert.UpdatedAt)}
+
+	if alert.ID != 0 {
+		_, err := r.db.Exec(`
+			UPDATE alerts SET rule_id = ?, rule_name = ?, severity = ?, group_key = ?, state = ?, value = ?,
+				summary = ?, started_at = ?, fired_at = ?, resolved_at = ?, updated_at = ?
+			WHERE id = ?`,
+			append(values, alert.ID)...,
+		)
+		return err
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO alerts (rule_id, rule_name, severity, group_key, state, value, summary,
+			started_at, fired_at, resolved_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		values...,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	alert.ID = int(id)
+	return nil
+}
+
+// DeleteAlert removes an alert, used for pending alerts that never fired
+func (r *alertRepository) DeleteAlert(id int) error {
+	_, err := r.db.Exec(`DELETE FROM alerts WHERE id = ?`, id)
+	return err
+}
+
+const alertColumns = `id, rule_id, rule_name, severity, COALESCE(group_key, ''), state, value, COALESCE(summary, ''),
+	started_at, fired_at, resolved_at, updated_at`
+
+// GetAlerts returns alerts started since the given time, optionally in one state
+func (r *alertRepository) GetAlerts(state string, since time.Time, limit int) ([]models.Alert, error) {
+	rows, err := r.db.Query(`
+		SELECT `+alertColumns+` FROM alerts
+		WHERE (? = '' OR state = ?) AND (started_at >= ? OR state != ?)
+		ORDER BY id DESC LIMIT ?`,
+		state, state, formatTime(since), models.AlertResolved, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAlerts(rows)
+}
+
+// GetActiveAlerts returns all pending and firing alerts
+func (r *alertRepository) GetActiveAlerts() ([]models.Alert, error) {
+	rows, err := r.db.Query(
+		`SELECT `+alertColumns+` FROM alerts WHERE state IN (?, ?) ORDER BY id`,
+		models.AlertPending, models.AlertFiring,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAlerts(rows)
+}
+
+func scanAlerts(rows rowScanner) ([]models.Alert, error) {
+	var alerts []models.Alert
+	for rows.Next() {
+		var (
+			a               models.Alert
+			fired, resolved sql.NullTime
+		)
+		if err := rows.Scan(&a.ID, &a.RuleID, &a.RuleName, &a.Severity, &a.GroupKey, &a.State, &a.Value, &a.Summary,
+			&a.StartedAt, &fired, &resolved, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if fired.Valid {
+			a.FiredAt = &fired.Time
+		}
+		if resolved.Valid {
+			a.ResolvedAt = &resolved.Time
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+func nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// attributionInsertColumns lists the attribution columns in the order of models.Attribution
+const attributionInsertColumns = `cgroup_path, systemd_unit, container_id, container_runtime, ` +
+	`pod_name, pod_namespace, pod_uid, pod_labels, node_name, host, host_labels`
+
+// attributionColumnCount is the number of columns in attributionInsertColumns
+const attributionColumnCount = 11
+
+// attributionPlaceholders binds the values of attributionInsertColumns
+const attributionPlaceholders = "?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?"
+
+// attributionSelect reads the attribution columns of the table with the given
+// alias, turning the NULLs of rows written before attribution existed into
+// empty strings
+func attributionSelect(alias string) string {
+	if alias != "" {
+		alias += "."
+	}
+	columns := []string{"cgroup_path", "systemd_unit", "container_id", "container_runtime", "pod_name", "pod_namespace", "pod_uid"}
+	parts := make([]string, 0, attributionColumnCount)
+	for _, column := range columns {
+		parts = append(parts, "COALESCE("+alias+column+", '')")
+	}
+	parts = append(parts, alias+"pod_labels", "COALESCE("+alias+"node_name, '')", "COALESCE("+alias+"host, '')", alias+"host_labels")
+	return strings.Join(parts, ", ")
+}
+
+func attributionValues(a models.Attribution) []interface{} {
+	return []interface{}{a.CgroupPath, a.SystemdUnit, a.ContainerID, a.ContainerRuntime,
+		a.PodName, a.PodNamespace, a.PodUID, a.PodLabels, a.NodeName, a.Host, a.HostLabels}
+}
+
+func attributionDest(a *models.Attribution) []interface{} {
+	return []interface{}{&a.CgroupPath, &a.SystemdUnit, &a.ContainerID, &a.ContainerRuntime,
+		&a.PodName, &a.PodNamespace, &a.PodUID, &a.PodLabels, &a.NodeName, &a.Host, &a.HostLabels}
+}
+
+// filterClause renders f as conditions on the attribution columns of the
+// table with the given alias. The result is empty or starts with " AND ".
+func filterClause(f models.Filter, alias string) (string, []interface{}) {
+	hostWhere, hostArgs := hostClause(f, alias)
+	if alias != "" {
+		alias += "."
+	}
+
+	var (
+		clause strings.Builder
+		args   []interface{}
+	)
+	if f.Namespace != "" {
+		clause.WriteString(" AND " + alias + "pod_namespace = ?")
+		args = append(args, f.Namespace)
+	}
+	if f.Pod != "" {
+		clause.WriteString(" AND " + alias + "pod_name = ?")
+		args = append(args, f.Pod)
+	}
+	labelWhere, labelArgs := labelClause(alias+"pod_labels", f.Labels)
+	clause.WriteString(labelWhere + hostWhere)
+	args = append(append(args, labelArgs...), hostArgs...)
+
+	return clause.String(), args
+}
+
+// hostClause renders the host conditions of f on the host and host_labels
+// columns of the table with the given alias, which tables without pod
+// attribution carry as well. The result is empty or starts with " AND ".
+func hostClause(f models.Filter, alias string) (string, []interface{}) {
+	if alias != "" {
+		alias += "."
+	}
+
+	var (
+		clause strings.Builder
+		args   []interface{}
+	)
+	if f.Host != "" {
+		clause.WriteString(" AND " + alias + "host = ?")
+		args = append(args, f.Host)
+	}
+	labelWhere, labelArgs := labelClause(alias+"host_labels", f.HostLabels)
+	clause.WriteString(labelWhere)
+	args = append(args, labelArgs...)
+
+	return clause.String(), args
+}
+
+// labelClause renders conditions matching labels against the JSON column
+func labelClause(column string, labels map[string]string) (string, []interface{}) {
+	// Sort keys so identical filters produce identical statements
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		clause strings.Builder
+		args   []interface{}
+	)
+	for _, key := range keys {
+		// Quote the key so label names with dots and slashes
+		// (app.kubernetes.io/name) are taken literally
+		clause.WriteString(" AND json_extract(" + column + ", ?) = ?")
+		args = append(args, `$."`+key+`"`, labels[key])
+	}
+	return clause.String(), args
+}
+
+// attributionGroups maps the supported groupings to SQL expressions
+var attributionGroups = map[string]string{
+	"cgroup_path":   "COALESCE(cgroup_path, '')",
+	"systemd_unit":  "COALESCE(systemd_unit, '')",
+	"container_id":  "COALESCE(container_id, '')",
+	"pod_namespace": "COALESCE(pod_namespace, '')",
+	"pod":           "COALESCE(pod_namespace || '/' || pod_name, '')",
+	"host":          "COALESCE(host, '')",
+}
+
+type AttributionRepository interface {
+	GetSummary(groupBy string, since, until time.Time, filter models.Filter) ([]models.AttributionSummary, error)
+}
+
+type attributionRepository struct {
+	db *sql.DB
+}
+
+func NewAttributionRepository(db *sql.DB) AttributionRepository {
+	return &attributionRepository{db: db}
+}
+
+// GetSummary aggregates execs, connects, TCP sessions and CPU samples per group
+func (r *attributionRepository) GetSummary(groupBy string, since, until time.Time, filter models.Filter) ([]models.AttributionSummary, error) {
+	group, ok := attributionGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot group by %q", groupBy)
+	}
+
+	conditions, filterArgs := filterClause(filter, "")
+	window := "timestamp >= ? AND timestamp <= ?" + conditions
+	query := `
+		SELECT grp, SUM(execs), SUM(connects), SUM(sessions), SUM(tx_kb), SUM(rx_kb), SUM(samples)
+		FROM (
+			SELECT ` + group + ` AS grp, COUNT(*) AS execs, 0 AS connects, 0 AS sessions,
+				0 AS tx_kb, 0 AS rx_kb, 0 AS samples
+			FROM processes WHERE ` + window + ` GROUP BY grp
+			UNION ALL
+			SELECT ` + group + `, 0, COUNT(*), 0, 0, 0, 0
+			FROM network_connections WHERE ` + window + ` GROUP BY 1
+			UNION ALL
+			SELECT ` + group + `, 0, 0, COUNT(*), SUM(tx_kb), SUM(rx_kb), 0
+			FROM tcp_lifecycle WHERE ` + window + ` GROUP BY 1
+			UNION ALL
+			SELECT ` + group + `, 0, 0, 0, 0, 0, SUM(sample_count)
+			FROM cpu_profiles WHERE ` + window + ` GROUP BY 1
+		)
+		GROUP BY grp
+		ORDER BY SUM(execs) + SUM(connects) + SUM(sessions) + SUM(samples) DESC`
+
+	var args []interface{}
+	for i := 0; i < 4; i++ {
+		args = append(args, formatTime(since), formatTime(until))
+		args = append(args, filterArgs...)
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.AttributionSummary
+	for rows.Next() {
+		var s models.AttributionSummary
+		if err := rows.Scan(&s.Group, &s.Execs, &s.Connects, &s.TCPSessions, &s.TxKB, &s.RxKB, &s.CPUSamples); err != nil {
+			return nil, err
+		}
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"time"
+)
+
+type BaselineRepository interface {
+	SaveBaselines(baselines []models.Baseline) error
+	GetBaselines(metric, subject string) ([]models.Baseline, error)
+	SaveDestPorts(ports map[string][]int, firstSeen time.Time) error
+	GetDestPorts() (map[string][]int, error)
+	SaveAnomalies(anomalies []models.Anomaly) error
+	GetAnomalies(since, until time.Time, metric, comm string, limit int) ([]models.Anomaly, error)
+}
+
+type baselineRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewBaselineRepository(db *sql.DB, writer *SQLiteWriter) BaselineRepository {
+	return &baselineRepository{db: db, writer: writer}
+}
+
+// SaveBaselines inserts or replaces baselines by metric, subject and slot
+func (r *baselineRepository) SaveBaselines(baselines []models.Baseline) error {
+	rows := make([][]interface{}, 0, len(baselines))
+	for _, b := range baselines {
+		rows = append(rows, []interface{}{b.Metric, b.Subject, b.Slot, b.Mean, b.StdDev, b.Samples, formatTime(b.UpdatedAt)})
+	}
+	r.writer.enqueue(`
+		INSERT OR REPLACE INTO baselines (metric, subject, slot, mean, stddev, samples, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, rows)
+	return nil
+}
+
+// GetBaselines returns the baselines of a metric and subject, or all of them
+// if they are empty
+func (r *baselineRepository) GetBaselines(metric, subject string) ([]models.Baseline, error) {
+	rows, err := r.db.Query(`
+		SELECT metric, subject, slot, mean, stddev, samples, updated_at
+		FROM baselines
+		WHERE (? = '' OR metric = ?) AND (? = '' OR subject = ?)
+		ORDER BY metric, subject, slot`,
+		metric, metric, subject, subject,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var baselines []models.Baseline
+	for rows.Next() {
+		var b models.Baseline
+		if err := rows.Scan(&b.Metric, &b.Subject, &b.Slot, &b.Mean, &b.StdDev, &b.Samples, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		baselines = append(baselines, b)
+	}
+	return baselines, rows.Err()
+}
+
+// SaveDestPorts records destination ports seen for the first time per command
+func (r *baselineRepository) SaveDestPorts(ports map[string][]int, firstSeen time.Time) error {
+	var rows [][]interface{}
+	for comm, list := range ports {
+		for _, port := range list {
+			rows = append(rows, []interface{}{comm, port, formatTime(firstSeen)})
+		}
+	}
+	r.writer.enqueue(`
+		INSERT OR IGNORE INTO baseline_dest_ports (comm, port, first_seen)
+		VALUES (?, ?, ?)
+	`, rows)
+	return nil
+}
+
+// GetDestPorts returns the known destination ports per command
+func (r *baselineRepository) GetDestPorts() (map[string][]int, error) {
+	rows, err := r.db.Query(`SELECT comm, port FROM baseline_dest_ports`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ports := make(map[string][]int)
+	for rows.Next() {
+		var (
+			comm string
+			port int
+		)
+		if err := rows.Scan(&comm, &port); err != nil {
+			return nil, err
+		}
+		ports[comm] = append(ports[comm], port)
+	}
+	return ports, rows.Err()
+}
+
+// SaveAnomalies queues detected anomalies for the writer
+func (r *baselineRepository) SaveAnomalies(anomalies []models.Anomaly) error {
+	rows := make([][]interface{}, 0, len(anomalies))
+	for _, a := range anomalies {
+		rows = append(rows, []interface{}{formatTime(a.Timestamp), a.Metric, a.Subject, a.Comm, a.Value, a.Expected,
+			a.StdDev, a.Score, a.Method, a.Slot})
+	}
+	r.writer.enqueue(`
+		INSERT INTO anomalies (timestamp, metric, subject, comm, value, expected, stddev, score, method, slot)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rows)
+	return nil
+}
+
+const anomalyColumns = `id, timestamp, metric, subject, COALESCE(comm, ''), value, expected, stddev, score, method, slot`
+
+// GetAnomalies returns anomalies within a time window, newest first,
+// optionally of one metric or command
+func (r *baselineRepository) GetAnomalies(since, until time.Time, metric, comm string, limit int) ([]models.Anomaly, error) {
+	rows, err := r.db.Query(`
+		SELECT `+anomalyColumns+` FROM anomalies
+		WHERE timestamp >= ? AND timestamp <= ? AND (? = '' OR metric = ?) AND (? = '' OR comm = ?)
+		ORDER BY timestamp DESC, id DESC LIMIT ?`,
+		formatTime(since), formatTime(until), metric, metric, comm, comm, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAnomalies(rows)
+}
+
+func scanAnomalies(rows rowScanner) ([]models.Anomaly, error) {
+	var anomalies []models.Anomaly
+	for rows.Next() {
+		var a models.Anomaly
+		if err := rows.Scan(&a.ID, &a.Timestamp, &a.Metric, &a.Subject, &a.Comm, &a.Value, &a.Expected,
+			&a.StdDev, &a.Score, &a.Method, &a.Slot); err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"encoding/json"
+)
+
+type BehaviorRepository interface {
+	SaveProfiles(profiles []models.BehaviorProfile) error
+	GetProfiles() ([]models.BehaviorProfile, error)
+	DeleteProfile(comm string) error
+}
+
+type behaviorRepository struct {
+	db *sql.DB
+}
+
+func NewBehaviorRepository(db *sql.DB) BehaviorRepository {
+	return &behaviorRepository{db: db}
+}
+
+// SaveProfiles inserts or updates profiles by command name
+func (r *behaviorRepository) SaveProfiles(profiles []models.BehaviorProfile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO behavior_profiles (comm, mode, children, connect, syscalls, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (comm) DO UPDATE SET mode = excluded.mode, children = excluded.children,
+			connect = excluded.connect, syscalls = excluded.syscalls, updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range profiles {
+		children, err := json.Marshal(p.Children)
+		if err != nil {
+			return err
+		}
+		connect, err := json.Marshal(p.Connect)
+		if err != nil {
+			return err
+		}
+		syscalls, err := json.Marshal(p.Syscalls)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(p.Comm, p.Mode, string(children), string(connect), string(syscalls),
+			formatTime(p.CreatedAt), formatTime(p.UpdatedAt)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetProfiles returns all profiles ordered by command name
+func (r *behaviorRepository) GetProfiles() ([]models.BehaviorProfile, error) {
+	rows, err := r.db.Query(`SELECT ` + behaviorProfileColumns + ` FROM behavior_profiles ORDER BY comm`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanBehaviorProfiles(rows)
+}
+
+const behaviorProfileColumns = `comm, mode, COALESCE(children, 'null'), COALESCE(connect, 'null'), COALESCE(syscalls, 'null'),
+	created_at, updated_at`
+
+func scanBehaviorProfiles(rows rowScanner) ([]models.BehaviorProfile, error) {
+	var profiles []models.BehaviorProfile
+	for rows.Next() {
+		var (
+			p                           models.BehaviorProfile
+			children, connect, syscalls string
+		)
+		if err := rows.Scan(&p.Comm, &p.Mode, &children, &connect, &syscalls, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(children), &p.Children); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(connect), &p.Connect); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(syscalls), &p.Syscalls); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+func (r *behaviorRepository) DeleteProfile(comm string) error {
+	result, err := r.db.Exec(`DELETE FROM behavior_profiles WHERE comm = ?`, comm)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
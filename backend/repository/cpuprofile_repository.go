@@ -3,99 +3,128 @@ package repository
 import (
 	"database/sql"
 	"ebpf-dashboard/models"
-	"time"
 )
 
-type CPUProfileRepository struct {
-	db *sql.DB
+type CPUProfileRepository interface {
+	SaveCPUProfiles(profiles []models.CPUProfile) error
+	GetRecentCPUProfiles(limit int, filter models.Filter) ([]models.CPUProfile, error)
+	GetStackTotals(q models.StackQuery) ([]models.StackTotal, error)
 }
 
-func NewCPUProfileRepository(db *sql.DB) *CPUProfileRepository {
-	return &CPUProfileRepository{db: db}
+type cpuProfileRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
 }
 
-// SaveCPUProfiles saves multiple CPU profile samples to the database
-func (r *CPUProfileRepository) SaveCPUProfiles(profiles []models.CPUProfile) error {
-	if len(profiles) == 0 {
-		return nil
-	}
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`
-		INSERT INTO cpu_profiles (process_name, stack_trace, sample_count)
-		VALUES (?, ?, ?)
-	`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+func NewCPUProfileRepository(db *sql.DB, writer *SQLiteWriter) CPUProfileRepository {
+	return &cpuProfileRepository{db: db, writer: writer}
+}
 
+// SaveCPUProfiles queues multiple CPU profile samples for the writer
+func (r *cpuProfileRepository) SaveCPUProfiles(profiles []models.CPUProfile) error {
+	rows := make([][]interface{}, 0, len(profiles))
 	for _, profile := range profiles {
-		_, err := stmt.Exec(profile.ProcessName, profile.StackTrace, profile.SampleCount)
-		if err != nil {
-			return err
-		}
+		rows = append(rows, append([]interface{}{timestampValue(profile.Timestamp), profile.ProcessName, profile.Comm, profile.PID, profile.StackTrace, profile.SampleCount},
+			attributionValues(profile.Attribution)...))
 	}
-
-	return tx.Commit()
+	r.writer.enqueue(`
+		INSERT INTO cpu_profiles (timestamp, process_name, comm, pid, stack_trace, sample_count, `+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
 }
 
 // GetRecentCPUProfiles retrieves the most recent CPU profile samples
-func (r *CPUProfileRepository) GetRecentCPUProfiles(limit int) ([]models.CPUProfile, error) {
+func (r *cpuProfileRepository) GetRecentCPUProfiles(limit int, filter models.Filter) ([]models.CPUProfile, error) {
+	conditions, args := filterClause(filter, "")
 	query := `
-		SELECT id, timestamp, process_name, stack_trace, sample_count
+		SELECT ` + cpuProfileColumns + `
 		FROM cpu_profiles
+		WHERE 1 = 1` + conditions + `
 		ORDER BY timestamp DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCPUProfiles(rows)
+}
+
+// cpuProfileComm falls back to the process line for samples collected
+// before the command name was parsed from it
+const cpuProfileComm = "COALESCE(NULLIF(comm, ''), process_name)"
+
+// GetStackTotals sums up the samples of every stack per command in the window
+func (r *cpuProfileRepository) GetStackTotals(q models.StackQuery) ([]models.StackTotal, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.PID > 0 {
+		where += " AND pid = ?"
+		args = append(args, q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+
+	rows, err := r.db.Query(
+		"SELECT "+cpuProfileComm+", stack_trace, SUM(sample_count) FROM cpu_profiles WHERE"+where+conditions+
+			" GROUP BY "+cpuProfileComm+", stack_trace",
+		append(args, filterArgs...)...,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanStackTotals(rows)
+}
+
+func scanStackTotals(rows rowScanner) ([]models.StackTotal, error) {
+	var totals []models.StackTotal
+	for rows.Next() {
+		var t models.StackTotal
+		if err := rows.Scan(&t.Comm, &t.Stack, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+var cpuProfileColumns = `id, timestamp, process_name, COALESCE(comm, ''), COALESCE(pid, 0), stack_trace, sample_count, ` +
+	attributionSelect("")
+
+func scanCPUProfiles(rows *sql.Rows) ([]models.CPUProfile, error) {
 	var profiles []models.CPUProfile
 	for rows.Next() {
 		var profile models.CPUProfile
 		var timestamp string
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&profile.ID,
 			&timestamp,
 			&profile.ProcessName,
+			&profile.Comm,
+			&profile.PID,
 			&profile.StackTrace,
 			&profile.SampleCount,
-		)
-		if err != nil {
-			return nil, err
 		}
-
-		// Parse timestamp - try multiple formats
-		// SQLite might return different formats depending on configuration
-		formats := []string{
-			"2006-01-02 15:04:05",
-			time.RFC3339,
-			"2006-01-02T15:04:05Z",
-			"2006-01-02T15:04:05",
-		}
-
-		for _, format := range formats {
-			if t, err := time.Parse(format, timestamp); err == nil {
-				profile.Timestamp = t
-				break
-			}
+		if err := rows.Scan(append(dest, attributionDest(&profile.Attribution)...)...); err != nil {
+			return nil, err
 		}
 
-		// If parsing failed for all formats, profile.Timestamp will be zero value
+		// SQLite might return different formats depending on configuration,
+		// the timestamp stays zero if none matches
+		profile.Timestamp = parseTimestamp(timestamp)
 
 		profiles = append(profiles, profile)
 	}
 
-	return profiles, nil
+	return profiles, rows.Err()
 }
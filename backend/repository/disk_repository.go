@@ -7,46 +7,38 @@ import (
 
 type DiskRepository interface {
 	SaveLatencySnapshot(latencies []models.DiskLatency) error
-	GetLatestLatency(limit int) ([]models.DiskLatency, error)
+	GetLatestLatency(limit int, filter models.Filter) ([]models.DiskLatency, error)
 }
 
 type diskRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	writer *SQLiteWriter
 }
 
-func NewDiskRepository(db *sql.DB) DiskRepository {
-	return &diskRepository{db: db}
+func NewDiskRepository(db *sql.DB, writer *SQLiteWriter) DiskRepository {
+	return &diskRepository{db: db, writer: writer}
 }
 
+// SaveLatencySnapshot queues the buckets of a snapshot as one insert, so they
+// are written with adjacent IDs
 func (r *diskRepository) SaveLatencySnapshot(latencies []models.DiskLatency) error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(
-		"INSERT INTO disk_latency (range_min, range_max, count) VALUES (?, ?, ?)",
-	)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
+	rows := make([][]interface{}, 0, len(latencies))
 	for _, lat := range latencies {
-		if _, err := stmt.Exec(lat.RangeMin, lat.RangeMax, lat.Count); err != nil {
-			return err
-		}
+		rows = append(rows, []interface{}{timestampValue(lat.Timestamp), lat.RangeMin, lat.RangeMax, lat.Count, lat.Host, lat.HostLabels})
 	}
-
-	return tx.Commit()
+	r.writer.enqueue(
+		"INSERT INTO disk_latency (timestamp, range_min, range_max, count, host, host_labels) VALUES ("+eventTimestamp+", ?, ?, ?, ?, ?)",
+		rows,
+	)
+	return nil
 }
 
-func (r *diskRepository) GetLatestLatency(limit int) ([]models.DiskLatency, error) {
+func (r *diskRepository) GetLatestLatency(limit int, filter models.Filter) ([]models.DiskLatency, error) {
+	conditions, args := hostClause(filter, "")
 	rows, err := r.db.Query(
-		`SELECT id, timestamp, range_min, range_max, count 
-		FROM disk_latency ORDER BY id DESC LIMIT ?`,
-		limit,
+		`SELECT id, timestamp, range_min, range_max, count, COALESCE(host, ''), host_labels
+		FROM disk_latency WHERE 1 = 1`+conditions+` ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
 	)
 	if err != nil {
 		return nil, err
@@ -56,7 +48,7 @@ func (r *diskRepository) GetLatestLatency(limit int) ([]models.DiskLatency, erro
 	var results []models.DiskLatency
 	for rows.Next() {
 		var lat models.DiskLatency
-		if err := rows.Scan(&lat.ID, &lat.Timestamp, &lat.RangeMin, &lat.RangeMax, &lat.Count); err != nil {
+		if err := rows.Scan(&lat.ID, &lat.Timestamp, &lat.RangeMin, &lat.RangeMax, &lat.Count, &lat.Host, &lat.HostLabels); err != nil {
 			return nil, err
 		}
 		results = append(results, lat)
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+)
+
+type DNSRepository interface {
+	SaveLookups(lookups []models.DNSLookup) error
+	GetRecentLookups(limit int, filter models.Filter) ([]models.DNSLookup, error)
+	GetSlowLookups(q models.DNSQuery) ([]models.DNSLookup, error)
+	GetHostStats(q models.DNSQuery) ([]models.DNSHostStats, error)
+}
+
+type dnsRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewDNSRepository(db *sql.DB, writer *SQLiteWriter) DNSRepository {
+	return &dnsRepository{db: db, writer: writer}
+}
+
+var dnsLookupColumns = "id, timestamp, time, pid, comm, hostname, latency_ms, " + attributionSelect("")
+
+// SaveLookups queues lookups for the writer
+func (r *dnsRepository) SaveLookups(lookups []models.DNSLookup) error {
+	rows := make([][]interface{}, 0, len(lookups))
+	for _, l := range lookups {
+		values := []interface{}{timestampValue(l.Timestamp), l.Time, l.PID, l.Comm, l.Hostname, l.LatencyMS}
+		rows = append(rows, append(values, attributionValues(l.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO dns_lookups (timestamp, time, pid, comm, hostname, latency_ms, `+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *dnsRepository) GetRecentLookups(limit int, filter models.Filter) ([]models.DNSLookup, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+dnsLookupColumns+" FROM dns_lookups WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDNSLookups(rows)
+}
+
+// GetSlowLookups returns the lookups selected by q, slowest first
+func (r *dnsRepository) GetSlowLookups(q models.DNSQuery) ([]models.DNSLookup, error) {
+	where, args := dnsWhere(q)
+	rows, err := r.db.Query(
+		"SELECT "+dnsLookupColumns+" FROM dns_lookups WHERE"+where+" ORDER BY latency_ms DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDNSLookups(rows)
+}
+
+// GetHostStats summarizes the lookups selected by q per hostname, slowest
+// on average first
+func (r *dnsRepository) GetHostStats(q models.DNSQuery) ([]models.DNSHostStats, error) {
+	where, args := dnsWhere(q)
+	rows, err := r.db.Query(`
+		SELECT hostname, COUNT(*), AVG(latency_ms), MAX(latency_ms), MAX(timestamp)
+		FROM dns_lookups
+		WHERE`+where+`
+		GROUP BY hostname
+		ORDER BY AVG(latency_ms) DESC, hostname
+		LIMIT ?`,
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.DNSHostStats
+	for rows.Next() {
+		var (
+			h        models.DNSHostStats
+			lastSeen string
+		)
+		if err := rows.Scan(&h.Hostname, &h.Lookups, &h.AvgLatencyMS, &h.MaxLatencyMS, &lastSeen); err != nil {
+			return nil, err
+		}
+		h.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, h)
+	}
+	return results, rows.Err()
+}
+
+// dnsWhere renders the conditions of q, without the limit
+func dnsWhere(q models.DNSQuery) (string, []interface{}) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.Hostname != "" {
+		where += " AND hostname = ?"
+		args = append(args, q.Hostname)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	if q.MinLatencyMS > 0 {
+		where += " AND latency_ms >= ?"
+		args = append(args, q.MinLatencyMS)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+	return where + conditions, append(args, filterArgs...)
+}
+
+func scanDNSLookups(rows rowScanner) ([]models.DNSLookup, error) {
+	var lookups []models.DNSLookup
+	for rows.Next() {
+		var l models.DNSLookup
+		dest := []interface{}{&l.ID, &l.Timestamp, &l.Time, &l.PID, &l.Comm, &l.Hostname, &l.LatencyMS}
+		if err := rows.Scan(append(dest, attributionDest(&l.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		lookups = append(lookups, l)
+	}
+	return lookups, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"time"
+)
+
+type ExitRepository interface {
+	SaveExits(exits []models.ProcessExit) error
+	GetRecentExits(limit int, filter models.Filter) ([]models.ProcessExit, error)
+	GetLifetimes(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.ProcessLifetime, error)
+	GetShortLivedStorms(since, until time.Time, maxAge float64, minCount int, filter models.Filter) ([]models.ShortLivedStorm, error)
+	GetCrashLoops(since, until time.Time, minFailures int, filter models.Filter) ([]models.CrashLoop, error)
+	GetKilled(since, until time.Time, signal int, limit int, filter models.Filter) ([]models.ProcessExit, error)
+}
+
+type exitRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewExitRepository(db *sql.DB, writer *SQLiteWriter) ExitRepository {
+	return &exitRepository{db: db, writer: writer}
+}
+
+var exitColumns = `id, timestamp, time, pid, ppid, tid, comm, age_seconds, exit_code, signal, COALESCE(signal_name, ''), core_dumped, ` +
+	attributionSelect("")
+
+func (r *exitRepository) SaveExits(exits []models.ProcessExit) error {
+	rows := make([][]interface{}, 0, len(exits))
+	for _, e := range exits {
+		values := []interface{}{timestampValue(e.Timestamp), e.Time, e.PID, e.PPID, e.TID, e.Comm, e.AgeSeconds,
+			e.ExitCode, e.Signal, e.SignalName, e.CoreDumped}
+		rows = append(rows, append(values, attributionValues(e.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO process_exits (timestamp, time, pid, ppid, tid, comm, age_seconds, exit_code, signal, signal_name, core_dumped,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *exitRepository) GetRecentExits(limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		`SELECT `+exitColumns+` FROM process_exits WHERE 1 = 1`+conditions+` ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanExits(rows)
+}
+
+// GetLifetimes joins exits with the latest exec of the same PID that happened
+// during the lifetime of the process. The exec is looked up with a couple of
+// seconds of slack because exec and exit events are saved by separate tickers.
+func (r *exitRepository) GetLifetimes(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.ProcessLifetime, error) {
+	conditions, filterArgs := filterClause(filter, "e")
+	args := append([]interface{}{formatTime(since), formatTime(until), comm, comm}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT e.pid, e.ppid, e.comm, e.timestamp, e.age_seconds, e.exit_code, e.signal,
+			COALESCE(e.signal_name, ''), e.core_dumped, `+attributionSelect("e")+`,
+			(SELECT p.args FROM processes p
+				WHERE p.pid = e.pid
+				AND p.timestamp <= datetime(e.timestamp, '+2 seconds')
+				AND p.timestamp >= datetime(e.timestamp, '-' || (CAST(e.age_seconds AS INTEGER) + 2) || ' seconds')
+				ORDER BY p.id DESC LIMIT 1)
+		FROM process_exits e
+		WHERE e.timestamp >= ? AND e.timestamp <= ? AND (? = '' OR e.comm = ?)`+conditions+`
+		ORDER BY e.id DESC
+		LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.ProcessLifetime
+	for rows.Next() {
+		var (
+			l    models.ProcessLifetime
+			args sql.NullString
+		)
+		dest := []interface{}{&l.PID, &l.PPID, &l.Comm, &l.ExitTime, &l.DurationSeconds,
+			&l.ExitCode, &l.Signal, &l.SignalName, &l.CoreDumped}
+		dest = append(dest, attributionDest(&l.Attribution)...)
+		if err := rows.Scan(append(dest, &args)...); err != nil {
+			return nil, err
+		}
+		l.Args = args.String
+		l.ExecSeen = args.Valid
+		l.StartTime = l.ExitTime.Add(-time.Duration(l.DurationSeconds * float64(time.Second)))
+		results = append(results, l)
+	}
+	return results, rows.Err()
+}
+
+// GetShortLivedStorms returns process names with at least minCount exits of
+// processes that lived no longer than maxAge seconds
+func (r *exitRepository) GetShortLivedStorms(since, until time.Time, maxAge float64, minCount int, filter models.Filter) ([]models.ShortLivedStorm, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until), maxAge}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT comm, COUNT(*), COUNT(DISTINCT ppid), AVG(age_seconds), MIN(timestamp), MAX(timestamp)
+		FROM process_exits
+		WHERE timestamp >= ? AND timestamp <= ? AND age_seconds <= ?`+conditions+`
+		GROUP BY comm
+		HAVING COUNT(*) >= ?
+		ORDER BY COUNT(*) DESC`,
+		append(args, minCount)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.ShortLivedStorm
+	for rows.Next() {
+		var (
+			s               models.ShortLivedStorm
+			first, lastSeen string
+		)
+		if err := rows.Scan(&s.Comm, &s.Count, &s.Parents, &s.AvgAgeSeconds, &first, &lastSeen); err != nil {
+			return nil, err
+		}
+		s.FirstSeen = parseTimestamp(first)
+		s.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, s)
+	}
+	return results, rows.Err()
+}
+
+// GetCrashLoops returns process names that exited with a non-zero code or a
+// signal at least minFailures times
+func (r *exitRepository) GetCrashLoops(since, until time.Time, minFailures int, filter models.Filter) ([]models.CrashLoop, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until)}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT g.comm, g.failures, g.first_seen, g.last_seen, l.exit_code, l.signal
+		FROM (
+			SELECT comm, COUNT(*) AS failures, MIN(timestamp) AS first_seen,
+				MAX(timestamp) AS last_seen, MAX(id) AS last_id
+			FROM process_exits
+			WHERE timestamp >= ? AND timestamp <= ? AND (exit_code != 0 OR signal != 0)`+conditions+`
+			GROUP BY comm
+			HAVING COUNT(*) >= ?
+		) g
+		JOIN process_exits l ON l.id = g.last_id
+		ORDER BY g.failures DESC`,
+		append(args, minFailures)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.CrashLoop
+	for rows.Next() {
+		var (
+			cl              models.CrashLoop
+			first, lastSeen string
+		)
+		if err := rows.Scan(&cl.Comm, &cl.Failures, &first, &lastSeen, &cl.LastExitCode, &cl.LastSignal); err != nil {
+			return nil, err
+		}
+		cl.FirstSeen = parseTimestamp(first)
+		cl.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, cl)
+	}
+	return results, rows.Err()
+}
+
+// GetKilled returns processes terminated by a signal, optionally a specific one
+func (r *exitRepository) GetKilled(since, until time.Time, signal int, limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until), signal, signal}, filterArgs...)
+	rows, err := r.db.Query(
+		`SELECT `+exitColumns+` FROM process_exits
+		WHERE timestamp >= ? AND timestamp <= ? AND signal != 0 AND (? = 0 OR signal = ?)`+conditions+`
+		ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanExits(rows)
+}
+
+func scanExits(rows rowScanner) ([]models.ProcessExit, error) {
+	var results []models.ProcessExit
+	for rows.Next() {
+		var e models.ProcessExit
+		dest := []interface{}{&e.ID, &e.Timestamp, &e.Time, &e.PID, &e.PPID, &e.TID, &e.Comm,
+			&e.AgeSeconds, &e.ExitCode, &e.Signal, &e.SignalName, &e.CoreDumped}
+		if err := rows.Scan(append(dest, attributionDest(&e.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		results = append(results, e)
+	}
+	return results, rows.Err()
+}
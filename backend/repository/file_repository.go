@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"time"
+	"unicode/utf8"
+)
+
+type FileRepository interface {
+	SaveOpens(opens []models.FileOpen) error
+	GetRecentOpens(limit int, filter models.Filter) ([]models.FileOpen, error)
+	GetOpens(q models.FileOpenQuery) ([]models.FileOpen, error)
+	GetFailures(since, until time.Time, errno string, minCount, limit int, filter models.Filter) ([]models.OpenFailure, error)
+	GetAccessors(path string, since, until time.Time, filter models.Filter) ([]models.FileAccessor, error)
+}
+
+type fileRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewFileRepository(db *sql.DB, writer *SQLiteWriter) FileRepository {
+	return &fileRepository{db: db, writer: writer}
+}
+
+var fileOpenColumns = `id, timestamp, COALESCE(time, ''), uid, pid, comm, fd, errno, COALESCE(errno_name, ''), flags,
+	COALESCE(flag_names, ''), path, ` + attributionSelect("")
+
+// SaveOpens queues multiple file opens for the writer
+func (r *fileRepository) SaveOpens(opens []models.FileOpen) error {
+	rows := make([][]interface{}, 0, len(opens))
+	for _, o := range opens {
+		values := []interface{}{timestampValue(o.Timestamp), o.Time, o.UID, o.PID, o.Comm, o.FD, o.Errno, o.ErrnoName,
+			o.Flags, o.FlagNames, o.Path}
+		rows = append(rows, append(values, attributionValues(o.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO file_opens (timestamp, time, uid, pid, comm, fd, errno, errno_name, flags, flag_names, path,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *fileRepository) GetRecentOpens(limit int, filter models.Filter) ([]models.FileOpen, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		`SELECT `+fileOpenColumns+` FROM file_opens WHERE 1 = 1`+conditions+` ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFileOpens(rows)
+}
+
+// GetOpens returns the opens selected by q, newest first
+func (r *fileRepository) GetOpens(q models.FileOpenQuery) ([]models.FileOpen, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.PID > 0 {
+		where += " AND pid = ?"
+		args = append(args, q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	if q.Path != "" {
+		where += " AND path = ?"
+		args = append(args, q.Path)
+	}
+	if q.PathPrefix != "" {
+		where += " AND substr(path, 1, ?) = ?"
+		args = append(args, utf8.RuneCountInString(q.PathPrefix), q.PathPrefix)
+	}
+	if q.FailedOnly {
+		where += " AND errno != 0"
+	}
+	if q.Errno != "" {
+		where += " AND errno_name = ?"
+		args = append(args, q.Errno)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+	args = append(append(args, filterArgs...), q.Limit)
+
+	rows, err := r.db.Query(
+		"SELECT "+fileOpenColumns+" FROM file_opens WHERE"+where+conditions+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFileOpens(rows)
+}
+
+// GetFailures returns the paths failing to open most often, per error,
+// optionally of one error only
+func (r *fileRepository) GetFailures(since, until time.Time, errno string, minCount, limit int, filter models.Filter) ([]models.OpenFailure, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until), errno, errno}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT g.path, g.errno, g.errno_name, g.failures, g.commands, l.comm, g.first_seen, g.last_seen
+		FROM (
+			SELECT path, errno, COALESCE(errno_name, '') AS errno_name, COUNT(*) AS failures,
+				COUNT(DISTINCT comm) AS commands, MIN(timestamp) AS first_seen, MAX(timestamp) AS last_seen,
+				MAX(id) AS last_id
+			FROM file_opens
+			WHERE timestamp >= ? AND timestamp <= ? AND errno != 0 AND (? = '' OR errno_name = ?)`+conditions+`
+			GROUP BY path, errno
+			HAVING COUNT(*) >= ?
+		) g
+		JOIN file_opens l ON l.id = g.last_id
+		ORDER BY g.failures DESC, g.last_id DESC
+		LIMIT ?`,
+		append(args, minCount, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.OpenFailure
+	for rows.Next() {
+		var (
+			f               models.OpenFailure
+			first, lastSeen string
+		)
+		if err := rows.Scan(&f.Path, &f.Errno, &f.ErrnoName, &f.Count, &f.Commands, &f.LastComm, &first, &lastSeen); err != nil {
+			return nil, err
+		}
+		f.FirstSeen = parseTimestamp(first)
+		f.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, f)
+	}
+	return results, rows.Err()
+}
+
+// GetAccessors returns the commands and users that opened path, most opens
+// first. Opens with O_WRONLY or O_RDWR count as writes.
+func (r *fileRepository) GetAccessors(path string, since, until time.Time, filter models.Filter) ([]models.FileAccessor, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{path, formatTime(since), formatTime(until)}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT comm, uid, COUNT(DISTINCT pid), COUNT(*), SUM(CASE WHEN flags & 3 != 0 THEN 1 ELSE 0 END),
+			SUM(CASE WHEN errno != 0 THEN 1 ELSE 0 END), MIN(timestamp), MAX(timestamp)
+		FROM file_opens
+		WHERE path = ? AND timestamp >= ? AND timestamp <= ?`+conditions+`
+		GROUP BY comm, uid
+		ORDER BY COUNT(*) DESC, comm, uid`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.FileAccessor
+	for rows.Next() {
+		var (
+			a               models.FileAccessor
+			first, lastSeen string
+		)
+		if err := rows.Scan(&a.Comm, &a.UID, &a.PIDs, &a.Opens, &a.Writes, &a.Failures, &first, &lastSeen); err != nil {
+			return nil, err
+		}
+		a.FirstSeen = parseTimestamp(first)
+		a.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+func scanFileOpens(rows rowScanner) ([]models.FileOpen, error) {
+	var results []models.FileOpen
+	for rows.Next() {
+		var o models.FileOpen
+		dest := []interface{}{&o.ID, &o.Timestamp, &o.Time, &o.UID, &o.PID, &o.Comm, &o.FD, &o.Errno, &o.ErrnoName,
+			&o.Flags, &o.FlagNames, &o.Path}
+		if err := rows.Scan(append(dest, attributionDest(&o.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		results = append(results, o)
+	}
+	return results, rows.Err()
+}
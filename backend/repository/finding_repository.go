@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"strings"
+)
+
+type FindingRepository interface {
+	SaveFindings(findings []models.Finding) error
+	GetFindings(q models.FindingQuery) ([]models.Finding, error)
+}
+
+type findingRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewFindingRepository(db *sql.DB, writer *SQLiteWriter) FindingRepository {
+	return &findingRepository{db: db, writer: writer}
+}
+
+var findingColumns = "id, timestamp, rule, title, severity, COALESCE(techniques, ''), pid, COALESCE(ppid, 0), " +
+	"COALESCE(uid, ''), comm, detail, " + attributionSelect("")
+
+// SaveFindings queues multiple findings for the writer
+func (r *findingRepository) SaveFindings(findings []models.Finding) error {
+	rows := make([][]interface{}, 0, len(findings))
+	for _, f := range findings {
+		values := []interface{}{formatTime(f.Timestamp), f.Rule, f.Title, f.Severity, strings.Join(f.Techniques, ","),
+			f.PID, f.PPID, f.UID, f.Comm, f.Detail}
+		rows = append(rows, append(values, attributionValues(f.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO findings (timestamp, rule, title, severity, techniques, pid, ppid, uid, comm, detail, `+attributionInsertColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+// GetFindings returns the findings selected by q, newest first
+func (r *findingRepository) GetFindings(q models.FindingQuery) ([]models.Finding, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+
+	if q.Severity != "" {
+		var severities []string
+		for _, severity := range []string{models.SeverityLow, models.SeverityMedium, models.SeverityHigh, models.SeverityCritical} {
+			if models.SeverityRank(severity) >= models.SeverityRank(q.Severity) {
+				severities = append(severities, "?")
+				args = append(args, severity)
+			}
+		}
+		where += " AND severity IN (" + strings.Join(severities, ", ") + ")"
+	}
+	if q.Rule != "" {
+		where += " AND rule = ?"
+		args = append(args, q.Rule)
+	}
+	if q.PID > 0 {
+		where += " AND pid = ?"
+		args = append(args, q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+	args = append(append(args, filterArgs...), q.Limit)
+
+	rows, err := r.db.Query(
+		"SELECT "+findingColumns+" FROM findings WHERE"+where+conditions+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFindings(rows)
+}
+
+func scanFindings(rows rowScanner) ([]models.Finding, error) {
+	var findings []models.Finding
+	for rows.Next() {
+		var (
+			f          models.Finding
+			techniques string
+		)
+		dest := []interface{}{&f.ID, &f.Timestamp, &f.Rule, &f.Title, &f.Severity, &techniques, &f.PID, &f.PPID,
+			&f.UID, &f.Comm, &f.Detail}
+		if err := rows.Scan(append(dest, attributionDest(&f.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		if techniques != "" {
+			f.Techniques = strings.Split(techniques, ",")
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
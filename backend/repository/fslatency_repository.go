@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+)
+
+type FSLatencyRepository interface {
+	SaveSlowOps(ops []models.SlowFileOp) error
+	GetRecentSlowOps(limit int, filter models.Filter) ([]models.SlowFileOp, error)
+	GetSlowFiles(q models.SlowFileOpQuery) ([]models.SlowFile, error)
+	GetProcessLatency(q models.SlowFileOpQuery) ([]models.ProcessFSLatency, error)
+}
+
+type fsLatencyRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewFSLatencyRepository(db *sql.DB, writer *SQLiteWriter) FSLatencyRepository {
+	return &fsLatencyRepository{db: db, writer: writer}
+}
+
+var slowFileOpColumns = "id, timestamp, time, pid, comm, filesystem, op, bytes, offset_kb, latency_ms, file, " +
+	attributionSelect("")
+
+// SaveSlowOps queues slow operations for the writer
+func (r *fsLatencyRepository) SaveSlowOps(ops []models.SlowFileOp) error {
+	rows := make([][]interface{}, 0, len(ops))
+	for _, o := range ops {
+		values := []interface{}{timestampValue(o.Timestamp), o.Time, o.PID, o.Comm, o.Filesystem, o.Op,
+			o.Bytes, o.OffsetKB, o.LatencyMS, o.File}
+		rows = append(rows, append(values, attributionValues(o.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO fs_slow_ops (timestamp, time, pid, comm, filesystem, op, bytes, offset_kb, latency_ms, file,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *fsLatencyRepository) GetRecentSlowOps(limit int, filter models.Filter) ([]models.SlowFileOp, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+slowFileOpColumns+" FROM fs_slow_ops WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSlowFileOps(rows)
+}
+
+// GetSlowFiles summarizes the operations selected by q per file name and
+// filesystem, the files that stalled their processes longest first
+func (r *fsLatencyRepository) GetSlowFiles(q models.SlowFileOpQuery) ([]models.SlowFile, error) {
+	where, args := slowFileOpWhere(q)
+	rows, err := r.db.Query(`
+		SELECT file, filesystem, COUNT(*), COUNT(DISTINCT comm), SUM(bytes),
+			AVG(latency_ms), MAX(latency_ms), SUM(latency_ms), MAX(timestamp)
+		FROM fs_slow_ops
+		WHERE`+where+`
+		GROUP BY file, filesystem
+		ORDER BY SUM(latency_ms) DESC, file
+		LIMIT ?`,
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SlowFile
+	for rows.Next() {
+		var (
+			f        models.SlowFile
+			lastSeen string
+		)
+		if err := rows.Scan(&f.File, &f.Filesystem, &f.Ops, &f.Processes, &f.Bytes,
+			&f.AvgLatencyMS, &f.MaxLatencyMS, &f.TotalLatencyMS, &lastSeen); err != nil {
+			return nil, err
+		}
+		f.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, f)
+	}
+	return results, rows.Err()
+}
+
+// GetProcessLatency summarizes the operations selected by q per process,
+// the processes stalled longest first
+func (r *fsLatencyRepository) GetProcessLatency(q models.SlowFileOpQuery) ([]models.ProcessFSLatency, error) {
+	where, args := slowFileOpWhere(q)
+	rows, err := r.db.Query(`
+		SELECT pid, comm, COUNT(*),
+			SUM(CASE WHEN op = 'R' THEN 1 ELSE 0 END), SUM(CASE WHEN op = 'W' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN op = 'O' THEN 1 ELSE 0 END), SUM(CASE WHEN op = 'S' THEN 1 ELSE 0 END),
+			COUNT(DISTINCT file), AVG(latency_ms), MAX(latency_ms), SUM(latency_ms), MAX(timestamp)
+		FROM fs_slow_ops
+		WHERE`+where+`
+		GROUP BY pid, comm
+		ORDER BY SUM(latency_ms) DESC, pid
+		LIMIT ?`,
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.ProcessFSLatency
+	for rows.Next() {
+		var (
+			p        models.ProcessFSLatency
+			lastSeen string
+		)
+		if err := rows.Scan(&p.PID, &p.Comm, &p.Ops, &p.Reads, &p.Writes, &p.Opens, &p.Syncs, &p.Files,
+			&p.AvgLatencyMS, &p.MaxLatencyMS, &p.TotalLatencyMS, &lastSeen); err != nil {
+			return nil, err
+		}
+		p.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+// slowFileOpWhere renders the conditions of q, without the limit
+func slowFileOpWhere(q models.SlowFileOpQuery) (string, []interface{}) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	if q.Filesystem != "" {
+		where += " AND filesystem = ?"
+		args = append(args, q.Filesystem)
+	}
+	if q.Op != "" {
+		where += " AND op = ?"
+		args = append(args, q.Op)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+	return where + conditions, append(args, filterArgs...)
+}
+
+func scanSlowFileOps(rows rowScanner) ([]models.SlowFileOp, error) {
+	var ops []models.SlowFileOp
+	for rows.Next() {
+		var o models.SlowFileOp
+		dest := []interface{}{&o.ID, &o.Timestamp, &o.Time, &o.PID, &o.Comm, &o.Filesystem, &o.Op,
+			&o.Bytes, &o.OffsetKB, &o.LatencyMS, &o.File}
+		if err := rows.Scan(append(dest, attributionDest(&o.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		ops = append(ops, o)
+	}
+	return ops, rows.Err()
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"time"
+)
+
+type InboundRepository interface {
+	SaveInbound(events []models.InboundConnection) error
+	GetRecentInbound(limit int, filter models.Filter) ([]models.InboundConnection, error)
+	GetServiceClients(q models.InboundQuery) ([]models.ServiceClient, error)
+	// ReplaceListeners stores the listening sockets found on host at the
+	// given time and returns the sockets opened and closed since the last
+	// inventory of that host. The first inventory of a host yields no events.
+	ReplaceListeners(host string, sockets []models.ListeningSocket, at time.Time) ([]models.ListenEvent, error)
+	GetListeners(filter models.Filter) ([]models.ListeningSocket, error)
+	GetListenEvents(since, until time.Time, kind string, limit int, filter models.Filter) ([]models.ListenEvent, error)
+}
+
+type inboundRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewInboundRepository(db *sql.DB, writer *SQLiteWriter) InboundRepository {
+	return &inboundRepository{db: db, writer: writer}
+}
+
+var inboundColumns = "id, timestamp, pid, comm, ip_version, remote_addr, remote_port, local_addr, local_port, " +
+	attributionSelect("")
+
+var listenerColumns = "addr, port, pid, comm, uid, first_seen, last_seen, " + attributionSelect("")
+
+var listenEventColumns = "id, timestamp, kind, addr, port, pid, comm, uid, " + attributionSelect("")
+
+// SaveInbound queues accepted connections for the writer
+func (r *inboundRepository) SaveInbound(events []models.InboundConnection) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, e := range events {
+		values := []interface{}{
+			timestampValue(e.Timestamp), e.PID, e.Comm, e.IPVersion,
+			e.RemoteAddr, e.RemotePort, e.LocalAddr, e.LocalPort,
+		}
+		rows = append(rows, append(values, attributionValues(e.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO inbound_connections (timestamp, pid, comm, ip_version, remote_addr, remote_port, local_addr, local_port,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *inboundRepository) GetRecentInbound(limit int, filter models.Filter) ([]models.InboundConnection, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+inboundColumns+" FROM inbound_connections WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInbound(rows)
+}
+
+// GetServiceClients counts the accepted connections in the window per local
+// port, command and remote address, most connections first
+func (r *inboundRepository) GetServiceClients(q models.InboundQuery) ([]models.ServiceClient, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.LocalPort != 0 {
+		where += " AND local_port = ?"
+		args = append(args, q.LocalPort)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+	args = append(args, filterArgs...)
+
+	rows, err := r.db.Query(`
+		SELECT local_port, comm, remote_addr, COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM inbound_connections
+		WHERE`+where+conditions+`
+		GROUP BY local_port, comm, remote_addr
+		ORDER BY COUNT(*) DESC, local_port, remote_addr
+		LIMIT ?`,
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []models.ServiceClient
+	for rows.Next() {
+		var (
+			c                   models.ServiceClient
+			firstSeen, lastSeen string
+		)
+		if err := rows.Scan(&c.LocalPort, &c.Comm, &c.RemoteAddr, &c.Connections, &firstSeen, &lastSeen); err != nil {
+			return nil, err
+		}
+		c.FirstSeen = parseTimestamp(firstSeen)
+		c.LastSeen = parseTimestamp(lastSeen)
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
+
+// ReplaceListeners writes the inventory directly rather than through the
+// writer, as the next inventory is diffed against it
+func (r *inboundRepository) ReplaceListeners(host string, sockets []models.ListeningSocket, at time.Time) ([]models.ListenEvent, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+listenerColumns+" FROM listening_sockets WHERE COALESCE(host, '') = ?", host)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := scanListeners(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	current, events := diffListeners(previous, sockets, at)
+
+	if _, err := tx.Exec("DELETE FROM listening_sockets WHERE COALESCE(host, '') = ?", host); err != nil {
+		return nil, err
+	}
+	for _, s := range current {
+		values := []interface{}{s.Addr, s.Port, s.PID, s.Comm, s.UID, formatTime(s.FirstSeen), formatTime(s.LastSeen)}
+		if _, err := tx.Exec(`
+			INSERT INTO listening_sockets (addr, port, pid, comm, uid, first_seen, last_seen, `+attributionInsertColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)`,
+			append(values, attributionValues(s.Attribution)...)...,
+		); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range events {
+		values := []interface{}{formatTime(e.Timestamp), e.Kind, e.Addr, e.Port, e.PID, e.Comm, e.UID}
+		if _, err := tx.Exec(`
+			INSERT INTO listen_events (timestamp, kind, addr, port, pid, comm, uid, `+attributionInsertColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)`,
+			append(values, attributionValues(e.Attribution)...)...,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return events, tx.Commit()
+}
+
+// GetListeners returns the listening sockets of the latest inventory of
+// every host, ordered by host, port and address
+func (r *inboundRepository) GetListeners(filter models.Filter) ([]models.ListeningSocket, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+listenerColumns+" FROM listening_sockets WHERE 1 = 1"+conditions+" ORDER BY host, port, addr",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanListeners(rows)
+}
+
+// GetListenEvents returns the listen events in the window, newest first. An
+// empty kind selects both kinds.
+func (r *inboundRepository) GetListenEvents(since, until time.Time, kind string, limit int, filter models.Filter) ([]models.ListenEvent, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(since), formatTime(until)}
+	if kind != "" {
+		where += " AND kind = ?"
+		args = append(args, kind)
+	}
+	conditions, filterArgs := filterClause(filter, "")
+	args = append(args, filterArgs...)
+
+	rows, err := r.db.Query(
+		"SELECT "+listenEventColumns+" FROM listen_events WHERE"+where+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanListenEvents(rows)
+}
+
+// diffListeners compares the stored inventory of a host with a new one. It
+// returns the new inventory, in which sockets seen before keep their first
+// sighting, and the events of the sockets that appeared or went away.
+// Without a previous inventory every socket is taken as already known.
+func diffListeners(previous, sockets []models.ListeningSocket, at time.Time) ([]models.ListeningSocket, []models.ListenEvent) {
+	type listenKey struct {
+		addr string
+		port int
+	}
+
+	known := make(map[listenKey]models.ListeningSocket, len(previous))
+	for _, s := range previous {
+		known[listenKey{s.Addr, s.Port}] = s
+	}
+
+	var events []models.ListenEvent
+	current := make([]models.ListeningSocket, 0, len(sockets))
+	for _, s := range sockets {
+		key := listenKey{s.Addr, s.Port}
+		s.FirstSeen, s.LastSeen = at, at
+		if old, ok := known[key]; ok {
+			s.FirstSeen = old.FirstSeen
+			delete(known, key)
+		} else if len(previous) > 0 {
+			events = append(events, listenEvent(models.ListenOpened, s, at))
+		}
+		current = append(current, s)
+	}
+	for _, s := range previous {
+		if _, ok := known[listenKey{s.Addr, s.Port}]; ok {
+			events = append(events, listenEvent(models.ListenClosed, s, at))
+		}
+	}
+	return current, events
+}
+
+func listenEvent(kind string, s models.ListeningSocket, at time.Time) models.ListenEvent {
+	return models.ListenEvent{
+		Timestamp:   at,
+		Kind:        kind,
+		Addr:        s.Addr,
+		Port:        s.Port,
+		PID:         s.PID,
+		Comm:        s.Comm,
+		UID:         s.UID,
+		Attribution: s.Attribution,
+	}
+}
+
+func scanInbound(rows rowScanner) ([]models.InboundConnection, error) {
+	var events []models.InboundConnection
+	for rows.Next() {
+		var e models.InboundConnection
+		dest := []interface{}{
+			&e.ID, &e.Timestamp, &e.PID, &e.Comm, &e.IPVersion,
+			&e.RemoteAddr, &e.RemotePort, &e.LocalAddr, &e.LocalPort,
+		}
+		if err := rows.Scan(append(dest, attributionDest(&e.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func scanListeners(rows rowScanner) ([]models.ListeningSocket, error) {
+	var sockets []models.ListeningSocket
+	for rows.Next() {
+		var s models.ListeningSocket
+		dest := []interface{}{&s.Addr, &s.Port, &s.PID, &s.Comm, &s.UID, &s.FirstSeen, &s.LastSeen}
+		if err := rows.Scan(append(dest, attributionDest(&s.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, s)
+	}
+	return sockets, rows.Err()
+}
+
+func scanListenEvents(rows rowScanner) ([]models.ListenEvent, error) {
+	var events []models.ListenEvent
+	for rows.Next() {
+		var e models.ListenEvent
+		dest := []interface{}{&e.ID, &e.Timestamp, &e.Kind, &e.Addr, &e.Port, &e.PID, &e.Comm, &e.UID}
+		if err := rows.Scan(append(dest, attributionDest(&e.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"time"
+)
+
+type KillRepository interface {
+	SaveOOMKills(kills []models.OOMKill) error
+	GetRecentOOMKills(limit int, filter models.Filter) ([]models.OOMKill, error)
+	GetOOMKills(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.OOMKill, error)
+	SaveSignals(signals []models.Signal) error
+	GetRecentSignals(limit int, filter models.Filter) ([]models.Signal, error)
+	GetSignals(q models.SignalQuery) ([]models.Signal, error)
+}
+
+type killRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewKillRepository(db *sql.DB, writer *SQLiteWriter) KillRepository {
+	return &killRepository{db: db, writer: writer}
+}
+
+var oomKillColumns = "id, timestamp, time, pid, comm, trigger_pid, trigger_comm, pages, COALESCE(trigger_cgroup, ''), " +
+	attributionSelect("")
+
+var signalColumns = "id, timestamp, time, pid, comm, sender_pid, sender_comm, signal, signal_name, errno, errno_name, " +
+	attributionSelect("")
+
+// SaveOOMKills queues OOM kills for the writer
+func (r *killRepository) SaveOOMKills(kills []models.OOMKill) error {
+	rows := make([][]interface{}, 0, len(kills))
+	for _, k := range kills {
+		values := []interface{}{timestampValue(k.Timestamp), k.Time, k.PID, k.Comm, k.TriggerPID, k.TriggerComm, k.Pages, k.TriggerCgroup}
+		rows = append(rows, append(values, attributionValues(k.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO oom_kills (timestamp, time, pid, comm, trigger_pid, trigger_comm, pages, trigger_cgroup, `+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *killRepository) GetRecentOOMKills(limit int, filter models.Filter) ([]models.OOMKill, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+oomKillColumns+" FROM oom_kills WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOOMKills(rows)
+}
+
+// GetOOMKills returns the OOM kills in the window, optionally of victims
+// with the given command
+func (r *killRepository) GetOOMKills(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.OOMKill, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until), comm, comm}, filterArgs...)
+	rows, err := r.db.Query(
+		`SELECT `+oomKillColumns+` FROM oom_kills
+		WHERE timestamp >= ? AND timestamp <= ? AND (? = '' OR comm = ?)`+conditions+`
+		ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOOMKills(rows)
+}
+
+// SaveSignals queues signals for the writer
+func (r *killRepository) SaveSignals(signals []models.Signal) error {
+	rows := make([][]interface{}, 0, len(signals))
+	for _, s := range signals {
+		values := []interface{}{timestampValue(s.Timestamp), s.Time, s.PID, s.Comm, s.SenderPID, s.SenderComm,
+			s.Signal, s.SignalName, s.Errno, s.ErrnoName}
+		rows = append(rows, append(values, attributionValues(s.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO signals (timestamp, time, pid, comm, sender_pid, sender_comm, signal, signal_name, errno, errno_name,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *killRepository) GetRecentSignals(limit int, filter models.Filter) ([]models.Signal, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+signalColumns+" FROM signals WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSignals(rows)
+}
+
+// GetSignals returns the signals selected by q, newest first
+func (r *killRepository) GetSignals(q models.SignalQuery) ([]models.Signal, error) {
+	where, args := signalWhere(q)
+	rows, err := r.db.Query(
+		"SELECT "+signalColumns+" FROM signals WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSignals(rows)
+}
+
+// signalWhere renders the conditions of q, without the limit
+func signalWhere(q models.SignalQuery) (string, []interface{}) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.PID != 0 {
+		where += " AND pid = ?"
+		args = append(args, q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	if q.SenderComm != "" {
+		where += " AND sender_comm = ?"
+		args = append(args, q.SenderComm)
+	}
+	if q.Signal != 0 {
+		where += " AND signal = ?"
+		args = append(args, q.Signal)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+	return where + conditions, append(args, filterArgs...)
+}
+
+func scanOOMKills(rows rowScanner) ([]models.OOMKill, error) {
+	var kills []models.OOMKill
+	for rows.Next() {
+		var k models.OOMKill
+		dest := []interface{}{&k.ID, &k.Timestamp, &k.Time, &k.PID, &k.Comm, &k.TriggerPID, &k.TriggerComm, &k.Pages, &k.TriggerCgroup}
+		if err := rows.Scan(append(dest, attributionDest(&k.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		kills = append(kills, k)
+	}
+	return kills, rows.Err()
+}
+
+func scanSignals(rows rowScanner) ([]models.Signal, error) {
+	var signals []models.Signal
+	for rows.Next() {
+		var s models.Signal
+		dest := []interface{}{&s.ID, &s.Timestamp, &s.Time, &s.PID, &s.Comm, &s.SenderPID, &s.SenderComm,
+			&s.Signal, &s.SignalName, &s.Errno, &s.ErrnoName}
+		if err := rows.Scan(append(dest, attributionDest(&s.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		signals = append(signals, s)
+	}
+	return signals, rows.Err()
+}
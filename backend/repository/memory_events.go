@@ -0,0 +1,1139 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryProcessRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryProcessRepository) SaveProcess(p models.ProcessEvent) error {
+	return r.SaveProcesses([]models.ProcessEvent{p})
+}
+
+func (r *memoryProcessRepository) SaveProcesses(processes []models.ProcessEvent) error {
+	stored := make([]models.ProcessEvent, 0, len(processes))
+	for _, p := range processes {
+		p.Timestamp = storedTime(p.Timestamp)
+		stored = append(stored, p)
+	}
+	r.db.processes.add(stored...)
+	return nil
+}
+
+func (r *memoryProcessRepository) GetRecentProcesses(limit int, filter models.Filter) ([]models.ProcessEvent, error) {
+	return r.db.processes.recent(limit, func(p models.ProcessEvent) bool {
+		return matchesFilter(filter, p.Attribution)
+	}), nil
+}
+
+type memoryNetworkRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryNetworkRepository) SaveConnection(conn models.NetworkConnection) error {
+	return r.SaveConnections([]models.NetworkConnection{conn})
+}
+
+func (r *memoryNetworkRepository) SaveConnections(connections []models.NetworkConnection) error {
+	stored := make([]models.NetworkConnection, 0, len(connections))
+	for _, conn := range connections {
+		conn.Timestamp = storedTime(conn.Timestamp)
+		stored = append(stored, conn)
+	}
+	r.db.connections.add(stored...)
+	return nil
+}
+
+func (r *memoryNetworkRepository) GetRecentConnections(limit int, filter models.Filter) ([]models.NetworkConnection, error) {
+	return r.db.connections.recent(limit, func(conn models.NetworkConnection) bool {
+		return matchesFilter(filter, conn.Attribution)
+	}), nil
+}
+
+type memoryDiskRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryDiskRepository) SaveLatencySnapshot(latencies []models.DiskLatency) error {
+	stored := make([]models.DiskLatency, 0, len(latencies))
+	for _, lat := range latencies {
+		lat.Timestamp = storedTime(lat.Timestamp)
+		stored = append(stored, lat)
+	}
+	r.db.diskLatency.add(stored...)
+	return nil
+}
+
+func (r *memoryDiskRepository) GetLatestLatency(limit int, filter models.Filter) ([]models.DiskLatency, error) {
+	return r.db.diskLatency.recent(limit, func(lat models.DiskLatency) bool {
+		return matchesHost(filter, lat.Host, lat.HostLabels)
+	}), nil
+}
+
+type memoryCPUProfileRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryCPUProfileRepository) SaveCPUProfiles(profiles []models.CPUProfile) error {
+	stored := make([]models.CPUProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		profile.Timestamp = storedTime(profile.Timestamp)
+		stored = append(stored, profile)
+	}
+	r.db.cpuProfiles.add(stored...)
+	return nil
+}
+
+func (r *memoryCPUProfileRepository) GetRecentCPUProfiles(limit int, filter models.Filter) ([]models.CPUProfile, error) {
+	profiles := r.db.cpuProfiles.matching(func(profile models.CPUProfile) bool {
+		return matchesFilter(filter, profile.Attribution)
+	})
+	return sortNewest(profiles, limit, func(p models.CPUProfile) (time.Time, int) { return p.Timestamp, p.ID }), nil
+}
+
+func (r *memoryCPUProfileRepository) GetStackTotals(q models.StackQuery) ([]models.StackTotal, error) {
+	totals := newStackTotals()
+	r.db.cpuProfiles.oldestFirst(func(p models.CPUProfile) bool {
+		if inWindow(p.Timestamp, q.Since, q.Until) && (q.PID == 0 || p.PID == q.PID) && (q.Comm == "" || p.Comm == q.Comm) &&
+			matchesFilter(q.Filter, p.Attribution) {
+			comm := p.Comm
+			if comm == "" {
+				comm = p.ProcessName
+			}
+			totals.add(comm, p.StackTrace, int64(p.SampleCount))
+		}
+		return true
+	})
+	return totals.list, nil
+}
+
+// stackTotals is the in-memory counterpart of GROUP BY comm, stack
+type stackTotals struct {
+	list  []models.StackTotal
+	index map[[2]string]int
+}
+
+func newStackTotals() *stackTotals {
+	return &stackTotals{index: make(map[[2]string]int)}
+}
+
+func (t *stackTotals) add(comm, stack string, value int64) {
+	key := [2]string{comm, stack}
+	if i, ok := t.index[key]; ok {
+		t.list[i].Total += value
+		return
+	}
+	t.index[key] = len(t.list)
+	t.list = append(t.list, models.StackTotal{Comm: comm, Stack: stack, Total: value})
+}
+
+type memoryTCPLifeRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryTCPLifeRepository) SaveTCPLifeEvents(events []models.TCPLifeEvent) error {
+	stored := make([]models.TCPLifeEvent, 0, len(events))
+	for _, event := range events {
+		event.Timestamp = storedTime(event.Timestamp)
+		stored = append(stored, event)
+	}
+	r.db.tcpSessions.add(stored...)
+	return nil
+}
+
+func (r *memoryTCPLifeRepository) GetRecentTCPLifeEvents(limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	events := r.db.tcpSessions.matching(func(event models.TCPLifeEvent) bool {
+		return matchesFilter(filter, event.Attribution)
+	})
+	return sortNewest(events, limit, func(e models.TCPLifeEvent) (time.Time, int) { return e.Timestamp, e.ID }), nil
+}
+
+func (r *memoryTCPLifeRepository) GetSlowSessions(since, until time.Time, minDurationMS float64, limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	events := r.db.tcpSessions.matching(func(event models.TCPLifeEvent) bool {
+		return inWindow(event.Timestamp, since, until) && event.DurationMS >= minDurationMS &&
+			matchesFilter(filter, event.Attribution)
+	})
+	sort.SliceStable(events, func(i, j int) bool { return events[i].DurationMS > events[j].DurationMS })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+type memorySyscallRepository struct {
+	db *memoryDB
+}
+
+func (r *memorySyscallRepository) SaveSyscallStats(stats []models.SyscallStat) error {
+	stored := make([]models.SyscallStat, 0, len(stats))
+	for _, stat := range stats {
+		stat.Timestamp = storedTime(stat.Timestamp)
+		stored = append(stored, stat)
+	}
+	r.db.syscalls.add(stored...)
+	return nil
+}
+
+func (r *memorySyscallRepository) SaveProcessSyscallStats(stats []models.ProcessSyscallStat) error {
+	stored := make([]models.ProcessSyscallStat, 0, len(stats))
+	for _, stat := range stats {
+		stat.Timestamp = storedTime(stat.Timestamp)
+		stored = append(stored, stat)
+	}
+	r.db.processSyscalls.add(stored...)
+	return nil
+}
+
+func (r *memorySyscallRepository) GetRecentSyscallStats(limit int, filter models.Filter) ([]models.SyscallStat, error) {
+	stats := r.db.syscalls.matching(func(stat models.SyscallStat) bool {
+		return matchesHost(filter, stat.Host, stat.HostLabels)
+	})
+	return sortNewest(stats, limit, func(s models.SyscallStat) (time.Time, int) { return s.Timestamp, s.ID }), nil
+}
+
+func (r *memorySyscallRepository) GetRecentProcessSyscallStats(limit int, filter models.Filter) ([]models.ProcessSyscallStat, error) {
+	return r.db.processSyscalls.recent(limit, func(stat models.ProcessSyscallStat) bool {
+		return matchesHost(filter, stat.Host, stat.HostLabels)
+	}), nil
+}
+
+type memoryExitRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryExitRepository) SaveExits(exits []models.ProcessExit) error {
+	stored := make([]models.ProcessExit, 0, len(exits))
+	for _, e := range exits {
+		e.Timestamp = storedTime(e.Timestamp)
+		stored = append(stored, e)
+	}
+	r.db.exits.add(stored...)
+	return nil
+}
+
+func (r *memoryExitRepository) GetRecentExits(limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	return r.db.exits.recent(limit, func(e models.ProcessExit) bool {
+		return matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+// GetLifetimes joins exits with the latest exec of the same PID, with the
+// same slack as the SQLite query
+func (r *memoryExitRepository) GetLifetimes(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.ProcessLifetime, error) {
+	exits := r.db.exits.recent(limit, func(e models.ProcessExit) bool {
+		return inWindow(e.Timestamp, since, until) && (comm == "" || e.Comm == comm) && matchesFilter(filter, e.Attribution)
+	})
+
+	var results []models.ProcessLifetime
+	for _, e := range exits {
+		l := models.ProcessLifetime{
+			PID:             e.PID,
+			PPID:            e.PPID,
+			Comm:            e.Comm,
+			ExitTime:        e.Timestamp,
+			DurationSeconds: e.AgeSeconds,
+			ExitCode:        e.ExitCode,
+			Signal:          e.Signal,
+			SignalName:      e.SignalName,
+			CoreDumped:      e.CoreDumped,
+			Attribution:     e.Attribution,
+		}
+
+		pid := strconv.Itoa(e.PID)
+		earliest := e.Timestamp.Add(-time.Duration(int(e.AgeSeconds)+2) * time.Second)
+		latest := e.Timestamp.Add(2 * time.Second)
+		r.db.processes.newestFirst(func(p models.ProcessEvent) bool {
+			if p.PID == pid && inWindow(p.Timestamp, earliest, latest) {
+				l.Args = p.Args
+				l.ExecSeen = true
+				return false
+			}
+			return true
+		})
+
+		l.StartTime = l.ExitTime.Add(-time.Duration(l.DurationSeconds * float64(time.Second)))
+		results = append(results, l)
+	}
+	return results, nil
+}
+
+// GetShortLivedStorms returns process names with at least minCount exits of
+// processes that lived no longer than maxAge seconds
+func (r *memoryExitRepository) GetShortLivedStorms(since, until time.Time, maxAge float64, minCount int, filter models.Filter) ([]models.ShortLivedStorm, error) {
+	type storm struct {
+		models.ShortLivedStorm
+		parents map[int]bool
+		ageSum  float64
+	}
+	storms := make(map[string]*storm)
+	r.db.exits.oldestFirst(func(e models.ProcessExit) bool {
+		if !inWindow(e.Timestamp, since, until) || e.AgeSeconds > maxAge || !matchesFilter(filter, e.Attribution) {
+			return true
+		}
+		s, ok := storms[e.Comm]
+		if !ok {
+			s = &storm{parents: make(map[int]bool)}
+			s.Comm = e.Comm
+			s.FirstSeen = e.Timestamp
+			s.LastSeen = e.Timestamp
+			storms[e.Comm] = s
+		}
+		s.Count++
+		s.parents[e.PPID] = true
+		s.ageSum += e.AgeSeconds
+		if e.Timestamp.Before(s.FirstSeen) {
+			s.FirstSeen = e.Timestamp
+		}
+		if e.Timestamp.After(s.LastSeen) {
+			s.LastSeen = e.Timestamp
+		}
+		return true
+	})
+
+	var results []models.ShortLivedStorm
+	for _, s := range storms {
+		if s.Count < minCount {
+			continue
+		}
+		s.Parents = len(s.parents)
+		s.AvgAgeSeconds = s.ageSum / float64(s.Count)
+		results = append(results, s.ShortLivedStorm)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Comm < results[j].Comm
+	})
+	return results, nil
+}
+
+// GetCrashLoops returns process names that exited with a non-zero code or a
+// signal at least minFailures times
+func (r *memoryExitRepository) GetCrashLoops(since, until time.Time, minFailures int, filter models.Filter) ([]models.CrashLoop, error) {
+	loops := make(map[string]*models.CrashLoop)
+	r.db.exits.oldestFirst(func(e models.ProcessExit) bool {
+		if !inWindow(e.Timestamp, since, until) || (e.ExitCode == 0 && e.Signal == 0) || !matchesFilter(filter, e.Attribution) {
+			return true
+		}
+		cl, ok := loops[e.Comm]
+		if !ok {
+			cl = &models.CrashLoop{Comm: e.Comm, FirstSeen: e.Timestamp, LastSeen: e.Timestamp}
+			loops[e.Comm] = cl
+		}
+		cl.Failures++
+		if e.Timestamp.Before(cl.FirstSeen) {
+			cl.FirstSeen = e.Timestamp
+		}
+		if e.Timestamp.After(cl.LastSeen) {
+			cl.LastSeen = e.Timestamp
+		}
+		// Visited in ID order, so the last failure wins
+		cl.LastExitCode = e.ExitCode
+		cl.LastSignal = e.Signal
+		return true
+	})
+
+	var results []models.CrashLoop
+	for _, cl := range loops {
+		if cl.Failures >= minFailures {
+			results = append(results, *cl)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Failures != results[j].Failures {
+			return results[i].Failures > results[j].Failures
+		}
+		return results[i].Comm < results[j].Comm
+	})
+	return results, nil
+}
+
+// GetKilled returns processes terminated by a signal, optionally a specific one
+func (r *memoryExitRepository) GetKilled(since, until time.Time, signal int, limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	return r.db.exits.recent(limit, func(e models.ProcessExit) bool {
+		return inWindow(e.Timestamp, since, until) && e.Signal != 0 && (signal == 0 || e.Signal == signal) &&
+			matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+type memoryFileRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryFileRepository) SaveOpens(opens []models.FileOpen) error {
+	stored := make([]models.FileOpen, 0, len(opens))
+	for _, o := range opens {
+		o.Timestamp = storedTime(o.Timestamp)
+		stored = append(stored, o)
+	}
+	r.db.fileOpens.add(stored...)
+	return nil
+}
+
+func (r *memoryFileRepository) GetRecentOpens(limit int, filter models.Filter) ([]models.FileOpen, error) {
+	return r.db.fileOpens.recent(limit, func(o models.FileOpen) bool {
+		return matchesFilter(filter, o.Attribution)
+	}), nil
+}
+
+func (r *memoryFileRepository) GetOpens(q models.FileOpenQuery) ([]models.FileOpen, error) {
+	opens := r.db.fileOpens.matching(func(o models.FileOpen) bool {
+		return inWindow(o.Timestamp, q.Since, q.Until) &&
+			(q.PID == 0 || o.PID == q.PID) &&
+			(q.Comm == "" || o.Comm == q.Comm) &&
+			(q.Path == "" || o.Path == q.Path) &&
+			strings.HasPrefix(o.Path, q.PathPrefix) &&
+			(!q.FailedOnly || o.Failed()) &&
+			(q.Errno == "" || o.ErrnoName == q.Errno) &&
+			matchesFilter(q.Filter, o.Attribution)
+	})
+	return sortNewest(opens, q.Limit, func(o models.FileOpen) (time.Time, int) { return o.Timestamp, o.ID }), nil
+}
+
+// GetFailures returns the paths failing to open most often, per error,
+// optionally of one error only
+func (r *memoryFileRepository) GetFailures(since, until time.Time, errno string, minCount, limit int, filter models.Filter) ([]models.OpenFailure, error) {
+	type failure struct {
+		models.OpenFailure
+		comms  map[string]bool
+		lastID int
+	}
+	type key struct {
+		path  string
+		errno int
+	}
+	failures := make(map[key]*failure)
+	r.db.fileOpens.oldestFirst(func(o models.FileOpen) bool {
+		if !inWindow(o.Timestamp, since, until) || !o.Failed() || (errno != "" && o.ErrnoName != errno) ||
+			!matchesFilter(filter, o.Attribution) {
+			return true
+		}
+		k := key{o.Path, o.Errno}
+		f, ok := failures[k]
+		if !ok {
+			f = &failure{comms: make(map[string]bool)}
+			f.Path = o.Path
+			f.Errno = o.Errno
+			f.ErrnoName = o.ErrnoName
+			f.FirstSeen = o.Timestamp
+			f.LastSeen = o.Timestamp
+			failures[k] = f
+		}
+		f.Count++
+		f.comms[o.Comm] = true
+		if o.Timestamp.Before(f.FirstSeen) {
+			f.FirstSeen = o.Timestamp
+		}
+		if o.Timestamp.After(f.LastSeen) {
+			f.LastSeen = o.Timestamp
+		}
+		// Visited in ID order, so the last failure wins
+		f.LastComm = o.Comm
+		f.lastID = o.ID
+		return true
+	})
+
+	var selected []*failure
+	for _, f := range failures {
+		if f.Count >= minCount {
+			f.Commands = len(f.comms)
+			selected = append(selected, f)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].Count != selected[j].Count {
+			return selected[i].Count > selected[j].Count
+		}
+		return selected[i].lastID > selected[j].lastID
+	})
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+
+	results := make([]models.OpenFailure, 0, len(selected))
+	for _, f := range selected {
+		results = append(results, f.OpenFailure)
+	}
+	return results, nil
+}
+
+// GetAccessors returns the commands and users that opened path, most opens
+// first. Opens with O_WRONLY or O_RDWR count as writes.
+func (r *memoryFileRepository) GetAccessors(path string, since, until time.Time, filter models.Filter) ([]models.FileAccessor, error) {
+	type accessor struct {
+		models.FileAccessor
+		pids map[int]bool
+	}
+	type key struct {
+		comm string
+		uid  int
+	}
+	accessors := make(map[key]*accessor)
+	r.db.fileOpens.oldestFirst(func(o models.FileOpen) bool {
+		if o.Path != path || !inWindow(o.Timestamp, since, until) || !matchesFilter(filter, o.Attribution) {
+			return true
+		}
+		k := key{o.Comm, o.UID}
+		a, ok := accessors[k]
+		if !ok {
+			a = &accessor{pids: make(map[int]bool)}
+			a.Comm = o.Comm
+			a.UID = o.UID
+			a.FirstSeen = o.Timestamp
+			a.LastSeen = o.Timestamp
+			accessors[k] = a
+		}
+		a.Opens++
+		a.pids[o.PID] = true
+		if o.Flags&3 != 0 {
+			a.Writes++
+		}
+		if o.Failed() {
+			a.Failures++
+		}
+		if o.Timestamp.Before(a.FirstSeen) {
+			a.FirstSeen = o.Timestamp
+		}
+		if o.Timestamp.After(a.LastSeen) {
+			a.LastSeen = o.Timestamp
+		}
+		return true
+	})
+
+	results := make([]models.FileAccessor, 0, len(accessors))
+	for _, a := range accessors {
+		a.PIDs = len(a.pids)
+		results = append(results, a.FileAccessor)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Opens != results[j].Opens {
+			return results[i].Opens > results[j].Opens
+		}
+		if results[i].Comm != results[j].Comm {
+			return results[i].Comm < results[j].Comm
+		}
+		return results[i].UID < results[j].UID
+	})
+	return results, nil
+}
+
+type memoryRunqRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryRunqRepository) SaveRunqSnapshot(latencies []models.RunqLatency) error {
+	stored := make([]models.RunqLatency, 0, len(latencies))
+	for _, lat := range latencies {
+		lat.Timestamp = storedTime(lat.Timestamp)
+		stored = append(stored, lat)
+	}
+	r.db.runqLatency.add(stored...)
+	return nil
+}
+
+func (r *memoryRunqRepository) GetLatestRunq(limit int, filter models.Filter) ([]models.RunqLatency, error) {
+	return r.db.runqLatency.recent(limit, func(lat models.RunqLatency) bool {
+		return matchesHost(filter, lat.Host, lat.HostLabels)
+	}), nil
+}
+
+func (r *memoryRunqRepository) GetRunqBuckets(q models.RunqQuery) ([]models.RunqLatency, error) {
+	var buckets []models.RunqLatency
+	r.db.runqLatency.oldestFirst(func(lat models.RunqLatency) bool {
+		if inWindow(lat.Timestamp, q.Since, q.Until) && (q.PIDNS == 0 || lat.PIDNS == q.PIDNS) &&
+			matchesHost(q.Filter, lat.Host, lat.HostLabels) {
+			buckets = append(buckets, lat)
+		}
+		return true
+	})
+	return buckets, nil
+}
+
+type memoryTCPRTTRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryTCPRTTRepository) SaveRTTSnapshot(rtts []models.TCPRTT) error {
+	stored := make([]models.TCPRTT, 0, len(rtts))
+	for _, rtt := range rtts {
+		rtt.Timestamp = storedTime(rtt.Timestamp)
+		stored = append(stored, rtt)
+	}
+	r.db.tcpRTT.add(stored...)
+	return nil
+}
+
+func (r *memoryTCPRTTRepository) GetLatestRTT(limit int, filter models.Filter) ([]models.TCPRTT, error) {
+	return r.db.tcpRTT.recent(limit, func(rtt models.TCPRTT) bool {
+		return matchesHost(filter, rtt.Host, rtt.HostLabels)
+	}), nil
+}
+
+func (r *memoryTCPRTTRepository) GetRTTBuckets(q models.RTTQuery) ([]models.TCPRTT, error) {
+	var buckets []models.TCPRTT
+	r.db.tcpRTT.oldestFirst(func(rtt models.TCPRTT) bool {
+		if inWindow(rtt.Timestamp, q.Since, q.Until) && (q.RemoteAddr == "" || rtt.RemoteAddr == q.RemoteAddr) &&
+			matchesHost(q.Filter, rtt.Host, rtt.HostLabels) {
+			buckets = append(buckets, rtt)
+		}
+		return true
+	})
+	return buckets, nil
+}
+
+type memoryOffCPURepository struct {
+	db *memoryDB
+}
+
+func (r *memoryOffCPURepository) SaveOffCPUStacks(stacks []models.OffCPUStack) error {
+	stored := make([]models.OffCPUStack, 0, len(stacks))
+	for _, s := range stacks {
+		s.Timestamp = storedTime(s.Timestamp)
+		stored = append(stored, s)
+	}
+	r.db.offCPUStacks.add(stored...)
+	return nil
+}
+
+func (r *memoryOffCPURepository) GetRecentOffCPUStacks(limit int, filter models.Filter) ([]models.OffCPUStack, error) {
+	return r.db.offCPUStacks.recent(limit, func(s models.OffCPUStack) bool {
+		return matchesFilter(filter, s.Attribution)
+	}), nil
+}
+
+func (r *memoryOffCPURepository) GetStackTotals(q models.StackQuery) ([]models.StackTotal, error) {
+	totals := newStackTotals()
+	r.db.offCPUStacks.oldestFirst(func(s models.OffCPUStack) bool {
+		if inWindow(s.Timestamp, q.Since, q.Until) && (q.Comm == "" || s.Comm == q.Comm) && matchesFilter(q.Filter, s.Attribution) {
+			totals.add(s.Comm, s.Stack, s.TotalUS)
+		}
+		return true
+	})
+	return totals.list, nil
+}
+
+type memoryTCPRetransRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryTCPRetransRepository) SaveRetransmits(events []models.TCPRetransmit) error {
+	stored := make([]models.TCPRetransmit, 0, len(events))
+	for _, e := range events {
+		e.Timestamp = storedTime(e.Timestamp)
+		stored = append(stored, e)
+	}
+	r.db.tcpRetransmits.add(stored...)
+	return nil
+}
+
+func (r *memoryTCPRetransRepository) GetRecentRetransmits(limit int, filter models.Filter) ([]models.TCPRetransmit, error) {
+	return r.db.tcpRetransmits.recent(limit, func(e models.TCPRetransmit) bool {
+		return matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+func (r *memoryTCPRetransRepository) GetRetransmitCounts(q models.RetransmitQuery) ([]models.RetransmitCount, error) {
+	var counts []models.RetransmitCount
+	index := make(map[models.RetransmitCount]int) // by count key, with Count zero
+	r.db.tcpRetransmits.oldestFirst(func(e models.TCPRetransmit) bool {
+		if !inWindow(e.Timestamp, q.Since, q.Until) || !matchesFilter(q.Filter, e.Attribution) ||
+			(q.RemoteAddr != "" && e.RemoteAddr != q.RemoteAddr) || (q.RemotePort != 0 && e.RemotePort != q.RemotePort) ||
+			(q.Comm != "" && e.Comm != q.Comm) {
+			return true
+		}
+		key := models.RetransmitCount{
+			Timestamp: e.Timestamp, LocalAddr: e.LocalAddr, LocalPort: e.LocalPort,
+			RemoteAddr: e.RemoteAddr, RemotePort: e.RemotePort, Comm: e.Comm, Type: e.Type,
+		}
+		if i, ok := index[key]; ok {
+			counts[i].Count++
+			return true
+		}
+		index[key] = len(counts)
+		key.Count = 1
+		counts = append(counts, key)
+		return true
+	})
+	return counts, nil
+}
+
+func (r *memoryTCPRetransRepository) SaveDrops(events []models.TCPDrop) error {
+	stored := make([]models.TCPDrop, 0, len(events))
+	for _, e := range events {
+		e.Timestamp = storedTime(e.Timestamp)
+		stored = append(stored, e)
+	}
+	r.db.tcpDrops.add(stored...)
+	return nil
+}
+
+func (r *memoryTCPRetransRepository) GetRecentDrops(limit int, filter models.Filter) ([]models.TCPDrop, error) {
+	return r.db.tcpDrops.recent(limit, func(e models.TCPDrop) bool {
+		return matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+func (r *memoryTCPRetransRepository) GetDropSummary(since, until time.Time, limit int, filter models.Filter) ([]models.DropSummary, error) {
+	type dropKey struct{ reason, state, stack string }
+	var results []models.DropSummary
+	index := make(map[dropKey]int)
+	r.db.tcpDrops.oldestFirst(func(e models.TCPDrop) bool {
+		if !inWindow(e.Timestamp, since, until) || !matchesFilter(filter, e.Attribution) {
+			return true
+		}
+		key := dropKey{e.Reason, e.State, e.Stack}
+		i, ok := index[key]
+		if !ok {
+			i = len(results)
+			index[key] = i
+			results = append(results, models.DropSummary{Reason: e.Reason, State: e.State, Stack: e.Stack})
+		}
+		results[i].Count++
+		results[i].LastSeen = e.Timestamp
+		return true
+	})
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Count > results[j].Count })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+type memoryInboundRepository struct {
+	db *memoryDB
+
+	mu        sync.Mutex
+	listeners map[string][]models.ListeningSocket // by host
+}
+
+func newMemoryInboundRepository(db *memoryDB) *memoryInboundRepository {
+	return &memoryInboundRepository{db: db, listeners: make(map[string][]models.ListeningSocket)}
+}
+
+func (r *memoryInboundRepository) SaveInbound(events []models.InboundConnection) error {
+	stored := make([]models.InboundConnection, 0, len(events))
+	for _, e := range events {
+		e.Timestamp = storedTime(e.Timestamp)
+		stored = append(stored, e)
+	}
+	r.db.inbound.add(stored...)
+	return nil
+}
+
+func (r *memoryInboundRepository) GetRecentInbound(limit int, filter models.Filter) ([]models.InboundConnection, error) {
+	return r.db.inbound.recent(limit, func(e models.InboundConnection) bool {
+		return matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+func (r *memoryInboundRepository) GetServiceClients(q models.InboundQuery) ([]models.ServiceClient, error) {
+	type clientKey struct {
+		port       int
+		comm, addr string
+	}
+	var clients []models.ServiceClient
+	index := make(map[clientKey]int)
+	r.db.inbound.oldestFirst(func(e models.InboundConnection) bool {
+		if !inWindow(e.Timestamp, q.Since, q.Until) || !matchesFilter(q.Filter, e.Attribution) ||
+			(q.LocalPort != 0 && e.LocalPort != q.LocalPort) || (q.Comm != "" && e.Comm != q.Comm) {
+			return true
+		}
+		key := clientKey{e.LocalPort, e.Comm, e.RemoteAddr}
+		i, ok := index[key]
+		if !ok {
+			i = len(clients)
+			index[key] = i
+			clients = append(clients, models.ServiceClient{
+				LocalPort: e.LocalPort, Comm: e.Comm, RemoteAddr: e.RemoteAddr, FirstSeen: e.Timestamp,
+			})
+		}
+		clients[i].Connections++
+		clients[i].LastSeen = e.Timestamp
+		return true
+	})
+	sort.SliceStable(clients, func(i, j int) bool {
+		a, b := clients[i], clients[j]
+		if a.Connections != b.Connections {
+			return a.Connections > b.Connections
+		}
+		if a.LocalPort != b.LocalPort {
+			return a.LocalPort < b.LocalPort
+		}
+		return a.RemoteAddr < b.RemoteAddr
+	})
+	if len(clients) > q.Limit {
+		clients = clients[:q.Limit]
+	}
+	return clients, nil
+}
+
+func (r *memoryInboundRepository) ReplaceListeners(host string, sockets []models.ListeningSocket, at time.Time) ([]models.ListenEvent, error) {
+	at = columnTime(at)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, events := diffListeners(r.listeners[host], sockets, at)
+	r.listeners[host] = current
+	r.db.listenEvents.add(events...)
+	return events, nil
+}
+
+func (r *memoryInboundRepository) GetListeners(filter models.Filter) ([]models.ListeningSocket, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hosts := make([]string, 0, len(r.listeners))
+	for host := range r.listeners {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var sockets []models.ListeningSocket
+	for _, host := range hosts {
+		for _, s := range r.listeners[host] {
+			if matchesFilter(filter, s.Attribution) {
+				sockets = append(sockets, s)
+			}
+		}
+	}
+	return sockets, nil
+}
+
+func (r *memoryInboundRepository) GetListenEvents(since, until time.Time, kind string, limit int, filter models.Filter) ([]models.ListenEvent, error) {
+	return r.db.listenEvents.recent(limit, func(e models.ListenEvent) bool {
+		return inWindow(e.Timestamp, since, until) && (kind == "" || e.Kind == kind) && matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+type memoryTCPStateRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryTCPStateRepository) SaveStateChanges(events []models.TCPStateChange) error {
+	stored := make([]models.TCPStateChange, 0, len(events))
+	for _, e := range events {
+		e.Timestamp = storedTime(e.Timestamp)
+		stored = append(stored, e)
+	}
+	r.db.tcpStates.add(stored...)
+	return nil
+}
+
+func (r *memoryTCPStateRepository) GetRecentStateChanges(limit int, filter models.Filter) ([]models.TCPStateChange, error) {
+	return r.db.tcpStates.recent(limit, func(e models.TCPStateChange) bool {
+		return matchesFilter(filter, e.Attribution)
+	}), nil
+}
+
+func (r *memoryTCPStateRepository) GetStateCounts(q models.StateQuery) ([]models.StateCount, error) {
+	var counts []models.StateCount
+	index := make(map[models.StateCount]int) // by count key, with Count zero
+	r.db.tcpStates.oldestFirst(func(e models.TCPStateChange) bool {
+		if !inWindow(e.Timestamp, q.Since, q.Until) || !matchesFilter(q.Filter, e.Attribution) ||
+			(q.State != "" && e.NewState != q.State) || (q.Comm != "" && e.Comm != q.Comm) {
+			return true
+		}
+		key := models.StateCount{Timestamp: e.Timestamp, State: e.NewState, Comm: e.Comm}
+		if i, ok := index[key]; ok {
+			counts[i].Count++
+			return true
+		}
+		index[key] = len(counts)
+		key.Count = 1
+		counts = append(counts, key)
+		return true
+	})
+	return counts, nil
+}
+
+func (r *memoryTCPStateRepository) GetStuckConnections(q models.StuckQuery) ([]models.TCPStateChange, error) {
+	type socketKey struct{ host, skaddr string }
+	latest := make(map[socketKey]models.TCPStateChange)
+	r.db.tcpStates.oldestFirst(func(e models.TCPStateChange) bool {
+		if !e.Timestamp.Before(q.Since.Truncate(time.Second)) {
+			latest[socketKey{e.Host, e.SKAddr}] = e
+		}
+		return true
+	})
+
+	var results []models.TCPStateChange
+	for _, e := range latest {
+		if slices.Contains(q.States, e.NewState) && !e.Timestamp.After(q.Before.Truncate(time.Second)) &&
+			matchesFilter(q.Filter, e.Attribution) {
+			results = append(results, e)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].Timestamp.Equal(results[j].Timestamp) {
+			return results[i].Timestamp.Before(results[j].Timestamp)
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+type memoryDNSRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryDNSRepository) SaveLookups(lookups []models.DNSLookup) error {
+	stored := make([]models.DNSLookup, 0, len(lookups))
+	for _, l := range lookups {
+		l.Timestamp = storedTime(l.Timestamp)
+		stored = append(stored, l)
+	}
+	r.db.dnsLookups.add(stored...)
+	return nil
+}
+
+func (r *memoryDNSRepository) GetRecentLookups(limit int, filter models.Filter) ([]models.DNSLookup, error) {
+	return r.db.dnsLookups.recent(limit, func(l models.DNSLookup) bool {
+		return matchesFilter(filter, l.Attribution)
+	}), nil
+}
+
+func (r *memoryDNSRepository) GetSlowLookups(q models.DNSQuery) ([]models.DNSLookup, error) {
+	var results []models.DNSLookup
+	r.db.dnsLookups.oldestFirst(func(l models.DNSLookup) bool {
+		if matchesDNSQuery(q, l) {
+			results = append(results, l)
+		}
+		return true
+	})
+	sort.SliceStable(results, func(i, j int) bool { return results[i].LatencyMS > results[j].LatencyMS })
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func (r *memoryDNSRepository) GetHostStats(q models.DNSQuery) ([]models.DNSHostStats, error) {
+	var results []models.DNSHostStats
+	index := make(map[string]int) // by hostname
+	r.db.dnsLookups.oldestFirst(func(l models.DNSLookup) bool {
+		if !matchesDNSQuery(q, l) {
+			return true
+		}
+		i, ok := index[l.Hostname]
+		if !ok {
+			i = len(results)
+			index[l.Hostname] = i
+			results = append(results, models.DNSHostStats{Hostname: l.Hostname})
+		}
+		h := &results[i]
+		h.Lookups++
+		h.AvgLatencyMS += l.LatencyMS // the sum until divided below
+		h.MaxLatencyMS = max(h.MaxLatencyMS, l.LatencyMS)
+		h.LastSeen = l.Timestamp
+		return true
+	})
+	for i := range results {
+		results[i].AvgLatencyMS /= float64(results[i].Lookups)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].AvgLatencyMS != results[j].AvgLatencyMS {
+			return results[i].AvgLatencyMS > results[j].AvgLatencyMS
+		}
+		return results[i].Hostname < results[j].Hostname
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// matchesDNSQuery is the in-memory counterpart of dnsWhere
+func matchesDNSQuery(q models.DNSQuery, l models.DNSLookup) bool {
+	return inWindow(l.Timestamp, q.Since, q.Until) && matchesFilter(q.Filter, l.Attribution) &&
+		(q.Hostname == "" || l.Hostname == q.Hostname) && (q.Comm == "" || l.Comm == q.Comm) &&
+		(q.MinLatencyMS <= 0 || l.LatencyMS >= q.MinLatencyMS)
+}
+
+type memoryKillRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryKillRepository) SaveOOMKills(kills []models.OOMKill) error {
+	stored := make([]models.OOMKill, 0, len(kills))
+	for _, k := range kills {
+		k.Timestamp = storedTime(k.Timestamp)
+		stored = append(stored, k)
+	}
+	r.db.oomKills.add(stored...)
+	return nil
+}
+
+func (r *memoryKillRepository) GetRecentOOMKills(limit int, filter models.Filter) ([]models.OOMKill, error) {
+	return r.db.oomKills.recent(limit, func(k models.OOMKill) bool {
+		return matchesFilter(filter, k.Attribution)
+	}), nil
+}
+
+func (r *memoryKillRepository) GetOOMKills(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.OOMKill, error) {
+	return r.db.oomKills.recent(limit, func(k models.OOMKill) bool {
+		return inWindow(k.Timestamp, since, until) && (comm == "" || k.Comm == comm) && matchesFilter(filter, k.Attribution)
+	}), nil
+}
+
+func (r *memoryKillRepository) SaveSignals(signals []models.Signal) error {
+	stored := make([]models.Signal, 0, len(signals))
+	for _, s := range signals {
+		s.Timestamp = storedTime(s.Timestamp)
+		stored = append(stored, s)
+	}
+	r.db.signals.add(stored...)
+	return nil
+}
+
+func (r *memoryKillRepository) GetRecentSignals(limit int, filter models.Filter) ([]models.Signal, error) {
+	return r.db.signals.recent(limit, func(s models.Signal) bool {
+		return matchesFilter(filter, s.Attribution)
+	}), nil
+}
+
+// GetSignals is the in-memory counterpart of signalWhere
+func (r *memoryKillRepository) GetSignals(q models.SignalQuery) ([]models.Signal, error) {
+	return r.db.signals.recent(q.Limit, func(s models.Signal) bool {
+		return inWindow(s.Timestamp, q.Since, q.Until) && matchesFilter(q.Filter, s.Attribution) &&
+			(q.PID == 0 || s.PID == q.PID) && (q.Comm == "" || s.Comm == q.Comm) &&
+			(q.SenderComm == "" || s.SenderComm == q.SenderComm) && (q.Signal == 0 || s.Signal == q.Signal)
+	}), nil
+}
+
+type memoryFSLatencyRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryFSLatencyRepository) SaveSlowOps(ops []models.SlowFileOp) error {
+	stored := make([]models.SlowFileOp, 0, len(ops))
+	for _, o := range ops {
+		o.Timestamp = storedTime(o.Timestamp)
+		stored = append(stored, o)
+	}
+	r.db.fsSlowOps.add(stored...)
+	return nil
+}
+
+func (r *memoryFSLatencyRepository) GetRecentSlowOps(limit int, filter models.Filter) ([]models.SlowFileOp, error) {
+	return r.db.fsSlowOps.recent(limit, func(o models.SlowFileOp) bool {
+		return matchesFilter(filter, o.Attribution)
+	}), nil
+}
+
+func (r *memoryFSLatencyRepository) GetSlowFiles(q models.SlowFileOpQuery) ([]models.SlowFile, error) {
+	var results []models.SlowFile
+	type fileKey struct{ file, filesystem string }
+	index := make(map[fileKey]int)
+	comms := make(map[fileKey]map[string]bool)
+	r.db.fsSlowOps.oldestFirst(func(o models.SlowFileOp) bool {
+		if !matchesSlowFileOpQuery(q, o) {
+			return true
+		}
+		key := fileKey{o.File, o.Filesystem}
+		i, ok := index[key]
+		if !ok {
+			i = len(results)
+			index[key] = i
+			comms[key] = make(map[string]bool)
+			results = append(results, models.SlowFile{File: o.File, Filesystem: o.Filesystem})
+		}
+		f := &results[i]
+		f.Ops++
+		f.Bytes += o.Bytes
+		f.MaxLatencyMS = max(f.MaxLatencyMS, o.LatencyMS)
+		f.TotalLatencyMS += o.LatencyMS
+		f.LastSeen = o.Timestamp
+		comms[key][o.Comm] = true
+		return true
+	})
+	for i := range results {
+		f := &results[i]
+		f.AvgLatencyMS = f.TotalLatencyMS / float64(f.Ops)
+		f.Processes = len(comms[fileKey{f.File, f.Filesystem}])
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].TotalLatencyMS != results[j].TotalLatencyMS {
+			return results[i].TotalLatencyMS > results[j].TotalLatencyMS
+		}
+		return results[i].File < results[j].File
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+func (r *memoryFSLatencyRepository) GetProcessLatency(q models.SlowFileOpQuery) ([]models.ProcessFSLatency, error) {
+	var results []models.ProcessFSLatency
+	type processKey struct {
+		pid  int
+		comm string
+	}
+	index := make(map[processKey]int)
+	files := make(map[processKey]map[string]bool)
+	r.db.fsSlowOps.oldestFirst(func(o models.SlowFileOp) bool {
+		if !matchesSlowFileOpQuery(q, o) {
+			return true
+		}
+		key := processKey{o.PID, o.Comm}
+		i, ok := index[key]
+		if !ok {
+			i = len(results)
+			index[key] = i
+			files[key] = make(map[string]bool)
+			results = append(results, models.ProcessFSLatency{PID: o.PID, Comm: o.Comm})
+		}
+		p := &results[i]
+		p.Ops++
+		switch o.Op {
+		case models.FSOpRead:
+			p.Reads++
+		case models.FSOpWrite:
+			p.Writes++
+		case models.FSOpOpen:
+			p.Opens++
+		case models.FSOpSync:
+			p.Syncs++
+		}
+		p.MaxLatencyMS = max(p.MaxLatencyMS, o.LatencyMS)
+		p.TotalLatencyMS += o.LatencyMS
+		p.LastSeen = o.Timestamp
+		files[key][o.File] = true
+		return true
+	})
+	for i := range results {
+		p := &results[i]
+		p.AvgLatencyMS = p.TotalLatencyMS / float64(p.Ops)
+		p.Files = len(files[processKey{p.PID, p.Comm}])
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].TotalLatencyMS != results[j].TotalLatencyMS {
+			return results[i].TotalLatencyMS > results[j].TotalLatencyMS
+		}
+		return results[i].PID < results[j].PID
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// matchesSlowFileOpQuery is the in-memory counterpart of slowFileOpWhere
+func matchesSlowFileOpQuery(q models.SlowFileOpQuery, o models.SlowFileOp) bool {
+	return inWindow(o.Timestamp, q.Since, q.Until) && matchesFilter(q.Filter, o.Attribution) &&
+		(q.Comm == "" || o.Comm == q.Comm) && (q.Filesystem == "" || o.Filesystem == q.Filesystem) &&
+		(q.Op == "" || o.Op == q.Op)
+}
@@ -0,0 +1,454 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryAlertRepository struct {
+	mu         sync.RWMutex
+	rules      map[int]models.AlertRule
+	alerts     map[int]models.Alert
+	lastRuleID int
+	lastID     int
+}
+
+func newMemoryAlertRepository() *memoryAlertRepository {
+	return &memoryAlertRepository{
+		rules:  make(map[int]models.AlertRule),
+		alerts: make(map[int]models.Alert),
+	}
+}
+
+// cloneRule copies the slices of the match, which callers may modify
+func cloneRule(rule models.AlertRule) models.AlertRule {
+	rule.Match.Comm = slices.Clone(rule.Match.Comm)
+	rule.Match.Addr = slices.Clone(rule.Match.Addr)
+	rule.Match.Port = slices.Clone(rule.Match.Port)
+	rule.Match.Signal = slices.Clone(rule.Match.Signal)
+	return rule
+}
+
+func (r *memoryAlertRepository) CreateRule(rule *models.AlertRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := columnTime(time.Now())
+	r.lastRuleID++
+	rule.ID = r.lastRuleID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	r.rules[rule.ID] = cloneRule(*rule)
+	return nil
+}
+
+func (r *memoryAlertRepository) UpdateRule(rule *models.AlertRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.rules[rule.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	rule.UpdatedAt = columnTime(time.Now())
+	updated := cloneRule(*rule)
+	updated.CreatedAt = existing.CreatedAt
+	r.rules[rule.ID] = updated
+	return nil
+}
+
+func (r *memoryAlertRepository) DeleteRule(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+func (r *memoryAlertRepository) GetRule(id int) (models.AlertRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.rules[id]
+	if !ok {
+		return models.AlertRule{}, ErrNotFound
+	}
+	return cloneRule(rule), nil
+}
+
+func (r *memoryAlertRepository) GetRules() ([]models.AlertRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []models.AlertRule
+	for _, rule := range r.rules {
+		rules = append(rules, cloneRule(rule))
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+// SaveAlert inserts a new alert or updates an existing one
+func (r *memoryAlertRepository) SaveAlert(alert *models.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if alert.ID == 0 {
+		r.lastID++
+		alert.ID = r.lastID
+	}
+
+	stored := *alert
+	stored.StartedAt = columnTime(stored.StartedAt)
+	stored.UpdatedAt = columnTime(stored.UpdatedAt)
+	if stored.FiredAt != nil {
+		fired := columnTime(*stored.FiredAt)
+		stored.FiredAt = &fired
+	}
+	if stored.ResolvedAt != nil {
+		resolved := columnTime(*stored.ResolvedAt)
+		stored.ResolvedAt = &resolved
+	}
+	r.alerts[alert.ID] = stored
+	return nil
+}
+
+// DeleteAlert removes an alert, used for pending alerts that never fired
+func (r *memoryAlertRepository) DeleteAlert(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.alerts, id)
+	return nil
+}
+
+// GetAlerts returns alerts started since the given time, optionally in one state
+func (r *memoryAlertRepository) GetAlerts(state string, since time.Time, limit int) ([]models.Alert, error) {
+	alerts := r.selectAlerts(func(a models.Alert) bool {
+		return (state == "" || a.State == state) &&
+			(!a.StartedAt.Before(columnTime(since)) || a.State != models.AlertResolved)
+	})
+	slices.Reverse(alerts)
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
+
+// GetActiveAlerts returns all pending and firing alerts
+func (r *memoryAlertRepository) GetActiveAlerts() ([]models.Alert, error) {
+	return r.selectAlerts(func(a models.Alert) bool {
+		return a.State == models.AlertPending || a.State == models.AlertFiring
+	}), nil
+}
+
+// selectAlerts returns the alerts matching keep ordered by ID
+func (r *memoryAlertRepository) selectAlerts(keep func(a models.Alert) bool) []models.Alert {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var alerts []models.Alert
+	for _, a := range r.alerts {
+		if keep(a) {
+			alerts = append(alerts, a)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+	return alerts
+}
+
+// baselineKey identifies a baseline like the primary key of the baselines table
+type baselineKey struct {
+	metric  string
+	subject string
+	slot    int
+}
+
+type memoryBaselineRepository struct {
+	db *memoryDB
+
+	mu        sync.RWMutex
+	baselines map[baselineKey]models.Baseline
+	destPorts map[string][]int
+}
+
+func newMemoryBaselineRepository(db *memoryDB) *memoryBaselineRepository {
+	return &memoryBaselineRepository{
+		db:        db,
+		baselines: make(map[baselineKey]models.Baseline),
+		destPorts: make(map[string][]int),
+	}
+}
+
+// SaveBaselines inserts or replaces baselines by metric, subject and slot
+func (r *memoryBaselineRepository) SaveBaselines(baselines []models.Baseline) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, b := range baselines {
+		b.UpdatedAt = columnTime(b.UpdatedAt)
+		r.baselines[baselineKey{b.Metric, b.Subject, b.Slot}] = b
+	}
+	return nil
+}
+
+// GetBaselines returns the baselines of a metric and subject, or all of them
+// if they are empty
+func (r *memoryBaselineRepository) GetBaselines(metric, subject string) ([]models.Baseline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var baselines []models.Baseline
+	for key, b := range r.baselines {
+		if (metric == "" || key.metric == metric) && (subject == "" || key.subject == subject) {
+			baselines = append(baselines, b)
+		}
+	}
+	sort.Slice(baselines, func(i, j int) bool {
+		a, b := baselines[i], baselines[j]
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Slot < b.Slot
+	})
+	return baselines, nil
+}
+
+// SaveDestPorts records destination ports seen for the first time per command
+func (r *memoryBaselineRepository) SaveDestPorts(ports map[string][]int, firstSeen time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for comm, list := range ports {
+		for _, port := range list {
+			if !slices.Contains(r.destPorts[comm], port) {
+				r.destPorts[comm] = append(r.destPorts[comm], port)
+			}
+		}
+	}
+	return nil
+}
+
+// GetDestPorts returns the known destination ports per command
+func (r *memoryBaselineRepository) GetDestPorts() (map[string][]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ports := make(map[string][]int, len(r.destPorts))
+	for comm, list := range r.destPorts {
+		ports[comm] = slices.Clone(list)
+	}
+	return ports, nil
+}
+
+// SaveAnomalies saves detected anomalies
+func (r *memoryBaselineRepository) SaveAnomalies(anomalies []models.Anomaly) error {
+	stored := make([]models.Anomaly, 0, len(anomalies))
+	for _, a := range anomalies {
+		a.Timestamp = columnTime(a.Timestamp)
+		stored = append(stored, a)
+	}
+	r.db.anomalies.add(stored...)
+	return nil
+}
+
+// GetAnomalies returns anomalies within a time window, newest first,
+// optionally of one metric or command
+func (r *memoryBaselineRepository) GetAnomalies(since, until time.Time, metric, comm string, limit int) ([]models.Anomaly, error) {
+	anomalies := r.db.anomalies.matching(func(a models.Anomaly) bool {
+		return inWindow(a.Timestamp, since, until) && (metric == "" || a.Metric == metric) && (comm == "" || a.Comm == comm)
+	})
+	return sortNewest(anomalies, limit, func(a models.Anomaly) (time.Time, int) { return a.Timestamp, a.ID }), nil
+}
+
+type memoryFindingRepository struct {
+	db *memoryDB
+}
+
+// SaveFindings saves multiple findings
+func (r *memoryFindingRepository) SaveFindings(findings []models.Finding) error {
+	stored := make([]models.Finding, 0, len(findings))
+	for _, f := range findings {
+		f.Timestamp = columnTime(f.Timestamp)
+		f.Techniques = slices.Clone(f.Techniques)
+		stored = append(stored, f)
+	}
+	r.db.findings.add(stored...)
+	return nil
+}
+
+// GetFindings returns the findings selected by q, newest first
+func (r *memoryFindingRepository) GetFindings(q models.FindingQuery) ([]models.Finding, error) {
+	findings := r.db.findings.matching(func(f models.Finding) bool {
+		if !inWindow(f.Timestamp, q.Since, q.Until) {
+			return false
+		}
+		// Like the IN list of the SQLite query, unknown severities never match
+		if q.Severity != "" && (models.SeverityRank(f.Severity) == 0 ||
+			models.SeverityRank(f.Severity) < models.SeverityRank(q.Severity)) {
+			return false
+		}
+		return (q.Rule == "" || f.Rule == q.Rule) && (q.PID <= 0 || f.PID == q.PID) &&
+			(q.Comm == "" || f.Comm == q.Comm) && matchesFilter(q.Filter, f.Attribution)
+	})
+	return sortNewest(findings, q.Limit, func(f models.Finding) (time.Time, int) { return f.Timestamp, f.ID }), nil
+}
+
+type memoryAgentRepository struct {
+	mu     sync.RWMutex
+	agents map[string]models.Agent
+}
+
+func newMemoryAgentRepository() *memoryAgentRepository {
+	return &memoryAgentRepository{agents: make(map[string]models.Agent)}
+}
+
+// Register inserts an agent, or updates the host, labels and versions of a
+// known one
+func (r *memoryAgentRepository) Register(reg models.AgentRegistration, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agent, ok := r.agents[reg.ID]
+	if !ok {
+		agent = models.Agent{ID: reg.ID, RegisteredAt: columnTime(at)}
+	}
+	agent.Host = reg.Host
+	agent.Labels = reg.Labels
+	agent.Kernel = reg.Kernel
+	agent.BCCVersion = reg.BCCVersion
+	agent.LastHeartbeat = columnTime(at)
+	r.agents[reg.ID] = agent
+	return nil
+}
+
+// Heartbeat records that an agent is alive, along with the state of its
+// collectors
+func (r *memoryAgentRepository) Heartbeat(id string, hb models.AgentHeartbeat, at time.Time) error {
+	return r.update(id, func(agent *models.Agent) {
+		agent.LastHeartbeat = columnTime(at)
+		agent.Spooled = hb.Spooled
+		agent.Collectors = slices.Clone(hb.Collectors)
+	})
+}
+
+// RecordBatch counts a batch received from an agent
+func (r *memoryAgentRepository) RecordBatch(id string, events int, at time.Time) error {
+	return r.update(id, func(agent *models.Agent) {
+		agent.LastBatchAt = columnTime(at)
+		agent.Batches++
+		agent.Events += events
+	})
+}
+
+// update applies fn to a known agent, or returns ErrNotFound
+func (r *memoryAgentRepository) update(id string, fn func(agent *models.Agent)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agent, ok := r.agents[id]
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&agent)
+	r.agents[id] = agent
+	return nil
+}
+
+func (r *memoryAgentRepository) GetAgent(id string) (models.Agent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agent, ok := r.agents[id]
+	if !ok {
+		return models.Agent{}, ErrNotFound
+	}
+	agent.Collectors = slices.Clone(agent.Collectors)
+	return agent, nil
+}
+
+// GetAgents returns all agents ordered by host
+func (r *memoryAgentRepository) GetAgents() ([]models.Agent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var agents []models.Agent
+	for _, agent := range r.agents {
+		agent.Collectors = slices.Clone(agent.Collectors)
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if agents[i].Host != agents[j].Host {
+			return agents[i].Host < agents[j].Host
+		}
+		return agents[i].ID < agents[j].ID
+	})
+	return agents, nil
+}
+
+type memoryBehaviorRepository struct {
+	mu       sync.RWMutex
+	profiles map[string]models.BehaviorProfile
+}
+
+func newMemoryBehaviorRepository() *memoryBehaviorRepository {
+	return &memoryBehaviorRepository{profiles: make(map[string]models.BehaviorProfile)}
+}
+
+// cloneProfile copies the allowlists of a profile, which callers may modify
+func cloneProfile(p models.BehaviorProfile) models.BehaviorProfile {
+	p.Children = slices.Clone(p.Children)
+	p.Connect = slices.Clone(p.Connect)
+	p.Syscalls = slices.Clone(p.Syscalls)
+	return p
+}
+
+// SaveProfiles inserts or updates profiles by command name
+func (r *memoryBehaviorRepository) SaveProfiles(profiles []models.BehaviorProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range profiles {
+		stored := cloneProfile(p)
+		stored.CreatedAt = columnTime(p.CreatedAt)
+		stored.UpdatedAt = columnTime(p.UpdatedAt)
+		if existing, ok := r.profiles[p.Comm]; ok {
+			stored.CreatedAt = existing.CreatedAt
+		}
+		r.profiles[p.Comm] = stored
+	}
+	return nil
+}
+
+// GetProfiles returns all profiles ordered by command name
+func (r *memoryBehaviorRepository) GetProfiles() ([]models.BehaviorProfile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var profiles []models.BehaviorProfile
+	for _, p := range r.profiles {
+		profiles = append(profiles, cloneProfile(p))
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Comm < profiles[j].Comm })
+	return profiles, nil
+}
+
+func (r *memoryBehaviorRepository) DeleteProfile(comm string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.profiles[comm]; !ok {
+		return ErrNotFound
+	}
+	delete(r.profiles, comm)
+	return nil
+}
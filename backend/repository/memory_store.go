@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ring keeps the newest items of one kind, the in-memory counterpart of a
+// table. Once full, every new item replaces the oldest one. Items get
+// increasing IDs like rows of an AUTOINCREMENT table.
+type ring[T any] struct {
+	mu       sync.RWMutex
+	items    []T
+	capacity int
+	next     int // position of the oldest item once the ring is full
+	lastID   int
+	setID    func(item *T, id int)
+}
+
+func newRing[T any](capacity int, setID func(item *T, id int)) *ring[T] {
+	return &ring[T]{capacity: capacity, setID: setID}
+}
+
+// add stores items at once, assigning their IDs, so the items of one call
+// are adjacent like the rows of one transaction
+func (r *ring[T]) add(items ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range items {
+		r.lastID++
+		r.setID(&item, r.lastID)
+		if len(r.items) < r.capacity {
+			r.items = append(r.items, item)
+			continue
+		}
+		r.items[r.next] = item
+		r.next = (r.next + 1) % r.capacity
+	}
+}
+
+// newestFirst calls fn for the items in descending ID order until fn
+// returns false
+func (r *ring[T]) newestFirst(fn func(item T) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.items)
+	for i := 1; i <= n; i++ {
+		if !fn(r.items[(r.next-i+n)%n]) {
+			return
+		}
+	}
+}
+
+// oldestFirst calls fn for the items in ascending ID order until fn returns
+// false
+func (r *ring[T]) oldestFirst(fn func(item T) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.items)
+	for i := 0; i < n; i++ {
+		if !fn(r.items[(r.next+i)%n]) {
+			return
+		}
+	}
+}
+
+// recent returns up to limit items matching keep, newest first
+func (r *ring[T]) recent(limit int, keep func(item T) bool) []T {
+	var results []T
+	r.newestFirst(func(item T) bool {
+		if len(results) >= limit {
+			return false
+		}
+		if keep(item) {
+			results = append(results, item)
+		}
+		return true
+	})
+	return results
+}
+
+// matching returns all items matching keep, newest first
+func (r *ring[T]) matching(keep func(item T) bool) []T {
+	var results []T
+	r.newestFirst(func(item T) bool {
+		if keep(item) {
+			results = append(results, item)
+		}
+		return true
+	})
+	return results
+}
+
+// memoryDB holds the tables of the in-memory backend. The repositories are
+// views on it, so queries joining several tables work as with SQLite.
+type memoryDB struct {
+	processes       *ring[models.ProcessEvent]
+	connections     *ring[models.NetworkConnection]
+	diskLatency     *ring[models.DiskLatency]
+	cpuProfiles     *ring[models.CPUProfile]
+	tcpSessions     *ring[models.TCPLifeEvent]
+	syscalls        *ring[models.SyscallStat]
+	processSyscalls *ring[models.ProcessSyscallStat]
+	exits           *ring[models.ProcessExit]
+	anomalies       *ring[models.Anomaly]
+	findings        *ring[models.Finding]
+	fileOpens       *ring[models.FileOpen]
+	runqLatency     *ring[models.RunqLatency]
+	offCPUStacks    *ring[models.OffCPUStack]
+	tcpRetransmits  *ring[models.TCPRetransmit]
+	tcpDrops        *ring[models.TCPDrop]
+	tcpRTT          *ring[models.TCPRTT]
+	inbound         *ring[models.InboundConnection]
+	listenEvents    *ring[models.ListenEvent]
+	tcpStates       *ring[models.TCPStateChange]
+	dnsLookups      *ring[models.DNSLookup]
+	oomKills        *ring[models.OOMKill]
+	signals         *ring[models.Signal]
+	fsSlowOps       *ring[models.SlowFileOp]
+}
+
+// NewMemoryStores returns repositories that keep the newest capacity rows of
+// every table in memory and nothing on disk. Rules, alerts, baselines,
+// agents and behavior profiles are kept as well, but are lost on restart.
+func NewMemoryStores(capacity int, thresholds TimelineThresholds) Stores {
+	db := &memoryDB{
+		processes:       newRing(capacity, func(p *models.ProcessEvent, id int) { p.ID = id }),
+		connections:     newRing(capacity, func(c *models.NetworkConnection, id int) { c.ID = id }),
+		diskLatency:     newRing(capacity, func(l *models.DiskLatency, id int) { l.ID = id }),
+		cpuProfiles:     newRing(capacity, func(p *models.CPUProfile, id int) { p.ID = id }),
+		tcpSessions:     newRing(capacity, func(t *models.TCPLifeEvent, id int) { t.ID = id }),
+		syscalls:        newRing(capacity, func(s *models.SyscallStat, id int) { s.ID = id }),
+		processSyscalls: newRing(capacity, func(s *models.ProcessSyscallStat, id int) { s.ID = id }),
+		exits:           newRing(capacity, func(e *models.ProcessExit, id int) { e.ID = id }),
+		anomalies:       newRing(capacity, func(a *models.Anomaly, id int) { a.ID = id }),
+		findings:        newRing(capacity, func(f *models.Finding, id int) { f.ID = id }),
+		fileOpens:       newRing(capacity, func(o *models.FileOpen, id int) { o.ID = id }),
+		runqLatency:     newRing(capacity, func(l *models.RunqLatency, id int) { l.ID = id }),
+		offCPUStacks:    newRing(capacity, func(s *models.OffCPUStack, id int) { s.ID = id }),
+		tcpRetransmits:  newRing(capacity, func(e *models.TCPRetransmit, id int) { e.ID = id }),
+		tcpDrops:        newRing(capacity, func(e *models.TCPDrop, id int) { e.ID = id }),
+		tcpRTT:          newRing(capacity, func(r *models.TCPRTT, id int) { r.ID = id }),
+		inbound:         newRing(capacity, func(e *models.InboundConnection, id int) { e.ID = id }),
+		listenEvents:    newRing(capacity, func(e *models.ListenEvent, id int) { e.ID = id }),
+		tcpStates:       newRing(capacity, func(e *models.TCPStateChange, id int) { e.ID = id }),
+		dnsLookups:      newRing(capacity, func(l *models.DNSLookup, id int) { l.ID = id }),
+		oomKills:        newRing(capacity, func(k *models.OOMKill, id int) { k.ID = id }),
+		signals:         newRing(capacity, func(s *models.Signal, id int) { s.ID = id }),
+		fsSlowOps:       newRing(capacity, func(o *models.SlowFileOp, id int) { o.ID = id }),
+	}
+
+	stores := Stores{
+		Processes:   &memoryProcessRepository{db: db},
+		Connections: &memoryNetworkRepository{db: db},
+		Disk:        &memoryDiskRepository{db: db},
+		CPUProfiles: &memoryCPUProfileRepository{db: db},
+		TCPSessions: &memoryTCPLifeRepository{db: db},
+		Syscalls:    &memorySyscallRepository{db: db},
+		Exits:       &memoryExitRepository{db: db},
+		Attribution: &memoryAttributionRepository{db: db},
+		Profiles:    &memoryProfileRepository{db: db},
+		Alerts:      newMemoryAlertRepository(),
+		Baselines:   newMemoryBaselineRepository(db),
+		Findings:    &memoryFindingRepository{db: db},
+		Agents:      newMemoryAgentRepository(),
+		Behavior:    newMemoryBehaviorRepository(),
+		Files:       &memoryFileRepository{db: db},
+		Runq:        &memoryRunqRepository{db: db},
+		OffCPU:      &memoryOffCPURepository{db: db},
+		TCPRetrans:  &memoryTCPRetransRepository{db: db},
+		TCPRTT:      &memoryTCPRTTRepository{db: db},
+		Inbound:     newMemoryInboundRepository(db),
+		TCPStates:   &memoryTCPStateRepository{db: db},
+		DNS:         &memoryDNSRepository{db: db},
+		Kills:       &memoryKillRepository{db: db},
+		FSLatency:   &memoryFSLatencyRepository{db: db},
+		Timeline:    newMemoryTimelineSources(db, thresholds),
+	}
+	stores.Batches = memoryBatchWriter{stores: stores}
+	return stores
+}
+
+// memoryBatchWriter saves batches directly, as saving to memory cannot fail
+type memoryBatchWriter struct {
+	stores Stores
+}
+
+func (w memoryBatchWriter) WriteBatch(fn func(stores Stores) error) error {
+	return fn(w.stores)
+}
+
+// inWindow is the in-memory counterpart of timestamp >= since AND timestamp <= until
+func inWindow(t, since, until time.Time) bool {
+	return !t.Before(since.Truncate(time.Second)) && !t.After(until.Truncate(time.Second))
+}
+
+// matchesFilter is the in-memory counterpart of filterClause
+func matchesFilter(f models.Filter, a models.Attribution) bool {
+	if f.Namespace != "" && a.PodNamespace != f.Namespace {
+		return false
+	}
+	if f.Pod != "" && a.PodName != f.Pod {
+		return false
+	}
+	return hasLabels(a.PodLabels, f.Labels) && matchesHost(f, a.Host, a.HostLabels)
+}
+
+// matchesHost is the in-memory counterpart of hostClause
+func matchesHost(f models.Filter, host string, labels models.Labels) bool {
+	if f.Host != "" && host != f.Host {
+		return false
+	}
+	return hasLabels(labels, f.HostLabels)
+}
+
+// hasLabels reports whether labels has every key of selector with the same
+// value, like json_extract does for labels stored as JSON
+func hasLabels(labels models.Labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if got, ok := labels[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// sortNewest orders items by descending time, then by descending ID, like
+// ORDER BY timestamp DESC, id DESC, and keeps the first limit of them
+func sortNewest[T any](items []T, limit int, key func(item T) (time.Time, int)) []T {
+	sort.SliceStable(items, func(i, j int) bool {
+		ti, idi := key(items[i])
+		tj, idj := key(items[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return idi > idj
+	})
+	if limit >= 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"time"
+)
+
+func newMemoryTimelineSources(db *memoryDB, thresholds TimelineThresholds) []TimelineSource {
+	return []TimelineSource{
+		&memoryProcessSource[models.ProcessEvent]{
+			eventType: models.TimelineExec,
+			events:    db.processes,
+			describe:  func(p models.ProcessEvent) (string, models.Attribution) { return p.Comm, p.Attribution },
+			event:     execEvent,
+		},
+		&memoryProcessSource[models.ProcessExit]{
+			eventType: models.TimelineExit,
+			events:    db.exits,
+			describe:  func(e models.ProcessExit) (string, models.Attribution) { return e.Comm, e.Attribution },
+			event:     exitEvent,
+		},
+		&memoryProcessSource[models.OOMKill]{
+			eventType: models.TimelineOOMKill,
+			events:    db.oomKills,
+			describe:  func(k models.OOMKill) (string, models.Attribution) { return k.Comm, k.Attribution },
+			event:     oomKillEvent,
+		},
+		&memoryProcessSource[models.Signal]{
+			eventType: models.TimelineSignal,
+			events:    db.signals,
+			describe:  func(s models.Signal) (string, models.Attribution) { return s.Comm, s.Attribution },
+			event:     signalEvent,
+		},
+		&memoryProcessSource[models.NetworkConnection]{
+			eventType: models.TimelineConnect,
+			events:    db.connections,
+			describe:  func(c models.NetworkConnection) (string, models.Attribution) { return c.Comm, c.Attribution },
+			event:     connectEvent,
+		},
+		&memoryProcessSource[models.TCPLifeEvent]{
+			eventType: models.TimelineTCPSession,
+			events:    db.tcpSessions,
+			describe:  func(t models.TCPLifeEvent) (string, models.Attribution) { return t.Comm, t.Attribution },
+			event:     tcpSessionEvent,
+		},
+		&memoryProcessSource[models.CPUProfile]{
+			eventType: models.TimelineCPUHotStack,
+			events:    db.cpuProfiles,
+			keep:      func(p models.CPUProfile) bool { return p.SampleCount >= thresholds.CPUHotStackCount },
+			describe:  func(p models.CPUProfile) (string, models.Attribution) { return p.Comm, p.Attribution },
+			event:     cpuHotStackEvent,
+		},
+		&memoryDiskSpikeSource{db: db, thresholdUS: int(thresholds.DiskP99 / time.Microsecond)},
+		&memorySyscallSpikeSource{db: db, factor: thresholds.SyscallFactor, minCount: thresholds.SyscallMinCount},
+		&memoryAnomalySource{db: db},
+	}
+}
+
+// memoryProcessSource is the in-memory counterpart of the sources of
+// per-process events, which all select by window, process, pod and cursor
+type memoryProcessSource[T any] struct {
+	eventType string
+	events    *ring[T]
+	keep      func(item T) bool // further conditions, nil if none
+	describe  func(item T) (comm string, a models.Attribution)
+	event     func(item T) models.TimelineEvent
+}
+
+func (s *memoryProcessSource[T]) Type() string   { return s.eventType }
+func (s *memoryProcessSource[T]) HostWide() bool { return false }
+
+func (s *memoryProcessSource[T]) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	var events []models.TimelineEvent
+	s.events.newestFirst(func(item T) bool {
+		if s.keep != nil && !s.keep(item) {
+			return true
+		}
+		comm, attribution := s.describe(item)
+		if (q.Comm != "" && comm != q.Comm) || !matchesFilter(q.Filter, attribution) {
+			return true
+		}
+		event := s.event(item)
+		if inWindow(event.Time, q.Since, q.Until) && (q.PID <= 0 || event.PID == q.PID) && follows(q.After, event) {
+			events = append(events, event)
+		}
+		return true
+	})
+	return sortEvents(events, q.Limit), nil
+}
+
+// follows is the in-memory counterpart of cursorClause
+func follows(after *models.TimelineCursor, event models.TimelineEvent) bool {
+	return after == nil || after.Before(event.Cursor())
+}
+
+func sortEvents(events []models.TimelineEvent, limit int) []models.TimelineEvent {
+	return sortNewest(events, limit, func(e models.TimelineEvent) (time.Time, int) { return e.Time, e.ID })
+}
+
+// memoryDiskSpikeSource reports histogram snapshots whose p99 latency
+// reaches the threshold
+type memoryDiskSpikeSource struct {
+	db          *memoryDB
+	thresholdUS int
+}
+
+func (s *memoryDiskSpikeSource) Type() string   { return models.TimelineDiskSpike }
+func (s *memoryDiskSpikeSource) HostWide() bool { return true }
+
+func (s *memoryDiskSpikeSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	buckets := s.db.diskLatency.matching(func(lat models.DiskLatency) bool {
+		return inWindow(lat.Timestamp, q.Since, q.Until) && matchesHost(q.Filter, lat.Host, lat.HostLabels)
+	})
+	buckets = sortNewest(buckets, -1, func(l models.DiskLatency) (time.Time, int) { return l.Timestamp, l.ID })
+
+	var (
+		events   []models.TimelineEvent
+		snapshot []models.DiskLatency
+	)
+	flush := func() {
+		if len(snapshot) == 0 {
+			return
+		}
+		event, ok := diskSpikeEvent(snapshot, snapshot[0].ID, s.thresholdUS)
+		if ok && follows(q.After, event) {
+			events = append(events, event)
+		}
+	}
+	for _, lat := range buckets {
+		if len(snapshot) > 0 && (!lat.Timestamp.Equal(snapshot[0].Timestamp) || lat.Host != snapshot[0].Host) {
+			flush()
+			snapshot = snapshot[:0]
+		}
+		snapshot = append(snapshot, lat)
+	}
+	flush()
+
+	return sortEvents(events, q.Limit), nil
+}
+
+// memorySyscallSpikeSource reports syscall counts of an interval that exceed
+// the average of the preceding intervals of the same host by factor
+type memorySyscallSpikeSource struct {
+	db       *memoryDB
+	factor   float64
+	minCount int
+}
+
+func (s *memorySyscallSpikeSource) Type() string   { return models.TimelineSyscallSpike }
+func (s *memorySyscallSpikeSource) HostWide() bool { return true }
+
+func (s *memorySyscallSpikeSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	// The baseline looks back a few minutes before the window, like the
+	// SQLite query does
+	lookback := q.Since.Add(-5 * time.Minute)
+	history := make(map[string][]int) // preceding counts by host and syscall
+
+	var events []models.TimelineEvent
+	s.db.syscalls.oldestFirst(func(stat models.SyscallStat) bool {
+		if !inWindow(stat.Timestamp, lookback, q.Until) || !matchesHost(q.Filter, stat.Host, stat.HostLabels) {
+			return true
+		}
+		key := stat.Host + "|" + stat.SyscallName
+		preceding := history[key]
+		history[key] = append(preceding, stat.Count)
+		if len(history[key]) > syscallBaselineIntervals {
+			history[key] = history[key][1:]
+		}
+
+		if len(preceding) == 0 || !inWindow(stat.Timestamp, q.Since, q.Until) || stat.Count < s.minCount {
+			return true
+		}
+		sum := 0
+		for _, count := range preceding {
+			sum += count
+		}
+		baseline := float64(sum) / float64(len(preceding))
+		if float64(stat.Count) <= baseline*s.factor {
+			return true
+		}
+
+		event := syscallSpikeEvent(stat.ID, stat.Timestamp, models.SyscallSpike{
+			Host:     stat.Host,
+			Syscall:  stat.SyscallName,
+			Count:    stat.Count,
+			Baseline: baseline,
+		})
+		if follows(q.After, event) {
+			events = append(events, event)
+		}
+		return true
+	})
+
+	return sortEvents(events, q.Limit), nil
+}
+
+// memoryAnomalySource reports deviations from the learned baselines
+type memoryAnomalySource struct {
+	db *memoryDB
+}
+
+func (s *memoryAnomalySource) Type() string   { return models.TimelineAnomaly }
+func (s *memoryAnomalySource) HostWide() bool { return false }
+
+func (s *memoryAnomalySource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	if q.PID > 0 || !q.Filter.IsEmpty() {
+		return nil, nil
+	}
+
+	var events []models.TimelineEvent
+	s.db.anomalies.newestFirst(func(a models.Anomaly) bool {
+		if !inWindow(a.Timestamp, q.Since, q.Until) || (q.Comm != "" && a.Comm != q.Comm) {
+			return true
+		}
+		if event := anomalyEvent(a); follows(q.After, event) {
+			events = append(events, event)
+		}
+		return true
+	})
+	return sortEvents(events, q.Limit), nil
+}
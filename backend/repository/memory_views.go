@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type memoryAttributionRepository struct {
+	db *memoryDB
+}
+
+// memoryAttributionGroups are the in-memory counterparts of attributionGroups
+var memoryAttributionGroups = map[string]func(a models.Attribution) string{
+	"cgroup_path":   func(a models.Attribution) string { return a.CgroupPath },
+	"systemd_unit":  func(a models.Attribution) string { return a.SystemdUnit },
+	"container_id":  func(a models.Attribution) string { return a.ContainerID },
+	"pod_namespace": func(a models.Attribution) string { return a.PodNamespace },
+	"pod":           func(a models.Attribution) string { return a.PodNamespace + "/" + a.PodName },
+	"host":          func(a models.Attribution) string { return a.Host },
+}
+
+// GetSummary aggregates execs, connects, TCP sessions and CPU samples per group
+func (r *memoryAttributionRepository) GetSummary(groupBy string, since, until time.Time, filter models.Filter) ([]models.AttributionSummary, error) {
+	group, ok := memoryAttributionGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot group by %q", groupBy)
+	}
+
+	summaries := make(map[string]*models.AttributionSummary)
+	summary := func(timestamp time.Time, a models.Attribution) *models.AttributionSummary {
+		if !inWindow(timestamp, since, until) || !matchesFilter(filter, a) {
+			return nil
+		}
+		key := group(a)
+		s, ok := summaries[key]
+		if !ok {
+			s = &models.AttributionSummary{Group: key}
+			summaries[key] = s
+		}
+		return s
+	}
+
+	r.db.processes.oldestFirst(func(p models.ProcessEvent) bool {
+		if s := summary(p.Timestamp, p.Attribution); s != nil {
+			s.Execs++
+		}
+		return true
+	})
+	r.db.connections.oldestFirst(func(conn models.NetworkConnection) bool {
+		if s := summary(conn.Timestamp, conn.Attribution); s != nil {
+			s.Connects++
+		}
+		return true
+	})
+	r.db.tcpSessions.oldestFirst(func(t models.TCPLifeEvent) bool {
+		if s := summary(t.Timestamp, t.Attribution); s != nil {
+			s.TCPSessions++
+			s.TxKB += t.TxKB
+			s.RxKB += t.RxKB
+		}
+		return true
+	})
+	r.db.cpuProfiles.oldestFirst(func(p models.CPUProfile) bool {
+		if s := summary(p.Timestamp, p.Attribution); s != nil {
+			s.CPUSamples += p.SampleCount
+		}
+		return true
+	})
+
+	results := make([]models.AttributionSummary, 0, len(summaries))
+	for _, s := range summaries {
+		results = append(results, *s)
+	}
+	activity := func(s models.AttributionSummary) int {
+		return s.Execs + s.Connects + s.TCPSessions + s.CPUSamples
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if activity(results[i]) != activity(results[j]) {
+			return activity(results[i]) > activity(results[j])
+		}
+		return results[i].Group < results[j].Group
+	})
+	return results, nil
+}
+
+type memoryProfileRepository struct {
+	db *memoryDB
+}
+
+// selects is the in-memory counterpart of selectorClause
+func selects(sel models.ProcessSelector, since, until time.Time, timestamp time.Time, pid int, comm, host string, hostLabels models.Labels) bool {
+	if !inWindow(timestamp, since, until) || !matchesHost(sel.Filter, host, hostLabels) {
+		return false
+	}
+	if sel.PID > 0 {
+		return pid == sel.PID
+	}
+	return comm == sel.Comm
+}
+
+func (r *memoryProfileRepository) GetExecs(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessEvent, error) {
+	return r.db.processes.recent(limit, func(p models.ProcessEvent) bool {
+		pid, err := strconv.Atoi(p.PID)
+		return err == nil && selects(sel, since, until, p.Timestamp, pid, p.Comm, p.Host, p.HostLabels)
+	}), nil
+}
+
+func (r *memoryProfileRepository) GetExits(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessExit, error) {
+	return r.db.exits.recent(limit, func(e models.ProcessExit) bool {
+		return selects(sel, since, until, e.Timestamp, e.PID, e.Comm, e.Host, e.HostLabels)
+	}), nil
+}
+
+func (r *memoryProfileRepository) GetOOMKills(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.OOMKill, error) {
+	return r.db.oomKills.recent(limit, func(k models.OOMKill) bool {
+		return selects(sel, since, until, k.Timestamp, k.PID, k.Comm, k.Host, k.HostLabels)
+	}), nil
+}
+
+// GetSignals returns the signals sent to the process
+func (r *memoryProfileRepository) GetSignals(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.Signal, error) {
+	return r.db.signals.recent(limit, func(s models.Signal) bool {
+		return selects(sel, since, until, s.Timestamp, s.PID, s.Comm, s.Host, s.HostLabels)
+	}), nil
+}
+
+func (r *memoryProfileRepository) GetConnections(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.NetworkConnection, error) {
+	return r.db.connections.recent(limit, func(conn models.NetworkConnection) bool {
+		pid, err := strconv.Atoi(conn.PID)
+		return err == nil && selects(sel, since, until, conn.Timestamp, pid, conn.Comm, conn.Host, conn.HostLabels)
+	}), nil
+}
+
+func (r *memoryProfileRepository) GetTCPSessions(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.TCPLifeEvent, error) {
+	return r.db.tcpSessions.recent(limit, func(t models.TCPLifeEvent) bool {
+		return selects(sel, since, until, t.Timestamp, t.PID, t.Comm, t.Host, t.HostLabels)
+	}), nil
+}
+
+// GetTCPSummary aggregates all TCP sessions in the window, not only the ones
+// returned by GetTCPSessions
+func (r *memoryProfileRepository) GetTCPSummary(sel models.ProcessSelector, since, until time.Time) (models.TCPSummary, error) {
+	var (
+		summary     models.TCPSummary
+		peers       = make(map[string]bool)
+		durationSum float64
+	)
+	r.db.tcpSessions.oldestFirst(func(t models.TCPLifeEvent) bool {
+		if !selects(sel, since, until, t.Timestamp, t.PID, t.Comm, t.Host, t.HostLabels) {
+			return true
+		}
+		summary.Sessions++
+		peers[t.RemoteAddr] = true
+		summary.TxKB += t.TxKB
+		summary.RxKB += t.RxKB
+		durationSum += t.DurationMS
+		if t.DurationMS > summary.MaxDurationMS {
+			summary.MaxDurationMS = t.DurationMS
+		}
+		return true
+	})
+	summary.Peers = len(peers)
+	if summary.Sessions > 0 {
+		summary.AvgDurationMS = durationSum / float64(summary.Sessions)
+	}
+	return summary, nil
+}
+
+// GetCPUStacks returns the hottest stacks in the window along with the total
+// number of samples
+func (r *memoryProfileRepository) GetCPUStacks(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.StackSample, int, error) {
+	total := 0
+	samples := make(map[string]int)
+	r.db.cpuProfiles.oldestFirst(func(p models.CPUProfile) bool {
+		if selects(sel, since, until, p.Timestamp, p.PID, p.Comm, p.Host, p.HostLabels) {
+			total += p.SampleCount
+			samples[p.StackTrace] += p.SampleCount
+		}
+		return true
+	})
+
+	stacks := make([]models.StackSample, 0, len(samples))
+	for stack, count := range samples {
+		stacks = append(stacks, models.StackSample{Stack: stack, Samples: count})
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		if stacks[i].Samples != stacks[j].Samples {
+			return stacks[i].Samples > stacks[j].Samples
+		}
+		return stacks[i].Stack < stacks[j].Stack
+	})
+	if len(stacks) > limit {
+		stacks = stacks[:limit]
+	}
+	return stacks, total, nil
+}
+
+// GetSyscallSeries returns the syscall counts per collection interval. When
+// selecting by comm, the counts of all matching processes are summed.
+func (r *memoryProfileRepository) GetSyscallSeries(sel models.ProcessSelector, since, until time.Time) ([]models.SyscallPoint, error) {
+	counts := make(map[time.Time]int)
+	r.db.processSyscalls.oldestFirst(func(s models.ProcessSyscallStat) bool {
+		if selects(sel, since, until, s.Timestamp, s.PID, s.Comm, s.Host, s.HostLabels) {
+			counts[s.Timestamp] += s.Count
+		}
+		return true
+	})
+
+	points := make([]models.SyscallPoint, 0, len(counts))
+	for timestamp, count := range counts {
+		points = append(points, models.SyscallPoint{Timestamp: timestamp, Count: count})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	return points, nil
+}
@@ -8,78 +8,69 @@ import (
 type NetworkRepository interface {
 	SaveConnection(conn models.NetworkConnection) error
 	SaveConnections(connections []models.NetworkConnection) error
-	GetRecentConnections(limit int) ([]models.NetworkConnection, error)
+	GetRecentConnections(limit int, filter models.Filter) ([]models.NetworkConnection, error)
 }
 
 type networkRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	writer *SQLiteWriter
 }
 
-func NewNetworkRepository(db *sql.DB) NetworkRepository {
-	return &networkRepository{db: db}
+func NewNetworkRepository(db *sql.DB, writer *SQLiteWriter) NetworkRepository {
+	return &networkRepository{db: db, writer: writer}
 }
 
+const connectionInsert = `INSERT INTO network_connections 
+		(timestamp, pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port, dest_host, ` + attributionInsertColumns + `) 
+		VALUES (` + eventTimestamp + `, ?, ?, ?, ?, ?, ?, ?, ?, ` + attributionPlaceholders + `)`
+
 func (r *networkRepository) SaveConnection(conn models.NetworkConnection) error {
-	_, err := r.db.Exec(
-		`INSERT INTO network_connections 
-		(pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		conn.PID, conn.Comm, conn.IPVersion, conn.SourceAddr,
-		conn.SourcePort, conn.DestAddr, conn.DestPort,
-	)
-	return err
+	return r.SaveConnections([]models.NetworkConnection{conn})
 }
 
 func (r *networkRepository) SaveConnections(connections []models.NetworkConnection) error {
-	if len(connections) == 0 {
-		return nil
-	}
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`INSERT INTO network_connections 
-		(pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
+	rows := make([][]interface{}, 0, len(connections))
 	for _, conn := range connections {
-		if _, err := stmt.Exec(conn.PID, conn.Comm, conn.IPVersion, conn.SourceAddr,
-			conn.SourcePort, conn.DestAddr, conn.DestPort); err != nil {
-			return err
-		}
+		rows = append(rows, connectionValues(conn))
 	}
-
-	return tx.Commit()
+	r.writer.enqueue(connectionInsert, rows)
+	return nil
 }
 
-func (r *networkRepository) GetRecentConnections(limit int) ([]models.NetworkConnection, error) {
+func (r *networkRepository) GetRecentConnections(limit int, filter models.Filter) ([]models.NetworkConnection, error) {
+	conditions, args := filterClause(filter, "")
 	rows, err := r.db.Query(
-		`SELECT id, timestamp, pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port 
-		FROM network_connections ORDER BY id DESC LIMIT ?`,
-		limit,
+		`SELECT `+connectionColumns+` FROM network_connections WHERE 1 = 1`+conditions+` ORDER BY id DESC LIMIT ?`,
+		append(args, limit)...,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanConnections(rows)
+}
+
+var connectionColumns = `id, timestamp, pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port, COALESCE(dest_host, ''), ` + attributionSelect("")
+
+func scanConnections(rows rowScanner) ([]models.NetworkConnection, error) {
 	var results []models.NetworkConnection
 	for rows.Next() {
 		var conn models.NetworkConnection
-		if err := rows.Scan(
+		dest := append([]interface{}{
 			&conn.ID, &conn.Timestamp, &conn.PID, &conn.Comm, &conn.IPVersion,
-			&conn.SourceAddr, &conn.SourcePort, &conn.DestAddr, &conn.DestPort,
-		); err != nil {
+			&conn.SourceAddr, &conn.SourcePort, &conn.DestAddr, &conn.DestPort, &conn.DestHost,
+		}, attributionDest(&conn.Attribution)...)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 		results = append(results, conn)
 	}
-	return results, nil
+	return results, rows.Err()
+}
+
+func connectionValues(conn models.NetworkConnection) []interface{} {
+	values := []interface{}{timestampValue(conn.Timestamp), conn.PID, conn.Comm, conn.IPVersion, conn.SourceAddr,
+		conn.SourcePort, conn.DestAddr, conn.DestPort, conn.DestHost}
+	return append(values, attributionValues(conn.Attribution)...)
 }
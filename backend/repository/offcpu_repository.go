@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+)
+
+type OffCPURepository interface {
+	SaveOffCPUStacks(stacks []models.OffCPUStack) error
+	GetRecentOffCPUStacks(limit int, filter models.Filter) ([]models.OffCPUStack, error)
+	GetStackTotals(q models.StackQuery) ([]models.StackTotal, error)
+}
+
+type offCPURepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewOffCPURepository(db *sql.DB, writer *SQLiteWriter) OffCPURepository {
+	return &offCPURepository{db: db, writer: writer}
+}
+
+var offCPUColumns = "id, timestamp, comm, stack, total_us, " + attributionSelect("")
+
+// SaveOffCPUStacks queues the off-CPU stacks of an interval for the writer
+func (r *offCPURepository) SaveOffCPUStacks(stacks []models.OffCPUStack) error {
+	rows := make([][]interface{}, 0, len(stacks))
+	for _, s := range stacks {
+		values := []interface{}{timestampValue(s.Timestamp), s.Comm, s.Stack, s.TotalUS}
+		rows = append(rows, append(values, attributionValues(s.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO offcpu_stacks (timestamp, comm, stack, total_us, `+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *offCPURepository) GetRecentOffCPUStacks(limit int, filter models.Filter) ([]models.OffCPUStack, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+offCPUColumns+" FROM offcpu_stacks WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOffCPUStacks(rows)
+}
+
+// GetStackTotals sums up the blocked time of every stack per command in the
+// window. Off-CPU stacks carry no PID, so q.PID is ignored.
+func (r *offCPURepository) GetStackTotals(q models.StackQuery) ([]models.StackTotal, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+
+	rows, err := r.db.Query(
+		"SELECT comm, stack, SUM(total_us) FROM offcpu_stacks WHERE"+where+conditions+" GROUP BY comm, stack",
+		append(args, filterArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStackTotals(rows)
+}
+
+func scanOffCPUStacks(rows rowScanner) ([]models.OffCPUStack, error) {
+	var stacks []models.OffCPUStack
+	for rows.Next() {
+		var s models.OffCPUStack
+		dest := []interface{}{&s.ID, &s.Timestamp, &s.Comm, &s.Stack, &s.TotalUS}
+		if err := rows.Scan(append(dest, attributionDest(&s.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, s)
+	}
+	return stacks, rows.Err()
+}
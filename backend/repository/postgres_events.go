@@ -0,0 +1,1162 @@
+package repository
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type pgProcessRepository struct {
+	pool pgConn
+}
+
+func (r *pgProcessRepository) SaveProcess(p models.ProcessEvent) error {
+	return r.SaveProcesses([]models.ProcessEvent{p})
+}
+
+func (r *pgProcessRepository) SaveProcesses(processes []models.ProcessEvent) error {
+	columns := append([]string{"timestamp", "time", "pid", "ppid", "uid", "comm", "args"}, attributionNames...)
+	return copyRows(r.pool, "processes", columns, processes, func(p models.ProcessEvent) []interface{} {
+		return append([]interface{}{storedTime(p.Timestamp), p.Time, p.PID, p.PPID, p.UID, p.Comm, p.Args}, attributionValues(p.Attribution)...)
+	})
+}
+
+func (r *pgProcessRepository) GetRecentProcesses(limit int, filter models.Filter) ([]models.ProcessEvent, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+processColumns+" FROM processes WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanProcesses),
+	)
+}
+
+type pgNetworkRepository struct {
+	pool pgConn
+}
+
+func (r *pgNetworkRepository) SaveConnection(conn models.NetworkConnection) error {
+	return r.SaveConnections([]models.NetworkConnection{conn})
+}
+
+func (r *pgNetworkRepository) SaveConnections(connections []models.NetworkConnection) error {
+	columns := append([]string{"timestamp", "pid", "comm", "ip_version", "source_addr", "source_port", "dest_addr", "dest_port", "dest_host"}, attributionNames...)
+	return copyRows(r.pool, "network_connections", columns, connections, func(conn models.NetworkConnection) []interface{} {
+		values := []interface{}{storedTime(conn.Timestamp), conn.PID, conn.Comm, conn.IPVersion, conn.SourceAddr,
+			conn.SourcePort, conn.DestAddr, conn.DestPort, conn.DestHost}
+		return append(values, attributionValues(conn.Attribution)...)
+	})
+}
+
+func (r *pgNetworkRepository) GetRecentConnections(limit int, filter models.Filter) ([]models.NetworkConnection, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+connectionColumns+" FROM network_connections WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanConnections),
+	)
+}
+
+type pgDiskRepository struct {
+	pool pgConn
+}
+
+func (r *pgDiskRepository) SaveLatencySnapshot(latencies []models.DiskLatency) error {
+	columns := []string{"timestamp", "range_min", "range_max", "count", "host", "host_labels"}
+	return copyRows(r.pool, "disk_latency", columns, latencies, func(lat models.DiskLatency) []interface{} {
+		return []interface{}{storedTime(lat.Timestamp), lat.RangeMin, lat.RangeMax, lat.Count, lat.Host, lat.HostLabels}
+	})
+}
+
+func (r *pgDiskRepository) GetLatestLatency(limit int, filter models.Filter) ([]models.DiskLatency, error) {
+	var args pgArgs
+	conditions := pgHostClause(filter, "", &args)
+	return query(r.pool,
+		`SELECT id, timestamp, range_min, range_max, count, host, host_labels
+		FROM disk_latency WHERE 1 = 1`+conditions+` ORDER BY id DESC LIMIT `+args.bind(limit),
+		args, pgScanDiskLatency,
+	)
+}
+
+func pgScanDiskLatency(rows pgx.Rows) ([]models.DiskLatency, error) {
+	var results []models.DiskLatency
+	for rows.Next() {
+		var lat models.DiskLatency
+		if err := rows.Scan(&lat.ID, &lat.Timestamp, &lat.RangeMin, &lat.RangeMax, &lat.Count, &lat.Host, &lat.HostLabels); err != nil {
+			return nil, err
+		}
+		results = append(results, lat)
+	}
+	return results, rows.Err()
+}
+
+type pgCPUProfileRepository struct {
+	pool pgConn
+}
+
+func (r *pgCPUProfileRepository) SaveCPUProfiles(profiles []models.CPUProfile) error {
+	columns := append([]string{"timestamp", "process_name", "comm", "pid", "stack_trace", "sample_count"}, attributionNames...)
+	return copyRows(r.pool, "cpu_profiles", columns, profiles, func(profile models.CPUProfile) []interface{} {
+		values := []interface{}{storedTime(profile.Timestamp), profile.ProcessName, profile.Comm, profile.PID,
+			profile.StackTrace, profile.SampleCount}
+		return append(values, attributionValues(profile.Attribution)...)
+	})
+}
+
+func (r *pgCPUProfileRepository) GetRecentCPUProfiles(limit int, filter models.Filter) ([]models.CPUProfile, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+cpuProfileColumns+" FROM cpu_profiles WHERE 1 = 1"+conditions+" ORDER BY timestamp DESC, id DESC LIMIT "+args.bind(limit),
+		args, pgScanCPUProfiles,
+	)
+}
+
+func (r *pgCPUProfileRepository) GetStackTotals(q models.StackQuery) ([]models.StackTotal, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.PID > 0 {
+		where += " AND pid = " + args.bind(q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+	return query(r.pool,
+		"SELECT "+cpuProfileComm+", stack_trace, SUM(sample_count) FROM cpu_profiles WHERE "+where+
+			" GROUP BY "+cpuProfileComm+", stack_trace",
+		args, scanWith(scanStackTotals),
+	)
+}
+
+// pgScanCPUProfiles reads the columns of cpuProfileColumns. Unlike SQLite,
+// PostgreSQL returns the timestamp as a time.Time.
+func pgScanCPUProfiles(rows pgx.Rows) ([]models.CPUProfile, error) {
+	var profiles []models.CPUProfile
+	for rows.Next() {
+		var profile models.CPUProfile
+		dest := []interface{}{&profile.ID, &profile.Timestamp, &profile.ProcessName, &profile.Comm, &profile.PID,
+			&profile.StackTrace, &profile.SampleCount}
+		if err := rows.Scan(append(dest, attributionDest(&profile.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+type pgTCPLifeRepository struct {
+	pool pgConn
+}
+
+func (r *pgTCPLifeRepository) SaveTCPLifeEvents(events []models.TCPLifeEvent) error {
+	columns := append([]string{"timestamp", "pid", "comm", "local_addr", "local_port", "remote_addr", "remote_port",
+		"tx_kb", "rx_kb", "duration_ms", "remote_host"}, attributionNames...)
+	return copyRows(r.pool, "tcp_lifecycle", columns, events, func(event models.TCPLifeEvent) []interface{} {
+		values := []interface{}{storedTime(event.Timestamp), event.PID, event.Comm, event.LocalAddr, event.LocalPort,
+			event.RemoteAddr, event.RemotePort, event.TxKB, event.RxKB, event.DurationMS, event.RemoteHost}
+		return append(values, attributionValues(event.Attribution)...)
+	})
+}
+
+func (r *pgTCPLifeRepository) GetRecentTCPLifeEvents(limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+tcpLifeColumns+" FROM tcp_lifecycle WHERE 1 = 1"+conditions+" ORDER BY timestamp DESC, id DESC LIMIT "+args.bind(limit),
+		args, pgScanTCPLifeEvents,
+	)
+}
+
+func (r *pgTCPLifeRepository) GetSlowSessions(since, until time.Time, minDurationMS float64, limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) +
+		" AND duration_ms >= " + args.bind(minDurationMS) + pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+tcpLifeColumns+" FROM tcp_lifecycle WHERE "+where+" ORDER BY duration_ms DESC LIMIT "+args.bind(limit),
+		args, pgScanTCPLifeEvents,
+	)
+}
+
+// pgScanTCPLifeEvents reads the columns of tcpLifeColumns
+func pgScanTCPLifeEvents(rows pgx.Rows) ([]models.TCPLifeEvent, error) {
+	var events []models.TCPLifeEvent
+	for rows.Next() {
+		var event models.TCPLifeEvent
+		dest := []interface{}{&event.ID, &event.Timestamp, &event.PID, &event.Comm, &event.LocalAddr, &event.LocalPort,
+			&event.RemoteAddr, &event.RemotePort, &event.TxKB, &event.RxKB, &event.DurationMS, &event.RemoteHost}
+		if err := rows.Scan(append(dest, attributionDest(&event.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+type pgSyscallRepository struct {
+	pool pgConn
+}
+
+func (r *pgSyscallRepository) SaveSyscallStats(stats []models.SyscallStat) error {
+	columns := []string{"timestamp", "syscall_name", "count", "host", "host_labels"}
+	return copyRows(r.pool, "syscall_stats", columns, stats, func(stat models.SyscallStat) []interface{} {
+		return []interface{}{storedTime(stat.Timestamp), stat.SyscallName, stat.Count, stat.Host, stat.HostLabels}
+	})
+}
+
+func (r *pgSyscallRepository) SaveProcessSyscallStats(stats []models.ProcessSyscallStat) error {
+	columns := []string{"timestamp", "pid", "comm", "count", "host", "host_labels"}
+	return copyRows(r.pool, "process_syscall_stats", columns, stats, func(stat models.ProcessSyscallStat) []interface{} {
+		return []interface{}{storedTime(stat.Timestamp), stat.PID, stat.Comm, stat.Count, stat.Host, stat.HostLabels}
+	})
+}
+
+func (r *pgSyscallRepository) GetRecentSyscallStats(limit int, filter models.Filter) ([]models.SyscallStat, error) {
+	var args pgArgs
+	conditions := pgHostClause(filter, "", &args)
+	return query(r.pool, `
+		SELECT id, timestamp, syscall_name, count, host, host_labels
+		FROM syscall_stats
+		WHERE 1 = 1`+conditions+`
+		ORDER BY timestamp DESC, id DESC
+		LIMIT `+args.bind(limit),
+		args, func(rows pgx.Rows) ([]models.SyscallStat, error) {
+			var stats []models.SyscallStat
+			for rows.Next() {
+				var stat models.SyscallStat
+				if err := rows.Scan(&stat.ID, &stat.Timestamp, &stat.SyscallName, &stat.Count, &stat.Host, &stat.HostLabels); err != nil {
+					return nil, err
+				}
+				stats = append(stats, stat)
+			}
+			return stats, rows.Err()
+		},
+	)
+}
+
+func (r *pgSyscallRepository) GetRecentProcessSyscallStats(limit int, filter models.Filter) ([]models.ProcessSyscallStat, error) {
+	var args pgArgs
+	conditions := pgHostClause(filter, "", &args)
+	return query(r.pool, `
+		SELECT id, timestamp, pid, comm, count, host, host_labels
+		FROM process_syscall_stats
+		WHERE 1 = 1`+conditions+`
+		ORDER BY id DESC
+		LIMIT `+args.bind(limit),
+		args, func(rows pgx.Rows) ([]models.ProcessSyscallStat, error) {
+			var stats []models.ProcessSyscallStat
+			for rows.Next() {
+				var stat models.ProcessSyscallStat
+				if err := rows.Scan(&stat.ID, &stat.Timestamp, &stat.PID, &stat.Comm, &stat.Count, &stat.Host, &stat.HostLabels); err != nil {
+					return nil, err
+				}
+				stats = append(stats, stat)
+			}
+			return stats, rows.Err()
+		},
+	)
+}
+
+type pgExitRepository struct {
+	pool pgConn
+}
+
+func (r *pgExitRepository) SaveExits(exits []models.ProcessExit) error {
+	columns := append([]string{"timestamp", "time", "pid", "ppid", "tid", "comm", "age_seconds", "exit_code", "signal",
+		"signal_name", "core_dumped"}, attributionNames...)
+	return copyRows(r.pool, "process_exits", columns, exits, func(e models.ProcessExit) []interface{} {
+		values := []interface{}{storedTime(e.Timestamp), e.Time, e.PID, e.PPID, e.TID, e.Comm, e.AgeSeconds,
+			e.ExitCode, e.Signal, e.SignalName, e.CoreDumped}
+		return append(values, attributionValues(e.Attribution)...)
+	})
+}
+
+func (r *pgExitRepository) GetRecentExits(limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+exitColumns+" FROM process_exits WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanExits),
+	)
+}
+
+// GetLifetimes joins exits with the latest exec of the same PID, with the
+// same slack as the SQLite query. The pid column of processes is TEXT.
+func (r *pgExitRepository) GetLifetimes(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.ProcessLifetime, error) {
+	var args pgArgs
+	where := "e.timestamp >= " + args.bind(columnTime(since)) + " AND e.timestamp <= " + args.bind(columnTime(until))
+	if comm != "" {
+		where += " AND e.comm = " + args.bind(comm)
+	}
+	where += pgFilterClause(filter, "e", &args)
+
+	return query(r.pool, `
+		SELECT e.pid, e.ppid, e.comm, e.timestamp, e.age_seconds, e.exit_code, e.signal,
+			e.signal_name, e.core_dumped, `+attributionSelect("e")+`,
+			(SELECT p.args FROM processes p
+				WHERE p.pid = e.pid::text
+				AND p.timestamp <= e.timestamp + interval '2 seconds'
+				AND p.timestamp >= e.timestamp - make_interval(secs => floor(e.age_seconds) + 2)
+				ORDER BY p.id DESC LIMIT 1)
+		FROM process_exits e
+		WHERE `+where+`
+		ORDER BY e.id DESC
+		LIMIT `+args.bind(limit),
+		args, func(rows pgx.Rows) ([]models.ProcessLifetime, error) {
+			var results []models.ProcessLifetime
+			for rows.Next() {
+				var (
+					l        models.ProcessLifetime
+					execArgs *string
+				)
+				dest := []interface{}{&l.PID, &l.PPID, &l.Comm, &l.ExitTime, &l.DurationSeconds,
+					&l.ExitCode, &l.Signal, &l.SignalName, &l.CoreDumped}
+				dest = append(dest, attributionDest(&l.Attribution)...)
+				if err := rows.Scan(append(dest, &execArgs)...); err != nil {
+					return nil, err
+				}
+				if execArgs != nil {
+					l.Args = *execArgs
+					l.ExecSeen = true
+				}
+				l.StartTime = l.ExitTime.Add(-time.Duration(l.DurationSeconds * float64(time.Second)))
+				results = append(results, l)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+// GetShortLivedStorms returns process names with at least minCount exits of
+// processes that lived no longer than maxAge seconds
+func (r *pgExitRepository) GetShortLivedStorms(since, until time.Time, maxAge float64, minCount int, filter models.Filter) ([]models.ShortLivedStorm, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) +
+		" AND age_seconds <= " + args.bind(maxAge) + pgFilterClause(filter, "", &args)
+
+	return query(r.pool, `
+		SELECT comm, COUNT(*), COUNT(DISTINCT ppid), AVG(age_seconds), MIN(timestamp), MAX(timestamp)
+		FROM process_exits
+		WHERE `+where+`
+		GROUP BY comm
+		HAVING COUNT(*) >= `+args.bind(minCount)+`
+		ORDER BY COUNT(*) DESC`,
+		args, func(rows pgx.Rows) ([]models.ShortLivedStorm, error) {
+			var results []models.ShortLivedStorm
+			for rows.Next() {
+				var s models.ShortLivedStorm
+				if err := rows.Scan(&s.Comm, &s.Count, &s.Parents, &s.AvgAgeSeconds, &s.FirstSeen, &s.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, s)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+// GetCrashLoops returns process names that exited with a non-zero code or a
+// signal at least minFailures times
+func (r *pgExitRepository) GetCrashLoops(since, until time.Time, minFailures int, filter models.Filter) ([]models.CrashLoop, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) +
+		" AND (exit_code != 0 OR signal != 0)" + pgFilterClause(filter, "", &args)
+
+	return query(r.pool, `
+		SELECT g.comm, g.failures, g.first_seen, g.last_seen, l.exit_code, l.signal
+		FROM (
+			SELECT comm, COUNT(*) AS failures, MIN(timestamp) AS first_seen,
+				MAX(timestamp) AS last_seen, MAX(id) AS last_id
+			FROM process_exits
+			WHERE `+where+`
+			GROUP BY comm
+			HAVING COUNT(*) >= `+args.bind(minFailures)+`
+		) g
+		JOIN process_exits l ON l.id = g.last_id
+		ORDER BY g.failures DESC`,
+		args, func(rows pgx.Rows) ([]models.CrashLoop, error) {
+			var results []models.CrashLoop
+			for rows.Next() {
+				var cl models.CrashLoop
+				if err := rows.Scan(&cl.Comm, &cl.Failures, &cl.FirstSeen, &cl.LastSeen, &cl.LastExitCode, &cl.LastSignal); err != nil {
+					return nil, err
+				}
+				results = append(results, cl)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+// GetKilled returns processes terminated by a signal, optionally a specific one
+func (r *pgExitRepository) GetKilled(since, until time.Time, signal int, limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) + " AND signal != 0"
+	if signal != 0 {
+		where += " AND signal = " + args.bind(signal)
+	}
+	where += pgFilterClause(filter, "", &args)
+
+	return query(r.pool,
+		"SELECT "+exitColumns+" FROM process_exits WHERE "+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanExits),
+	)
+}
+
+type pgFileRepository struct {
+	pool pgConn
+}
+
+func (r *pgFileRepository) SaveOpens(opens []models.FileOpen) error {
+	columns := append([]string{"timestamp", "time", "uid", "pid", "comm", "fd", "errno", "errno_name", "flags",
+		"flag_names", "path"}, attributionNames...)
+	return copyRows(r.pool, "file_opens", columns, opens, func(o models.FileOpen) []interface{} {
+		values := []interface{}{storedTime(o.Timestamp), o.Time, o.UID, o.PID, o.Comm, o.FD, o.Errno, o.ErrnoName,
+			o.Flags, o.FlagNames, o.Path}
+		return append(values, attributionValues(o.Attribution)...)
+	})
+}
+
+func (r *pgFileRepository) GetRecentOpens(limit int, filter models.Filter) ([]models.FileOpen, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+fileOpenColumns+" FROM file_opens WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanFileOpens),
+	)
+}
+
+func (r *pgFileRepository) GetOpens(q models.FileOpenQuery) ([]models.FileOpen, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.PID > 0 {
+		where += " AND pid = " + args.bind(q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	if q.Path != "" {
+		where += " AND path = " + args.bind(q.Path)
+	}
+	if q.PathPrefix != "" {
+		where += " AND starts_with(path, " + args.bind(q.PathPrefix) + ")"
+	}
+	if q.FailedOnly {
+		where += " AND errno != 0"
+	}
+	if q.Errno != "" {
+		where += " AND errno_name = " + args.bind(q.Errno)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+
+	return query(r.pool,
+		"SELECT "+fileOpenColumns+" FROM file_opens WHERE "+where+" ORDER BY timestamp DESC, id DESC LIMIT "+args.bind(q.Limit),
+		args, scanWith(scanFileOpens),
+	)
+}
+
+// GetFailures returns the paths failing to open most often, per error,
+// optionally of one error only
+func (r *pgFileRepository) GetFailures(since, until time.Time, errno string, minCount, limit int, filter models.Filter) ([]models.OpenFailure, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) + " AND errno != 0"
+	if errno != "" {
+		where += " AND errno_name = " + args.bind(errno)
+	}
+	where += pgFilterClause(filter, "", &args)
+
+	return query(r.pool, `
+		SELECT g.path, g.errno, g.errno_name, g.failures, g.commands, l.comm, g.first_seen, g.last_seen
+		FROM (
+			SELECT path, errno, MAX(errno_name) AS errno_name, COUNT(*) AS failures,
+				COUNT(DISTINCT comm) AS commands, MIN(timestamp) AS first_seen, MAX(timestamp) AS last_seen,
+				MAX(id) AS last_id
+			FROM file_opens
+			WHERE `+where+`
+			GROUP BY path, errno
+			HAVING COUNT(*) >= `+args.bind(minCount)+`
+		) g
+		JOIN file_opens l ON l.id = g.last_id
+		ORDER BY g.failures DESC, g.last_id DESC
+		LIMIT `+args.bind(limit),
+		args, func(rows pgx.Rows) ([]models.OpenFailure, error) {
+			var results []models.OpenFailure
+			for rows.Next() {
+				var f models.OpenFailure
+				if err := rows.Scan(&f.Path, &f.Errno, &f.ErrnoName, &f.Count, &f.Commands, &f.LastComm, &f.FirstSeen, &f.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, f)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+// GetAccessors returns the commands and users that opened path, most opens
+// first. Opens with O_WRONLY or O_RDWR count as writes.
+func (r *pgFileRepository) GetAccessors(path string, since, until time.Time, filter models.Filter) ([]models.FileAccessor, error) {
+	var args pgArgs
+	where := "path = " + args.bind(path) + " AND timestamp >= " + args.bind(columnTime(since)) +
+		" AND timestamp <= " + args.bind(columnTime(until)) + pgFilterClause(filter, "", &args)
+
+	return query(r.pool, `
+		SELECT comm, uid, COUNT(DISTINCT pid), COUNT(*), COUNT(*) FILTER (WHERE flags & 3 != 0),
+			COUNT(*) FILTER (WHERE errno != 0), MIN(timestamp), MAX(timestamp)
+		FROM file_opens
+		WHERE `+where+`
+		GROUP BY comm, uid
+		ORDER BY COUNT(*) DESC, comm, uid`,
+		args, func(rows pgx.Rows) ([]models.FileAccessor, error) {
+			var results []models.FileAccessor
+			for rows.Next() {
+				var a models.FileAccessor
+				if err := rows.Scan(&a.Comm, &a.UID, &a.PIDs, &a.Opens, &a.Writes, &a.Failures, &a.FirstSeen, &a.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, a)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+type pgRunqRepository struct {
+	pool pgConn
+}
+
+func (r *pgRunqRepository) SaveRunqSnapshot(latencies []models.RunqLatency) error {
+	columns := []string{"timestamp", "pidns", "cgroup", "range_min", "range_max", "count", "host", "host_labels"}
+	return copyRows(r.pool, "runq_latency", columns, latencies, func(lat models.RunqLatency) []interface{} {
+		return []interface{}{storedTime(lat.Timestamp), lat.PIDNS, lat.Cgroup, lat.RangeMin, lat.RangeMax, lat.Count,
+			lat.Host, lat.HostLabels}
+	})
+}
+
+func (r *pgRunqRepository) GetLatestRunq(limit int, filter models.Filter) ([]models.RunqLatency, error) {
+	var args pgArgs
+	conditions := pgHostClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+runqColumns+" FROM runq_latency WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanRunqLatency),
+	)
+}
+
+func (r *pgRunqRepository) GetRunqBuckets(q models.RunqQuery) ([]models.RunqLatency, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.PIDNS != 0 {
+		where += " AND pidns = " + args.bind(q.PIDNS)
+	}
+	where += pgHostClause(q.Filter, "", &args)
+	return query(r.pool,
+		"SELECT "+runqColumns+" FROM runq_latency WHERE "+where+" ORDER BY timestamp, id",
+		args, scanWith(scanRunqLatency),
+	)
+}
+
+type pgTCPRTTRepository struct {
+	pool pgConn
+}
+
+func (r *pgTCPRTTRepository) SaveRTTSnapshot(rtts []models.TCPRTT) error {
+	columns := []string{"timestamp", "remote_addr", "range_min", "range_max", "count", "host", "host_labels"}
+	return copyRows(r.pool, "tcp_rtt", columns, rtts, func(rtt models.TCPRTT) []interface{} {
+		return []interface{}{storedTime(rtt.Timestamp), rtt.RemoteAddr, rtt.RangeMin, rtt.RangeMax, rtt.Count,
+			rtt.Host, rtt.HostLabels}
+	})
+}
+
+func (r *pgTCPRTTRepository) GetLatestRTT(limit int, filter models.Filter) ([]models.TCPRTT, error) {
+	var args pgArgs
+	conditions := pgHostClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+tcpRTTColumns+" FROM tcp_rtt WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanTCPRTT),
+	)
+}
+
+func (r *pgTCPRTTRepository) GetRTTBuckets(q models.RTTQuery) ([]models.TCPRTT, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.RemoteAddr != "" {
+		where += " AND remote_addr = " + args.bind(q.RemoteAddr)
+	}
+	where += pgHostClause(q.Filter, "", &args)
+	return query(r.pool,
+		"SELECT "+tcpRTTColumns+" FROM tcp_rtt WHERE "+where+" ORDER BY timestamp, id",
+		args, scanWith(scanTCPRTT),
+	)
+}
+
+type pgOffCPURepository struct {
+	pool pgConn
+}
+
+func (r *pgOffCPURepository) SaveOffCPUStacks(stacks []models.OffCPUStack) error {
+	columns := append([]string{"timestamp", "comm", "stack", "total_us"}, attributionNames...)
+	return copyRows(r.pool, "offcpu_stacks", columns, stacks, func(s models.OffCPUStack) []interface{} {
+		values := []interface{}{storedTime(s.Timestamp), s.Comm, s.Stack, s.TotalUS}
+		return append(values, attributionValues(s.Attribution)...)
+	})
+}
+
+func (r *pgOffCPURepository) GetRecentOffCPUStacks(limit int, filter models.Filter) ([]models.OffCPUStack, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+offCPUColumns+" FROM offcpu_stacks WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanOffCPUStacks),
+	)
+}
+
+func (r *pgOffCPURepository) GetStackTotals(q models.StackQuery) ([]models.StackTotal, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+	return query(r.pool,
+		"SELECT comm, stack, SUM(total_us)::bigint FROM offcpu_stacks WHERE "+where+" GROUP BY comm, stack",
+		args, scanWith(scanStackTotals),
+	)
+}
+
+type pgTCPRetransRepository struct {
+	pool pgConn
+}
+
+func (r *pgTCPRetransRepository) SaveRetransmits(events []models.TCPRetransmit) error {
+	columns := append([]string{"timestamp", "time", "pid", "comm", "ip_version", "local_addr", "local_port",
+		"remote_addr", "remote_port", "state", "type"}, attributionNames...)
+	return copyRows(r.pool, "tcp_retransmits", columns, events, func(e models.TCPRetransmit) []interface{} {
+		values := []interface{}{storedTime(e.Timestamp), e.Time, e.PID, e.Comm, e.IPVersion, e.LocalAddr, e.LocalPort,
+			e.RemoteAddr, e.RemotePort, e.State, e.Type}
+		return append(values, attributionValues(e.Attribution)...)
+	})
+}
+
+func (r *pgTCPRetransRepository) GetRecentRetransmits(limit int, filter models.Filter) ([]models.TCPRetransmit, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+retransmitColumns+" FROM tcp_retransmits WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanRetransmits),
+	)
+}
+
+func (r *pgTCPRetransRepository) GetRetransmitCounts(q models.RetransmitQuery) ([]models.RetransmitCount, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.RemoteAddr != "" {
+		where += " AND remote_addr = " + args.bind(q.RemoteAddr)
+	}
+	if q.RemotePort != 0 {
+		where += " AND remote_port = " + args.bind(q.RemotePort)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+
+	return query(r.pool, `
+		SELECT timestamp, local_addr, local_port, remote_addr, remote_port, comm, type, COUNT(*)
+		FROM tcp_retransmits
+		WHERE `+where+`
+		GROUP BY timestamp, local_addr, local_port, remote_addr, remote_port, comm, type
+		ORDER BY timestamp`,
+		args, func(rows pgx.Rows) ([]models.RetransmitCount, error) {
+			var counts []models.RetransmitCount
+			for rows.Next() {
+				var c models.RetransmitCount
+				if err := rows.Scan(&c.Timestamp, &c.LocalAddr, &c.LocalPort, &c.RemoteAddr, &c.RemotePort, &c.Comm, &c.Type, &c.Count); err != nil {
+					return nil, err
+				}
+				counts = append(counts, c)
+			}
+			return counts, rows.Err()
+		},
+	)
+}
+
+func (r *pgTCPRetransRepository) SaveDrops(events []models.TCPDrop) error {
+	columns := append([]string{"timestamp", "time", "pid", "comm", "ip_version", "source_addr", "source_port",
+		"dest_addr", "dest_port", "state", "flags", "reason", "stack"}, attributionNames...)
+	return copyRows(r.pool, "tcp_drops", columns, events, func(e models.TCPDrop) []interface{} {
+		values := []interface{}{storedTime(e.Timestamp), e.Time, e.PID, e.Comm, e.IPVersion, e.SourceAddr, e.SourcePort,
+			e.DestAddr, e.DestPort, e.State, e.Flags, e.Reason, e.Stack}
+		return append(values, attributionValues(e.Attribution)...)
+	})
+}
+
+func (r *pgTCPRetransRepository) GetRecentDrops(limit int, filter models.Filter) ([]models.TCPDrop, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+dropColumns+" FROM tcp_drops WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanDrops),
+	)
+}
+
+func (r *pgTCPRetransRepository) GetDropSummary(since, until time.Time, limit int, filter models.Filter) ([]models.DropSummary, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) +
+		pgFilterClause(filter, "", &args)
+
+	return query(r.pool, `
+		SELECT reason, state, stack, COUNT(*), MAX(timestamp)
+		FROM tcp_drops
+		WHERE `+where+`
+		GROUP BY reason, state, stack
+		ORDER BY COUNT(*) DESC
+		LIMIT `+args.bind(limit),
+		args, func(rows pgx.Rows) ([]models.DropSummary, error) {
+			var results []models.DropSummary
+			for rows.Next() {
+				var d models.DropSummary
+				if err := rows.Scan(&d.Reason, &d.State, &d.Stack, &d.Count, &d.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, d)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+type pgInboundRepository struct {
+	pool pgConn
+}
+
+func (r *pgInboundRepository) SaveInbound(events []models.InboundConnection) error {
+	columns := append([]string{"timestamp", "pid", "comm", "ip_version", "remote_addr", "remote_port",
+		"local_addr", "local_port"}, attributionNames...)
+	return copyRows(r.pool, "inbound_connections", columns, events, func(e models.InboundConnection) []interface{} {
+		values := []interface{}{storedTime(e.Timestamp), e.PID, e.Comm, e.IPVersion, e.RemoteAddr, e.RemotePort,
+			e.LocalAddr, e.LocalPort}
+		return append(values, attributionValues(e.Attribution)...)
+	})
+}
+
+func (r *pgInboundRepository) GetRecentInbound(limit int, filter models.Filter) ([]models.InboundConnection, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+inboundColumns+" FROM inbound_connections WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanInbound),
+	)
+}
+
+func (r *pgInboundRepository) GetServiceClients(q models.InboundQuery) ([]models.ServiceClient, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.LocalPort != 0 {
+		where += " AND local_port = " + args.bind(q.LocalPort)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+
+	return query(r.pool, `
+		SELECT local_port, comm, remote_addr, COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM inbound_connections
+		WHERE `+where+`
+		GROUP BY local_port, comm, remote_addr
+		ORDER BY COUNT(*) DESC, local_port, remote_addr
+		LIMIT `+args.bind(q.Limit),
+		args, func(rows pgx.Rows) ([]models.ServiceClient, error) {
+			var clients []models.ServiceClient
+			for rows.Next() {
+				var c models.ServiceClient
+				if err := rows.Scan(&c.LocalPort, &c.Comm, &c.RemoteAddr, &c.Connections, &c.FirstSeen, &c.LastSeen); err != nil {
+					return nil, err
+				}
+				clients = append(clients, c)
+			}
+			return clients, rows.Err()
+		},
+	)
+}
+
+func (r *pgInboundRepository) ReplaceListeners(host string, sockets []models.ListeningSocket, at time.Time) ([]models.ListenEvent, error) {
+	at = columnTime(at)
+
+	var events []models.ListenEvent
+	err := pgx.BeginFunc(context.Background(), r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(context.Background(),
+			"SELECT "+listenerColumns+" FROM listening_sockets WHERE host = $1 FOR UPDATE", host)
+		if err != nil {
+			return err
+		}
+		previous, err := scanListeners(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		var current []models.ListeningSocket
+		current, events = diffListeners(previous, sockets, at)
+
+		batch := &pgx.Batch{}
+		batch.Queue(`DELETE FROM listening_sockets WHERE host = $1`, host)
+		for _, s := range current {
+			values := []interface{}{s.Addr, s.Port, s.PID, s.Comm, s.UID, s.FirstSeen, s.LastSeen}
+			batch.Queue(`
+				INSERT INTO listening_sockets (addr, port, pid, comm, uid, first_seen, last_seen, `+attributionInsertColumns+`)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+				append(values, attributionValues(s.Attribution)...)...,
+			)
+		}
+		for _, e := range events {
+			values := []interface{}{e.Timestamp, e.Kind, e.Addr, e.Port, e.PID, e.Comm, e.UID}
+			batch.Queue(`
+				INSERT INTO listen_events (timestamp, kind, addr, port, pid, comm, uid, `+attributionInsertColumns+`)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+				append(values, attributionValues(e.Attribution)...)...,
+			)
+		}
+		return tx.SendBatch(context.Background(), batch).Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *pgInboundRepository) GetListeners(filter models.Filter) ([]models.ListeningSocket, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+listenerColumns+" FROM listening_sockets WHERE 1 = 1"+conditions+" ORDER BY host, port, addr",
+		args, scanWith(scanListeners),
+	)
+}
+
+func (r *pgInboundRepository) GetListenEvents(since, until time.Time, kind string, limit int, filter models.Filter) ([]models.ListenEvent, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until))
+	if kind != "" {
+		where += " AND kind = " + args.bind(kind)
+	}
+	where += pgFilterClause(filter, "", &args)
+
+	return query(r.pool,
+		"SELECT "+listenEventColumns+" FROM listen_events WHERE "+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanListenEvents),
+	)
+}
+
+type pgTCPStateRepository struct {
+	pool pgConn
+}
+
+func (r *pgTCPStateRepository) SaveStateChanges(events []models.TCPStateChange) error {
+	columns := append([]string{"timestamp", "skaddr", "pid", "comm", "local_addr", "local_port", "remote_addr",
+		"remote_port", "old_state", "new_state", "duration_ms"}, attributionNames...)
+	return copyRows(r.pool, "tcp_state_changes", columns, events, func(e models.TCPStateChange) []interface{} {
+		values := []interface{}{storedTime(e.Timestamp), e.SKAddr, e.PID, e.Comm, e.LocalAddr, e.LocalPort, e.RemoteAddr,
+			e.RemotePort, e.OldState, e.NewState, e.DurationMS}
+		return append(values, attributionValues(e.Attribution)...)
+	})
+}
+
+func (r *pgTCPStateRepository) GetRecentStateChanges(limit int, filter models.Filter) ([]models.TCPStateChange, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+stateChangeColumns+" FROM tcp_state_changes WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanStateChanges),
+	)
+}
+
+func (r *pgTCPStateRepository) GetStateCounts(q models.StateQuery) ([]models.StateCount, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.State != "" {
+		where += " AND new_state = " + args.bind(q.State)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+
+	return query(r.pool, `
+		SELECT timestamp, new_state, comm, COUNT(*)
+		FROM tcp_state_changes
+		WHERE `+where+`
+		GROUP BY timestamp, new_state, comm
+		ORDER BY timestamp`,
+		args, func(rows pgx.Rows) ([]models.StateCount, error) {
+			var counts []models.StateCount
+			for rows.Next() {
+				var c models.StateCount
+				if err := rows.Scan(&c.Timestamp, &c.State, &c.Comm, &c.Count); err != nil {
+					return nil, err
+				}
+				counts = append(counts, c)
+			}
+			return counts, rows.Err()
+		},
+	)
+}
+
+func (r *pgTCPStateRepository) GetStuckConnections(q models.StuckQuery) ([]models.TCPStateChange, error) {
+	var args pgArgs
+	since := args.bind(columnTime(q.Since))
+	states := make([]string, len(q.States))
+	for i, state := range q.States {
+		states[i] = args.bind(state)
+	}
+	where := "new_state IN (" + strings.Join(states, ", ") + ") AND timestamp <= " + args.bind(columnTime(q.Before)) +
+		pgFilterClause(q.Filter, "", &args)
+
+	return query(r.pool, `
+		SELECT `+stateChangeColumns+`
+		FROM tcp_state_changes
+		WHERE id IN (SELECT MAX(id) FROM tcp_state_changes WHERE timestamp >= `+since+` GROUP BY host, skaddr)
+			AND `+where+`
+		ORDER BY timestamp, id
+		LIMIT `+args.bind(q.Limit),
+		args, scanWith(scanStateChanges),
+	)
+}
+
+type pgDNSRepository struct {
+	pool pgConn
+}
+
+func (r *pgDNSRepository) SaveLookups(lookups []models.DNSLookup) error {
+	columns := append([]string{"timestamp", "time", "pid", "comm", "hostname", "latency_ms"}, attributionNames...)
+	return copyRows(r.pool, "dns_lookups", columns, lookups, func(l models.DNSLookup) []interface{} {
+		values := []interface{}{storedTime(l.Timestamp), l.Time, l.PID, l.Comm, l.Hostname, l.LatencyMS}
+		return append(values, attributionValues(l.Attribution)...)
+	})
+}
+
+func (r *pgDNSRepository) GetRecentLookups(limit int, filter models.Filter) ([]models.DNSLookup, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+dnsLookupColumns+" FROM dns_lookups WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanDNSLookups),
+	)
+}
+
+func (r *pgDNSRepository) GetSlowLookups(q models.DNSQuery) ([]models.DNSLookup, error) {
+	var args pgArgs
+	where := pgDNSWhere(q, &args)
+	return query(r.pool,
+		"SELECT "+dnsLookupColumns+" FROM dns_lookups WHERE "+where+" ORDER BY latency_ms DESC LIMIT "+args.bind(q.Limit),
+		args, scanWith(scanDNSLookups),
+	)
+}
+
+func (r *pgDNSRepository) GetHostStats(q models.DNSQuery) ([]models.DNSHostStats, error) {
+	var args pgArgs
+	where := pgDNSWhere(q, &args)
+	return query(r.pool, `
+		SELECT hostname, COUNT(*), AVG(latency_ms), MAX(latency_ms), MAX(timestamp)
+		FROM dns_lookups
+		WHERE `+where+`
+		GROUP BY hostname
+		ORDER BY AVG(latency_ms) DESC, hostname
+		LIMIT `+args.bind(q.Limit),
+		args, func(rows pgx.Rows) ([]models.DNSHostStats, error) {
+			var results []models.DNSHostStats
+			for rows.Next() {
+				var h models.DNSHostStats
+				if err := rows.Scan(&h.Hostname, &h.Lookups, &h.AvgLatencyMS, &h.MaxLatencyMS, &h.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, h)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+// pgDNSWhere is the PostgreSQL counterpart of dnsWhere
+func pgDNSWhere(q models.DNSQuery, args *pgArgs) string {
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.Hostname != "" {
+		where += " AND hostname = " + args.bind(q.Hostname)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	if q.MinLatencyMS > 0 {
+		where += " AND latency_ms >= " + args.bind(q.MinLatencyMS)
+	}
+	return where + pgFilterClause(q.Filter, "", args)
+}
+
+type pgKillRepository struct {
+	pool pgConn
+}
+
+func (r *pgKillRepository) SaveOOMKills(kills []models.OOMKill) error {
+	columns := append([]string{"timestamp", "time", "pid", "comm", "trigger_pid", "trigger_comm", "pages", "trigger_cgroup"}, attributionNames...)
+	return copyRows(r.pool, "oom_kills", columns, kills, func(k models.OOMKill) []interface{} {
+		values := []interface{}{storedTime(k.Timestamp), k.Time, k.PID, k.Comm, k.TriggerPID, k.TriggerComm, k.Pages, k.TriggerCgroup}
+		return append(values, attributionValues(k.Attribution)...)
+	})
+}
+
+func (r *pgKillRepository) GetRecentOOMKills(limit int, filter models.Filter) ([]models.OOMKill, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+oomKillColumns+" FROM oom_kills WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanOOMKills),
+	)
+}
+
+func (r *pgKillRepository) GetOOMKills(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.OOMKill, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until))
+	if comm != "" {
+		where += " AND comm = " + args.bind(comm)
+	}
+	where += pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+oomKillColumns+" FROM oom_kills WHERE "+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanOOMKills),
+	)
+}
+
+func (r *pgKillRepository) SaveSignals(signals []models.Signal) error {
+	columns := append([]string{"timestamp", "time", "pid", "comm", "sender_pid", "sender_comm", "signal", "signal_name",
+		"errno", "errno_name"}, attributionNames...)
+	return copyRows(r.pool, "signals", columns, signals, func(s models.Signal) []interface{} {
+		values := []interface{}{storedTime(s.Timestamp), s.Time, s.PID, s.Comm, s.SenderPID, s.SenderComm,
+			s.Signal, s.SignalName, s.Errno, s.ErrnoName}
+		return append(values, attributionValues(s.Attribution)...)
+	})
+}
+
+func (r *pgKillRepository) GetRecentSignals(limit int, filter models.Filter) ([]models.Signal, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+signalColumns+" FROM signals WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanSignals),
+	)
+}
+
+func (r *pgKillRepository) GetSignals(q models.SignalQuery) ([]models.Signal, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.PID != 0 {
+		where += " AND pid = " + args.bind(q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	if q.SenderComm != "" {
+		where += " AND sender_comm = " + args.bind(q.SenderComm)
+	}
+	if q.Signal != 0 {
+		where += " AND signal = " + args.bind(q.Signal)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+	return query(r.pool,
+		"SELECT "+signalColumns+" FROM signals WHERE "+where+" ORDER BY id DESC LIMIT "+args.bind(q.Limit),
+		args, scanWith(scanSignals),
+	)
+}
+
+type pgFSLatencyRepository struct {
+	pool pgConn
+}
+
+func (r *pgFSLatencyRepository) SaveSlowOps(ops []models.SlowFileOp) error {
+	columns := append([]string{"timestamp", "time", "pid", "comm", "filesystem", "op", "bytes", "offset_kb",
+		"latency_ms", "file"}, attributionNames...)
+	return copyRows(r.pool, "fs_slow_ops", columns, ops, func(o models.SlowFileOp) []interface{} {
+		values := []interface{}{storedTime(o.Timestamp), o.Time, o.PID, o.Comm, o.Filesystem, o.Op,
+			o.Bytes, o.OffsetKB, o.LatencyMS, o.File}
+		return append(values, attributionValues(o.Attribution)...)
+	})
+}
+
+func (r *pgFSLatencyRepository) GetRecentSlowOps(limit int, filter models.Filter) ([]models.SlowFileOp, error) {
+	var args pgArgs
+	conditions := pgFilterClause(filter, "", &args)
+	return query(r.pool,
+		"SELECT "+slowFileOpColumns+" FROM fs_slow_ops WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanSlowFileOps),
+	)
+}
+
+func (r *pgFSLatencyRepository) GetSlowFiles(q models.SlowFileOpQuery) ([]models.SlowFile, error) {
+	var args pgArgs
+	where := pgSlowFileOpWhere(q, &args)
+	return query(r.pool, `
+		SELECT file, filesystem, COUNT(*), COUNT(DISTINCT comm), SUM(bytes)::bigint,
+			AVG(latency_ms), MAX(latency_ms), SUM(latency_ms), MAX(timestamp)
+		FROM fs_slow_ops
+		WHERE `+where+`
+		GROUP BY file, filesystem
+		ORDER BY SUM(latency_ms) DESC, file
+		LIMIT `+args.bind(q.Limit),
+		args, func(rows pgx.Rows) ([]models.SlowFile, error) {
+			var results []models.SlowFile
+			for rows.Next() {
+				var f models.SlowFile
+				if err := rows.Scan(&f.File, &f.Filesystem, &f.Ops, &f.Processes, &f.Bytes,
+					&f.AvgLatencyMS, &f.MaxLatencyMS, &f.TotalLatencyMS, &f.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, f)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+func (r *pgFSLatencyRepository) GetProcessLatency(q models.SlowFileOpQuery) ([]models.ProcessFSLatency, error) {
+	var args pgArgs
+	where := pgSlowFileOpWhere(q, &args)
+	return query(r.pool, `
+		SELECT pid, comm, COUNT(*),
+			COUNT(*) FILTER (WHERE op = 'R'), COUNT(*) FILTER (WHERE op = 'W'),
+			COUNT(*) FILTER (WHERE op = 'O'), COUNT(*) FILTER (WHERE op = 'S'),
+			COUNT(DISTINCT file), AVG(latency_ms), MAX(latency_ms), SUM(latency_ms), MAX(timestamp)
+		FROM fs_slow_ops
+		WHERE `+where+`
+		GROUP BY pid, comm
+		ORDER BY SUM(latency_ms) DESC, pid
+		LIMIT `+args.bind(q.Limit),
+		args, func(rows pgx.Rows) ([]models.ProcessFSLatency, error) {
+			var results []models.ProcessFSLatency
+			for rows.Next() {
+				var p models.ProcessFSLatency
+				if err := rows.Scan(&p.PID, &p.Comm, &p.Ops, &p.Reads, &p.Writes, &p.Opens, &p.Syncs, &p.Files,
+					&p.AvgLatencyMS, &p.MaxLatencyMS, &p.TotalLatencyMS, &p.LastSeen); err != nil {
+					return nil, err
+				}
+				results = append(results, p)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+// pgSlowFileOpWhere is the PostgreSQL counterpart of slowFileOpWhere
+func pgSlowFileOpWhere(q models.SlowFileOpQuery, args *pgArgs) string {
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	if q.Filesystem != "" {
+		where += " AND filesystem = " + args.bind(q.Filesystem)
+	}
+	if q.Op != "" {
+		where += " AND op = " + args.bind(q.Op)
+	}
+	return where + pgFilterClause(q.Filter, "", args)
+}
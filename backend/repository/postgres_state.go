@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type pgAlertRepository struct {
+	pool pgConn
+}
+
+func (r *pgAlertRepository) CreateRule(rule *models.AlertRule) error {
+	match, err := json.Marshal(rule.Match)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := queryRow(r.pool, `
+		INSERT INTO alert_rules (name, description, source, match, group_by, window_seconds, operator,
+			threshold, for_seconds, severity, enabled, webhook_url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+		RETURNING id`,
+		[]interface{}{rule.Name, rule.Description, rule.Source, string(match), rule.GroupBy, rule.WindowSeconds, rule.Operator,
+			rule.Threshold, rule.ForSeconds, rule.Severity, rule.Enabled, rule.WebhookURL, now},
+		&rule.ID,
+	); err != nil {
+		return err
+	}
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return nil
+}
+
+func (r *pgAlertRepository) UpdateRule(rule *models.AlertRule) error {
+	match, err := json.Marshal(rule.Match)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := pgRequireRow(r.pool.Exec(context.Background(), `
+		UPDATE alert_rules SET name = $1, description = $2, source = $3, match = $4, group_by = $5, window_seconds = $6,
+			operator = $7, threshold = $8, for_seconds = $9, severity = $10, enabled = $11, webhook_url = $12, updated_at = $13
+		WHERE id = $14`,
+		rule.Name, rule.Description, rule.Source, string(match), rule.GroupBy, rule.WindowSeconds,
+		rule.Operator, rule.Threshold, rule.ForSeconds, rule.Severity, rule.Enabled, rule.WebhookURL, now,
+		rule.ID,
+	)); err != nil {
+		return err
+	}
+	rule.UpdatedAt = now
+	return nil
+}
+
+func (r *pgAlertRepository) DeleteRule(id int) error {
+	return pgRequireRow(r.pool.Exec(context.Background(), `DELETE FROM alert_rules WHERE id = $1`, id))
+}
+
+func (r *pgAlertRepository) GetRule(id int) (models.AlertRule, error) {
+	rules, err := query(r.pool, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`,
+		[]interface{}{id}, scanWith(scanAlertRules))
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	if len(rules) == 0 {
+		return models.AlertRule{}, ErrNotFound
+	}
+	return rules[0], nil
+}
+
+func (r *pgAlertRepository) GetRules() ([]models.AlertRule, error) {
+	return query(r.pool, `SELECT `+alertRuleColumns+` FROM alert_rules ORDER BY id`, nil, scanWith(scanAlertRules))
+}
+
+// SaveAlert inserts a new alert or updates an existing one
+func (r *pgAlertRepository) SaveAlert(alert *models.Alert) error {
+	values := []interface{}{alert.RuleID, alert.RuleName, alert.Severity, alert.GroupKey, alert.State, alert.Value,
+		alert.Summary, columnTime(alert.StartedAt), pgNullableTime(alert.FiredAt), pgNullableTime(alert.ResolvedAt),
+		columnTime(alert.UpdatedAt)}
+
+	if alert.ID != 0 {
+		_, err := r.pool.Exec(context.Background(), `
+			UPDATE alerts SET rule_id = $1, rule_name = $2, severity = $3, group_key = $4, state = $5, value = $6,
+				summary = $7, started_at = $8, fired_at = $9, resolved_at = $10, updated_at = $11
+			WHERE id = $12`,
+			append(values, alert.ID)...,
+		)
+		return err
+	}
+
+	return queryRow(r.pool, `
+		INSERT INTO alerts (rule_id, rule_name, severity, group_key, state, value, summary,
+			started_at, fired_at, resolved_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`,
+		values, &alert.ID,
+	)
+}
+
+// DeleteAlert removes an alert, used for pending alerts that never fired
+func (r *pgAlertRepository) DeleteAlert(id int) error {
+	_, err := r.pool.Exec(context.Background(), `DELETE FROM alerts WHERE id = $1`, id)
+	return err
+}
+
+// GetAlerts returns alerts started since the given time, optionally in one state
+func (r *pgAlertRepository) GetAlerts(state string, since time.Time, limit int) ([]models.Alert, error) {
+	var args pgArgs
+	where := "(started_at >= " + args.bind(columnTime(since)) + " OR state != " + args.bind(models.AlertResolved) + ")"
+	if state != "" {
+		where += " AND state = " + args.bind(state)
+	}
+	return query(r.pool,
+		`SELECT `+alertColumns+` FROM alerts WHERE `+where+` ORDER BY id DESC LIMIT `+args.bind(limit),
+		args, scanWith(scanAlerts),
+	)
+}
+
+// GetActiveAlerts returns all pending and firing alerts
+func (r *pgAlertRepository) GetActiveAlerts() ([]models.Alert, error) {
+	return query(r.pool,
+		`SELECT `+alertColumns+` FROM alerts WHERE state IN ($1, $2) ORDER BY id`,
+		[]interface{}{models.AlertPending, models.AlertFiring}, scanWith(scanAlerts),
+	)
+}
+
+func pgNullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return columnTime(*t)
+}
+
+// pgRequireRow is the PostgreSQL counterpart of requireRow
+func pgRequireRow(tag pgconn.CommandTag, err error) error {
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type pgBaselineRepository struct {
+	pool pgConn
+}
+
+// SaveBaselines inserts or replaces baselines by metric, subject and slot
+func (r *pgBaselineRepository) SaveBaselines(baselines []models.Baseline) error {
+	if len(baselines) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, b := range baselines {
+		batch.Queue(`
+			INSERT INTO baselines (metric, subject, slot, mean, stddev, samples, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (metric, subject, slot) DO UPDATE SET mean = excluded.mean, stddev = excluded.stddev,
+				samples = excluded.samples, updated_at = excluded.updated_at`,
+			b.Metric, b.Subject, b.Slot, b.Mean, b.StdDev, b.Samples, columnTime(b.UpdatedAt))
+	}
+	return sendBatch(r.pool, batch)
+}
+
+// GetBaselines returns the baselines of a metric and subject, or all of them
+// if they are empty
+func (r *pgBaselineRepository) GetBaselines(metric, subject string) ([]models.Baseline, error) {
+	var args pgArgs
+	where := "1 = 1"
+	if metric != "" {
+		where += " AND metric = " + args.bind(metric)
+	}
+	if subject != "" {
+		where += " AND subject = " + args.bind(subject)
+	}
+	return query(r.pool, `
+		SELECT metric, subject, slot, mean, stddev, samples, updated_at
+		FROM baselines
+		WHERE `+where+`
+		ORDER BY metric, subject, slot`,
+		args, func(rows pgx.Rows) ([]models.Baseline, error) {
+			var baselines []models.Baseline
+			for rows.Next() {
+				var b models.Baseline
+				if err := rows.Scan(&b.Metric, &b.Subject, &b.Slot, &b.Mean, &b.StdDev, &b.Samples, &b.UpdatedAt); err != nil {
+					return nil, err
+				}
+				baselines = append(baselines, b)
+			}
+			return baselines, rows.Err()
+		},
+	)
+}
+
+// SaveDestPorts records destination ports seen for the first time per command
+func (r *pgBaselineRepository) SaveDestPorts(ports map[string][]int, firstSeen time.Time) error {
+	batch := &pgx.Batch{}
+	for comm, list := range ports {
+		for _, port := range list {
+			batch.Queue(`
+				INSERT INTO baseline_dest_ports (comm, port, first_seen) VALUES ($1, $2, $3)
+				ON CONFLICT (comm, port) DO NOTHING`,
+				comm, port, columnTime(firstSeen))
+		}
+	}
+	return sendBatch(r.pool, batch)
+}
+
+// GetDestPorts returns the known destination ports per command
+func (r *pgBaselineRepository) GetDestPorts() (map[string][]int, error) {
+	rows, err := r.pool.Query(context.Background(), `SELECT comm, port FROM baseline_dest_ports`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ports := make(map[string][]int)
+	for rows.Next() {
+		var (
+			comm string
+			port int
+		)
+		if err := rows.Scan(&comm, &port); err != nil {
+			return nil, err
+		}
+		ports[comm] = append(ports[comm], port)
+	}
+	return ports, rows.Err()
+}
+
+// SaveAnomalies saves detected anomalies to the database
+func (r *pgBaselineRepository) SaveAnomalies(anomalies []models.Anomaly) error {
+	columns := []string{"timestamp", "metric", "subject", "comm", "value", "expected", "stddev", "score", "method", "slot"}
+	return copyRows(r.pool, "anomalies", columns, anomalies, func(a models.Anomaly) []interface{} {
+		return []interface{}{columnTime(a.Timestamp), a.Metric, a.Subject, a.Comm, a.Value, a.Expected,
+			a.StdDev, a.Score, a.Method, a.Slot}
+	})
+}
+
+// GetAnomalies returns anomalies within a time window, newest first,
+// optionally of one metric or command
+func (r *pgBaselineRepository) GetAnomalies(since, until time.Time, metric, comm string, limit int) ([]models.Anomaly, error) {
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until))
+	if metric != "" {
+		where += " AND metric = " + args.bind(metric)
+	}
+	if comm != "" {
+		where += " AND comm = " + args.bind(comm)
+	}
+	return query(r.pool,
+		`SELECT `+anomalyColumns+` FROM anomalies WHERE `+where+` ORDER BY timestamp DESC, id DESC LIMIT `+args.bind(limit),
+		args, scanWith(scanAnomalies),
+	)
+}
+
+type pgFindingRepository struct {
+	pool pgConn
+}
+
+// SaveFindings copies multiple findings to the database
+func (r *pgFindingRepository) SaveFindings(findings []models.Finding) error {
+	columns := append([]string{"timestamp", "rule", "title", "severity", "techniques", "pid", "ppid", "uid", "comm", "detail"},
+		attributionNames...)
+	return copyRows(r.pool, "findings", columns, findings, func(f models.Finding) []interface{} {
+		values := []interface{}{columnTime(f.Timestamp), f.Rule, f.Title, f.Severity, strings.Join(f.Techniques, ","),
+			f.PID, f.PPID, f.UID, f.Comm, f.Detail}
+		return append(values, attributionValues(f.Attribution)...)
+	})
+}
+
+// GetFindings returns the findings selected by q, newest first
+func (r *pgFindingRepository) GetFindings(q models.FindingQuery) ([]models.Finding, error) {
+	var args pgArgs
+	where := " timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.Severity != "" {
+		var severities []string
+		for _, severity := range []string{models.SeverityLow, models.SeverityMedium, models.SeverityHigh, models.SeverityCritical} {
+			if models.SeverityRank(severity) >= models.SeverityRank(q.Severity) {
+				severities = append(severities, args.bind(severity))
+			}
+		}
+		where += " AND severity IN (" + strings.Join(severities, ", ") + ")"
+	}
+	if q.Rule != "" {
+		where += " AND rule = " + args.bind(q.Rule)
+	}
+	if q.PID > 0 {
+		where += " AND pid = " + args.bind(q.PID)
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgFilterClause(q.Filter, "", &args)
+
+	return query(r.pool,
+		"SELECT "+findingColumns+" FROM findings WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT "+args.bind(q.Limit),
+		args, scanWith(scanFindings),
+	)
+}
+
+type pgAgentRepository struct {
+	pool pgConn
+}
+
+// Register inserts an agent, or updates the host, labels and versions of a
+// known one
+func (r *pgAgentRepository) Register(reg models.AgentRegistration, at time.Time) error {
+	_, err := r.pool.Exec(context.Background(), `
+		INSERT INTO agents (id, host, labels, kernel, bcc_version, registered_at, last_heartbeat)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (id) DO UPDATE SET host = excluded.host, labels = excluded.labels,
+			kernel = excluded.kernel, bcc_version = excluded.bcc_version,
+			last_heartbeat = excluded.last_heartbeat`,
+		reg.ID, reg.Host, reg.Labels, reg.Kernel, reg.BCCVersion, columnTime(at),
+	)
+	return err
+}
+
+// Heartbeat records that an agent is alive, along with the state of its
+// collectors
+func (r *pgAgentRepository) Heartbeat(id string, hb models.AgentHeartbeat, at time.Time) error {
+	collectors, err := json.Marshal(hb.Collectors)
+	if err != nil {
+		return err
+	}
+	return pgRequireRow(r.pool.Exec(context.Background(),
+		`UPDATE agents SET last_heartbeat = $1, spooled = $2, collectors = $3 WHERE id = $4`,
+		columnTime(at), hb.Spooled, string(collectors), id))
+}
+
+// RecordBatch counts a batch received from an agent
+func (r *pgAgentRepository) RecordBatch(id string, events int, at time.Time) error {
+	return pgRequireRow(r.pool.Exec(context.Background(), `
+		UPDATE agents SET last_batch_at = $1, batches = batches + 1, events = events + $2
+		WHERE id = $3`,
+		columnTime(at), events, id))
+}
+
+const pgAgentColumns = `id, host, labels, kernel, bcc_version, registered_at, last_heartbeat, last_batch_at,
+	batches, events, spooled, COALESCE(collectors, 'null')`
+
+func (r *pgAgentRepository) GetAgent(id string) (models.Agent, error) {
+	agents, err := query(r.pool, `SELECT `+pgAgentColumns+` FROM agents WHERE id = $1`, []interface{}{id}, pgScanAgents)
+	if err != nil {
+		return models.Agent{}, err
+	}
+	if len(agents) == 0 {
+		return models.Agent{}, ErrNotFound
+	}
+	return agents[0], nil
+}
+
+// GetAgents returns all agents ordered by host
+func (r *pgAgentRepository) GetAgents() ([]models.Agent, error) {
+	return query(r.pool, `SELECT `+pgAgentColumns+` FROM agents ORDER BY host, id`, nil, pgScanAgents)
+}
+
+func pgScanAgents(rows pgx.Rows) ([]models.Agent, error) {
+	var agents []models.Agent
+	for rows.Next() {
+		var (
+			a          models.Agent
+			lastBatch  *time.Time
+			collectors string
+		)
+		if err := rows.Scan(&a.ID, &a.Host, &a.Labels, &a.Kernel, &a.BCCVersion, &a.RegisteredAt, &a.LastHeartbeat,
+			&lastBatch, &a.Batches, &a.Events, &a.Spooled, &collectors); err != nil {
+			return nil, err
+		}
+		if lastBatch != nil {
+			a.LastBatchAt = *lastBatch
+		}
+		if err := json.Unmarshal([]byte(collectors), &a.Collectors); err != nil {
+			return nil, err
+		}
+		agents = append(agents, a)
+	}
+	return agents, rows.Err()
+}
+
+type pgBehaviorRepository struct {
+	pool pgConn
+}
+
+// SaveProfiles inserts or updates profiles by command name
+func (r *pgBehaviorRepository) SaveProfiles(profiles []models.BehaviorProfile) error {
+	batch := &pgx.Batch{}
+	for _, p := range profiles {
+		children, err := json.Marshal(p.Children)
+		if err != nil {
+			return err
+		}
+		connect, err := json.Marshal(p.Connect)
+		if err != nil {
+			return err
+		}
+		syscalls, err := json.Marshal(p.Syscalls)
+		if err != nil {
+			return err
+		}
+		batch.Queue(`
+			INSERT INTO behavior_profiles (comm, mode, children, connect, syscalls, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (comm) DO UPDATE SET mode = excluded.mode, children = excluded.children,
+				connect = excluded.connect, syscalls = excluded.syscalls, updated_at = excluded.updated_at`,
+			p.Comm, p.Mode, string(children), string(connect), string(syscalls),
+			columnTime(p.CreatedAt), columnTime(p.UpdatedAt))
+	}
+	return sendBatch(r.pool, batch)
+}
+
+// GetProfiles returns all profiles ordered by command name
+func (r *pgBehaviorRepository) GetProfiles() ([]models.BehaviorProfile, error) {
+	return query(r.pool, `SELECT `+behaviorProfileColumns+` FROM behavior_profiles ORDER BY comm`, nil,
+		scanWith(scanBehaviorProfiles))
+}
+
+func (r *pgBehaviorRepository) DeleteProfile(comm string) error {
+	return pgRequireRow(r.pool.Exec(context.Background(), `DELETE FROM behavior_profiles WHERE comm = $1`, comm))
+}
+
+// sendBatch runs the statements of batch in one transaction
+func sendBatch(pool pgConn, batch *pgx.Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	return pgx.BeginFunc(context.Background(), pool, func(tx pgx.Tx) error {
+		return tx.SendBatch(context.Background(), batch).Close()
+	})
+}
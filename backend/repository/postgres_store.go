@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// pgConn is what the repositories use of a pool. A transaction implements it
+// too, so the saves of an agent batch can share one.
+type pgConn interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// NewPostgresStores returns the repositories backed by the PostgreSQL
+// database behind pool, which database.InitPostgres prepares
+func NewPostgresStores(pool *pgxpool.Pool, thresholds TimelineThresholds) Stores {
+	stores := newPostgresStores(pool, thresholds)
+	stores.Batches = &pgBatchWriter{pool: pool, thresholds: thresholds}
+	return stores
+}
+
+// pgBatchWriter runs the saves of a batch in one transaction
+type pgBatchWriter struct {
+	pool       *pgxpool.Pool
+	thresholds TimelineThresholds
+}
+
+func (w *pgBatchWriter) WriteBatch(fn func(stores Stores) error) error {
+	return pgx.BeginFunc(context.Background(), w.pool, func(tx pgx.Tx) error {
+		return fn(newPostgresStores(tx, w.thresholds))
+	})
+}
+
+func newPostgresStores(pool pgConn, thresholds TimelineThresholds) Stores {
+	return Stores{
+		Processes:   &pgProcessRepository{pool: pool},
+		Connections: &pgNetworkRepository{pool: pool},
+		Disk:        &pgDiskRepository{pool: pool},
+		CPUProfiles: &pgCPUProfileRepository{pool: pool},
+		TCPSessions: &pgTCPLifeRepository{pool: pool},
+		Syscalls:    &pgSyscallRepository{pool: pool},
+		Exits:       &pgExitRepository{pool: pool},
+		Attribution: &pgAttributionRepository{pool: pool},
+		Profiles:    &pgProfileRepository{pool: pool},
+		Alerts:      &pgAlertRepository{pool: pool},
+		Baselines:   &pgBaselineRepository{pool: pool},
+		Findings:    &pgFindingRepository{pool: pool},
+		Agents:      &pgAgentRepository{pool: pool},
+		Behavior:    &pgBehaviorRepository{pool: pool},
+		Files:       &pgFileRepository{pool: pool},
+		Runq:        &pgRunqRepository{pool: pool},
+		OffCPU:      &pgOffCPURepository{pool: pool},
+		TCPRetrans:  &pgTCPRetransRepository{pool: pool},
+		TCPRTT:      &pgTCPRTTRepository{pool: pool},
+		Inbound:     &pgInboundRepository{pool: pool},
+		TCPStates:   &pgTCPStateRepository{pool: pool},
+		DNS:         &pgDNSRepository{pool: pool},
+		Kills:       &pgKillRepository{pool: pool},
+		FSLatency:   &pgFSLatencyRepository{pool: pool},
+		Timeline:    newPostgresTimelineSources(pool, thresholds),
+	}
+}
+
+// pgArgs collects the arguments of a statement, which PostgreSQL refers to
+// by position
+type pgArgs []interface{}
+
+// bind adds a value and returns its placeholder
+func (a *pgArgs) bind(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// attributionNames are the columns of attributionInsertColumns, as COPY
+// takes them
+var attributionNames = strings.Split(attributionInsertColumns, ", ")
+
+// pgFilterClause is the PostgreSQL counterpart of filterClause
+func pgFilterClause(f models.Filter, alias string, args *pgArgs) string {
+	prefix := alias
+	if prefix != "" {
+		prefix += "."
+	}
+
+	var clause strings.Builder
+	if f.Namespace != "" {
+		clause.WriteString(" AND " + prefix + "pod_namespace = " + args.bind(f.Namespace))
+	}
+	if f.Pod != "" {
+		clause.WriteString(" AND " + prefix + "pod_name = " + args.bind(f.Pod))
+	}
+	clause.WriteString(pgLabelClause(prefix+"pod_labels", f.Labels, args))
+	clause.WriteString(pgHostClause(f, alias, args))
+	return clause.String()
+}
+
+// pgHostClause is the PostgreSQL counterpart of hostClause
+func pgHostClause(f models.Filter, alias string, args *pgArgs) string {
+	if alias != "" {
+		alias += "."
+	}
+
+	var clause strings.Builder
+	if f.Host != "" {
+		clause.WriteString(" AND " + alias + "host = " + args.bind(f.Host))
+	}
+	clause.WriteString(pgLabelClause(alias+"host_labels", f.HostLabels, args))
+	return clause.String()
+}
+
+// pgLabelClause renders conditions matching labels against the JSONB column
+func pgLabelClause(column string, labels map[string]string, args *pgArgs) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var clause strings.Builder
+	for _, key := range keys {
+		clause.WriteString(" AND " + column + " ->> " + args.bind(key) + " = " + args.bind(labels[key]))
+	}
+	return clause.String()
+}
+
+// copyRows inserts items with COPY, which is much faster than one INSERT
+// per row for the batches the collectors and agents deliver
+func copyRows[T any](pool pgConn, table string, columns []string, items []T, values func(item T) []interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+	_, err := pool.CopyFrom(context.Background(), pgx.Identifier{table}, columns,
+		pgx.CopyFromSlice(len(items), func(i int) ([]interface{}, error) {
+			return values(items[i]), nil
+		}),
+	)
+	return err
+}
+
+// query runs a query and scans its rows with scan
+func query[T any](pool pgConn, sql string, args []interface{}, scan func(rows pgx.Rows) ([]T, error)) ([]T, error) {
+	rows, err := pool.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scan(rows)
+}
+
+// queryRow scans the single row of a query into dest
+func queryRow(pool pgConn, sql string, args []interface{}, dest ...interface{}) error {
+	return pool.QueryRow(context.Background(), sql, args...).Scan(dest...)
+}
+
+// scanWith adapts the scanners shared with SQLite to query
+func scanWith[T any](scan func(rows rowScanner) ([]T, error)) func(rows pgx.Rows) ([]T, error) {
+	return func(rows pgx.Rows) ([]T, error) { return scan(rows) }
+}
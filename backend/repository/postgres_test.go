@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"ebpf-dashboard/database"
+	"ebpf-dashboard/models"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// These tests run against the PostgreSQL database at POSTGRES_TEST_URL and
+// are skipped without it. Every test stores its events under a host name of
+// its own, so runs do not see each other's rows.
+
+func testPostgresStores(t *testing.T) (Stores, string) {
+	t.Helper()
+
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+	pool, err := database.InitPostgres(url, 4, database.TimescaleConfig{})
+	if err != nil {
+		t.Fatalf("InitPostgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	host := fmt.Sprintf("test-%s-%d", t.Name(), time.Now().UnixNano())
+	return NewPostgresStores(pool, TimelineThresholds{}), host
+}
+
+func TestPostgresProcessesRoundTrip(t *testing.T) {
+	stores, host := testPostgresStores(t)
+
+	want := models.ProcessEvent{
+		Timestamp: time.Now().Add(-time.Minute),
+		Time:      "12:00:00",
+		PID:       "4242",
+		PPID:      "1",
+		UID:       "1000",
+		Comm:      "curl",
+		Args:      "curl -s https://example.com",
+		Attribution: models.Attribution{
+			CgroupPath:  "/system.slice/test.service",
+			SystemdUnit: "test.service",
+			PodLabels:   models.Labels{"app": "web"},
+			Host:        host,
+			HostLabels:  models.Labels{"env": "test"},
+		},
+	}
+	if err := stores.Processes.SaveProcesses([]models.ProcessEvent{want}); err != nil {
+		t.Fatalf("SaveProcesses: %v", err)
+	}
+
+	got, err := stores.Processes.GetRecentProcesses(10, models.Filter{Host: host})
+	if err != nil {
+		t.Fatalf("GetRecentProcesses: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d processes, want 1", len(got))
+	}
+
+	p := got[0]
+	if p.ID == 0 {
+		t.Error("process has no ID")
+	}
+	if !p.Timestamp.Equal(columnTime(want.Timestamp)) {
+		t.Errorf("timestamp = %v, want %v", p.Timestamp, columnTime(want.Timestamp))
+	}
+	if p.Time != want.Time || p.PID != want.PID || p.PPID != want.PPID || p.UID != want.UID ||
+		p.Comm != want.Comm || p.Args != want.Args {
+		t.Errorf("process = %+v, want %+v", p, want)
+	}
+	if p.CgroupPath != want.CgroupPath || p.SystemdUnit != want.SystemdUnit || p.Host != host {
+		t.Errorf("attribution = %+v, want %+v", p.Attribution, want.Attribution)
+	}
+	if p.PodLabels["app"] != "web" || p.HostLabels["env"] != "test" {
+		t.Errorf("labels = %v and %v, want app=web and env=test", p.PodLabels, p.HostLabels)
+	}
+}
+
+func TestPostgresWriteBatchRollsBack(t *testing.T) {
+	stores, host := testPostgresStores(t)
+
+	failed := errors.New("failed")
+	err := stores.Batches.WriteBatch(func(batch Stores) error {
+		process := models.ProcessEvent{PID: "1", Comm: "init", Attribution: models.Attribution{Host: host}}
+		if err := batch.Processes.SaveProcesses([]models.ProcessEvent{process}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WriteBatch = %v, want %v", err, failed)
+	}
+
+	got, err := stores.Processes.GetRecentProcesses(10, models.Filter{Host: host})
+	if err != nil {
+		t.Fatalf("GetRecentProcesses: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %d processes after a failed batch, want 0", len(got))
+	}
+}
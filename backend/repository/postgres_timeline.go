@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func newPostgresTimelineSources(pool pgConn, thresholds TimelineThresholds) []TimelineSource {
+	return []TimelineSource{
+		&pgProcessSource[models.ProcessEvent]{
+			pool:      pool,
+			eventType: models.TimelineExec,
+			table:     "processes",
+			columns:   processColumns,
+			pidIsText: true,
+			scan:      scanWith(scanProcesses),
+			event:     execEvent,
+		},
+		&pgProcessSource[models.ProcessExit]{
+			pool:      pool,
+			eventType: models.TimelineExit,
+			table:     "process_exits",
+			columns:   exitColumns,
+			scan:      scanWith(scanExits),
+			event:     exitEvent,
+		},
+		&pgProcessSource[models.OOMKill]{
+			pool:      pool,
+			eventType: models.TimelineOOMKill,
+			table:     "oom_kills",
+			columns:   oomKillColumns,
+			scan:      scanWith(scanOOMKills),
+			event:     oomKillEvent,
+		},
+		&pgProcessSource[models.Signal]{
+			pool:      pool,
+			eventType: models.TimelineSignal,
+			table:     "signals",
+			columns:   signalColumns,
+			scan:      scanWith(scanSignals),
+			event:     signalEvent,
+		},
+		&pgProcessSource[models.NetworkConnection]{
+			pool:      pool,
+			eventType: models.TimelineConnect,
+			table:     "network_connections",
+			columns:   connectionColumns,
+			pidIsText: true,
+			scan:      scanWith(scanConnections),
+			event:     connectEvent,
+		},
+		&pgProcessSource[models.TCPLifeEvent]{
+			pool:      pool,
+			eventType: models.TimelineTCPSession,
+			table:     "tcp_lifecycle",
+			columns:   tcpLifeColumns,
+			scan:      pgScanTCPLifeEvents,
+			event:     tcpSessionEvent,
+		},
+		&pgProcessSource[models.CPUProfile]{
+			pool:       pool,
+			eventType:  models.TimelineCPUHotStack,
+			table:      "cpu_profiles",
+			columns:    cpuProfileColumns,
+			minSamples: thresholds.CPUHotStackCount,
+			scan:       pgScanCPUProfiles,
+			event:      cpuHotStackEvent,
+		},
+		&pgDiskSpikeSource{pool: pool, thresholdUS: int(thresholds.DiskP99 / time.Microsecond)},
+		&pgSyscallSpikeSource{pool: pool, factor: thresholds.SyscallFactor, minCount: thresholds.SyscallMinCount},
+		&pgAnomalySource{pool: pool},
+	}
+}
+
+// pgProcessSource is the PostgreSQL counterpart of the sources of
+// per-process events, which all select by window, process, pod and cursor
+type pgProcessSource[T any] struct {
+	pool       pgConn
+	eventType  string
+	table      string
+	columns    string
+	pidIsText  bool
+	minSamples int // minimum sample_count of a row, 0 for tables without one
+	scan       func(rows pgx.Rows) ([]T, error)
+	event      func(item T) models.TimelineEvent
+}
+
+func (s *pgProcessSource[T]) Type() string   { return s.eventType }
+func (s *pgProcessSource[T]) HostWide() bool { return false }
+
+func (s *pgProcessSource[T]) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	var args pgArgs
+	where := pgTimelineWhere(q, s.eventType, s.pidIsText, &args)
+	if s.minSamples > 0 {
+		where += " AND sample_count >= " + args.bind(s.minSamples)
+	}
+
+	items, err := query(s.pool,
+		"SELECT "+s.columns+" FROM "+s.table+" WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT "+args.bind(q.Limit),
+		args, s.scan,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(items))
+	for _, item := range items {
+		events = append(events, s.event(item))
+	}
+	return events, nil
+}
+
+// pgTimelineWhere is the PostgreSQL counterpart of timelineWhere
+func pgTimelineWhere(q models.TimelineQuery, eventType string, pidIsText bool, args *pgArgs) string {
+	where := " timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+
+	if q.PID > 0 {
+		if pidIsText {
+			where += " AND pid = " + args.bind(strconv.Itoa(q.PID))
+		} else {
+			where += " AND pid = " + args.bind(q.PID)
+		}
+	}
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+
+	return where + pgFilterClause(q.Filter, "", args) + pgCursorClause(q.After, eventType, args)
+}
+
+// pgCursorClause is the PostgreSQL counterpart of cursorClause
+func pgCursorClause(after *models.TimelineCursor, eventType string, args *pgArgs) string {
+	if after == nil {
+		return ""
+	}
+	ts := columnTime(after.Time)
+	switch {
+	case eventType > after.Type:
+		return " AND timestamp <= " + args.bind(ts)
+	case eventType == after.Type:
+		return " AND (timestamp < " + args.bind(ts) + " OR (timestamp = " + args.bind(ts) + " AND id < " + args.bind(after.ID) + "))"
+	default:
+		return " AND timestamp < " + args.bind(ts)
+	}
+}
+
+// pgDiskSpikeSource reports histogram snapshots whose p99 latency reaches
+// the threshold
+type pgDiskSpikeSource struct {
+	pool        pgConn
+	thresholdUS int
+}
+
+func (s *pgDiskSpikeSource) Type() string   { return models.TimelineDiskSpike }
+func (s *pgDiskSpikeSource) HostWide() bool { return true }
+
+func (s *pgDiskSpikeSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	until := q.Until
+	if q.After != nil && q.After.Time.Before(until) {
+		until = q.After.Time
+	}
+	var args pgArgs
+	where := "timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(until)) +
+		pgHostClause(q.Filter, "", &args)
+
+	buckets, err := query(s.pool, `
+		SELECT id, timestamp, range_min, range_max, count, host, host_labels
+		FROM disk_latency
+		WHERE `+where+`
+		ORDER BY timestamp DESC, host, id DESC`,
+		args, pgScanDiskLatency,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events   []models.TimelineEvent
+		snapshot []models.DiskLatency
+	)
+	flush := func() {
+		if len(snapshot) == 0 {
+			return
+		}
+		event, ok := diskSpikeEvent(snapshot, snapshot[0].ID, s.thresholdUS)
+		if ok && follows(q.After, event) {
+			events = append(events, event)
+		}
+	}
+	// Ordering by host keeps the buckets of a snapshot adjacent when several
+	// hosts share a timestamp
+	for _, lat := range buckets {
+		if len(snapshot) > 0 && (!lat.Timestamp.Equal(snapshot[0].Timestamp) || lat.Host != snapshot[0].Host) {
+			flush()
+			snapshot = snapshot[:0]
+		}
+		snapshot = append(snapshot, lat)
+	}
+	flush()
+
+	return sortEvents(events, q.Limit), nil
+}
+
+// pgSyscallSpikeSource reports syscall counts of an interval that exceed the
+// average of the preceding intervals of the same host by factor
+type pgSyscallSpikeSource struct {
+	pool     pgConn
+	factor   float64
+	minCount int
+}
+
+func (s *pgSyscallSpikeSource) Type() string   { return models.TimelineSyscallSpike }
+func (s *pgSyscallSpikeSource) HostWide() bool { return true }
+
+func (s *pgSyscallSpikeSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	var args pgArgs
+	since, until := args.bind(columnTime(q.Since)), args.bind(columnTime(q.Until))
+	hostConditions := pgHostClause(q.Filter, "", &args)
+	conditions := " AND count >= " + args.bind(s.minCount) + " AND count > baseline * " + args.bind(s.factor) +
+		pgCursorClause(q.After, s.Type(), &args)
+
+	// The baseline looks back a few minutes before the window, like the
+	// SQLite query does
+	return query(s.pool, `
+		SELECT id, timestamp, host, syscall_name, count, baseline
+		FROM (
+			SELECT id, timestamp, host, syscall_name, count,
+				AVG(count) OVER (
+					PARTITION BY host, syscall_name ORDER BY id
+					ROWS BETWEEN `+strconv.Itoa(syscallBaselineIntervals)+` PRECEDING AND 1 PRECEDING
+				) AS baseline
+			FROM syscall_stats
+			WHERE timestamp >= `+since+`::timestamptz - interval '5 minutes' AND timestamp <= `+until+hostConditions+`
+		) s
+		WHERE timestamp >= `+since+` AND timestamp <= `+until+` AND baseline IS NOT NULL`+conditions+`
+		ORDER BY timestamp DESC, id DESC
+		LIMIT `+args.bind(q.Limit),
+		args, func(rows pgx.Rows) ([]models.TimelineEvent, error) {
+			var events []models.TimelineEvent
+			for rows.Next() {
+				var (
+					id        int
+					timestamp time.Time
+					spike     models.SyscallSpike
+				)
+				if err := rows.Scan(&id, &timestamp, &spike.Host, &spike.Syscall, &spike.Count, &spike.Baseline); err != nil {
+					return nil, err
+				}
+				events = append(events, syscallSpikeEvent(id, timestamp, spike))
+			}
+			return events, rows.Err()
+		},
+	)
+}
+
+// pgAnomalySource reports deviations from the learned baselines
+type pgAnomalySource struct {
+	pool pgConn
+}
+
+func (s *pgAnomalySource) Type() string   { return models.TimelineAnomaly }
+func (s *pgAnomalySource) HostWide() bool { return false }
+
+func (s *pgAnomalySource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	if q.PID > 0 || !q.Filter.IsEmpty() {
+		return nil, nil
+	}
+
+	var args pgArgs
+	where := " timestamp >= " + args.bind(columnTime(q.Since)) + " AND timestamp <= " + args.bind(columnTime(q.Until))
+	if q.Comm != "" {
+		where += " AND comm = " + args.bind(q.Comm)
+	}
+	where += pgCursorClause(q.After, s.Type(), &args)
+
+	anomalies, err := query(s.pool,
+		"SELECT "+anomalyColumns+" FROM anomalies WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT "+args.bind(q.Limit),
+		args, scanWith(scanAnomalies),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(anomalies))
+	for _, a := range anomalies {
+		events = append(events, anomalyEvent(a))
+	}
+	return events, nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"ebpf-dashboard/models"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type pgAttributionRepository struct {
+	pool pgConn
+}
+
+// GetSummary aggregates execs, connects, TCP sessions and CPU samples per group
+func (r *pgAttributionRepository) GetSummary(groupBy string, since, until time.Time, filter models.Filter) ([]models.AttributionSummary, error) {
+	group, ok := attributionGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot group by %q", groupBy)
+	}
+
+	// Every table refers to the same arguments
+	var args pgArgs
+	window := "timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until)) +
+		pgFilterClause(filter, "", &args)
+
+	return query(r.pool, `
+		SELECT grp, SUM(execs)::bigint, SUM(connects)::bigint, SUM(sessions)::bigint, SUM(tx_kb), SUM(rx_kb), SUM(samples)::bigint
+		FROM (
+			SELECT `+group+` AS grp, COUNT(*) AS execs, 0 AS connects, 0 AS sessions,
+				0::double precision AS tx_kb, 0::double precision AS rx_kb, 0 AS samples
+			FROM processes WHERE `+window+` GROUP BY 1
+			UNION ALL
+			SELECT `+group+`, 0, COUNT(*), 0, 0, 0, 0
+			FROM network_connections WHERE `+window+` GROUP BY 1
+			UNION ALL
+			SELECT `+group+`, 0, 0, COUNT(*), SUM(tx_kb), SUM(rx_kb), 0
+			FROM tcp_lifecycle WHERE `+window+` GROUP BY 1
+			UNION ALL
+			SELECT `+group+`, 0, 0, 0, 0, 0, SUM(sample_count)
+			FROM cpu_profiles WHERE `+window+` GROUP BY 1
+		) t
+		GROUP BY grp
+		ORDER BY SUM(execs) + SUM(connects) + SUM(sessions) + SUM(samples) DESC`,
+		args, func(rows pgx.Rows) ([]models.AttributionSummary, error) {
+			var results []models.AttributionSummary
+			for rows.Next() {
+				var s models.AttributionSummary
+				if err := rows.Scan(&s.Group, &s.Execs, &s.Connects, &s.TCPSessions, &s.TxKB, &s.RxKB, &s.CPUSamples); err != nil {
+					return nil, err
+				}
+				results = append(results, s)
+			}
+			return results, rows.Err()
+		},
+	)
+}
+
+type pgProfileRepository struct {
+	pool pgConn
+}
+
+// pgSelectorClause is the PostgreSQL counterpart of selectorClause
+func pgSelectorClause(sel models.ProcessSelector, since, until time.Time, pidIsText bool, args *pgArgs) string {
+	where := " timestamp >= " + args.bind(columnTime(since)) + " AND timestamp <= " + args.bind(columnTime(until))
+	switch {
+	case sel.PID > 0 && pidIsText:
+		where += " AND pid = " + args.bind(strconv.Itoa(sel.PID))
+	case sel.PID > 0:
+		where += " AND pid = " + args.bind(sel.PID)
+	default:
+		where += " AND comm = " + args.bind(sel.Comm)
+	}
+	return where + pgHostClause(sel.Filter, "", args)
+}
+
+func (r *pgProfileRepository) GetExecs(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessEvent, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, true, &args)
+	return query(r.pool,
+		"SELECT "+processColumns+" FROM processes WHERE"+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanProcesses),
+	)
+}
+
+func (r *pgProfileRepository) GetExits(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessExit, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, false, &args)
+	return query(r.pool,
+		"SELECT "+exitColumns+" FROM process_exits WHERE"+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanExits),
+	)
+}
+
+func (r *pgProfileRepository) GetOOMKills(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.OOMKill, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, false, &args)
+	return query(r.pool,
+		"SELECT "+oomKillColumns+" FROM oom_kills WHERE"+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanOOMKills),
+	)
+}
+
+// GetSignals returns the signals sent to the process
+func (r *pgProfileRepository) GetSignals(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.Signal, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, false, &args)
+	return query(r.pool,
+		"SELECT "+signalColumns+" FROM signals WHERE"+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanSignals),
+	)
+}
+
+func (r *pgProfileRepository) GetConnections(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.NetworkConnection, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, true, &args)
+	return query(r.pool,
+		"SELECT "+connectionColumns+" FROM network_connections WHERE"+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, scanWith(scanConnections),
+	)
+}
+
+func (r *pgProfileRepository) GetTCPSessions(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.TCPLifeEvent, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, false, &args)
+	return query(r.pool,
+		"SELECT "+tcpLifeColumns+" FROM tcp_lifecycle WHERE"+where+" ORDER BY id DESC LIMIT "+args.bind(limit),
+		args, pgScanTCPLifeEvents,
+	)
+}
+
+// GetTCPSummary aggregates all TCP sessions in the window, not only the ones
+// returned by GetTCPSessions
+func (r *pgProfileRepository) GetTCPSummary(sel models.ProcessSelector, since, until time.Time) (models.TCPSummary, error) {
+	var (
+		args    pgArgs
+		summary models.TCPSummary
+	)
+	where := pgSelectorClause(sel, since, until, false, &args)
+	err := queryRow(r.pool, `
+		SELECT COUNT(*), COUNT(DISTINCT remote_addr), COALESCE(SUM(tx_kb), 0), COALESCE(SUM(rx_kb), 0),
+			COALESCE(AVG(duration_ms), 0), COALESCE(MAX(duration_ms), 0)
+		FROM tcp_lifecycle WHERE`+where,
+		args, &summary.Sessions, &summary.Peers, &summary.TxKB, &summary.RxKB, &summary.AvgDurationMS, &summary.MaxDurationMS,
+	)
+	return summary, err
+}
+
+// GetCPUStacks returns the hottest stacks in the window along with the total
+// number of samples
+func (r *pgProfileRepository) GetCPUStacks(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.StackSample, int, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, false, &args)
+
+	var total int
+	if err := queryRow(r.pool, "SELECT COALESCE(SUM(sample_count), 0) FROM cpu_profiles WHERE"+where, args, &total); err != nil {
+		return nil, 0, err
+	}
+
+	stacks, err := query(r.pool, `
+		SELECT stack_trace, SUM(sample_count)
+		FROM cpu_profiles WHERE`+where+`
+		GROUP BY stack_trace
+		ORDER BY SUM(sample_count) DESC
+		LIMIT `+args.bind(limit),
+		args, func(rows pgx.Rows) ([]models.StackSample, error) {
+			var stacks []models.StackSample
+			for rows.Next() {
+				var s models.StackSample
+				if err := rows.Scan(&s.Stack, &s.Samples); err != nil {
+					return nil, err
+				}
+				stacks = append(stacks, s)
+			}
+			return stacks, rows.Err()
+		},
+	)
+	return stacks, total, err
+}
+
+// GetSyscallSeries returns the syscall counts per collection interval. When
+// selecting by comm, the counts of all matching processes are summed.
+func (r *pgProfileRepository) GetSyscallSeries(sel models.ProcessSelector, since, until time.Time) ([]models.SyscallPoint, error) {
+	var args pgArgs
+	where := pgSelectorClause(sel, since, until, false, &args)
+	return query(r.pool, `
+		SELECT timestamp, SUM(count)
+		FROM process_syscall_stats WHERE`+where+`
+		GROUP BY timestamp
+		ORDER BY timestamp`,
+		args, func(rows pgx.Rows) ([]models.SyscallPoint, error) {
+			var points []models.SyscallPoint
+			for rows.Next() {
+				var p models.SyscallPoint
+				if err := rows.Scan(&p.Timestamp, &p.Count); err != nil {
+					return nil, err
+				}
+				points = append(points, p)
+			}
+			return points, rows.Err()
+		},
+	)
+}
@@ -8,68 +8,62 @@ import (
 type ProcessRepository interface {
 	SaveProcess(p models.ProcessEvent) error
 	SaveProcesses(processes []models.ProcessEvent) error
-	GetRecentProcesses(limit int) ([]models.ProcessEvent, error)
+	GetRecentProcesses(limit int, filter models.Filter) ([]models.ProcessEvent, error)
 }
 
 type processRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	writer *SQLiteWriter
 }
 
-func NewProcessRepository(db *sql.DB) ProcessRepository {
-	return &processRepository{db: db}
+func NewProcessRepository(db *sql.DB, writer *SQLiteWriter) ProcessRepository {
+	return &processRepository{db: db, writer: writer}
 }
 
+var processColumns = "id, timestamp, time, pid, COALESCE(ppid, ''), COALESCE(uid, ''), comm, args, " + attributionSelect("")
+
+const processInsert = "INSERT INTO processes (timestamp, time, pid, ppid, uid, comm, args, " + attributionInsertColumns + ") VALUES (" + eventTimestamp + ", ?, ?, ?, ?, ?, ?, " + attributionPlaceholders + ")"
+
 func (r *processRepository) SaveProcess(p models.ProcessEvent) error {
-	_, err := r.db.Exec(
-		"INSERT INTO processes (time, pid, comm, args) VALUES (?, ?, ?, ?)",
-		p.Time, p.PID, p.Comm, p.Args,
-	)
-	return err
+	return r.SaveProcesses([]models.ProcessEvent{p})
 }
 
 func (r *processRepository) SaveProcesses(processes []models.ProcessEvent) error {
-	if len(processes) == 0 {
-		return nil
-	}
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare("INSERT INTO processes (time, pid, comm, args) VALUES (?, ?, ?, ?)")
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
+	rows := make([][]interface{}, 0, len(processes))
 	for _, p := range processes {
-		if _, err := stmt.Exec(p.Time, p.PID, p.Comm, p.Args); err != nil {
-			return err
-		}
+		rows = append(rows, processValues(p))
 	}
-
-	return tx.Commit()
+	r.writer.enqueue(processInsert, rows)
+	return nil
 }
 
-func (r *processRepository) GetRecentProcesses(limit int) ([]models.ProcessEvent, error) {
+func (r *processRepository) GetRecentProcesses(limit int, filter models.Filter) ([]models.ProcessEvent, error) {
+	conditions, args := filterClause(filter, "")
 	rows, err := r.db.Query(
-		"SELECT id, timestamp, time, pid, comm, args FROM processes ORDER BY id DESC LIMIT ?",
-		limit,
+		"SELECT "+processColumns+" FROM processes WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanProcesses(rows)
+}
+
+func scanProcesses(rows rowScanner) ([]models.ProcessEvent, error) {
 	var results []models.ProcessEvent
 	for rows.Next() {
 		var p models.ProcessEvent
-		if err := rows.Scan(&p.ID, &p.Timestamp, &p.Time, &p.PID, &p.Comm, &p.Args); err != nil {
+		dest := append([]interface{}{&p.ID, &p.Timestamp, &p.Time, &p.PID, &p.PPID, &p.UID, &p.Comm, &p.Args}, attributionDest(&p.Attribution)...)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
 		results = append(results, p)
 	}
-	return results, nil
+	return results, rows.Err()
+}
+
+func processValues(p models.ProcessEvent) []interface{} {
+	return append([]interface{}{timestampValue(p.Timestamp), p.Time, p.PID, p.PPID, p.UID, p.Comm, p.Args}, attributionValues(p.Attribution)...)
 }
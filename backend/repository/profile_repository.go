@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"strconv"
+	"time"
+)
+
+// ProfileRepository queries the events of a single process across all tables
+type ProfileRepository interface {
+	GetExecs(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessEvent, error)
+	GetExits(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessExit, error)
+	GetOOMKills(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.OOMKill, error)
+	GetSignals(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.Signal, error)
+	GetConnections(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.NetworkConnection, error)
+	GetTCPSessions(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.TCPLifeEvent, error)
+	GetTCPSummary(sel models.ProcessSelector, since, until time.Time) (models.TCPSummary, error)
+	GetCPUStacks(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.StackSample, int, error)
+	GetSyscallSeries(sel models.ProcessSelector, since, until time.Time) ([]models.SyscallPoint, error)
+}
+
+type profileRepository struct {
+	db *sql.DB
+}
+
+func NewProfileRepository(db *sql.DB) ProfileRepository {
+	return &profileRepository{db: db}
+}
+
+// selectorClause builds the window, process and host condition of a table.
+// The pid column of the processes and network_connections tables is TEXT, so
+// pidIsText makes the PID compare as a string there.
+func selectorClause(sel models.ProcessSelector, since, until time.Time, commColumn string, pidIsText bool) (string, []interface{}) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(since), formatTime(until)}
+	switch {
+	case sel.PID > 0 && pidIsText:
+		where += " AND pid = ?"
+		args = append(args, strconv.Itoa(sel.PID))
+	case sel.PID > 0:
+		where += " AND pid = ?"
+		args = append(args, sel.PID)
+	default:
+		where += " AND " + commColumn + " = ?"
+		args = append(args, sel.Comm)
+	}
+
+	conditions, hostArgs := hostClause(sel.Filter, "")
+	return where + conditions, append(args, hostArgs...)
+}
+
+func (r *profileRepository) GetExecs(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessEvent, error) {
+	where, args := selectorClause(sel, since, until, "comm", true)
+	rows, err := r.db.Query(
+		"SELECT "+processColumns+" FROM processes WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanProcesses(rows)
+}
+
+func (r *profileRepository) GetExits(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.ProcessExit, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+	rows, err := r.db.Query(
+		"SELECT "+exitColumns+" FROM process_exits WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanExits(rows)
+}
+
+func (r *profileRepository) GetOOMKills(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.OOMKill, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+	rows, err := r.db.Query(
+		"SELECT "+oomKillColumns+" FROM oom_kills WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOOMKills(rows)
+}
+
+// GetSignals returns the signals sent to the process
+func (r *profileRepository) GetSignals(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.Signal, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+	rows, err := r.db.Query(
+		"SELECT "+signalColumns+" FROM signals WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSignals(rows)
+}
+
+func (r *profileRepository) GetConnections(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.NetworkConnection, error) {
+	where, args := selectorClause(sel, since, until, "comm", true)
+	rows, err := r.db.Query(
+		"SELECT "+connectionColumns+" FROM network_connections WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanConnections(rows)
+}
+
+func (r *profileRepository) GetTCPSessions(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.TCPLifeEvent, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+	rows, err := r.db.Query(
+		"SELECT "+tcpLifeColumns+" FROM tcp_lifecycle WHERE"+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTCPLifeEvents(rows)
+}
+
+// GetTCPSummary aggregates all TCP sessions in the window, not only the ones
+// returned by GetTCPSessions
+func (r *profileRepository) GetTCPSummary(sel models.ProcessSelector, since, until time.Time) (models.TCPSummary, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+	var summary models.TCPSummary
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT remote_addr), COALESCE(SUM(tx_kb), 0), COALESCE(SUM(rx_kb), 0),
+			COALESCE(AVG(duration_ms), 0), COALESCE(MAX(duration_ms), 0)
+		FROM tcp_lifecycle WHERE`+where,
+		args...,
+	).Scan(&summary.Sessions, &summary.Peers, &summary.TxKB, &summary.RxKB, &summary.AvgDurationMS, &summary.MaxDurationMS)
+	return summary, err
+}
+
+// GetCPUStacks returns the hottest stacks in the window along with the total
+// number of samples
+func (r *profileRepository) GetCPUStacks(sel models.ProcessSelector, since, until time.Time, limit int) ([]models.StackSample, int, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+
+	var total int
+	if err := r.db.QueryRow(
+		"SELECT COALESCE(SUM(sample_count), 0) FROM cpu_profiles WHERE"+where,
+		args...,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT stack_trace, SUM(sample_count)
+		FROM cpu_profiles WHERE`+where+`
+		GROUP BY stack_trace
+		ORDER BY SUM(sample_count) DESC
+		LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var stacks []models.StackSample
+	for rows.Next() {
+		var s models.StackSample
+		if err := rows.Scan(&s.Stack, &s.Samples); err != nil {
+			return nil, 0, err
+		}
+		stacks = append(stacks, s)
+	}
+	return stacks, total, rows.Err()
+}
+
+// GetSyscallSeries returns the syscall counts per collection interval. When
+// selecting by comm, the counts of all matching processes are summed.
+func (r *profileRepository) GetSyscallSeries(sel models.ProcessSelector, since, until time.Time) ([]models.SyscallPoint, error) {
+	where, args := selectorClause(sel, since, until, "comm", false)
+	rows, err := r.db.Query(`
+		SELECT timestamp, SUM(count)
+		FROM process_syscall_stats WHERE`+where+`
+		GROUP BY timestamp
+		ORDER BY timestamp`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []models.SyscallPoint
+	for rows.Next() {
+		var (
+			p         models.SyscallPoint
+			timestamp string
+		)
+		if err := rows.Scan(&timestamp, &p.Count); err != nil {
+			return nil, err
+		}
+		p.Timestamp = parseTimestamp(timestamp)
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+)
+
+type RunqRepository interface {
+	SaveRunqSnapshot(latencies []models.RunqLatency) error
+	GetLatestRunq(limit int, filter models.Filter) ([]models.RunqLatency, error)
+	GetRunqBuckets(q models.RunqQuery) ([]models.RunqLatency, error)
+}
+
+type runqRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewRunqRepository(db *sql.DB, writer *SQLiteWriter) RunqRepository {
+	return &runqRepository{db: db, writer: writer}
+}
+
+const runqColumns = "id, timestamp, pidns, COALESCE(cgroup, ''), range_min, range_max, count, COALESCE(host, ''), host_labels"
+
+// SaveRunqSnapshot queues the buckets of a snapshot as one insert, so they
+// are written with adjacent IDs
+func (r *runqRepository) SaveRunqSnapshot(latencies []models.RunqLatency) error {
+	rows := make([][]interface{}, 0, len(latencies))
+	for _, lat := range latencies {
+		rows = append(rows, []interface{}{timestampValue(lat.Timestamp), lat.PIDNS, lat.Cgroup, lat.RangeMin, lat.RangeMax,
+			lat.Count, lat.Host, lat.HostLabels})
+	}
+	r.writer.enqueue(
+		"INSERT INTO runq_latency (timestamp, pidns, cgroup, range_min, range_max, count, host, host_labels) VALUES ("+
+			eventTimestamp+", ?, ?, ?, ?, ?, ?, ?)",
+		rows,
+	)
+	return nil
+}
+
+func (r *runqRepository) GetLatestRunq(limit int, filter models.Filter) ([]models.RunqLatency, error) {
+	conditions, args := hostClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+runqColumns+" FROM runq_latency WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRunqLatency(rows)
+}
+
+// GetRunqBuckets returns the buckets selected by q, oldest first
+func (r *runqRepository) GetRunqBuckets(q models.RunqQuery) ([]models.RunqLatency, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.PIDNS != 0 {
+		where += " AND pidns = ?"
+		args = append(args, q.PIDNS)
+	}
+	conditions, hostArgs := hostClause(q.Filter, "")
+
+	rows, err := r.db.Query(
+		"SELECT "+runqColumns+" FROM runq_latency WHERE"+where+conditions+" ORDER BY timestamp, id",
+		append(args, hostArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRunqLatency(rows)
+}
+
+func scanRunqLatency(rows rowScanner) ([]models.RunqLatency, error) {
+	var results []models.RunqLatency
+	for rows.Next() {
+		var lat models.RunqLatency
+		if err := rows.Scan(&lat.ID, &lat.Timestamp, &lat.PIDNS, &lat.Cgroup, &lat.RangeMin, &lat.RangeMax, &lat.Count,
+			&lat.Host, &lat.HostLabels); err != nil {
+			return nil, err
+		}
+		results = append(results, lat)
+	}
+	return results, rows.Err()
+}
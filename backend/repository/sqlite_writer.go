@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"log"
+	"sync"
+	"time"
+)
+
+// WriterConfig sizes the queue of the SQLite writer
+type WriterConfig struct {
+	QueueSize int // inserts that may wait before callers block
+	BatchRows int // rows after which a transaction is committed
+}
+
+// SQLiteWriter is the only goroutine inserting events into SQLite. Repositories
+// queue their inserts and return; the writer commits everything that queued
+// up meanwhile in a single transaction, so collectors never contend for the
+// database lock and readers, which WAL mode keeps off that lock, are never
+// held up by a burst of small transactions.
+type SQLiteWriter struct {
+	db        *sql.DB
+	batchRows int
+	queue     chan writeJob
+	done      chan struct{}
+
+	mu         sync.Mutex
+	stats      models.WriterStats
+	queuedRows int
+	latencySum time.Duration
+	latencyN   int
+
+	// group collects the inserts queued through a writer returned by
+	// newGroup instead of queueing them
+	group *writeGroup
+}
+
+// writeJob is one call of a repository: rows inserted with the same statement.
+// A job with done set carries a group of calls committed in a transaction of
+// their own, whose result is sent on done.
+type writeJob struct {
+	query     string
+	rows      [][]interface{}
+	queuedAt  time.Time
+	group     []writeJob
+	groupRows int
+	done      chan error
+}
+
+// writeGroup is the inserts collected by a group writer
+type writeGroup struct {
+	jobs []writeJob
+	rows int
+}
+
+// NewSQLiteWriter starts the writer of db
+func NewSQLiteWriter(db *sql.DB, cfg WriterConfig) *SQLiteWriter {
+	w := &SQLiteWriter{
+		db:        db,
+		batchRows: cfg.BatchRows,
+		queue:     make(chan writeJob, cfg.QueueSize),
+		done:      make(chan struct{}),
+	}
+	w.stats.QueueCapacity = cfg.QueueSize
+	go w.run()
+	return w
+}
+
+// Close writes the inserts still queued and stops the writer. Nothing may be
+// queued afterwards.
+func (w *SQLiteWriter) Close() {
+	close(w.queue)
+	<-w.done
+}
+
+// Stats returns the counters of the writer
+func (w *SQLiteWriter) Stats() models.WriterStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := w.stats
+	stats.QueueDepth = len(w.queue)
+	stats.QueuedRows = w.queuedRows
+	if w.latencyN > 0 {
+		stats.AvgLatencyMS = milliseconds(w.latencySum / time.Duration(w.latencyN))
+	}
+	return stats
+}
+
+// enqueue queues rows to be inserted with query, blocking while the queue is
+// full. Errors are logged by the writer, as the caller has moved on by then.
+func (w *SQLiteWriter) enqueue(query string, rows [][]interface{}) {
+	if len(rows) == 0 {
+		return
+	}
+	if w.group != nil {
+		w.group.jobs = append(w.group.jobs, writeJob{query: query, rows: rows, queuedAt: time.Now()})
+		w.group.rows += len(rows)
+		return
+	}
+	w.mu.Lock()
+	w.queuedRows += len(rows)
+	w.mu.Unlock()
+
+	w.queue <- writeJob{query: query, rows: rows, queuedAt: time.Now()}
+}
+
+// newGroup returns a writer collecting the inserts queued through it, which
+// commitGroup commits in one transaction. It is not safe for concurrent use.
+func (w *SQLiteWriter) newGroup() *SQLiteWriter {
+	return &SQLiteWriter{group: &writeGroup{}}
+}
+
+// commitGroup commits the inserts collected by group, a writer returned by
+// newGroup, in one transaction and waits for the result. Either all of them
+// are written or none.
+func (w *SQLiteWriter) commitGroup(group *SQLiteWriter) error {
+	g := group.group
+	if len(g.jobs) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	w.queuedRows += g.rows
+	w.mu.Unlock()
+
+	done := make(chan error, 1)
+	w.queue <- writeJob{group: g.jobs, groupRows: g.rows, queuedAt: time.Now(), done: done}
+	return <-done
+}
+
+func (w *SQLiteWriter) run() {
+	defer close(w.done)
+
+	for job := range w.queue {
+		if job.done != nil {
+			w.writeGroup(job)
+			continue
+		}
+		batch := []writeJob{job}
+		rows := len(job.rows)
+
+		// Take whatever queued up while the previous transaction committed,
+		// up to the next group
+		var group *writeJob
+	drain:
+		for rows < w.batchRows {
+			select {
+			case next, ok := <-w.queue:
+				if !ok {
+					break drain
+				}
+				if next.done != nil {
+					group = &next
+					break drain
+				}
+				batch = append(batch, next)
+				rows += len(next.rows)
+			default:
+				break drain
+			}
+		}
+
+		w.write(batch, rows)
+		if group != nil {
+			w.writeGroup(*group)
+		}
+	}
+}
+
+// writeGroup commits the calls of a group in one transaction and reports the
+// result to the caller waiting for it
+func (w *SQLiteWriter) writeGroup(job writeJob) {
+	start := time.Now()
+	err := w.commit(job.group)
+	if err != nil {
+		log.Printf("Error writing group of %d rows: %v", job.groupRows, err)
+	}
+	w.record(job.group, job.groupRows, start, err)
+	job.done <- err
+}
+
+// write commits a batch in one transaction. If that fails, the jobs are
+// retried one by one so a single bad insert does not lose the others.
+func (w *SQLiteWriter) write(batch []writeJob, rows int) {
+	start := time.Now()
+	err := w.commit(batch)
+	if err == nil {
+		w.record(batch, rows, start, nil)
+		return
+	}
+
+	log.Printf("Error writing %d rows, retrying inserts one by one: %v", rows, err)
+	for _, job := range batch {
+		start := time.Now()
+		err := w.commit([]writeJob{job})
+		if err != nil {
+			log.Printf("Error writing %d rows: %v", len(job.rows), err)
+		}
+		w.record([]writeJob{job}, len(job.rows), start, err)
+	}
+}
+
+func (w *SQLiteWriter) commit(batch []writeJob) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Jobs of the same table share a prepared statement
+	stmts := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, stmt := range stmts {
+			stmt.Close()
+		}
+	}()
+
+	for _, job := range batch {
+		stmt, ok := stmts[job.query]
+		if !ok {
+			if stmt, err = tx.Prepare(job.query); err != nil {
+				return err
+			}
+			stmts[job.query] = stmt
+		}
+		for _, values := range job.rows {
+			if _, err := stmt.Exec(values...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w *SQLiteWriter) record(batch []writeJob, rows int, start time.Time, err error) {
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.queuedRows -= rows
+	w.stats.Transactions++
+	w.stats.LastBatchRows = rows
+	w.stats.LastCommitMS = milliseconds(now.Sub(start))
+	if err != nil {
+		w.stats.RowsFailed += rows
+		w.stats.LastError = err.Error()
+		w.stats.LastErrorAt = &now
+		return
+	}
+
+	w.stats.RowsWritten += rows
+	for _, job := range batch {
+		latency := now.Sub(job.queuedAt)
+		w.latencySum += latency
+		w.latencyN++
+		if ms := milliseconds(latency); ms > w.stats.MaxLatencyMS {
+			w.stats.MaxLatencyMS = ms
+		}
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import "database/sql"
+
+// Stores are the repositories of one storage backend. Services only depend
+// on the interfaces, so every backend answers queries the same way.
+type Stores struct {
+	Processes   ProcessRepository
+	Connections NetworkRepository
+	Disk        DiskRepository
+	CPUProfiles CPUProfileRepository
+	TCPSessions TCPLifeRepository
+	Syscalls    SyscallRepository
+	Exits       ExitRepository
+	Attribution AttributionRepository
+	Profiles    ProfileRepository
+	Alerts      AlertRepository
+	Baselines   BaselineRepository
+	Findings    FindingRepository
+	Agents      AgentRepository
+	Behavior    BehaviorRepository
+	Files       FileRepository
+	Runq        RunqRepository
+	OffCPU      OffCPURepository
+	TCPRetrans  TCPRetransRepository
+	TCPRTT      TCPRTTRepository
+	Inbound     InboundRepository
+	TCPStates   TCPStateRepository
+	DNS         DNSRepository
+	Kills       KillRepository
+	FSLatency   FSLatencyRepository
+	Timeline    []TimelineSource
+	Batches     BatchWriter
+}
+
+// BatchWriter writes the events of an agent batch together: fn saves them
+// through the given stores, and either all are stored or none, so a batch
+// the agent resends after an error is not stored twice. Only event inserts
+// are covered on SQLite; state written directly, such as the inventory of
+// listening sockets, is committed right away. BatchWriter returns once the
+// events are committed.
+type BatchWriter interface {
+	WriteBatch(fn func(stores Stores) error) error
+}
+
+// rowScanner is implemented by the rows of database/sql and of pgx, so
+// backends share the scanning of the columns they have in common
+type rowScanner interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+}
+
+// NewSQLiteStores returns the repositories backed by the SQLite database db.
+// Events are inserted through writer; the rest of the state, which callers
+// read back right away, is written directly.
+func NewSQLiteStores(db *sql.DB, writer *SQLiteWriter, thresholds TimelineThresholds) Stores {
+	stores := newSQLiteStores(db, writer, thresholds)
+	stores.Batches = &sqliteBatchWriter{db: db, writer: writer, thresholds: thresholds}
+	return stores
+}
+
+// sqliteBatchWriter collects the inserts of a batch and has the writer
+// commit them in one transaction
+type sqliteBatchWriter struct {
+	db         *sql.DB
+	writer     *SQLiteWriter
+	thresholds TimelineThresholds
+}
+
+func (w *sqliteBatchWriter) WriteBatch(fn func(stores Stores) error) error {
+	group := w.writer.newGroup()
+	if err := fn(newSQLiteStores(w.db, group, w.thresholds)); err != nil {
+		return err
+	}
+	return w.writer.commitGroup(group)
+}
+
+func newSQLiteStores(db *sql.DB, writer *SQLiteWriter, thresholds TimelineThresholds) Stores {
+	return Stores{
+		Processes:   NewProcessRepository(db, writer),
+		Connections: NewNetworkRepository(db, writer),
+		Disk:        NewDiskRepository(db, writer),
+		CPUProfiles: NewCPUProfileRepository(db, writer),
+		TCPSessions: NewTCPLifeRepository(db, writer),
+		Syscalls:    NewSyscallRepository(db, writer),
+		Exits:       NewExitRepository(db, writer),
+		Attribution: NewAttributionRepository(db),
+		Profiles:    NewProfileRepository(db),
+		Alerts:      NewAlertRepository(db),
+		Baselines:   NewBaselineRepository(db, writer),
+		Findings:    NewFindingRepository(db, writer),
+		Agents:      NewAgentRepository(db),
+		Behavior:    NewBehaviorRepository(db),
+		Files:       NewFileRepository(db, writer),
+		Runq:        NewRunqRepository(db, writer),
+		OffCPU:      NewOffCPURepository(db, writer),
+		TCPRetrans:  NewTCPRetransRepository(db, writer),
+		TCPRTT:      NewTCPRTTRepository(db, writer),
+		Inbound:     NewInboundRepository(db, writer),
+		TCPStates:   NewTCPStateRepository(db, writer),
+		DNS:         NewDNSRepository(db, writer),
+		Kills:       NewKillRepository(db, writer),
+		FSLatency:   NewFSLatencyRepository(db, writer),
+		Timeline:    NewTimelineSources(db, thresholds),
+	}
+}
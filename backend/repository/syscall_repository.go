@@ -6,43 +6,73 @@ import (
 	"time"
 )
 
-type SyscallRepository struct {
-	db *sql.DB
+type SyscallRepository interface {
+	SaveSyscallStats(stats []models.SyscallStat) error
+	SaveProcessSyscallStats(stats []models.ProcessSyscallStat) error
+	GetRecentSyscallStats(limit int, filter models.Filter) ([]models.SyscallStat, error)
+	GetRecentProcessSyscallStats(limit int, filter models.Filter) ([]models.ProcessSyscallStat, error)
 }
 
-func NewSyscallRepository(db *sql.DB) *SyscallRepository {
-	return &SyscallRepository{db: db}
+type syscallRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
 }
 
-// SaveSyscallStats saves multiple syscall statistics to the database
-func (r *SyscallRepository) SaveSyscallStats(stats []models.SyscallStat) error {
-	if len(stats) == 0 {
-		return nil
+func NewSyscallRepository(db *sql.DB, writer *SQLiteWriter) SyscallRepository {
+	return &syscallRepository{db: db, writer: writer}
+}
+
+// SaveSyscallStats queues multiple syscall statistics for the writer
+func (r *syscallRepository) SaveSyscallStats(stats []models.SyscallStat) error {
+	rows := make([][]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, []interface{}{timestampValue(stat.Timestamp), stat.SyscallName, stat.Count, stat.Host, stat.HostLabels})
 	}
+	r.writer.enqueue(`
+		INSERT INTO syscall_stats (timestamp, syscall_name, count, host, host_labels)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?)
+	`, rows)
+	return nil
+}
 
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
+// SaveProcessSyscallStats queues per-process syscall counts for the writer
+func (r *syscallRepository) SaveProcessSyscallStats(stats []models.ProcessSyscallStat) error {
+	rows := make([][]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, []interface{}{timestampValue(stat.Timestamp), stat.PID, stat.Comm, stat.Count, stat.Host, stat.HostLabels})
 	}
-	defer tx.Rollback()
+	r.writer.enqueue(`
+		INSERT INTO process_syscall_stats (timestamp, pid, comm, count, host, host_labels)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?)
+	`, rows)
+	return nil
+}
 
-	stmt, err := tx.Prepare(`
-		INSERT INTO syscall_stats (syscall_name, count)
-		VALUES (?, ?)
-	`)
+// GetRecentProcessSyscallStats retrieves recent per-process syscall counts
+func (r *syscallRepository) GetRecentProcessSyscallStats(limit int, filter models.Filter) ([]models.ProcessSyscallStat, error) {
+	conditions, args := hostClause(filter, "")
+	rows, err := r.db.Query(`
+		SELECT id, timestamp, pid, comm, count, COALESCE(host, ''), host_labels
+		FROM process_syscall_stats
+		WHERE 1 = 1`+conditions+`
+		ORDER BY id DESC
+		LIMIT ?`,
+		append(args, limit)...,
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer stmt.Close()
+	defer rows.Close()
 
-	for _, stat := range stats {
-		_, err := stmt.Exec(stat.SyscallName, stat.Count)
-		if err != nil {
-			return err
+	var stats []models.ProcessSyscallStat
+	for rows.Next() {
+		var stat models.ProcessSyscallStat
+		if err := rows.Scan(&stat.ID, &stat.Timestamp, &stat.PID, &stat.Comm, &stat.Count, &stat.Host, &stat.HostLabels); err != nil {
+			return nil, err
 		}
+		stats = append(stats, stat)
 	}
-
-	return tx.Commit()
+	return stats, rows.Err()
 }
 
 // GetRecentSyscallStats retrieves recent syscall statistics
@@ -50,15 +80,17 @@ func (r *SyscallRepository) SaveSyscallStats(stats []models.SyscallStat) error {
 // Or returns raw entries depending on visualization needs.
 // For a Pie Chart, we usually want aggregated data over the last X minutes.
 // Here we return raw entries, aggregation can be done in frontend or via a different query.
-func (r *SyscallRepository) GetRecentSyscallStats(limit int) ([]models.SyscallStat, error) {
+func (r *syscallRepository) GetRecentSyscallStats(limit int, filter models.Filter) ([]models.SyscallStat, error) {
+	conditions, args := hostClause(filter, "")
 	query := `
-		SELECT id, timestamp, syscall_name, count
+		SELECT id, timestamp, syscall_name, count, COALESCE(host, ''), host_labels
 		FROM syscall_stats
+		WHERE 1 = 1` + conditions + `
 		ORDER BY timestamp DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.Query(query, append(args, limit)...)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +106,8 @@ func (r *SyscallRepository) GetRecentSyscallStats(limit int) ([]models.SyscallSt
 			&timestamp,
 			&stat.SyscallName,
 			&stat.Count,
+			&stat.Host,
+			&stat.HostLabels,
 		)
 		if err != nil {
 			return nil, err
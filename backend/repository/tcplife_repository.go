@@ -6,37 +6,27 @@ import (
 	"time"
 )
 
-type TCPLifeRepository struct {
-	db *sql.DB
+type TCPLifeRepository interface {
+	SaveTCPLifeEvents(events []models.TCPLifeEvent) error
+	GetRecentTCPLifeEvents(limit int, filter models.Filter) ([]models.TCPLifeEvent, error)
+	GetSlowSessions(since, until time.Time, minDurationMS float64, limit int, filter models.Filter) ([]models.TCPLifeEvent, error)
 }
 
-func NewTCPLifeRepository(db *sql.DB) *TCPLifeRepository {
-	return &TCPLifeRepository{db: db}
+type tcpLifeRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
 }
 
-// SaveTCPLifeEvents saves multiple TCP lifecycle events to the database
-func (r *TCPLifeRepository) SaveTCPLifeEvents(events []models.TCPLifeEvent) error {
-	if len(events) == 0 {
-		return nil
-	}
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	stmt, err := tx.Prepare(`
-		INSERT INTO tcp_lifecycle (pid, comm, local_addr, local_port, remote_addr, remote_port, tx_kb, rx_kb, duration_ms)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+func NewTCPLifeRepository(db *sql.DB, writer *SQLiteWriter) TCPLifeRepository {
+	return &tcpLifeRepository{db: db, writer: writer}
+}
 
+// SaveTCPLifeEvents queues multiple TCP lifecycle events for the writer
+func (r *tcpLifeRepository) SaveTCPLifeEvents(events []models.TCPLifeEvent) error {
+	rows := make([][]interface{}, 0, len(events))
 	for _, event := range events {
-		_, err := stmt.Exec(
+		values := []interface{}{
+			timestampValue(event.Timestamp),
 			event.PID,
 			event.Comm,
 			event.LocalAddr,
@@ -46,36 +36,70 @@ func (r *TCPLifeRepository) SaveTCPLifeEvents(events []models.TCPLifeEvent) erro
 			event.TxKB,
 			event.RxKB,
 			event.DurationMS,
-		)
-		if err != nil {
-			return err
+			event.RemoteHost,
 		}
+		rows = append(rows, append(values, attributionValues(event.Attribution)...))
 	}
 
-	return tx.Commit()
+	r.writer.enqueue(`
+		INSERT INTO tcp_lifecycle (timestamp, pid, comm, local_addr, local_port, remote_addr, remote_port, tx_kb, rx_kb, duration_ms,
+			remote_host, `+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
 }
 
 // GetRecentTCPLifeEvents retrieves the most recent TCP lifecycle events
-func (r *TCPLifeRepository) GetRecentTCPLifeEvents(limit int) ([]models.TCPLifeEvent, error) {
+func (r *tcpLifeRepository) GetRecentTCPLifeEvents(limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	conditions, args := filterClause(filter, "")
 	query := `
-		SELECT id, timestamp, pid, comm, local_addr, local_port, remote_addr, remote_port, tx_kb, rx_kb, duration_ms
+		SELECT ` + tcpLifeColumns + `
 		FROM tcp_lifecycle
+		WHERE 1 = 1` + conditions + `
 		ORDER BY timestamp DESC
 		LIMIT ?
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTCPLifeEvents(rows)
+}
+
+// GetSlowSessions returns the sessions that closed in the window after at
+// least minDurationMS, longest first
+func (r *tcpLifeRepository) GetSlowSessions(since, until time.Time, minDurationMS float64, limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until), minDurationMS}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT `+tcpLifeColumns+`
+		FROM tcp_lifecycle
+		WHERE timestamp >= ? AND timestamp <= ? AND duration_ms >= ?`+conditions+`
+		ORDER BY duration_ms DESC
+		LIMIT ?`,
+		append(args, limit)...,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanTCPLifeEvents(rows)
+}
+
+var tcpLifeColumns = `id, timestamp, pid, comm, local_addr, local_port, remote_addr, remote_port, tx_kb, rx_kb, duration_ms, ` +
+	`COALESCE(remote_host, ''), ` + attributionSelect("")
+
+func scanTCPLifeEvents(rows *sql.Rows) ([]models.TCPLifeEvent, error) {
 	var events []models.TCPLifeEvent
 	for rows.Next() {
 		var event models.TCPLifeEvent
 		var timestamp string
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&event.ID,
 			&timestamp,
 			&event.PID,
@@ -87,27 +111,15 @@ func (r *TCPLifeRepository) GetRecentTCPLifeEvents(limit int) ([]models.TCPLifeE
 			&event.TxKB,
 			&event.RxKB,
 			&event.DurationMS,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		// Parse timestamp - support multiple formats
-		formats := []string{
-			"2006-01-02 15:04:05",
-			time.RFC3339,
-			"2006-01-02T15:04:05Z",
+			&event.RemoteHost,
 		}
-
-		for _, format := range formats {
-			if t, err := time.Parse(format, timestamp); err == nil {
-				event.Timestamp = t
-				break
-			}
+		if err := rows.Scan(append(dest, attributionDest(&event.Attribution)...)...); err != nil {
+			return nil, err
 		}
+		event.Timestamp = parseTimestamp(timestamp)
 
 		events = append(events, event)
 	}
 
-	return events, nil
+	return events, rows.Err()
 }
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"time"
+)
+
+type TCPRetransRepository interface {
+	SaveRetransmits(events []models.TCPRetransmit) error
+	GetRecentRetransmits(limit int, filter models.Filter) ([]models.TCPRetransmit, error)
+	GetRetransmitCounts(q models.RetransmitQuery) ([]models.RetransmitCount, error)
+	SaveDrops(events []models.TCPDrop) error
+	GetRecentDrops(limit int, filter models.Filter) ([]models.TCPDrop, error)
+	GetDropSummary(since, until time.Time, limit int, filter models.Filter) ([]models.DropSummary, error)
+}
+
+type tcpRetransRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewTCPRetransRepository(db *sql.DB, writer *SQLiteWriter) TCPRetransRepository {
+	return &tcpRetransRepository{db: db, writer: writer}
+}
+
+var retransmitColumns = "id, timestamp, time, pid, comm, ip_version, local_addr, local_port, remote_addr, remote_port, state, type, " +
+	attributionSelect("")
+
+var dropColumns = "id, timestamp, time, pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port, state, flags, reason, stack, " +
+	attributionSelect("")
+
+// SaveRetransmits queues retransmits for the writer
+func (r *tcpRetransRepository) SaveRetransmits(events []models.TCPRetransmit) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, e := range events {
+		values := []interface{}{
+			timestampValue(e.Timestamp), e.Time, e.PID, e.Comm, e.IPVersion,
+			e.LocalAddr, e.LocalPort, e.RemoteAddr, e.RemotePort, e.State, e.Type,
+		}
+		rows = append(rows, append(values, attributionValues(e.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO tcp_retransmits (timestamp, time, pid, comm, ip_version, local_addr, local_port, remote_addr, remote_port, state, type,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *tcpRetransRepository) GetRecentRetransmits(limit int, filter models.Filter) ([]models.TCPRetransmit, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+retransmitColumns+" FROM tcp_retransmits WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRetransmits(rows)
+}
+
+// GetRetransmitCounts counts the retransmits in the window per timestamp,
+// connection, command and type, oldest first
+func (r *tcpRetransRepository) GetRetransmitCounts(q models.RetransmitQuery) ([]models.RetransmitCount, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.RemoteAddr != "" {
+		where += " AND remote_addr = ?"
+		args = append(args, q.RemoteAddr)
+	}
+	if q.RemotePort != 0 {
+		where += " AND remote_port = ?"
+		args = append(args, q.RemotePort)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+
+	rows, err := r.db.Query(`
+		SELECT timestamp, local_addr, local_port, remote_addr, remote_port, comm, type, COUNT(*)
+		FROM tcp_retransmits
+		WHERE`+where+conditions+`
+		GROUP BY timestamp, local_addr, local_port, remote_addr, remote_port, comm, type
+		ORDER BY timestamp`,
+		append(args, filterArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.RetransmitCount
+	for rows.Next() {
+		var (
+			c         models.RetransmitCount
+			timestamp string
+		)
+		if err := rows.Scan(&timestamp, &c.LocalAddr, &c.LocalPort, &c.RemoteAddr, &c.RemotePort, &c.Comm, &c.Type, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Timestamp = parseTimestamp(timestamp)
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// SaveDrops queues drops for the writer
+func (r *tcpRetransRepository) SaveDrops(events []models.TCPDrop) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, e := range events {
+		values := []interface{}{
+			timestampValue(e.Timestamp), e.Time, e.PID, e.Comm, e.IPVersion,
+			e.SourceAddr, e.SourcePort, e.DestAddr, e.DestPort, e.State, e.Flags, e.Reason, e.Stack,
+		}
+		rows = append(rows, append(values, attributionValues(e.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO tcp_drops (timestamp, time, pid, comm, ip_version, source_addr, source_port, dest_addr, dest_port, state, flags, reason, stack,
+			`+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *tcpRetransRepository) GetRecentDrops(limit int, filter models.Filter) ([]models.TCPDrop, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+dropColumns+" FROM tcp_drops WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDrops(rows)
+}
+
+// GetDropSummary counts the drops in the window by reason, state and stack,
+// most frequent first
+func (r *tcpRetransRepository) GetDropSummary(since, until time.Time, limit int, filter models.Filter) ([]models.DropSummary, error) {
+	conditions, filterArgs := filterClause(filter, "")
+	args := append([]interface{}{formatTime(since), formatTime(until)}, filterArgs...)
+	rows, err := r.db.Query(`
+		SELECT reason, state, stack, COUNT(*), MAX(timestamp)
+		FROM tcp_drops
+		WHERE timestamp >= ? AND timestamp <= ?`+conditions+`
+		GROUP BY reason, state, stack
+		ORDER BY COUNT(*) DESC
+		LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.DropSummary
+	for rows.Next() {
+		var (
+			d        models.DropSummary
+			lastSeen string
+		)
+		if err := rows.Scan(&d.Reason, &d.State, &d.Stack, &d.Count, &lastSeen); err != nil {
+			return nil, err
+		}
+		d.LastSeen = parseTimestamp(lastSeen)
+		results = append(results, d)
+	}
+	return results, rows.Err()
+}
+
+func scanRetransmits(rows rowScanner) ([]models.TCPRetransmit, error) {
+	var events []models.TCPRetransmit
+	for rows.Next() {
+		var e models.TCPRetransmit
+		dest := []interface{}{
+			&e.ID, &e.Timestamp, &e.Time, &e.PID, &e.Comm, &e.IPVersion,
+			&e.LocalAddr, &e.LocalPort, &e.RemoteAddr, &e.RemotePort, &e.State, &e.Type,
+		}
+		if err := rows.Scan(append(dest, attributionDest(&e.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func scanDrops(rows rowScanner) ([]models.TCPDrop, error) {
+	var events []models.TCPDrop
+	for rows.Next() {
+		var e models.TCPDrop
+		dest := []interface{}{
+			&e.ID, &e.Timestamp, &e.Time, &e.PID, &e.Comm, &e.IPVersion,
+			&e.SourceAddr, &e.SourcePort, &e.DestAddr, &e.DestPort, &e.State, &e.Flags, &e.Reason, &e.Stack,
+		}
+		if err := rows.Scan(append(dest, attributionDest(&e.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+)
+
+type TCPRTTRepository interface {
+	SaveRTTSnapshot(rtts []models.TCPRTT) error
+	GetLatestRTT(limit int, filter models.Filter) ([]models.TCPRTT, error)
+	GetRTTBuckets(q models.RTTQuery) ([]models.TCPRTT, error)
+}
+
+type tcpRTTRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewTCPRTTRepository(db *sql.DB, writer *SQLiteWriter) TCPRTTRepository {
+	return &tcpRTTRepository{db: db, writer: writer}
+}
+
+const tcpRTTColumns = "id, timestamp, remote_addr, range_min, range_max, count, COALESCE(host, ''), host_labels"
+
+// SaveRTTSnapshot queues the buckets of a snapshot as one insert, so they
+// are written with adjacent IDs
+func (r *tcpRTTRepository) SaveRTTSnapshot(rtts []models.TCPRTT) error {
+	rows := make([][]interface{}, 0, len(rtts))
+	for _, rtt := range rtts {
+		rows = append(rows, []interface{}{timestampValue(rtt.Timestamp), rtt.RemoteAddr, rtt.RangeMin, rtt.RangeMax,
+			rtt.Count, rtt.Host, rtt.HostLabels})
+	}
+	r.writer.enqueue(
+		"INSERT INTO tcp_rtt (timestamp, remote_addr, range_min, range_max, count, host, host_labels) VALUES ("+
+			eventTimestamp+", ?, ?, ?, ?, ?, ?)",
+		rows,
+	)
+	return nil
+}
+
+func (r *tcpRTTRepository) GetLatestRTT(limit int, filter models.Filter) ([]models.TCPRTT, error) {
+	conditions, args := hostClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+tcpRTTColumns+" FROM tcp_rtt WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTCPRTT(rows)
+}
+
+// GetRTTBuckets returns the buckets selected by q, oldest first
+func (r *tcpRTTRepository) GetRTTBuckets(q models.RTTQuery) ([]models.TCPRTT, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.RemoteAddr != "" {
+		where += " AND remote_addr = ?"
+		args = append(args, q.RemoteAddr)
+	}
+	conditions, hostArgs := hostClause(q.Filter, "")
+
+	rows, err := r.db.Query(
+		"SELECT "+tcpRTTColumns+" FROM tcp_rtt WHERE"+where+conditions+" ORDER BY timestamp, id",
+		append(args, hostArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTCPRTT(rows)
+}
+
+func scanTCPRTT(rows rowScanner) ([]models.TCPRTT, error) {
+	var results []models.TCPRTT
+	for rows.Next() {
+		var rtt models.TCPRTT
+		if err := rows.Scan(&rtt.ID, &rtt.Timestamp, &rtt.RemoteAddr, &rtt.RangeMin, &rtt.RangeMax, &rtt.Count,
+			&rtt.Host, &rtt.HostLabels); err != nil {
+			return nil, err
+		}
+		results = append(results, rtt)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"strings"
+)
+
+type TCPStateRepository interface {
+	SaveStateChanges(events []models.TCPStateChange) error
+	GetRecentStateChanges(limit int, filter models.Filter) ([]models.TCPStateChange, error)
+	GetStateCounts(q models.StateQuery) ([]models.StateCount, error)
+	GetStuckConnections(q models.StuckQuery) ([]models.TCPStateChange, error)
+}
+
+type tcpStateRepository struct {
+	db     *sql.DB
+	writer *SQLiteWriter
+}
+
+func NewTCPStateRepository(db *sql.DB, writer *SQLiteWriter) TCPStateRepository {
+	return &tcpStateRepository{db: db, writer: writer}
+}
+
+var stateChangeColumns = "id, timestamp, skaddr, pid, comm, local_addr, local_port, remote_addr, remote_port, " +
+	"old_state, new_state, duration_ms, " + attributionSelect("")
+
+// SaveStateChanges queues state transitions for the writer
+func (r *tcpStateRepository) SaveStateChanges(events []models.TCPStateChange) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, e := range events {
+		values := []interface{}{
+			timestampValue(e.Timestamp), e.SKAddr, e.PID, e.Comm, e.LocalAddr, e.LocalPort,
+			e.RemoteAddr, e.RemotePort, e.OldState, e.NewState, e.DurationMS,
+		}
+		rows = append(rows, append(values, attributionValues(e.Attribution)...))
+	}
+	r.writer.enqueue(`
+		INSERT INTO tcp_state_changes (timestamp, skaddr, pid, comm, local_addr, local_port, remote_addr, remote_port,
+			old_state, new_state, duration_ms, `+attributionInsertColumns+`)
+		VALUES (`+eventTimestamp+`, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+attributionPlaceholders+`)
+	`, rows)
+	return nil
+}
+
+func (r *tcpStateRepository) GetRecentStateChanges(limit int, filter models.Filter) ([]models.TCPStateChange, error) {
+	conditions, args := filterClause(filter, "")
+	rows, err := r.db.Query(
+		"SELECT "+stateChangeColumns+" FROM tcp_state_changes WHERE 1 = 1"+conditions+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStateChanges(rows)
+}
+
+// GetStateCounts counts the transitions in the window per timestamp, new
+// state and command, oldest first
+func (r *tcpStateRepository) GetStateCounts(q models.StateQuery) ([]models.StateCount, error) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.State != "" {
+		where += " AND new_state = ?"
+		args = append(args, q.State)
+	}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, filterArgs := filterClause(q.Filter, "")
+
+	rows, err := r.db.Query(`
+		SELECT timestamp, new_state, comm, COUNT(*)
+		FROM tcp_state_changes
+		WHERE`+where+conditions+`
+		GROUP BY timestamp, new_state, comm
+		ORDER BY timestamp`,
+		append(args, filterArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.StateCount
+	for rows.Next() {
+		var (
+			c         models.StateCount
+			timestamp string
+		)
+		if err := rows.Scan(&timestamp, &c.State, &c.Comm, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Timestamp = parseTimestamp(timestamp)
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// GetStuckConnections returns the latest transition of every socket that
+// changed state since q.Since, if it left the socket in one of q.States no
+// later than q.Before, longest stuck first
+func (r *tcpStateRepository) GetStuckConnections(q models.StuckQuery) ([]models.TCPStateChange, error) {
+	placeholders := make([]string, len(q.States))
+	args := []interface{}{formatTime(q.Since)}
+	for i, state := range q.States {
+		placeholders[i] = "?"
+		args = append(args, state)
+	}
+	args = append(args, formatTime(q.Before))
+	conditions, filterArgs := filterClause(q.Filter, "")
+	args = append(append(args, filterArgs...), q.Limit)
+
+	rows, err := r.db.Query(`
+		SELECT `+stateChangeColumns+`
+		FROM tcp_state_changes
+		WHERE id IN (SELECT MAX(id) FROM tcp_state_changes WHERE timestamp >= ? GROUP BY host, skaddr)
+			AND new_state IN (`+strings.Join(placeholders, ", ")+`) AND timestamp <= ?`+conditions+`
+		ORDER BY timestamp, id
+		LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStateChanges(rows)
+}
+
+func scanStateChanges(rows rowScanner) ([]models.TCPStateChange, error) {
+	var events []models.TCPStateChange
+	for rows.Next() {
+		var e models.TCPStateChange
+		dest := []interface{}{
+			&e.ID, &e.Timestamp, &e.SKAddr, &e.PID, &e.Comm, &e.LocalAddr, &e.LocalPort,
+			&e.RemoteAddr, &e.RemotePort, &e.OldState, &e.NewState, &e.DurationMS,
+		}
+		if err := rows.Scan(append(dest, attributionDest(&e.Attribution)...)...); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,653 @@
+package repository
+
+import (
+	"database/sql"
+	"ebpf-dashboard/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimelineSource produces the timeline events of one type
+type TimelineSource interface {
+	Type() string
+	// HostWide reports whether the events describe the whole host rather than
+	// a process, in which case process and pod filters do not apply
+	HostWide() bool
+	// Events returns up to q.Limit events following q.After in timeline order
+	Events(q models.TimelineQuery) ([]models.TimelineEvent, error)
+}
+
+// TimelineThresholds decide which samples of the aggregated tables are
+// spikes worth showing on the timeline
+type TimelineThresholds struct {
+	DiskP99          time.Duration // p99 disk latency of a histogram snapshot
+	SyscallFactor    float64       // syscall count relative to its recent baseline
+	SyscallMinCount  int           // minimum count of a syscall per interval to be a spike
+	CPUHotStackCount int           // minimum samples of a stack per collection
+}
+
+// syscallBaselineIntervals is the number of preceding intervals a syscall
+// count is compared against (one minute at 5 second intervals)
+const syscallBaselineIntervals = 12
+
+// NewTimelineSources returns the timeline sources backed by the SQLite tables
+func NewTimelineSources(db *sql.DB, thresholds TimelineThresholds) []TimelineSource {
+	return []TimelineSource{
+		&execSource{db: db},
+		&exitSource{db: db},
+		&oomKillSource{db: db},
+		&signalSource{db: db},
+		&connectSource{db: db},
+		&tcpSessionSource{db: db},
+		&cpuHotStackSource{db: db, minSamples: thresholds.CPUHotStackCount},
+		&diskSpikeSource{db: db, thresholdUS: int(thresholds.DiskP99 / time.Microsecond)},
+		&syscallSpikeSource{db: db, factor: thresholds.SyscallFactor, minCount: thresholds.SyscallMinCount},
+		&anomalySource{db: db},
+	}
+}
+
+// timelineWhere builds the conditions shared by all sources of per-process
+// events: the window, the process selection, the pod filter and the cursor.
+// The pid column of some tables is TEXT, which pidIsText accounts for.
+func timelineWhere(q models.TimelineQuery, eventType, commColumn string, pidIsText bool) (string, []interface{}) {
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+
+	if q.PID > 0 {
+		where += " AND pid = ?"
+		if pidIsText {
+			args = append(args, strconv.Itoa(q.PID))
+		} else {
+			args = append(args, q.PID)
+		}
+	}
+	if q.Comm != "" {
+		where += " AND " + commColumn + " = ?"
+		args = append(args, q.Comm)
+	}
+
+	conditions, filterArgs := filterClause(q.Filter, "")
+	where += conditions
+	args = append(args, filterArgs...)
+
+	conditions, cursorArgs := cursorClause(q.After, eventType)
+	return where + conditions, append(args, cursorArgs...)
+}
+
+// cursorClause selects the rows of eventType that follow the cursor. Events
+// of the same time are ordered by type, then by descending ID.
+func cursorClause(after *models.TimelineCursor, eventType string) (string, []interface{}) {
+	if after == nil {
+		return "", nil
+	}
+	ts := formatTime(after.Time)
+	switch {
+	case eventType > after.Type:
+		return " AND timestamp <= ?", []interface{}{ts}
+	case eventType == after.Type:
+		return " AND (timestamp < ? OR (timestamp = ? AND id < ?))", []interface{}{ts, ts, after.ID}
+	default:
+		return " AND timestamp < ?", []interface{}{ts}
+	}
+}
+
+type execSource struct {
+	db *sql.DB
+}
+
+func (s *execSource) Type() string   { return models.TimelineExec }
+func (s *execSource) HostWide() bool { return false }
+
+func (s *execSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", true)
+	rows, err := s.db.Query(
+		"SELECT "+processColumns+" FROM processes WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	processes, err := scanProcesses(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(processes))
+	for _, p := range processes {
+		events = append(events, execEvent(p))
+	}
+	return events, nil
+}
+
+func execEvent(p models.ProcessEvent) models.TimelineEvent {
+	pid, _ := strconv.Atoi(p.PID)
+	summary := "exec " + p.Args
+	if p.Args == "" {
+		summary = "exec " + p.Comm
+	}
+	return models.TimelineEvent{
+		Type:    models.TimelineExec,
+		Time:    p.Timestamp,
+		PID:     pid,
+		Comm:    p.Comm,
+		Summary: summary,
+		Payload: p,
+		ID:      p.ID,
+	}
+}
+
+type exitSource struct {
+	db *sql.DB
+}
+
+func (s *exitSource) Type() string   { return models.TimelineExit }
+func (s *exitSource) HostWide() bool { return false }
+
+func (s *exitSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", false)
+	rows, err := s.db.Query(
+		"SELECT "+exitColumns+" FROM process_exits WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exits, err := scanExits(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(exits))
+	for _, e := range exits {
+		events = append(events, exitEvent(e))
+	}
+	return events, nil
+}
+
+func exitEvent(e models.ProcessExit) models.TimelineEvent {
+	var summary string
+	switch {
+	case e.Signal != 0 && e.SignalName != "":
+		summary = fmt.Sprintf("%s killed by %s after %.2fs", e.Comm, e.SignalName, e.AgeSeconds)
+	case e.Signal != 0:
+		summary = fmt.Sprintf("%s killed by signal %d after %.2fs", e.Comm, e.Signal, e.AgeSeconds)
+	default:
+		summary = fmt.Sprintf("%s exited with code %d after %.2fs", e.Comm, e.ExitCode, e.AgeSeconds)
+	}
+	if e.CoreDumped {
+		summary += " (core dumped)"
+	}
+	return models.TimelineEvent{
+		Type:    models.TimelineExit,
+		Time:    e.Timestamp,
+		PID:     e.PID,
+		Comm:    e.Comm,
+		Summary: summary,
+		Payload: e,
+		ID:      e.ID,
+	}
+}
+
+type oomKillSource struct {
+	db *sql.DB
+}
+
+func (s *oomKillSource) Type() string   { return models.TimelineOOMKill }
+func (s *oomKillSource) HostWide() bool { return false }
+
+func (s *oomKillSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", false)
+	rows, err := s.db.Query(
+		"SELECT "+oomKillColumns+" FROM oom_kills WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	kills, err := scanOOMKills(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(kills))
+	for _, k := range kills {
+		events = append(events, oomKillEvent(k))
+	}
+	return events, nil
+}
+
+func oomKillEvent(k models.OOMKill) models.TimelineEvent {
+	return models.TimelineEvent{
+		Type:    models.TimelineOOMKill,
+		Time:    k.Timestamp,
+		PID:     k.PID,
+		Comm:    k.Comm,
+		Summary: fmt.Sprintf("%s OOM-killed with %d pages, triggered by %s (%d)", k.Comm, k.Pages, k.TriggerComm, k.TriggerPID),
+		Payload: k,
+		ID:      k.ID,
+	}
+}
+
+type signalSource struct {
+	db *sql.DB
+}
+
+func (s *signalSource) Type() string   { return models.TimelineSignal }
+func (s *signalSource) HostWide() bool { return false }
+
+func (s *signalSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", false)
+	rows, err := s.db.Query(
+		"SELECT "+signalColumns+" FROM signals WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signals, err := scanSignals(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(signals))
+	for _, sig := range signals {
+		events = append(events, signalEvent(sig))
+	}
+	return events, nil
+}
+
+// signalEvent places a signal on the timeline of its target
+func signalEvent(s models.Signal) models.TimelineEvent {
+	name := s.SignalName
+	if name == "" {
+		name = fmt.Sprintf("signal %d", s.Signal)
+	}
+	summary := fmt.Sprintf("%s from %s (%d)", name, s.SenderComm, s.SenderPID)
+	if s.Errno != 0 {
+		summary += " failed with " + s.ErrnoName
+	}
+	return models.TimelineEvent{
+		Type:    models.TimelineSignal,
+		Time:    s.Timestamp,
+		PID:     s.PID,
+		Comm:    s.Comm,
+		Summary: summary,
+		Payload: s,
+		ID:      s.ID,
+	}
+}
+
+type connectSource struct {
+	db *sql.DB
+}
+
+func (s *connectSource) Type() string   { return models.TimelineConnect }
+func (s *connectSource) HostWide() bool { return false }
+
+func (s *connectSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", true)
+	rows, err := s.db.Query(
+		"SELECT "+connectionColumns+" FROM network_connections WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	connections, err := scanConnections(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(connections))
+	for _, conn := range connections {
+		events = append(events, connectEvent(conn))
+	}
+	return events, nil
+}
+
+func connectEvent(conn models.NetworkConnection) models.TimelineEvent {
+	pid, _ := strconv.Atoi(conn.PID)
+	return models.TimelineEvent{
+		Type:    models.TimelineConnect,
+		Time:    conn.Timestamp,
+		PID:     pid,
+		Comm:    conn.Comm,
+		Summary: fmt.Sprintf("%s connected to %s:%s", conn.Comm, conn.DestAddr, conn.DestPort),
+		Payload: conn,
+		ID:      conn.ID,
+	}
+}
+
+type tcpSessionSource struct {
+	db *sql.DB
+}
+
+func (s *tcpSessionSource) Type() string   { return models.TimelineTCPSession }
+func (s *tcpSessionSource) HostWide() bool { return false }
+
+func (s *tcpSessionSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", false)
+	rows, err := s.db.Query(
+		"SELECT "+tcpLifeColumns+" FROM tcp_lifecycle WHERE"+where+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions, err := scanTCPLifeEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(sessions))
+	for _, t := range sessions {
+		events = append(events, tcpSessionEvent(t))
+	}
+	return events, nil
+}
+
+func tcpSessionEvent(t models.TCPLifeEvent) models.TimelineEvent {
+	return models.TimelineEvent{
+		Type: models.TimelineTCPSession,
+		Time: t.Timestamp,
+		PID:  t.PID,
+		Comm: t.Comm,
+		Summary: fmt.Sprintf("%s %s:%d -> %s:%d closed after %.0fms (tx %.1f KB, rx %.1f KB)",
+			t.Comm, t.LocalAddr, t.LocalPort, t.RemoteAddr, t.RemotePort, t.DurationMS, t.TxKB, t.RxKB),
+		Payload: t,
+		ID:      t.ID,
+	}
+}
+
+// cpuHotStackSource reports stacks seen in at least minSamples samples within
+// a single profile collection
+type cpuHotStackSource struct {
+	db         *sql.DB
+	minSamples int
+}
+
+func (s *cpuHotStackSource) Type() string   { return models.TimelineCPUHotStack }
+func (s *cpuHotStackSource) HostWide() bool { return false }
+
+func (s *cpuHotStackSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	where, args := timelineWhere(q, s.Type(), "comm", false)
+	args = append([]interface{}{s.minSamples}, args...)
+	rows, err := s.db.Query(
+		"SELECT "+cpuProfileColumns+" FROM cpu_profiles WHERE sample_count >= ? AND"+where+
+			" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles, err := scanCPUProfiles(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(profiles))
+	for _, p := range profiles {
+		events = append(events, cpuHotStackEvent(p))
+	}
+	return events, nil
+}
+
+func cpuHotStackEvent(p models.CPUProfile) models.TimelineEvent {
+	// profile prints the innermost frame first
+	frame, _, _ := strings.Cut(p.StackTrace, "\n")
+	comm := p.Comm
+	if comm == "" {
+		comm = p.ProcessName
+	}
+	return models.TimelineEvent{
+		Type:    models.TimelineCPUHotStack,
+		Time:    p.Timestamp,
+		PID:     p.PID,
+		Comm:    comm,
+		Summary: fmt.Sprintf("%s: %d samples in %s", comm, p.SampleCount, strings.TrimSpace(frame)),
+		Payload: p,
+		ID:      p.ID,
+	}
+}
+
+// diskSpikeSource reports histogram snapshots whose p99 latency reaches the
+// threshold. A snapshot consists of all buckets of a host saved with the same
+// timestamp.
+type diskSpikeSource struct {
+	db          *sql.DB
+	thresholdUS int
+}
+
+func (s *diskSpikeSource) Type() string   { return models.TimelineDiskSpike }
+func (s *diskSpikeSource) HostWide() bool { return true }
+
+func (s *diskSpikeSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	until := q.Until
+	if q.After != nil && q.After.Time.Before(until) {
+		until = q.After.Time
+	}
+	conditions, hostArgs := hostClause(q.Filter, "")
+	rows, err := s.db.Query(`
+		SELECT id, timestamp, range_min, range_max, count, COALESCE(host, '')
+		FROM disk_latency
+		WHERE timestamp >= ? AND timestamp <= ?`+conditions+`
+		ORDER BY timestamp DESC, id DESC`,
+		append([]interface{}{formatTime(q.Since), formatTime(until)}, hostArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		events   []models.TimelineEvent
+		snapshot []models.DiskLatency
+		lastID   int
+	)
+	flush := func() {
+		if len(snapshot) == 0 || len(events) >= q.Limit {
+			return
+		}
+		event, ok := diskSpikeEvent(snapshot, lastID, s.thresholdUS)
+		if ok && (q.After == nil || q.After.Before(event.Cursor())) {
+			events = append(events, event)
+		}
+	}
+
+	for rows.Next() {
+		var lat models.DiskLatency
+		if err := rows.Scan(&lat.ID, &lat.Timestamp, &lat.RangeMin, &lat.RangeMax, &lat.Count, &lat.Host); err != nil {
+			return nil, err
+		}
+		// Snapshots are saved in one transaction each, so the buckets of one
+		// are adjacent even when several hosts share a timestamp
+		if len(snapshot) > 0 && (!lat.Timestamp.Equal(snapshot[0].Timestamp) || lat.Host != snapshot[0].Host) {
+			flush()
+			snapshot = snapshot[:0]
+		}
+		if len(snapshot) == 0 {
+			lastID = lat.ID
+		}
+		snapshot = append(snapshot, lat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return events, nil
+}
+
+// diskSpikeEvent reports the snapshot of a host as a spike if its p99
+// latency reaches thresholdUS
+func diskSpikeEvent(snapshot []models.DiskLatency, id, thresholdUS int) (models.TimelineEvent, bool) {
+	p99 := models.LatencyPercentile(snapshot, 0.99)
+	if p99 < thresholdUS {
+		return models.TimelineEvent{}, false
+	}
+
+	spike := models.DiskSpike{
+		Host:        snapshot[0].Host,
+		P50US:       models.LatencyPercentile(snapshot, 0.5),
+		P99US:       p99,
+		ThresholdUS: thresholdUS,
+	}
+	for _, b := range snapshot {
+		spike.IOs += b.Count
+		if b.Count > 0 && b.RangeMax > spike.MaxUS {
+			spike.MaxUS = b.RangeMax
+		}
+	}
+
+	return models.TimelineEvent{
+		Type: models.TimelineDiskSpike,
+		Time: snapshot[0].Timestamp,
+		Summary: fmt.Sprintf("disk latency p99 %s over %d I/Os (threshold %s)",
+			time.Duration(p99)*time.Microsecond, spike.IOs, time.Duration(thresholdUS)*time.Microsecond),
+		Payload: spike,
+		ID:      id,
+	}, true
+}
+
+// syscallSpikeSource reports syscall counts of an interval that exceed the
+// average of the preceding intervals by factor
+type syscallSpikeSource struct {
+	db       *sql.DB
+	factor   float64
+	minCount int
+}
+
+func (s *syscallSpikeSource) Type() string   { return models.TimelineSyscallSpike }
+func (s *syscallSpikeSource) HostWide() bool { return true }
+
+func (s *syscallSpikeSource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	hostConditions, hostArgs := hostClause(q.Filter, "")
+	conditions, cursorArgs := cursorClause(q.After, s.Type())
+	args := append([]interface{}{formatTime(q.Since), formatTime(q.Until)}, hostArgs...)
+	args = append(args, formatTime(q.Since), formatTime(q.Until), s.minCount, s.factor)
+	args = append(args, cursorArgs...)
+
+	// The baseline looks back a few minutes before the window so that spikes
+	// at the start of the window are detected as well. Every host has its own.
+	rows, err := s.db.Query(`
+		SELECT id, timestamp, host, syscall_name, count, baseline
+		FROM (
+			SELECT id, timestamp, COALESCE(host, '') AS host, syscall_name, count,
+				AVG(count) OVER (
+					PARTITION BY host, syscall_name ORDER BY id
+					ROWS BETWEEN `+strconv.Itoa(syscallBaselineIntervals)+` PRECEDING AND 1 PRECEDING
+				) AS baseline
+			FROM syscall_stats
+			WHERE timestamp >= datetime(?, '-5 minutes') AND timestamp <= ?`+hostConditions+`
+		)
+		WHERE timestamp >= ? AND timestamp <= ? AND baseline IS NOT NULL
+			AND count >= ? AND count > baseline * ?`+conditions+`
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?`,
+		append(args, q.Limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TimelineEvent
+	for rows.Next() {
+		var (
+			id        int
+			timestamp string
+			spike     models.SyscallSpike
+		)
+		if err := rows.Scan(&id, &timestamp, &spike.Host, &spike.Syscall, &spike.Count, &spike.Baseline); err != nil {
+			return nil, err
+		}
+		events = append(events, syscallSpikeEvent(id, parseTimestamp(timestamp), spike))
+	}
+	return events, rows.Err()
+}
+
+func syscallSpikeEvent(id int, timestamp time.Time, spike models.SyscallSpike) models.TimelineEvent {
+	if spike.Baseline > 0 {
+		spike.Factor = float64(spike.Count) / spike.Baseline
+	}
+	return models.TimelineEvent{
+		Type: models.TimelineSyscallSpike,
+		Time: timestamp,
+		Summary: fmt.Sprintf("%s syscalls spiked to %d per interval (%.1fx the baseline of %.0f)",
+			spike.Syscall, spike.Count, spike.Factor, spike.Baseline),
+		Payload: spike,
+		ID:      id,
+	}
+}
+
+// anomalySource reports deviations from the learned baselines. Anomalies of
+// per-command metrics can be selected by comm; they carry no PID, pod or host.
+type anomalySource struct {
+	db *sql.DB
+}
+
+func (s *anomalySource) Type() string   { return models.TimelineAnomaly }
+func (s *anomalySource) HostWide() bool { return false }
+
+func (s *anomalySource) Events(q models.TimelineQuery) ([]models.TimelineEvent, error) {
+	if q.PID > 0 || !q.Filter.IsEmpty() {
+		return nil, nil
+	}
+
+	where := " timestamp >= ? AND timestamp <= ?"
+	args := []interface{}{formatTime(q.Since), formatTime(q.Until)}
+	if q.Comm != "" {
+		where += " AND comm = ?"
+		args = append(args, q.Comm)
+	}
+	conditions, cursorArgs := cursorClause(q.After, s.Type())
+	args = append(append(args, cursorArgs...), q.Limit)
+
+	rows, err := s.db.Query(
+		"SELECT "+anomalyColumns+" FROM anomalies WHERE"+where+conditions+" ORDER BY timestamp DESC, id DESC LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies, err := scanAnomalies(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.TimelineEvent, 0, len(anomalies))
+	for _, a := range anomalies {
+		events = append(events, anomalyEvent(a))
+	}
+	return events, nil
+}
+
+func anomalyEvent(a models.Anomaly) models.TimelineEvent {
+	return models.TimelineEvent{
+		Type: models.TimelineAnomaly,
+		Time: a.Timestamp,
+		Comm: a.Comm,
+		Summary: fmt.Sprintf("%s of %s was %.2f, expected %.2f ± %.2f (z-score %.1f, %s)",
+			a.Metric, a.Subject, a.Value, a.Expected, a.StdDev, a.Score, a.Method),
+		Payload: a,
+		ID:      a.ID,
+	}
+}
@@ -0,0 +1,57 @@
+package repository
+
+import "time"
+
+// sqliteTimeFormat matches the format of CURRENT_TIMESTAMP, which is UTC
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// formatTime converts t into a value comparable with timestamp columns
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// eventTimestamp binds the timestamp of an event on insert, falling back to
+// the insert time for events collected locally, which carry none
+const eventTimestamp = "COALESCE(?, CURRENT_TIMESTAMP)"
+
+// timestampValue returns the value bound to eventTimestamp
+func timestampValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return formatTime(t)
+}
+
+// columnTime returns t as a timestamp column returns it, in UTC with second
+// precision
+func columnTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// storedTime resolves eventTimestamp in Go for backends without SQL
+// defaults or where rows are copied: the insert time stands in for a zero t
+func storedTime(t time.Time) time.Time {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return columnTime(t)
+}
+
+// parseTimestamp parses a timestamp returned by SQLite as text, e.g. from
+// MIN(timestamp), where the driver cannot convert it to time.Time itself.
+// It returns the zero time if no known format matches.
+func parseTimestamp(value string) time.Time {
+	formats := []string{
+		sqliteTimeFormat,
+		time.RFC3339,
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05",
+	}
+
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
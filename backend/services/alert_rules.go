@@ -0,0 +1,224 @@
+package services
+
+import (
+	"ebpf-dashboard/models"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule is returned when an alert rule fails validation
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+// alertOperators compare a rule value against its threshold
+var alertOperators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// alertGroupBy lists the fields each event source can be grouped by
+var alertGroupBy = map[string][]string{
+	models.AlertSourceExec:       {"comm", "pid"},
+	models.AlertSourceExit:       {"comm", "pid"},
+	models.AlertSourceConnect:    {"comm", "pid", "addr", "port"},
+	models.AlertSourceTCPSession: {"comm", "pid", "addr", "port"},
+	models.AlertSourceAccept:     {"comm", "pid", "addr", "port"},
+	models.AlertSourceListen:     {"comm", "pid", "addr", "port"},
+}
+
+var alertSeverities = []string{"info", "warning", "critical"}
+
+// isEventSource reports whether the rule counts events rather than
+// evaluating an aggregate
+func isEventSource(source string) bool {
+	_, ok := alertGroupBy[source]
+	return ok
+}
+
+// normalizeRule fills in defaults and validates the rule
+func normalizeRule(rule *models.AlertRule) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+	}
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return invalid("name is required")
+	}
+	if rule.WindowSeconds == 0 {
+		rule.WindowSeconds = 60
+	}
+	if rule.Operator == "" {
+		rule.Operator = ">"
+	}
+	if rule.Severity == "" {
+		rule.Severity = "warning"
+	}
+
+	switch {
+	case isEventSource(rule.Source):
+		if rule.GroupBy != "" && !contains(alertGroupBy[rule.Source], rule.GroupBy) {
+			return invalid("group_by must be one of %s for source %s",
+				strings.Join(alertGroupBy[rule.Source], ", "), rule.Source)
+		}
+	case rule.Source == models.AlertSourceDiskP99 || rule.Source == models.AlertSourceSyscallRate:
+		if rule.GroupBy != "" {
+			return invalid("source %s does not support group_by", rule.Source)
+		}
+		if rule.Source == models.AlertSourceSyscallRate && rule.Match.Syscall == "" {
+			return invalid("match.syscall is required for source %s", rule.Source)
+		}
+	default:
+		return invalid("unknown source %q", rule.Source)
+	}
+
+	if rule.WindowSeconds < 0 || rule.ForSeconds < 0 {
+		return invalid("window_seconds and for_seconds must not be negative")
+	}
+	if _, ok := alertOperators[rule.Operator]; !ok {
+		return invalid("unknown operator %q", rule.Operator)
+	}
+	if !contains(alertSeverities, rule.Severity) {
+		return invalid("severity must be one of %s", strings.Join(alertSeverities, ", "))
+	}
+	if _, err := compileRule(*rule); err != nil {
+		return invalid("%v", err)
+	}
+	return nil
+}
+
+// alertEvent is the common shape events are matched in
+type alertEvent struct {
+	comm   string
+	pid    int
+	args   string
+	addr   string
+	port   int
+	signal int
+	failed bool
+}
+
+type alertHit struct {
+	at  time.Time
+	key string
+}
+
+// compiledRule is a rule with its matchers prepared and the hits of
+// matching events within the window
+type compiledRule struct {
+	rule  models.AlertRule
+	args  *regexp.Regexp
+	addrs map[string]bool
+	nets  []*net.IPNet
+	hits  []alertHit
+}
+
+func compileRule(rule models.AlertRule) (*compiledRule, error) {
+	compiled := &compiledRule{rule: rule, addrs: make(map[string]bool)}
+
+	if rule.Match.ArgsRegex != "" {
+		re, err := regexp.Compile(rule.Match.ArgsRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid args_regex: %v", err)
+		}
+		compiled.args = re
+	}
+
+	for _, addr := range rule.Match.Addr {
+		if strings.Contains(addr, "/") {
+			_, network, err := net.ParseCIDR(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid addr %q", addr)
+			}
+			compiled.nets = append(compiled.nets, network)
+			continue
+		}
+		compiled.addrs[addr] = true
+	}
+
+	return compiled, nil
+}
+
+func (r *compiledRule) window() time.Duration {
+	return time.Duration(r.rule.WindowSeconds) * time.Second
+}
+
+func (r *compiledRule) matches(e alertEvent) bool {
+	m := r.rule.Match
+	if len(m.Comm) > 0 && !contains(m.Comm, e.comm) {
+		return false
+	}
+	if r.args != nil && !r.args.MatchString(e.args) {
+		return false
+	}
+	if len(m.Addr) > 0 && !r.matchesAddr(e.addr) {
+		return false
+	}
+	if len(m.Port) > 0 && !containsInt(m.Port, e.port) {
+		return false
+	}
+	if len(m.Signal) > 0 && !containsInt(m.Signal, e.signal) {
+		return false
+	}
+	if m.Failed && !e.failed {
+		return false
+	}
+	return true
+}
+
+func (r *compiledRule) matchesAddr(addr string) bool {
+	if r.addrs[addr] {
+		return true
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range r.nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKey returns the value of the group_by field of e
+func (r *compiledRule) groupKey(e alertEvent) string {
+	switch r.rule.GroupBy {
+	case "comm":
+		return e.comm
+	case "pid":
+		return strconv.Itoa(e.pid)
+	case "addr":
+		return e.addr
+	case "port":
+		return strconv.Itoa(e.port)
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,564 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type AlertService interface {
+	Start() error
+	Stop()
+	HandleExecs(events []models.ProcessEvent)
+	HandleExits(events []models.ProcessExit)
+	HandleConnections(events []models.NetworkConnection)
+	HandleTCPSessions(events []models.TCPLifeEvent)
+	HandleAccepts(events []models.InboundConnection)
+	HandleListenEvents(events []models.ListenEvent)
+	HandleDiskLatency(buckets []models.DiskLatency)
+	HandleSyscalls(stats []models.SyscallStat)
+	HandleBatch(batch models.Batch, listenEvents []models.ListenEvent)
+	GetRules() ([]models.AlertRule, error)
+	GetRule(id int) (models.AlertRule, error)
+	CreateRule(rule models.AlertRule) (models.AlertRule, error)
+	UpdateRule(id int, rule models.AlertRule) (models.AlertRule, error)
+	DeleteRule(id int) error
+	GetAlerts(state string, since time.Time, limit int) ([]models.Alert, error)
+}
+
+type alertKey struct {
+	ruleID int
+	group  string
+}
+
+type diskSample struct {
+	at      time.Time
+	buckets []models.DiskLatency
+}
+
+type syscallSample struct {
+	at    time.Time
+	stats []models.SyscallStat
+}
+
+type alertService struct {
+	repo           repository.AlertRepository
+	notifier       AlertNotifier
+	defaultWebhook string
+	interval       time.Duration
+
+	mu       sync.Mutex
+	rules    map[int]*compiledRule
+	active   map[alertKey]*models.Alert
+	disk     []diskSample
+	syscalls []syscallSample
+
+	// saveMu serializes persisting alert changes outside of mu, so an alert
+	// is inserted once and its changes are saved in order
+	saveMu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewAlertService returns a rules engine that evaluates every interval and
+// sends state changes to the rule's webhook, or defaultWebhook if it has none
+func NewAlertService(repo repository.AlertRepository, notifier AlertNotifier, defaultWebhook string, interval time.Duration) AlertService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &alertService{
+		repo:           repo,
+		notifier:       notifier,
+		defaultWebhook: defaultWebhook,
+		interval:       interval,
+		rules:          make(map[int]*compiledRule),
+		active:         make(map[alertKey]*models.Alert),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// Start loads the rules and the alerts that were active at the last shutdown
+// and starts evaluating
+func (s *alertService) Start() error {
+	rules, err := s.repo.GetRules()
+	if err != nil {
+		return err
+	}
+	active, err := s.repo.GetActiveAlerts()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for _, rule := range rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			log.Printf("Skipping alert rule %d (%s): %v", rule.ID, rule.Name, err)
+			continue
+		}
+		s.rules[rule.ID] = compiled
+	}
+	for i := range active {
+		alert := active[i]
+		s.active[alertKey{alert.RuleID, alert.GroupKey}] = &alert
+	}
+	s.mu.Unlock()
+
+	s.notifier.Start()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.evaluate(now)
+			}
+		}
+	}()
+
+	log.Printf("Alert service started with %d rules", len(rules))
+	return nil
+}
+
+// Stop stops evaluating and flushes pending webhook deliveries
+func (s *alertService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	s.notifier.Stop()
+}
+
+// HandleExecs records exec events from execsnoop
+func (s *alertService) HandleExecs(events []models.ProcessEvent) {
+	batch := make([]alertEvent, 0, len(events))
+	for _, e := range events {
+		pid, _ := strconv.Atoi(e.PID)
+		batch = append(batch, alertEvent{comm: e.Comm, pid: pid, args: e.Args})
+	}
+	s.record(models.AlertSourceExec, batch)
+}
+
+// HandleExits records exit events from exitsnoop
+func (s *alertService) HandleExits(events []models.ProcessExit) {
+	batch := make([]alertEvent, 0, len(events))
+	for _, e := range events {
+		batch = append(batch, alertEvent{
+			comm:   e.Comm,
+			pid:    e.PID,
+			signal: e.Signal,
+			failed: e.ExitCode != 0 || e.Signal != 0,
+		})
+	}
+	s.record(models.AlertSourceExit, batch)
+}
+
+// HandleConnections records connect events from tcpconnect
+func (s *alertService) HandleConnections(events []models.NetworkConnection) {
+	batch := make([]alertEvent, 0, len(events))
+	for _, e := range events {
+		pid, _ := strconv.Atoi(e.PID)
+		port, _ := strconv.Atoi(e.DestPort)
+		batch = append(batch, alertEvent{comm: e.Comm, pid: pid, addr: e.DestAddr, port: port})
+	}
+	s.record(models.AlertSourceConnect, batch)
+}
+
+// HandleTCPSessions records closed TCP sessions from tcplife
+func (s *alertService) HandleTCPSessions(events []models.TCPLifeEvent) {
+	batch := make([]alertEvent, 0, len(events))
+	for _, e := range events {
+		batch = append(batch, alertEvent{comm: e.Comm, pid: e.PID, addr: e.RemoteAddr, port: e.RemotePort})
+	}
+	s.record(models.AlertSourceTCPSession, batch)
+}
+
+// HandleAccepts records inbound connections from tcpaccept. The address is
+// that of the client and the port the local one, so rules can match clients
+// of a service.
+func (s *alertService) HandleAccepts(events []models.InboundConnection) {
+	batch := make([]alertEvent, 0, len(events))
+	for _, e := range events {
+		batch = append(batch, alertEvent{comm: e.Comm, pid: e.PID, addr: e.RemoteAddr, port: e.LocalPort})
+	}
+	s.record(models.AlertSourceAccept, batch)
+}
+
+// HandleListenEvents records listening sockets that were opened
+func (s *alertService) HandleListenEvents(events []models.ListenEvent) {
+	batch := make([]alertEvent, 0, len(events))
+	for _, e := range events {
+		if e.Kind == models.ListenOpened {
+			batch = append(batch, alertEvent{comm: e.Comm, pid: e.PID, addr: e.Addr, port: e.Port})
+		}
+	}
+	s.record(models.AlertSourceListen, batch)
+}
+
+// HandleDiskLatency records one biolatency histogram
+func (s *alertService) HandleDiskLatency(buckets []models.DiskLatency) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disk = append(s.disk, diskSample{at: time.Now(), buckets: buckets})
+}
+
+// HandleSyscalls records one interval of host-wide syscall counts
+func (s *alertService) HandleSyscalls(stats []models.SyscallStat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syscalls = append(s.syscalls, syscallSample{at: time.Now(), stats: stats})
+}
+
+// HandleBatch records the events of an agent batch. Disk latency and
+// syscall rules see the histograms and counts of all hosts together.
+func (s *alertService) HandleBatch(batch models.Batch, listenEvents []models.ListenEvent) {
+	s.HandleExecs(batch.Processes)
+	s.HandleExits(batch.Exits)
+	s.HandleConnections(batch.Connections)
+	s.HandleTCPSessions(batch.TCPSessions)
+	s.HandleAccepts(batch.Inbound)
+	s.HandleListenEvents(listenEvents)
+	if len(batch.DiskLatency) > 0 {
+		s.HandleDiskLatency(batch.DiskLatency)
+	}
+	if len(batch.Syscalls) > 0 {
+		s.HandleSyscalls(batch.Syscalls)
+	}
+}
+
+// record adds a hit to every enabled rule on source that matches an event
+func (s *alertService) record(source string, events []alertEvent) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rule := range s.rules {
+		if rule.rule.Source != source || !rule.rule.Enabled {
+			continue
+		}
+		for _, e := range events {
+			if rule.matches(e) {
+				rule.hits = append(rule.hits, alertHit{at: now, key: rule.groupKey(e)})
+			}
+		}
+	}
+}
+
+// alertChange is a state change of an alert, persisted and notified after
+// s.mu is released so the collectors recording events do not wait on it
+type alertChange struct {
+	rule   models.AlertRule
+	live   *models.Alert // the alert as kept in s.active
+	alert  models.Alert  // the alert as of the change
+	delete bool
+	notify bool
+}
+
+// evaluate computes the value of every rule and moves alerts between states
+func (s *alertService) evaluate(now time.Time) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	var changes []alertChange
+	longest := time.Duration(0)
+	breaching := make(map[alertKey]bool)
+
+	for id, rule := range s.rules {
+		if rule.window() > longest {
+			longest = rule.window()
+		}
+		if !rule.rule.Enabled {
+			continue
+		}
+
+		compare := alertOperators[rule.rule.Operator]
+		for group, value := range s.values(rule, now) {
+			if !compare(value, rule.rule.Threshold) {
+				continue
+			}
+			key := alertKey{id, group}
+			breaching[key] = true
+			changes = append(changes, s.transition(rule, key, value, now))
+		}
+	}
+
+	for key, alert := range s.active {
+		if breaching[key] {
+			continue
+		}
+		rule, ok := s.rules[key.ruleID]
+		if !ok {
+			// The rule was deleted while the service was not running
+			changes = append(changes, s.clear(models.AlertRule{ID: alert.RuleID, Name: alert.RuleName, Severity: alert.Severity}, key, alert, now))
+			continue
+		}
+		changes = append(changes, s.clear(rule.rule, key, alert, now))
+	}
+
+	s.prune(now.Add(-longest))
+	s.mu.Unlock()
+
+	s.apply(changes)
+}
+
+// apply persists alert changes and sends their notifications. Callers hold
+// s.saveMu but not s.mu.
+func (s *alertService) apply(changes []alertChange) {
+	for i := range changes {
+		c := &changes[i]
+		if c.delete {
+			if err := s.repo.DeleteAlert(c.alert.ID); err != nil {
+				log.Printf("Error deleting pending alert %d: %v", c.alert.ID, err)
+			}
+			continue
+		}
+		if err := s.repo.SaveAlert(&c.alert); err != nil {
+			log.Printf("Error saving alert for rule %d: %v", c.rule.ID, err)
+		}
+		if c.notify {
+			s.notify(c.rule, c.alert)
+		}
+	}
+
+	// Alerts inserted just now have an ID, which their next update needs
+	s.mu.Lock()
+	for _, c := range changes {
+		if c.live.ID == 0 {
+			c.live.ID = c.alert.ID
+		}
+	}
+	s.mu.Unlock()
+}
+
+// values returns the rule value per group key. Ungrouped event rules always
+// report a value, so that conditions like "< 1" can fire on silence.
+func (s *alertService) values(rule *compiledRule, now time.Time) map[string]float64 {
+	from := now.Add(-rule.window())
+	values := make(map[string]float64)
+
+	switch rule.rule.Source {
+	case models.AlertSourceDiskP99:
+		var buckets []models.DiskLatency
+		for _, sample := range s.disk {
+			if !sample.at.Before(from) {
+				buckets = append(buckets, sample.buckets...)
+			}
+		}
+		if len(buckets) > 0 {
+			values[""] = float64(models.LatencyPercentile(buckets, 0.99)) / 1000
+		}
+
+	case models.AlertSourceSyscallRate:
+		total := 0
+		for _, sample := range s.syscalls {
+			if sample.at.Before(from) {
+				continue
+			}
+			for _, stat := range sample.stats {
+				if stat.SyscallName == rule.rule.Match.Syscall {
+					total += stat.Count
+				}
+			}
+		}
+		values[""] = float64(total) / rule.window().Seconds()
+
+	default:
+		kept := rule.hits[:0]
+		for _, hit := range rule.hits {
+			if !hit.at.Before(from) {
+				kept = append(kept, hit)
+				values[hit.key]++
+			}
+		}
+		rule.hits = kept
+		if _, ok := values[""]; !ok && rule.rule.GroupBy == "" {
+			values[""] = 0
+		}
+	}
+
+	return values
+}
+
+// transition handles a breaching condition: it starts a pending alert,
+// fires it once the condition held for for_seconds, or refreshes the value
+// of an alert that is already firing. Callers hold s.mu.
+func (s *alertService) transition(rule *compiledRule, key alertKey, value float64, now time.Time) alertChange {
+	alert, ok := s.active[key]
+	if !ok {
+		alert = &models.Alert{
+			RuleID:    rule.rule.ID,
+			RuleName:  rule.rule.Name,
+			Severity:  rule.rule.Severity,
+			GroupKey:  key.group,
+			State:     models.AlertPending,
+			StartedAt: now,
+		}
+		s.active[key] = alert
+	}
+
+	alert.Value = value
+	alert.Summary = alertSummary(rule.rule, key.group, value)
+	alert.UpdatedAt = now
+
+	notify := false
+	if alert.State == models.AlertPending && now.Sub(alert.StartedAt) >= time.Duration(rule.rule.ForSeconds)*time.Second {
+		alert.State = models.AlertFiring
+		fired := now
+		alert.FiredAt = &fired
+		notify = true
+	}
+
+	return alertChange{rule: rule.rule, live: alert, alert: *alert, notify: notify}
+}
+
+// clear ends an alert whose condition no longer holds. Pending alerts are
+// dropped, firing alerts are resolved. Callers hold s.mu.
+func (s *alertService) clear(rule models.AlertRule, key alertKey, alert *models.Alert, now time.Time) alertChange {
+	delete(s.active, key)
+
+	if alert.State == models.AlertPending {
+		return alertChange{rule: rule, live: alert, alert: *alert, delete: true}
+	}
+
+	alert.State = models.AlertResolved
+	resolved := now
+	alert.ResolvedAt = &resolved
+	alert.UpdatedAt = now
+	return alertChange{rule: rule, live: alert, alert: *alert, notify: true}
+}
+
+func (s *alertService) notify(rule models.AlertRule, alert models.Alert) {
+	url := rule.WebhookURL
+	if url == "" {
+		url = s.defaultWebhook
+	}
+	if url == "" {
+		return
+	}
+	s.notifier.Notify(url, models.AlertNotification{Status: alert.State, Alert: alert, Rule: rule})
+}
+
+// prune drops metric samples no rule looks at anymore
+func (s *alertService) prune(before time.Time) {
+	i := 0
+	for i < len(s.disk) && s.disk[i].at.Before(before) {
+		i++
+	}
+	s.disk = s.disk[i:]
+
+	i = 0
+	for i < len(s.syscalls) && s.syscalls[i].at.Before(before) {
+		i++
+	}
+	s.syscalls = s.syscalls[i:]
+}
+
+func alertSummary(rule models.AlertRule, group string, value float64) string {
+	summary := fmt.Sprintf("%s: %s is %.2f (%s %g over %ds)",
+		rule.Name, rule.Source, value, rule.Operator, rule.Threshold, rule.WindowSeconds)
+	if group != "" {
+		summary += fmt.Sprintf(" for %s=%s", rule.GroupBy, group)
+	}
+	return summary
+}
+
+func (s *alertService) GetRules() ([]models.AlertRule, error) {
+	return s.repo.GetRules()
+}
+
+func (s *alertService) GetRule(id int) (models.AlertRule, error) {
+	return s.repo.GetRule(id)
+}
+
+// CreateRule validates and stores a rule and starts evaluating it
+func (s *alertService) CreateRule(rule models.AlertRule) (models.AlertRule, error) {
+	if err := normalizeRule(&rule); err != nil {
+		return models.AlertRule{}, err
+	}
+	if err := s.repo.CreateRule(&rule); err != nil {
+		return models.AlertRule{}, err
+	}
+
+	s.setRule(rule)
+	return rule, nil
+}
+
+// UpdateRule replaces a rule. Counted events are discarded; alerts of the
+// rule resolve on the next evaluation if they no longer hold.
+func (s *alertService) UpdateRule(id int, rule models.AlertRule) (models.AlertRule, error) {
+	existing, err := s.repo.GetRule(id)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	if err := normalizeRule(&rule); err != nil {
+		return models.AlertRule{}, err
+	}
+
+	rule.ID = id
+	rule.CreatedAt = existing.CreatedAt
+	if err := s.repo.UpdateRule(&rule); err != nil {
+		return models.AlertRule{}, err
+	}
+
+	s.setRule(rule)
+	return rule, nil
+}
+
+// DeleteRule removes a rule and resolves its active alerts
+func (s *alertService) DeleteRule(id int) error {
+	if err := s.repo.DeleteRule(id); err != nil {
+		return err
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	rule, ok := s.rules[id]
+	delete(s.rules, id)
+	if !ok {
+		s.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	var changes []alertChange
+	for key, alert := range s.active {
+		if key.ruleID == id {
+			changes = append(changes, s.clear(rule.rule, key, alert, now))
+		}
+	}
+	s.mu.Unlock()
+
+	s.apply(changes)
+	return nil
+}
+
+func (s *alertService) setRule(rule models.AlertRule) {
+	compiled, err := compileRule(rule)
+	if err != nil {
+		// normalizeRule already compiled the rule once
+		log.Printf("Error compiling alert rule %d: %v", rule.ID, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.rules[rule.ID] = compiled
+	s.mu.Unlock()
+}
+
+func (s *alertService) GetAlerts(state string, since time.Time, limit int) ([]models.Alert, error) {
+	return s.repo.GetAlerts(state, since, limit)
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"time"
+)
+
+type AttributionService interface {
+	GetSummary(groupBy string, since, until time.Time, filter models.Filter) ([]models.AttributionSummary, error)
+}
+
+type attributionService struct {
+	repo repository.AttributionRepository
+}
+
+func NewAttributionService(repo repository.AttributionRepository) AttributionService {
+	return &attributionService{repo: repo}
+}
+
+// GetSummary aggregates all events in the window by systemd unit, container, cgroup or pod
+func (s *attributionService) GetSummary(groupBy string, since, until time.Time, filter models.Filter) ([]models.AttributionSummary, error) {
+	return s.repo.GetSummary(groupBy, since, until, filter)
+}
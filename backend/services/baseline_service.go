@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// minStdDev keeps series that never varied from flagging every small change
+const minStdDev = 0.5
+
+// Idle series, e.g. of commands that no longer run, stop being zero-filled
+// and are dropped from memory once their overall baseline has decayed to
+// about zero, or after seriesMaxIdleBuckets in any case. Their baselines stay
+// stored; a series that comes back learns from scratch.
+const (
+	seriesDecayedMean    = 0.01
+	seriesMinIdleBuckets = 60
+	seriesMaxIdleBuckets = 1000
+)
+
+type BaselineService interface {
+	Start() error
+	Stop()
+	HandleExecs(events []models.ProcessEvent)
+	HandleConnections(events []models.NetworkConnection)
+	HandleTCPSessions(events []models.TCPLifeEvent)
+	HandleSyscalls(stats []models.SyscallStat)
+	HandleBatch(batch models.Batch, listenEvents []models.ListenEvent)
+	GetBaselines(metric, subject string) ([]models.Baseline, error)
+	GetAnomalies(since, until time.Time, metric, comm string, limit int) ([]models.Anomaly, error)
+}
+
+// BaselineConfig tunes how baselines are learned and scored
+type BaselineConfig struct {
+	Bucket     time.Duration // values are aggregated per bucket before scoring
+	Alpha      float64       // EWMA smoothing factor, higher adapts faster
+	Threshold  float64       // z-score from which a value is an anomaly
+	MinSamples int           // samples a baseline needs before it is used for scoring
+}
+
+// twoSidedMetrics lists the metrics that are also anomalous when they drop.
+// Counts only alert on increases.
+var twoSidedMetrics = map[string]bool{
+	models.BaselineSyscallRate: true,
+	models.BaselineTCPDuration: true,
+}
+
+type baselineKey struct {
+	metric  string
+	subject string
+	slot    int
+}
+
+type seriesKey struct {
+	metric  string
+	subject string
+}
+
+// baselineBucket aggregates the events of one bucket
+type baselineBucket struct {
+	syscalls map[string]int
+	execs    map[string]int
+	newPorts map[string]int
+	tcpTotal map[string]float64
+	tcpCount map[string]int
+}
+
+func newBaselineBucket() *baselineBucket {
+	return &baselineBucket{
+		syscalls: make(map[string]int),
+		execs:    make(map[string]int),
+		newPorts: make(map[string]int),
+		tcpTotal: make(map[string]float64),
+		tcpCount: make(map[string]int),
+	}
+}
+
+type baselineService struct {
+	repo   repository.BaselineRepository
+	config BaselineConfig
+
+	mu         sync.Mutex
+	bucket     *baselineBucket
+	baselines  map[baselineKey]*models.Baseline
+	series     map[seriesKey]int // observed series, with the buckets since their last value
+	knownPorts map[string]map[int]bool
+	newPorts   map[string][]int // first seen in the current bucket, not yet saved
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewBaselineService(repo repository.BaselineRepository, config BaselineConfig) BaselineService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &baselineService{
+		repo:       repo,
+		config:     config,
+		bucket:     newBaselineBucket(),
+		baselines:  make(map[baselineKey]*models.Baseline),
+		series:     make(map[seriesKey]int),
+		knownPorts: make(map[string]map[int]bool),
+		newPorts:   make(map[string][]int),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start loads the learned baselines and known ports and starts scoring
+// a bucket at a time
+func (s *baselineService) Start() error {
+	baselines, err := s.repo.GetBaselines("", "")
+	if err != nil {
+		return err
+	}
+	ports, err := s.repo.GetDestPorts()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for i := range baselines {
+		b := baselines[i]
+		s.baselines[baselineKey{b.Metric, b.Subject, b.Slot}] = &b
+		s.series[seriesKey{b.Metric, b.Subject}] = 0
+	}
+	for comm, list := range ports {
+		known := make(map[int]bool, len(list))
+		for _, port := range list {
+			known[port] = true
+		}
+		s.knownPorts[comm] = known
+	}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.Bucket)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.closeBucket(now)
+			}
+		}
+	}()
+
+	log.Printf("Baseline service started with %d baselines", len(baselines))
+	return nil
+}
+
+func (s *baselineService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// HandleExecs counts exec events per command
+func (s *baselineService) HandleExecs(events []models.ProcessEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		s.bucket.execs[e.Comm]++
+	}
+}
+
+// HandleConnections counts destination ports a command has not connected to before
+func (s *baselineService) HandleConnections(events []models.NetworkConnection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		port, err := strconv.Atoi(e.DestPort)
+		if err != nil {
+			continue
+		}
+		known := s.knownPorts[e.Comm]
+		if known == nil {
+			known = make(map[int]bool)
+			s.knownPorts[e.Comm] = known
+		}
+		if known[port] {
+			// Make sure the command has a series even if all its ports are known
+			if _, ok := s.bucket.newPorts[e.Comm]; !ok {
+				s.bucket.newPorts[e.Comm] = 0
+			}
+			continue
+		}
+		known[port] = true
+		s.newPorts[e.Comm] = append(s.newPorts[e.Comm], port)
+		s.bucket.newPorts[e.Comm]++
+	}
+}
+
+// HandleTCPSessions sums TCP session durations per command
+func (s *baselineService) HandleTCPSessions(events []models.TCPLifeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		s.bucket.tcpTotal[e.Comm] += e.DurationMS
+		s.bucket.tcpCount[e.Comm]++
+	}
+}
+
+// HandleSyscalls sums host-wide syscall counts
+func (s *baselineService) HandleSyscalls(stats []models.SyscallStat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stat := range stats {
+		s.bucket.syscalls[stat.SyscallName] += stat.Count
+	}
+}
+
+// HandleBatch counts the events of an agent batch. Syscall counts are summed
+// with those of the other hosts.
+func (s *baselineService) HandleBatch(batch models.Batch, _ []models.ListenEvent) {
+	s.HandleExecs(batch.Processes)
+	s.HandleConnections(batch.Connections)
+	s.HandleTCPSessions(batch.TCPSessions)
+	s.HandleSyscalls(batch.Syscalls)
+}
+
+// closeBucket scores the values of the bucket against their baselines,
+// then folds them into the baselines
+func (s *baselineService) closeBucket(now time.Time) {
+	s.mu.Lock()
+	bucket := s.bucket
+	s.bucket = newBaselineBucket()
+	newPorts := s.newPorts
+	s.newPorts = make(map[string][]int)
+
+	values := make(map[seriesKey]float64)
+	for name, count := range bucket.syscalls {
+		values[seriesKey{models.BaselineSyscallRate, name}] = float64(count) / s.config.Bucket.Seconds()
+	}
+	for comm, count := range bucket.execs {
+		values[seriesKey{models.BaselineExecCount, comm}] = float64(count)
+	}
+	for comm, count := range bucket.newPorts {
+		values[seriesKey{models.BaselineNewDestPorts, comm}] = float64(count)
+	}
+	for comm, count := range bucket.tcpCount {
+		values[seriesKey{models.BaselineTCPDuration, comm}] = bucket.tcpTotal[comm] / float64(count)
+	}
+
+	for key := range values {
+		s.series[key] = 0
+	}
+
+	// A series that was seen before and has no value in this bucket counts as
+	// zero, except durations, which only exist when sessions were closed
+	for key, idle := range s.series {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		idle++
+		if s.expired(key, idle) {
+			s.dropSeries(key)
+			continue
+		}
+		s.series[key] = idle
+		if key.metric != models.BaselineTCPDuration {
+			values[key] = 0
+		}
+	}
+
+	slot := models.HourOfWeek(now)
+	var (
+		anomalies []models.Anomaly
+		updated   []models.Baseline
+	)
+	for key, value := range values {
+		if anomaly, ok := s.score(key, slot, value); ok {
+			anomaly.Timestamp = now
+			anomalies = append(anomalies, anomaly)
+		}
+
+		for _, learnSlot := range []int{slot, models.BaselineOverall} {
+			updated = append(updated, s.learn(baselineKey{key.metric, key.subject, learnSlot}, value, now))
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.repo.SaveDestPorts(newPorts, now); err != nil {
+		log.Printf("Error saving destination ports: %v", err)
+	}
+	if err := s.repo.SaveBaselines(updated); err != nil {
+		log.Printf("Error saving baselines: %v", err)
+	}
+	if err := s.repo.SaveAnomalies(anomalies); err != nil {
+		log.Printf("Error saving anomalies: %v", err)
+		return
+	}
+	if len(anomalies) > 0 {
+		log.Printf("Detected %d anomalies", len(anomalies))
+	}
+}
+
+// expired reports whether a series without a value for idle buckets is
+// dropped. Callers hold s.mu.
+func (s *baselineService) expired(key seriesKey, idle int) bool {
+	if idle >= seriesMaxIdleBuckets {
+		return true
+	}
+	overall := s.baselines[baselineKey{key.metric, key.subject, models.BaselineOverall}]
+	return idle >= seriesMinIdleBuckets && (overall == nil || math.Abs(overall.Mean) < seriesDecayedMean)
+}
+
+// dropSeries forgets a series and its baselines. Callers hold s.mu.
+func (s *baselineService) dropSeries(key seriesKey) {
+	delete(s.series, key)
+	delete(s.baselines, baselineKey{key.metric, key.subject, models.BaselineOverall})
+	for slot := 0; slot < 7*24; slot++ {
+		delete(s.baselines, baselineKey{key.metric, key.subject, slot})
+	}
+}
+
+// score compares value with the baseline of the same hour of the week, or
+// the overall baseline while the seasonal one has too few samples
+func (s *baselineService) score(key seriesKey, slot int, value float64) (models.Anomaly, bool) {
+	method := models.AnomalySeasonal
+	baseline := s.baselines[baselineKey{key.metric, key.subject, slot}]
+	if baseline == nil || baseline.Samples < s.config.MinSamples {
+		method = models.AnomalyEWMA
+		slot = models.BaselineOverall
+		baseline = s.baselines[baselineKey{key.metric, key.subject, slot}]
+	}
+	if baseline == nil || baseline.Samples < s.config.MinSamples {
+		return models.Anomaly{}, false
+	}
+
+	z := (value - baseline.Mean) / math.Max(baseline.StdDev, minStdDev)
+	if z < s.config.Threshold && !(twoSidedMetrics[key.metric] && z <= -s.config.Threshold) {
+		return models.Anomaly{}, false
+	}
+
+	anomaly := models.Anomaly{
+		Metric:   key.metric,
+		Subject:  key.subject,
+		Value:    value,
+		Expected: baseline.Mean,
+		StdDev:   baseline.StdDev,
+		Score:    z,
+		Method:   method,
+		Slot:     slot,
+	}
+	if key.metric != models.BaselineSyscallRate {
+		anomaly.Comm = key.subject
+	}
+	return anomaly, true
+}
+
+// learn folds value into the exponentially weighted mean and variance of a
+// baseline and returns the updated baseline
+func (s *baselineService) learn(key baselineKey, value float64, now time.Time) models.Baseline {
+	baseline := s.baselines[key]
+	if baseline == nil {
+		baseline = &models.Baseline{Metric: key.metric, Subject: key.subject, Slot: key.slot, Mean: value}
+		s.baselines[key] = baseline
+	} else {
+		diff := value - baseline.Mean
+		increment := s.config.Alpha * diff
+		variance := (1 - s.config.Alpha) * (baseline.StdDev*baseline.StdDev + diff*increment)
+		baseline.Mean += increment
+		baseline.StdDev = math.Sqrt(variance)
+	}
+	baseline.Samples++
+	baseline.UpdatedAt = now
+	return *baseline
+}
+
+func (s *baselineService) GetBaselines(metric, subject string) ([]models.Baseline, error) {
+	return s.repo.GetBaselines(metric, subject)
+}
+
+func (s *baselineService) GetAnomalies(since, until time.Time, metric, comm string, limit int) ([]models.Anomaly, error) {
+	return s.repo.GetAnomalies(since, until, metric, comm, limit)
+}
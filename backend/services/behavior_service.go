@@ -0,0 +1,568 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/detect"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ErrInvalidProfile is returned when an imported or updated profile is invalid
+var ErrInvalidProfile = errors.New("invalid behavior profile")
+
+// profileDedupWindow suppresses repeated violations of the same kind and value
+const profileDedupWindow = 10 * time.Minute
+
+type BehaviorService interface {
+	Start()
+	Stop()
+	HandleExecs(events []models.ProcessEvent)
+	HandleConnections(events []models.NetworkConnection)
+	HandleSyscalls(stats []models.CommSyscallStat)
+	GetProfiles() []models.BehaviorProfile
+	GetProfile(comm string) (models.BehaviorProfile, error)
+	SetMode(comm, mode string) (models.BehaviorProfile, error)
+	DeleteProfile(comm string) error
+	Export(comms []string) ([]byte, error)
+	Import(data []byte, merge bool) (int, error)
+}
+
+// BehaviorConfig selects what the profile service observes
+type BehaviorConfig struct {
+	AutoLearn bool // create learning profiles for commands seen for the first time
+	Syscalls  bool // record syscalls with bpftrace
+}
+
+// behaviorState is a profile with its lists kept as sets
+type behaviorState struct {
+	profile  models.BehaviorProfile
+	children map[string]bool
+	connect  map[models.ConnectRule]bool
+	networks []connectNetwork
+	syscalls map[string]bool
+	dirty    bool
+}
+
+type connectNetwork struct {
+	network *net.IPNet
+	port    int
+}
+
+type behaviorService struct {
+	repo      repository.BehaviorRepository
+	lineage   LineageService
+	findings  FindingService
+	collector *collector.CommSyscallCollector
+	config    BehaviorConfig
+
+	mu       sync.Mutex
+	profiles map[string]*behaviorState
+	seen     map[string]time.Time
+
+	// saveMu serializes taking snapshots and saving them, so an older
+	// snapshot never overwrites a newer one or brings back a deleted profile
+	saveMu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewBehaviorService(repo repository.BehaviorRepository, lineage LineageService, findings FindingService, config BehaviorConfig) BehaviorService {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &behaviorService{
+		repo:     repo,
+		lineage:  lineage,
+		findings: findings,
+		config:   config,
+		profiles: make(map[string]*behaviorState),
+		seen:     make(map[string]time.Time),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	if config.Syscalls {
+		s.collector = collector.NewCommSyscallCollector()
+	}
+
+	profiles, err := repo.GetProfiles()
+	if err != nil {
+		log.Printf("Error loading behavior profiles: %v", err)
+	}
+	for _, p := range profiles {
+		state, err := newBehaviorState(p)
+		if err != nil {
+			log.Printf("Skipping behavior profile %s: %v", p.Comm, err)
+			continue
+		}
+		s.profiles[p.Comm] = state
+	}
+	return s
+}
+
+// Start starts the syscall collector and persists learned behavior every 10 seconds
+func (s *behaviorService) Start() {
+	if s.collector != nil {
+		if err := s.collector.Start(); err != nil {
+			log.Printf("Failed to start syscall profile collector: %v", err)
+		}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for tick := 1; ; tick++ {
+			select {
+			case <-s.ctx.Done():
+				s.flush()
+				return
+			case <-ticker.C:
+				if s.collector != nil {
+					s.HandleSyscalls(s.collector.GetEvents())
+				}
+				if tick%2 == 0 {
+					s.flush()
+				}
+			}
+		}
+	}()
+
+	log.Printf("Behavior profile service started with %d profiles", len(s.GetProfiles()))
+}
+
+func (s *behaviorService) Stop() {
+	s.cancel()
+	if s.collector != nil {
+		s.collector.Stop()
+	}
+	s.wg.Wait()
+}
+
+// HandleExecs records the commands exec'd by each parent command
+func (s *behaviorService) HandleExecs(events []models.ProcessEvent) {
+	now := time.Now()
+	var findings []models.Finding
+
+	for _, e := range events {
+		ppid, err := strconv.Atoi(e.PPID)
+		if err != nil {
+			continue
+		}
+		chain, err := s.lineage.Ancestors(ppid)
+		if err != nil || len(chain) == 0 {
+			continue
+		}
+		parent := chain[0].Comm
+
+		s.observe(parent, now, func(state *behaviorState) {
+			if state.children[e.Comm] {
+				return
+			}
+			if state.profile.Mode == models.ProfileLearning {
+				state.children[e.Comm] = true
+				return
+			}
+			if s.firstViolation(parent+"|exec|"+e.Comm, now) {
+				finding := detect.NewFinding(detect.RuleProfileViolation, now)
+				finding.PID = ppid
+				finding.UID = e.UID
+				finding.Comm = parent
+				finding.Detail = fmt.Sprintf("%s exec'd %s (pid %s: %s), which its profile does not allow", parent, e.Comm, e.PID, e.Args)
+				finding.Attribution = e.Attribution
+				findings = append(findings, finding)
+			}
+		})
+	}
+
+	s.findings.Report(findings)
+}
+
+// HandleConnections records the destinations each command connects to
+func (s *behaviorService) HandleConnections(events []models.NetworkConnection) {
+	now := time.Now()
+	var findings []models.Finding
+
+	for _, e := range events {
+		ip := net.ParseIP(e.DestAddr)
+		port, err := strconv.Atoi(e.DestPort)
+		if ip == nil || err != nil {
+			continue
+		}
+
+		s.observe(e.Comm, now, func(state *behaviorState) {
+			if state.allowsConnect(ip, port) {
+				return
+			}
+			if state.profile.Mode == models.ProfileLearning {
+				state.addConnect(models.ConnectRule{Network: hostNetwork(ip), Port: port})
+				return
+			}
+			dest := net.JoinHostPort(e.DestAddr, e.DestPort)
+			if s.firstViolation(e.Comm+"|connect|"+dest, now) {
+				finding := detect.NewFinding(detect.RuleProfileViolation, now)
+				finding.PID, _ = strconv.Atoi(e.PID)
+				finding.Comm = e.Comm
+				finding.Detail = fmt.Sprintf("%s connected to %s, which its profile does not allow", e.Comm, dest)
+				finding.Attribution = e.Attribution
+				findings = append(findings, finding)
+			}
+		})
+	}
+
+	s.findings.Report(findings)
+}
+
+// HandleSyscalls records the syscalls each command makes
+func (s *behaviorService) HandleSyscalls(stats []models.CommSyscallStat) {
+	now := time.Now()
+	var findings []models.Finding
+
+	for _, stat := range stats {
+		s.observe(stat.Comm, now, func(state *behaviorState) {
+			if state.syscalls[stat.Syscall] {
+				return
+			}
+			if state.profile.Mode == models.ProfileLearning {
+				state.syscalls[stat.Syscall] = true
+				return
+			}
+			if s.firstViolation(stat.Comm+"|syscall|"+stat.Syscall, now) {
+				finding := detect.NewFinding(detect.RuleProfileViolation, now)
+				finding.Comm = stat.Comm
+				finding.Detail = fmt.Sprintf("%s called %s %d times, which its profile does not allow", stat.Comm, stat.Syscall, stat.Count)
+				findings = append(findings, finding)
+			}
+		})
+	}
+
+	s.findings.Report(findings)
+}
+
+// observe runs check on the profile of comm, creating a learning profile
+// first if auto-learning is on. In learning mode check adds the behavior to
+// the profile, in enforcing mode it reports behavior the profile lacks.
+func (s *behaviorService) observe(comm string, now time.Time, check func(state *behaviorState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.profiles[comm]
+	if state == nil {
+		if !s.config.AutoLearn {
+			return
+		}
+		state, _ = newBehaviorState(models.BehaviorProfile{Comm: comm, Mode: models.ProfileLearning, CreatedAt: now})
+		s.profiles[comm] = state
+	}
+
+	before := state.size()
+	check(state)
+	if state.size() != before {
+		state.profile.UpdatedAt = now
+		state.dirty = true
+	}
+}
+
+// firstViolation reports whether key was not reported within the dedup
+// window and records it. Callers hold s.mu.
+func (s *behaviorService) firstViolation(key string, now time.Time) bool {
+	if at, ok := s.seen[key]; ok && now.Sub(at) < profileDedupWindow {
+		return false
+	}
+	s.seen[key] = now
+
+	if len(s.seen) > maxSeenViolations {
+		for k, at := range s.seen {
+			if now.Sub(at) >= profileDedupWindow {
+				delete(s.seen, k)
+			}
+		}
+	}
+	return true
+}
+
+// maxSeenViolations triggers a sweep of expired dedup entries once exceeded
+const maxSeenViolations = 10000
+
+// flush saves the profiles that changed since the last flush. Profiles that
+// fail to save stay dirty and are retried on the next flush.
+func (s *behaviorService) flush() {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	var (
+		states []*behaviorState
+		dirty  []models.BehaviorProfile
+	)
+	for _, state := range s.profiles {
+		if state.dirty {
+			states = append(states, state)
+			dirty = append(dirty, state.snapshot())
+			state.dirty = false
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.repo.SaveProfiles(dirty); err != nil {
+		log.Printf("Error saving behavior profiles: %v", err)
+		s.markDirty(states)
+	}
+}
+
+// markDirty flags states whose save failed for the next flush, unless they
+// were deleted or replaced in the meantime
+func (s *behaviorService) markDirty(states []*behaviorState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, state := range states {
+		if s.profiles[state.profile.Comm] == state {
+			state.dirty = true
+		}
+	}
+}
+
+func (s *behaviorService) GetProfiles() []models.BehaviorProfile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profiles := make([]models.BehaviorProfile, 0, len(s.profiles))
+	for _, state := range s.profiles {
+		profiles = append(profiles, state.snapshot())
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Comm < profiles[j].Comm })
+	return profiles
+}
+
+func (s *behaviorService) GetProfile(comm string) (models.BehaviorProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.profiles[comm]
+	if !ok {
+		return models.BehaviorProfile{}, repository.ErrNotFound
+	}
+	return state.snapshot(), nil
+}
+
+// SetMode switches a profile between learning and enforcing
+func (s *behaviorService) SetMode(comm, mode string) (models.BehaviorProfile, error) {
+	if mode != models.ProfileLearning && mode != models.ProfileEnforcing {
+		return models.BehaviorProfile{}, fmt.Errorf("%w: mode must be %s or %s", ErrInvalidProfile, models.ProfileLearning, models.ProfileEnforcing)
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	state, ok := s.profiles[comm]
+	if !ok {
+		s.mu.Unlock()
+		return models.BehaviorProfile{}, repository.ErrNotFound
+	}
+	state.profile.Mode = mode
+	state.profile.UpdatedAt = time.Now()
+	state.dirty = false
+	profile := state.snapshot()
+	s.mu.Unlock()
+
+	if err := s.repo.SaveProfiles([]models.BehaviorProfile{profile}); err != nil {
+		s.markDirty([]*behaviorState{state})
+		return profile, err
+	}
+	return profile, nil
+}
+
+func (s *behaviorService) DeleteProfile(comm string) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	_, ok := s.profiles[comm]
+	delete(s.profiles, comm)
+	s.mu.Unlock()
+
+	err := s.repo.DeleteProfile(comm)
+	if errors.Is(err, repository.ErrNotFound) && ok {
+		// Learned but not flushed yet
+		return nil
+	}
+	return err
+}
+
+// Export renders the profiles of the given commands, or all profiles, as YAML
+func (s *behaviorService) Export(comms []string) ([]byte, error) {
+	set := models.BehaviorProfileSet{}
+	for _, p := range s.GetProfiles() {
+		if len(comms) == 0 || contains(comms, p.Comm) {
+			set.Profiles = append(set.Profiles, p)
+		}
+	}
+	return yaml.Marshal(set)
+}
+
+// Import loads profiles from YAML. Imported profiles replace existing ones
+// of the same command, or are added to them if merge is set.
+func (s *behaviorService) Import(data []byte, merge bool) (int, error) {
+	var set models.BehaviorProfileSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	now := time.Now()
+	states := make([]*behaviorState, 0, len(set.Profiles))
+	for _, p := range set.Profiles {
+		if p.Comm == "" {
+			return 0, fmt.Errorf("%w: comm is required", ErrInvalidProfile)
+		}
+		if p.Mode == "" {
+			p.Mode = models.ProfileEnforcing
+		}
+		if p.Mode != models.ProfileLearning && p.Mode != models.ProfileEnforcing {
+			return 0, fmt.Errorf("%w: invalid mode %q for %s", ErrInvalidProfile, p.Mode, p.Comm)
+		}
+		p.CreatedAt, p.UpdatedAt = now, now
+		state, err := newBehaviorState(p)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s: %v", ErrInvalidProfile, p.Comm, err)
+		}
+		states = append(states, state)
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	saved := make([]models.BehaviorProfile, 0, len(states))
+	for i, state := range states {
+		comm := state.profile.Comm
+		if existing, ok := s.profiles[comm]; ok {
+			state.profile.CreatedAt = existing.profile.CreatedAt
+			if merge {
+				existing.merge(state)
+				existing.profile.Mode = state.profile.Mode
+				existing.profile.UpdatedAt = now
+				state = existing
+			}
+		}
+		state.dirty = false
+		s.profiles[comm] = state
+		states[i] = state
+		saved = append(saved, state.snapshot())
+	}
+	s.mu.Unlock()
+
+	if err := s.repo.SaveProfiles(saved); err != nil {
+		s.markDirty(states)
+		return len(saved), err
+	}
+	return len(saved), nil
+}
+
+func newBehaviorState(p models.BehaviorProfile) (*behaviorState, error) {
+	state := &behaviorState{
+		profile:  p,
+		children: make(map[string]bool),
+		connect:  make(map[models.ConnectRule]bool),
+		syscalls: make(map[string]bool),
+	}
+	for _, child := range p.Children {
+		state.children[child] = true
+	}
+	for _, rule := range p.Connect {
+		if _, _, err := net.ParseCIDR(rule.Network); err != nil {
+			return nil, fmt.Errorf("invalid network %q", rule.Network)
+		}
+		state.addConnect(rule)
+	}
+	for _, syscall := range p.Syscalls {
+		state.syscalls[syscall] = true
+	}
+	return state, nil
+}
+
+func (s *behaviorState) addConnect(rule models.ConnectRule) {
+	if s.connect[rule] {
+		return
+	}
+	_, network, err := net.ParseCIDR(rule.Network)
+	if err != nil {
+		return
+	}
+	s.connect[rule] = true
+	s.networks = append(s.networks, connectNetwork{network: network, port: rule.Port})
+}
+
+func (s *behaviorState) allowsConnect(ip net.IP, port int) bool {
+	for _, n := range s.networks {
+		if (n.port == 0 || n.port == port) && n.network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *behaviorState) merge(other *behaviorState) {
+	for child := range other.children {
+		s.children[child] = true
+	}
+	for rule := range other.connect {
+		s.addConnect(rule)
+	}
+	for syscall := range other.syscalls {
+		s.syscalls[syscall] = true
+	}
+}
+
+func (s *behaviorState) size() int {
+	return len(s.children) + len(s.connect) + len(s.syscalls)
+}
+
+// snapshot returns the profile with its lists sorted
+func (s *behaviorState) snapshot() models.BehaviorProfile {
+	p := s.profile
+	p.Children = sortedKeys(s.children)
+	p.Syscalls = sortedKeys(s.syscalls)
+	p.Connect = make([]models.ConnectRule, 0, len(s.connect))
+	for rule := range s.connect {
+		p.Connect = append(p.Connect, rule)
+	}
+	sort.Slice(p.Connect, func(i, j int) bool {
+		if p.Connect[i].Network != p.Connect[j].Network {
+			return p.Connect[i].Network < p.Connect[j].Network
+		}
+		return p.Connect[i].Port < p.Connect[j].Port
+	})
+	return p
+}
+
+// hostNetwork returns the single-address network of ip
+func hostNetwork(ip net.IP) string {
+	if ip.To4() != nil {
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
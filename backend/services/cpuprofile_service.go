@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
 	"ebpf-dashboard/models"
 	"ebpf-dashboard/repository"
 	"log"
@@ -13,24 +14,26 @@ import (
 type CPUProfileService interface {
 	Start()
 	Stop()
-	GetRecentProfiles(limit int) ([]models.CPUProfile, error)
+	GetRecentProfiles(limit int, filter models.Filter) ([]models.CPUProfile, error)
 }
 
 type cpuProfileService struct {
-	repo      *repository.CPUProfileRepository
-	collector *collector.CPUProfileCollector
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
+	repo       repository.CPUProfileRepository
+	collector  *collector.CPUProfileCollector
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
 }
 
-func NewCPUProfileService(repo *repository.CPUProfileRepository) CPUProfileService {
+func NewCPUProfileService(repo repository.CPUProfileRepository, attributor enrich.Attributor) CPUProfileService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &cpuProfileService{
-		repo:      repo,
-		collector: collector.NewCPUProfileCollector(),
-		ctx:       ctx,
-		cancel:    cancel,
+		repo:       repo,
+		collector:  collector.NewCPUProfileCollector(),
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -79,6 +82,10 @@ func (s *cpuProfileService) collectAndSave() {
 		return
 	}
 
+	for i := range profiles {
+		profiles[i].Attribution = s.attributor.Attribute(profiles[i].PID, 0)
+	}
+
 	if err := s.repo.SaveCPUProfiles(profiles); err != nil {
 		log.Printf("Error saving CPU profiles: %v", err)
 		return
@@ -88,6 +95,6 @@ func (s *cpuProfileService) collectAndSave() {
 }
 
 // GetRecentProfiles retrieves recent CPU profile data
-func (s *cpuProfileService) GetRecentProfiles(limit int) ([]models.CPUProfile, error) {
-	return s.repo.GetRecentCPUProfiles(limit)
+func (s *cpuProfileService) GetRecentProfiles(limit int, filter models.Filter) ([]models.CPUProfile, error) {
+	return s.repo.GetRecentCPUProfiles(limit, filter)
 }
@@ -13,22 +13,30 @@ import (
 type DiskService interface {
 	StartCollecting()
 	StopCollecting()
-	GetLatestLatency(limit int) ([]models.DiskLatency, error)
+	GetLatestLatency(limit int, filter models.Filter) ([]models.DiskLatency, error)
+	Subscribe(fn func([]models.DiskLatency))
 }
 
 type diskService struct {
 	repo      repository.DiskRepository
 	collector *collector.DiskCollector
+	host      string
+	labels    models.Labels
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
+
+	subMu       sync.RWMutex
+	subscribers []func([]models.DiskLatency)
 }
 
-func NewDiskService(repo repository.DiskRepository) DiskService {
+func NewDiskService(repo repository.DiskRepository, host string, labels models.Labels) DiskService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &diskService{
 		repo:      repo,
 		collector: collector.NewDiskCollector(),
+		host:      host,
+		labels:    labels,
 		ctx:       ctx,
 		cancel:    cancel,
 	}
@@ -57,9 +65,14 @@ func (s *diskService) StartCollecting() {
 			case <-ticker.C:
 				latencies := s.collector.GetEvents()
 				if len(latencies) > 0 {
+					for i := range latencies {
+						latencies[i].Host = s.host
+						latencies[i].HostLabels = s.labels
+					}
 					if err := s.repo.SaveLatencySnapshot(latencies); err != nil {
 						log.Printf("Error saving disk latency: %v", err)
 					}
+					s.publish(latencies)
 				}
 			}
 		}
@@ -72,6 +85,21 @@ func (s *diskService) StopCollecting() {
 	s.wg.Wait()
 }
 
-func (s *diskService) GetLatestLatency(limit int) ([]models.DiskLatency, error) {
-	return s.repo.GetLatestLatency(limit)
+func (s *diskService) GetLatestLatency(limit int, filter models.Filter) ([]models.DiskLatency, error) {
+	return s.repo.GetLatestLatency(limit, filter)
+}
+
+// Subscribe registers fn to receive every batch of collected latency histogram buckets
+func (s *diskService) Subscribe(fn func([]models.DiskLatency)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *diskService) publish(events []models.DiskLatency) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(events)
+	}
 }
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type DNSService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetRecentLookups(limit int, filter models.Filter) ([]models.DNSLookup, error)
+	GetSlowLookups(q models.DNSQuery) ([]models.DNSLookup, error)
+	GetHostStats(q models.DNSQuery) ([]models.DNSHostStats, error)
+}
+
+type dnsService struct {
+	repo       repository.DNSRepository
+	collector  *collector.GethostlatencyCollector
+	attributor enrich.Attributor
+	hostnames  *enrich.HostnameResolver
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewDNSService returns a service collecting lookups with gethostlatency.
+// Every lookup is passed to hostnames, which names the connections of the
+// network and TCP lifecycle services after it.
+func NewDNSService(repo repository.DNSRepository, attributor enrich.Attributor, hostnames *enrich.HostnameResolver) DNSService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dnsService{
+		repo:       repo,
+		collector:  collector.NewGethostlatencyCollector(),
+		attributor: attributor,
+		hostnames:  hostnames,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *dnsService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.processEvents()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *dnsService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+func (s *dnsService) processEvents() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			lookups := s.collector.GetEvents()
+			if len(lookups) > 0 {
+				for i := range lookups {
+					lookups[i].Attribution = s.attributor.Attribute(lookups[i].PID, 0)
+				}
+				s.hostnames.AddLookups(lookups)
+				if err := s.repo.SaveLookups(lookups); err != nil {
+					log.Printf("Error saving DNS lookups: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// GetRecentLookups retrieves the most recent lookups
+func (s *dnsService) GetRecentLookups(limit int, filter models.Filter) ([]models.DNSLookup, error) {
+	return s.repo.GetRecentLookups(limit, filter)
+}
+
+// GetSlowLookups returns the lookups selected by q, slowest first
+func (s *dnsService) GetSlowLookups(q models.DNSQuery) ([]models.DNSLookup, error) {
+	return s.repo.GetSlowLookups(q)
+}
+
+// GetHostStats summarizes the lookups selected by q per hostname, slowest
+// on average first
+func (s *dnsService) GetHostStats(q models.DNSQuery) ([]models.DNSHostStats, error) {
+	return s.repo.GetHostStats(q)
+}
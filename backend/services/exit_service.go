@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type ExitService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetRecentExits(limit int, filter models.Filter) ([]models.ProcessExit, error)
+	GetLifetimes(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.ProcessLifetime, error)
+	GetShortLivedStorms(since, until time.Time, maxAge time.Duration, minCount int, filter models.Filter) ([]models.ShortLivedStorm, error)
+	GetCrashLoops(since, until time.Time, minFailures int, filter models.Filter) ([]models.CrashLoop, error)
+	GetKilled(since, until time.Time, signal int, limit int, filter models.Filter) ([]models.ProcessExit, error)
+	Subscribe(fn func([]models.ProcessExit))
+}
+
+type exitService struct {
+	repo       repository.ExitRepository
+	collector  *collector.ExitCollector
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	subMu       sync.RWMutex
+	subscribers []func([]models.ProcessExit)
+}
+
+func NewExitService(repo repository.ExitRepository, attributor enrich.Attributor) ExitService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &exitService{
+		repo:       repo,
+		collector:  collector.NewExitCollector(),
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *exitService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.processEvents()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *exitService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+func (s *exitService) processEvents() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			events := s.collector.GetEvents()
+			if len(events) > 0 {
+				s.attribute(events)
+				if err := s.repo.SaveExits(events); err != nil {
+					log.Printf("Error saving process exits: %v", err)
+				}
+				s.publish(events)
+			}
+		}
+	}
+}
+
+// GetRecentExits retrieves the most recent process exits
+func (s *exitService) GetRecentExits(limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	return s.repo.GetRecentExits(limit, filter)
+}
+
+// GetLifetimes retrieves exited processes joined with their exec events
+func (s *exitService) GetLifetimes(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.ProcessLifetime, error) {
+	return s.repo.GetLifetimes(since, until, comm, limit, filter)
+}
+
+// GetShortLivedStorms finds process names that spawned many processes living at most maxAge
+func (s *exitService) GetShortLivedStorms(since, until time.Time, maxAge time.Duration, minCount int, filter models.Filter) ([]models.ShortLivedStorm, error) {
+	return s.repo.GetShortLivedStorms(since, until, maxAge.Seconds(), minCount, filter)
+}
+
+// GetCrashLoops finds process names that repeatedly exited with a failure
+func (s *exitService) GetCrashLoops(since, until time.Time, minFailures int, filter models.Filter) ([]models.CrashLoop, error) {
+	return s.repo.GetCrashLoops(since, until, minFailures, filter)
+}
+
+// GetKilled retrieves processes that were terminated by a signal
+func (s *exitService) GetKilled(since, until time.Time, signal int, limit int, filter models.Filter) ([]models.ProcessExit, error) {
+	return s.repo.GetKilled(since, until, signal, limit, filter)
+}
+
+// attribute resolves the cgroup, unit and container of each exited process.
+// The process is already gone, so this relies on the attributor having seen
+// it (or its parent) earlier.
+func (s *exitService) attribute(events []models.ProcessExit) {
+	for i := range events {
+		events[i].Attribution = s.attributor.Attribute(events[i].PID, events[i].PPID)
+	}
+}
+
+// Subscribe registers fn to receive every batch of collected exit events
+func (s *exitService) Subscribe(fn func([]models.ProcessExit)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *exitService) publish(events []models.ProcessExit) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(events)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type FileService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetRecentOpens(limit int, filter models.Filter) ([]models.FileOpen, error)
+	GetOpens(q models.FileOpenQuery) ([]models.FileOpen, error)
+	GetFailures(since, until time.Time, errno string, minCount, limit int, filter models.Filter) ([]models.OpenFailure, error)
+	GetAccessors(path string, since, until time.Time, filter models.Filter) ([]models.FileAccessor, error)
+}
+
+type fileService struct {
+	repo       repository.FileRepository
+	collector  *collector.OpenCollector
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewFileService returns the service tracing file opens, or only failed
+// ones if failedOnly is set
+func NewFileService(repo repository.FileRepository, attributor enrich.Attributor, failedOnly bool) FileService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fileService{
+		repo:       repo,
+		collector:  collector.NewOpenCollector(failedOnly),
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *fileService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.processEvents()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *fileService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+func (s *fileService) processEvents() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			events := s.collector.GetEvents()
+			if len(events) > 0 {
+				for i := range events {
+					events[i].Attribution = s.attributor.Attribute(events[i].PID, 0)
+				}
+				if err := s.repo.SaveOpens(events); err != nil {
+					log.Printf("Error saving file opens: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// GetRecentOpens retrieves the most recent file opens
+func (s *fileService) GetRecentOpens(limit int, filter models.Filter) ([]models.FileOpen, error) {
+	return s.repo.GetRecentOpens(limit, filter)
+}
+
+// GetOpens retrieves the file opens selected by q
+func (s *fileService) GetOpens(q models.FileOpenQuery) ([]models.FileOpen, error) {
+	return s.repo.GetOpens(q)
+}
+
+// GetFailures finds the paths failing to open most often
+func (s *fileService) GetFailures(since, until time.Time, errno string, minCount, limit int, filter models.Filter) ([]models.OpenFailure, error) {
+	return s.repo.GetFailures(since, until, errno, minCount, limit, filter)
+}
+
+// GetAccessors finds the commands and users that opened path
+func (s *fileService) GetAccessors(path string, since, until time.Time, filter models.Filter) ([]models.FileAccessor, error) {
+	return s.repo.GetAccessors(path, since, until, filter)
+}
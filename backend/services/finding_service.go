@@ -0,0 +1,74 @@
+package services
+
+import (
+	"ebpf-dashboard/detect"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"time"
+)
+
+type FindingService interface {
+	HandleExecs(events []models.ProcessEvent)
+	HandleConnections(events []models.NetworkConnection)
+	Report(findings []models.Finding)
+	GetFindings(q models.FindingQuery) ([]models.Finding, error)
+	GetRules() []models.DetectionRule
+}
+
+type findingService struct {
+	repo     repository.FindingRepository
+	detector *detect.Detector
+}
+
+func NewFindingService(repo repository.FindingRepository, detector *detect.Detector) FindingService {
+	return &findingService{
+		repo:     repo,
+		detector: detector,
+	}
+}
+
+// HandleExecs runs the exec detections on a batch of exec events
+func (s *findingService) HandleExecs(events []models.ProcessEvent) {
+	now := time.Now()
+	var findings []models.Finding
+	for _, e := range events {
+		findings = append(findings, s.detector.Exec(e, now)...)
+	}
+	s.save(findings)
+}
+
+// HandleConnections runs the network detections on a batch of connect events
+func (s *findingService) HandleConnections(events []models.NetworkConnection) {
+	now := time.Now()
+	var findings []models.Finding
+	for _, e := range events {
+		findings = append(findings, s.detector.Connect(e, now)...)
+	}
+	s.save(findings)
+}
+
+// Report stores findings raised outside the built-in detections
+func (s *findingService) Report(findings []models.Finding) {
+	s.save(findings)
+}
+
+func (s *findingService) save(findings []models.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	for _, f := range findings {
+		log.Printf("Security finding [%s] %s: %s (pid %d, %s)", f.Severity, f.Title, f.Detail, f.PID, f.Comm)
+	}
+	if err := s.repo.SaveFindings(findings); err != nil {
+		log.Printf("Error saving findings: %v", err)
+	}
+}
+
+func (s *findingService) GetFindings(q models.FindingQuery) ([]models.Finding, error) {
+	return s.repo.GetFindings(q)
+}
+
+func (s *findingService) GetRules() []models.DetectionRule {
+	return detect.Rules()
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FlameGraphService interface {
+	GetFlameGraph(kind string, q models.StackQuery) (*models.FlameNode, error)
+	GetFolded(kind string, q models.StackQuery) ([]string, error)
+}
+
+type flameGraphService struct {
+	cpu    repository.CPUProfileRepository
+	offCPU repository.OffCPURepository
+}
+
+// NewFlameGraphService returns the service building flame graphs from on-CPU
+// samples and off-CPU stacks
+func NewFlameGraphService(cpu repository.CPUProfileRepository, offCPU repository.OffCPURepository) FlameGraphService {
+	return &flameGraphService{cpu: cpu, offCPU: offCPU}
+}
+
+// ErrInvalidFlameGraph is returned for an unknown flame graph type or a
+// query the stacks of the type cannot answer
+var ErrInvalidFlameGraph = errors.New("invalid flame graph")
+
+// sampleUS is the on-CPU time a profile sample stands for
+const sampleUS = int64(time.Second/time.Microsecond) / collector.ProfileFrequency
+
+// flameStack is a stack from the root to the leaf, starting with the command
+// name, and the time spent in it in microseconds
+type flameStack struct {
+	frames []string
+	onCPU  int64
+	offCPU int64
+}
+
+// stacks returns the on-CPU and off-CPU stacks of a flame graph of kind.
+// On-CPU samples are weighted by the sampling period so they add up with
+// off-CPU time to wall-clock time.
+func (s *flameGraphService) stacks(kind string, q models.StackQuery) ([]flameStack, error) {
+	switch kind {
+	case models.FlameOnCPU:
+	case models.FlameOffCPU, models.FlameWallClock:
+		if q.PID > 0 {
+			return nil, fmt.Errorf("%w: off-CPU stacks are recorded per command, select them with comm instead of pid", ErrInvalidFlameGraph)
+		}
+	default:
+		return nil, fmt.Errorf("%w: type must be %s, %s or %s", ErrInvalidFlameGraph, models.FlameOnCPU, models.FlameOffCPU, models.FlameWallClock)
+	}
+
+	var stacks []flameStack
+
+	if kind == models.FlameOnCPU || kind == models.FlameWallClock {
+		totals, err := s.cpu.GetStackTotals(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range totals {
+			// profile-bpfcc prints the leaf frame first
+			lines := strings.Split(t.Stack, "\n")
+			frames := []string{t.Comm}
+			for i := len(lines) - 1; i >= 0; i-- {
+				if frame := strings.TrimSpace(lines[i]); frame != "" {
+					frames = append(frames, frame)
+				}
+			}
+			stacks = append(stacks, flameStack{frames: frames, onCPU: t.Total * sampleUS})
+		}
+	}
+
+	if kind == models.FlameOffCPU || kind == models.FlameWallClock {
+		totals, err := s.offCPU.GetStackTotals(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range totals {
+			frames := []string{t.Comm}
+			if t.Stack != "" {
+				frames = append(frames, strings.Split(t.Stack, ";")...)
+			}
+			stacks = append(stacks, flameStack{frames: frames, offCPU: t.Total})
+		}
+	}
+
+	return stacks, nil
+}
+
+// GetFlameGraph merges the stacks of kind into a tree of frames, with the
+// callees of every frame ordered by name
+func (s *flameGraphService) GetFlameGraph(kind string, q models.StackQuery) (*models.FlameNode, error) {
+	stacks, err := s.stacks(kind, q)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &models.FlameNode{Name: kind}
+	children := make(map[*models.FlameNode]map[string]*models.FlameNode)
+	for _, stack := range stacks {
+		node := root
+		node.OnCPU += stack.onCPU
+		node.OffCPU += stack.offCPU
+		for _, frame := range stack.frames {
+			if children[node] == nil {
+				children[node] = make(map[string]*models.FlameNode)
+			}
+			child, ok := children[node][frame]
+			if !ok {
+				child = &models.FlameNode{Name: frame}
+				children[node][frame] = child
+				node.Children = append(node.Children, child)
+			}
+			node = child
+			node.OnCPU += stack.onCPU
+			node.OffCPU += stack.offCPU
+		}
+	}
+
+	var finish func(node *models.FlameNode)
+	finish = func(node *models.FlameNode) {
+		node.Value = node.OnCPU + node.OffCPU
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+		for _, child := range node.Children {
+			finish(child)
+		}
+	}
+	finish(root)
+
+	return root, nil
+}
+
+// GetFolded returns the stacks of kind in the folded format of flamegraph.pl,
+// "frame;frame;... microseconds", one line per distinct stack
+func (s *flameGraphService) GetFolded(kind string, q models.StackQuery) ([]string, error) {
+	stacks, err := s.stacks(kind, q)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64)
+	for _, stack := range stacks {
+		totals[strings.Join(stack.frames, ";")] += stack.onCPU + stack.offCPU
+	}
+
+	lines := make([]string, 0, len(totals))
+	for stack, total := range totals {
+		lines = append(lines, stack+" "+strconv.FormatInt(total, 10))
+	}
+	sort.Strings(lines)
+
+	return lines, nil
+}
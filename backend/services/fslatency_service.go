@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type FSLatencyService interface {
+	Start()
+	Stop()
+	GetRecentSlowOps(limit int, filter models.Filter) ([]models.SlowFileOp, error)
+	GetSlowFiles(q models.SlowFileOpQuery) ([]models.SlowFile, error)
+	GetProcessLatency(q models.SlowFileOpQuery) ([]models.ProcessFSLatency, error)
+}
+
+type fsLatencyService struct {
+	repo       repository.FSLatencyRepository
+	collectors []*collector.FSSlowerCollector
+	threshold  time.Duration
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewFSLatencyService(repo repository.FSLatencyRepository, attributor enrich.Attributor, threshold time.Duration) FSLatencyService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fsLatencyService{
+		repo:       repo,
+		threshold:  threshold,
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start runs the slower tool of every supported filesystem that is mounted
+func (s *fsLatencyService) Start() {
+	filesystems, err := collector.DetectFilesystems()
+	if err != nil {
+		log.Printf("Failed to detect mounted filesystems: %v", err)
+		return
+	}
+	if len(filesystems) == 0 {
+		log.Printf("No ext4, xfs or btrfs filesystem mounted, not tracing slow filesystem operations")
+		return
+	}
+
+	for _, fs := range filesystems {
+		c := collector.NewFSSlowerCollector(fs, s.threshold)
+		if err := c.Start(); err != nil {
+			log.Printf("Failed to start %sslower collector: %v", fs, err)
+			continue
+		}
+		s.collectors = append(s.collectors, c)
+	}
+
+	s.wg.Add(1)
+	go s.collectPeriodically()
+}
+
+// Stop stops all collectors
+func (s *fsLatencyService) Stop() {
+	s.cancel()
+	for _, c := range s.collectors {
+		c.Stop()
+	}
+	s.wg.Wait()
+}
+
+func (s *fsLatencyService) collectPeriodically() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectAndSave()
+		}
+	}
+}
+
+func (s *fsLatencyService) collectAndSave() {
+	for _, c := range s.collectors {
+		ops := c.GetEvents()
+		if len(ops) == 0 {
+			continue
+		}
+		for i := range ops {
+			ops[i].Attribution = s.attributor.Attribute(ops[i].PID, 0)
+		}
+		if err := s.repo.SaveSlowOps(ops); err != nil {
+			log.Printf("Error saving slow filesystem operations: %v", err)
+		}
+	}
+}
+
+// GetRecentSlowOps retrieves the most recent slow filesystem operations
+func (s *fsLatencyService) GetRecentSlowOps(limit int, filter models.Filter) ([]models.SlowFileOp, error) {
+	return s.repo.GetRecentSlowOps(limit, filter)
+}
+
+// GetSlowFiles retrieves the files that stalled their processes longest
+func (s *fsLatencyService) GetSlowFiles(q models.SlowFileOpQuery) ([]models.SlowFile, error) {
+	return s.repo.GetSlowFiles(q)
+}
+
+// GetProcessLatency retrieves the processes stalled longest by the filesystem
+func (s *fsLatencyService) GetProcessLatency(q models.SlowFileOpQuery) ([]models.ProcessFSLatency, error) {
+	return s.repo.GetProcessLatency(q)
+}
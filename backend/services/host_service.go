@@ -0,0 +1,85 @@
+package services
+
+import (
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+type HostService interface {
+	// GetHosts returns the local host and the hosts of all agents that match
+	// the host conditions of filter
+	GetHosts(filter models.Filter) ([]models.Host, error)
+}
+
+type hostService struct {
+	name   string
+	labels models.Labels
+	ingest IngestService
+
+	// The versions do not change while the process runs, so they are read
+	// once on first use
+	versionsOnce sync.Once
+	kernel       string
+	bccVersion   string
+}
+
+func NewHostService(name string, labels models.Labels, ingest IngestService) HostService {
+	return &hostService{name: name, labels: labels, ingest: ingest}
+}
+
+func (s *hostService) GetHosts(filter models.Filter) ([]models.Host, error) {
+	agents, err := s.ingest.GetAgents()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]models.Host, 0, len(agents)+1)
+	hosts = append(hosts, s.local())
+	for _, agent := range agents {
+		collectors := agent.Collectors
+		if collectors == nil {
+			collectors = []models.CollectorStatus{}
+		}
+		hosts = append(hosts, models.Host{
+			Name:       agent.Host,
+			Labels:     agent.Labels,
+			Kernel:     agent.Kernel,
+			BCCVersion: agent.BCCVersion,
+			AgentID:    agent.ID,
+			Status:     agent.Status,
+			LastSeen:   agent.LastHeartbeat,
+			Collectors: collectors,
+		})
+	}
+
+	matching := hosts[:0]
+	for _, host := range hosts {
+		if (filter.Host == "" || host.Name == filter.Host) && host.Labels.Matches(filter.HostLabels) {
+			matching = append(matching, host)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].Name < matching[j].Name })
+	return matching, nil
+}
+
+// local describes the host this process runs on
+func (s *hostService) local() models.Host {
+	s.versionsOnce.Do(func() {
+		s.kernel = collector.KernelRelease()
+		s.bccVersion = collector.BCCVersion()
+	})
+
+	return models.Host{
+		Name:       s.name,
+		Labels:     s.labels,
+		Kernel:     s.kernel,
+		BCCVersion: s.bccVersion,
+		Local:      true,
+		Status:     models.AgentOnline,
+		LastSeen:   time.Now(),
+		Collectors: collector.Statuses(),
+	}
+}
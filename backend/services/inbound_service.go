@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type InboundService interface {
+	Start()
+	Stop()
+	GetRecentInbound(limit int, filter models.Filter) ([]models.InboundConnection, error)
+	GetServiceClients(q models.InboundQuery) ([]models.ServiceClient, error)
+	GetListeners(filter models.Filter) ([]models.ListeningSocket, error)
+	GetListenEvents(since, until time.Time, kind string, limit int, filter models.Filter) ([]models.ListenEvent, error)
+	SubscribeAccepts(fn func([]models.InboundConnection))
+	SubscribeListenEvents(fn func([]models.ListenEvent))
+}
+
+type inboundService struct {
+	repo       repository.InboundRepository
+	accepts    *collector.TCPAcceptCollector
+	listeners  *collector.ListenCollector
+	attributor enrich.Attributor
+	host       string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	subMu        sync.RWMutex
+	acceptSubs   []func([]models.InboundConnection)
+	listenerSubs []func([]models.ListenEvent)
+}
+
+// NewInboundService returns a service tracking the connections accepted on
+// host and taking an inventory of its listening sockets every listenInterval
+func NewInboundService(repo repository.InboundRepository, attributor enrich.Attributor, host string, listenInterval time.Duration) InboundService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &inboundService{
+		repo:       repo,
+		accepts:    collector.NewTCPAcceptCollector(),
+		listeners:  collector.NewListenCollector(listenInterval),
+		attributor: attributor,
+		host:       host,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start starts tcpaccept and the listening socket inventory. Either works
+// without the other.
+func (s *inboundService) Start() {
+	if err := s.accepts.Start(); err != nil {
+		log.Printf("Failed to start tcpaccept collector: %v", err)
+	}
+	if err := s.listeners.Start(); err != nil {
+		log.Printf("Failed to start listen collector: %v", err)
+	}
+
+	s.wg.Add(1)
+	go s.collectPeriodically()
+}
+
+// Stop stops both collectors
+func (s *inboundService) Stop() {
+	s.cancel()
+	s.accepts.Stop()
+	s.listeners.Stop()
+	s.wg.Wait()
+}
+
+func (s *inboundService) collectPeriodically() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectAndSave()
+		}
+	}
+}
+
+func (s *inboundService) collectAndSave() {
+	if events := s.accepts.GetEvents(); len(events) > 0 {
+		for i := range events {
+			events[i].Attribution = s.attributor.Attribute(events[i].PID, 0)
+		}
+		if err := s.repo.SaveInbound(events); err != nil {
+			log.Printf("Error saving inbound connections: %v", err)
+		}
+		s.publishAccepts(events)
+	}
+
+	if inventory, ok := s.listeners.GetEvents(); ok {
+		for i := range inventory.Sockets {
+			inventory.Sockets[i].Attribution = s.attributor.Attribute(inventory.Sockets[i].PID, 0)
+		}
+		events, err := s.repo.ReplaceListeners(s.host, inventory.Sockets, inventory.ScannedAt)
+		if err != nil {
+			log.Printf("Error saving listening sockets: %v", err)
+			return
+		}
+		if len(events) > 0 {
+			s.publishListenEvents(events)
+		}
+	}
+}
+
+// GetRecentInbound retrieves the most recent accepted connections
+func (s *inboundService) GetRecentInbound(limit int, filter models.Filter) ([]models.InboundConnection, error) {
+	return s.repo.GetRecentInbound(limit, filter)
+}
+
+// GetServiceClients counts who connected to which local port and command
+func (s *inboundService) GetServiceClients(q models.InboundQuery) ([]models.ServiceClient, error) {
+	return s.repo.GetServiceClients(q)
+}
+
+// GetListeners returns the latest inventory of listening sockets
+func (s *inboundService) GetListeners(filter models.Filter) ([]models.ListeningSocket, error) {
+	return s.repo.GetListeners(filter)
+}
+
+// GetListenEvents returns the listening sockets opened or closed in the window
+func (s *inboundService) GetListenEvents(since, until time.Time, kind string, limit int, filter models.Filter) ([]models.ListenEvent, error) {
+	return s.repo.GetListenEvents(since, until, kind, limit, filter)
+}
+
+// SubscribeAccepts registers fn to receive every batch of accepted connections
+func (s *inboundService) SubscribeAccepts(fn func([]models.InboundConnection)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.acceptSubs = append(s.acceptSubs, fn)
+}
+
+// SubscribeListenEvents registers fn to receive the listening sockets opened
+// and closed between two inventories
+func (s *inboundService) SubscribeListenEvents(fn func([]models.ListenEvent)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.listenerSubs = append(s.listenerSubs, fn)
+}
+
+func (s *inboundService) publishAccepts(events []models.InboundConnection) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.acceptSubs {
+		fn(events)
+	}
+}
+
+func (s *inboundService) publishListenEvents(events []models.ListenEvent) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.listenerSubs {
+		fn(events)
+	}
+}
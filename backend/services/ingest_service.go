@@ -0,0 +1,334 @@
+package services
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"errors"
+	"sync"
+	"time"
+)
+
+// batchDedupWindow is how long batch IDs are remembered, so a batch the
+// agent resends after a lost response is stored only once
+const batchDedupWindow = time.Hour
+
+type IngestService interface {
+	Register(reg models.AgentRegistration) (models.Agent, error)
+	Heartbeat(id string, hb models.AgentHeartbeat) error
+	// Ingest stores a batch and reports whether it was new
+	Ingest(batch models.Batch) (bool, error)
+	GetAgents() ([]models.Agent, error)
+	// Subscribe registers fn to receive every batch stored
+	Subscribe(fn BatchSubscriber)
+}
+
+// ErrBatchInProgress is returned for a batch that is being stored by an
+// earlier request, e.g. one the agent gave up waiting for. The agent retries
+// and learns the outcome then.
+var ErrBatchInProgress = errors.New("batch is being stored")
+
+// BatchSubscriber receives every stored agent batch, together with the
+// listening sockets its inventory opened or closed
+type BatchSubscriber func(batch models.Batch, listenEvents []models.ListenEvent)
+
+type ingestService struct {
+	agents       repository.AgentRepository
+	batches      repository.BatchWriter
+	offlineAfter time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time // by agent and batch ID, zero while being stored
+
+	subMu       sync.RWMutex
+	subscribers []BatchSubscriber
+}
+
+func NewIngestService(agents repository.AgentRepository, batches repository.BatchWriter, offlineAfter time.Duration) IngestService {
+	return &ingestService{
+		agents:       agents,
+		batches:      batches,
+		offlineAfter: offlineAfter,
+		seen:         make(map[string]time.Time),
+	}
+}
+
+func (s *ingestService) Register(reg models.AgentRegistration) (models.Agent, error) {
+	if err := s.agents.Register(reg, time.Now()); err != nil {
+		return models.Agent{}, err
+	}
+	agent, err := s.agents.GetAgent(reg.ID)
+	if err != nil {
+		return models.Agent{}, err
+	}
+	return s.withStatus(agent, time.Now()), nil
+}
+
+func (s *ingestService) Heartbeat(id string, hb models.AgentHeartbeat) error {
+	return s.agents.Heartbeat(id, hb, time.Now())
+}
+
+// Ingest stamps every event of the batch with the host of the agent and
+// stores it. Unknown agents get repository.ErrNotFound and register again.
+func (s *ingestService) Ingest(batch models.Batch) (bool, error) {
+	agent, err := s.agents.GetAgent(batch.AgentID)
+	if err != nil {
+		return false, err
+	}
+
+	key := batch.AgentID + "|" + batch.ID
+	s.mu.Lock()
+	if at, ok := s.seen[key]; ok {
+		s.mu.Unlock()
+		if at.IsZero() {
+			return false, ErrBatchInProgress
+		}
+		return false, nil
+	}
+	// Reserve the batch, so a resend arriving while it is stored is not
+	// stored again
+	s.seen[key] = time.Time{}
+	s.mu.Unlock()
+
+	s.stamp(&batch, agent.Host, agent.Labels)
+	listenEvents, err := s.store(batch, agent.Host)
+
+	now := time.Now()
+	s.mu.Lock()
+	if err != nil {
+		delete(s.seen, key)
+		s.mu.Unlock()
+		return false, err
+	}
+	s.seen[key] = now
+	for k, at := range s.seen {
+		if !at.IsZero() && now.Sub(at) > batchDedupWindow {
+			delete(s.seen, k)
+		}
+	}
+	s.mu.Unlock()
+
+	s.publish(batch, listenEvents)
+	return true, s.agents.RecordBatch(batch.AgentID, batch.Len(), now)
+}
+
+// Subscribe registers fn to receive every batch stored
+func (s *ingestService) Subscribe(fn BatchSubscriber) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *ingestService) publish(batch models.Batch, listenEvents []models.ListenEvent) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(batch, listenEvents)
+	}
+}
+
+// stamp sets the host and host labels of every event, overriding whatever
+// the agent sent
+func (s *ingestService) stamp(batch *models.Batch, host string, labels models.Labels) {
+	for i := range batch.Processes {
+		batch.Processes[i].Host = host
+		batch.Processes[i].HostLabels = labels
+	}
+	for i := range batch.Connections {
+		batch.Connections[i].Host = host
+		batch.Connections[i].HostLabels = labels
+	}
+	for i := range batch.DiskLatency {
+		batch.DiskLatency[i].Host = host
+		batch.DiskLatency[i].HostLabels = labels
+	}
+	for i := range batch.CPUProfiles {
+		batch.CPUProfiles[i].Host = host
+		batch.CPUProfiles[i].HostLabels = labels
+	}
+	for i := range batch.TCPSessions {
+		batch.TCPSessions[i].Host = host
+		batch.TCPSessions[i].HostLabels = labels
+	}
+	for i := range batch.Syscalls {
+		batch.Syscalls[i].Host = host
+		batch.Syscalls[i].HostLabels = labels
+	}
+	for i := range batch.ProcessSyscalls {
+		batch.ProcessSyscalls[i].Host = host
+		batch.ProcessSyscalls[i].HostLabels = labels
+	}
+	for i := range batch.Exits {
+		batch.Exits[i].Host = host
+		batch.Exits[i].HostLabels = labels
+	}
+	for i := range batch.FileOpens {
+		batch.FileOpens[i].Host = host
+		batch.FileOpens[i].HostLabels = labels
+	}
+	for i := range batch.RunqLatency {
+		batch.RunqLatency[i].Host = host
+		batch.RunqLatency[i].HostLabels = labels
+	}
+	for i := range batch.OffCPUStacks {
+		batch.OffCPUStacks[i].Host = host
+		batch.OffCPUStacks[i].HostLabels = labels
+	}
+	for i := range batch.TCPRetransmits {
+		batch.TCPRetransmits[i].Host = host
+		batch.TCPRetransmits[i].HostLabels = labels
+	}
+	for i := range batch.TCPDrops {
+		batch.TCPDrops[i].Host = host
+		batch.TCPDrops[i].HostLabels = labels
+	}
+	for i := range batch.TCPRTT {
+		batch.TCPRTT[i].Host = host
+		batch.TCPRTT[i].HostLabels = labels
+	}
+	for i := range batch.Inbound {
+		batch.Inbound[i].Host = host
+		batch.Inbound[i].HostLabels = labels
+	}
+	for i := range batch.TCPStates {
+		batch.TCPStates[i].Host = host
+		batch.TCPStates[i].HostLabels = labels
+	}
+	for i := range batch.DNSLookups {
+		batch.DNSLookups[i].Host = host
+		batch.DNSLookups[i].HostLabels = labels
+	}
+	for i := range batch.OOMKills {
+		batch.OOMKills[i].Host = host
+		batch.OOMKills[i].HostLabels = labels
+	}
+	for i := range batch.Signals {
+		batch.Signals[i].Host = host
+		batch.Signals[i].HostLabels = labels
+	}
+	for i := range batch.SlowFileOps {
+		batch.SlowFileOps[i].Host = host
+		batch.SlowFileOps[i].HostLabels = labels
+	}
+	if batch.Listeners != nil {
+		for i := range batch.Listeners.Sockets {
+			batch.Listeners.Sockets[i].Host = host
+			batch.Listeners.Sockets[i].HostLabels = labels
+		}
+	}
+}
+
+// store saves the events of batch in one transaction where the backend
+// supports it. The inventory of listening sockets, if any, replaces the one
+// stored for host first: replacing it again when the batch is resent after
+// a failure changes nothing.
+func (s *ingestService) store(batch models.Batch, host string) ([]models.ListenEvent, error) {
+	var listenEvents []models.ListenEvent
+	err := s.batches.WriteBatch(func(stores repository.Stores) error {
+		var err error
+		if batch.Listeners != nil {
+			listenEvents, err = stores.Inbound.ReplaceListeners(host, batch.Listeners.Sockets, batch.Listeners.ScannedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return saveBatch(stores, batch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return listenEvents, nil
+}
+
+// saveBatch saves the events of batch
+func saveBatch(stores repository.Stores, batch models.Batch) error {
+	if len(batch.Processes) > 0 {
+		if err := stores.Processes.SaveProcesses(batch.Processes); err != nil {
+			return err
+		}
+	}
+	if err := stores.Connections.SaveConnections(batch.Connections); err != nil {
+		return err
+	}
+	if len(batch.DiskLatency) > 0 {
+		if err := stores.Disk.SaveLatencySnapshot(batch.DiskLatency); err != nil {
+			return err
+		}
+	}
+	if err := stores.CPUProfiles.SaveCPUProfiles(batch.CPUProfiles); err != nil {
+		return err
+	}
+	if err := stores.TCPSessions.SaveTCPLifeEvents(batch.TCPSessions); err != nil {
+		return err
+	}
+	if err := stores.Syscalls.SaveSyscallStats(batch.Syscalls); err != nil {
+		return err
+	}
+	if err := stores.Syscalls.SaveProcessSyscallStats(batch.ProcessSyscalls); err != nil {
+		return err
+	}
+	if err := stores.Exits.SaveExits(batch.Exits); err != nil {
+		return err
+	}
+	if err := stores.Files.SaveOpens(batch.FileOpens); err != nil {
+		return err
+	}
+	if len(batch.RunqLatency) > 0 {
+		if err := stores.Runq.SaveRunqSnapshot(batch.RunqLatency); err != nil {
+			return err
+		}
+	}
+	if err := stores.OffCPU.SaveOffCPUStacks(batch.OffCPUStacks); err != nil {
+		return err
+	}
+	if err := stores.TCPRetrans.SaveRetransmits(batch.TCPRetransmits); err != nil {
+		return err
+	}
+	if err := stores.TCPRetrans.SaveDrops(batch.TCPDrops); err != nil {
+		return err
+	}
+	if len(batch.TCPRTT) > 0 {
+		if err := stores.TCPRTT.SaveRTTSnapshot(batch.TCPRTT); err != nil {
+			return err
+		}
+	}
+	if err := stores.Inbound.SaveInbound(batch.Inbound); err != nil {
+		return err
+	}
+	if err := stores.TCPStates.SaveStateChanges(batch.TCPStates); err != nil {
+		return err
+	}
+	if err := stores.DNS.SaveLookups(batch.DNSLookups); err != nil {
+		return err
+	}
+	if err := stores.Kills.SaveOOMKills(batch.OOMKills); err != nil {
+		return err
+	}
+	if err := stores.Kills.SaveSignals(batch.Signals); err != nil {
+		return err
+	}
+	if err := stores.FSLatency.SaveSlowOps(batch.SlowFileOps); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAgents returns all agents with their status
+func (s *ingestService) GetAgents() ([]models.Agent, error) {
+	agents, err := s.agents.GetAgents()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range agents {
+		agents[i] = s.withStatus(agents[i], now)
+	}
+	return agents, nil
+}
+
+func (s *ingestService) withStatus(agent models.Agent, now time.Time) models.Agent {
+	agent.Status = models.AgentOffline
+	if now.Sub(agent.LastHeartbeat) <= s.offlineAfter {
+		agent.Status = models.AgentOnline
+	}
+	return agent
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type KillService interface {
+	Start()
+	Stop()
+	GetRecentOOMKills(limit int, filter models.Filter) ([]models.OOMKill, error)
+	GetOOMKills(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.OOMKill, error)
+	GetRecentSignals(limit int, filter models.Filter) ([]models.Signal, error)
+	GetSignals(q models.SignalQuery) ([]models.Signal, error)
+}
+
+type killService struct {
+	repo       repository.KillRepository
+	oomKills   *collector.OomkillCollector
+	signals    *collector.KillsnoopCollector
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewKillService(repo repository.KillRepository, attributor enrich.Attributor) KillService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &killService{
+		repo:       repo,
+		oomKills:   collector.NewOomkillCollector(),
+		signals:    collector.NewKillsnoopCollector(),
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start starts oomkill and killsnoop. Either works without the other.
+func (s *killService) Start() {
+	if err := s.oomKills.Start(); err != nil {
+		log.Printf("Failed to start oomkill collector: %v", err)
+	}
+	if err := s.signals.Start(); err != nil {
+		log.Printf("Failed to start killsnoop collector: %v", err)
+	}
+
+	s.wg.Add(1)
+	go s.collectPeriodically()
+}
+
+// Stop stops both collectors
+func (s *killService) Stop() {
+	s.cancel()
+	s.oomKills.Stop()
+	s.signals.Stop()
+	s.wg.Wait()
+}
+
+func (s *killService) collectPeriodically() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectAndSave()
+		}
+	}
+}
+
+// collectAndSave attributes OOM kills and signals to their targets, which are
+// often gone already. Neither the process that triggered an OOM kill nor the
+// sender of a signal stands in for them: a global OOM kill hits any process
+// on the host, and signals come from e.g. systemd or the kubelet stopping a
+// container. The cgroup of the trigger is recorded next to the victim.
+func (s *killService) collectAndSave() {
+	if kills := s.oomKills.GetEvents(); len(kills) > 0 {
+		for i := range kills {
+			kills[i].Attribution = s.attributor.Attribute(kills[i].PID, 0)
+			kills[i].TriggerCgroup = s.attributor.Attribute(kills[i].TriggerPID, 0).CgroupPath
+		}
+		if err := s.repo.SaveOOMKills(kills); err != nil {
+			log.Printf("Error saving OOM kills: %v", err)
+		}
+	}
+
+	if signals := s.signals.GetEvents(); len(signals) > 0 {
+		for i := range signals {
+			signals[i].Attribution = s.attributor.Attribute(signals[i].PID, 0)
+		}
+		if err := s.repo.SaveSignals(signals); err != nil {
+			log.Printf("Error saving signals: %v", err)
+		}
+	}
+}
+
+// GetRecentOOMKills retrieves the most recent OOM kills
+func (s *killService) GetRecentOOMKills(limit int, filter models.Filter) ([]models.OOMKill, error) {
+	return s.repo.GetRecentOOMKills(limit, filter)
+}
+
+// GetOOMKills retrieves the OOM kills in the window, optionally of victims
+// with the given command
+func (s *killService) GetOOMKills(since, until time.Time, comm string, limit int, filter models.Filter) ([]models.OOMKill, error) {
+	return s.repo.GetOOMKills(since, until, comm, limit, filter)
+}
+
+// GetRecentSignals retrieves the most recent signals
+func (s *killService) GetRecentSignals(limit int, filter models.Filter) ([]models.Signal, error) {
+	return s.repo.GetRecentSignals(limit, filter)
+}
+
+// GetSignals retrieves the signals selected by q, newest first
+func (s *killService) GetSignals(q models.SignalQuery) ([]models.Signal, error) {
+	return s.repo.GetSignals(q)
+}
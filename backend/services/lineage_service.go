@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/procfs"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrProcessNotFound is returned when a PID is not present in the process tree
+var ErrProcessNotFound = errors.New("process not found")
+
+// maxExitedProcesses caps how many exited processes are kept for lineage lookups
+const maxExitedProcesses = 50000
+
+// startTolerance absorbs the imprecision of start times taken from exec events
+const startTolerance = 2 * time.Second
+
+type LineageService interface {
+	Start()
+	Stop()
+	HandleExecs(events []models.ProcessEvent)
+	HandleExits(events []models.ProcessExit)
+	Ancestors(pid int) ([]models.ProcessNode, error)
+	Descendants(pid int) (*models.ProcessTree, error)
+	TreeBetween(since, until time.Time) []*models.ProcessTree
+}
+
+type lineageEntry struct {
+	node       models.ProcessNode
+	startTicks uint64 // starttime from /proc/<pid>/stat, 0 if unknown
+}
+
+type lineageService struct {
+	mu        sync.RWMutex
+	live      map[int]*lineageEntry
+	exited    []*lineageEntry         // oldest first
+	exitedBy  map[int][]*lineageEntry // exited, by PID
+	retention time.Duration
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewLineageService(retention time.Duration) LineageService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lineageService{
+		live:      make(map[int]*lineageEntry),
+		exitedBy:  make(map[int][]*lineageEntry),
+		retention: retention,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start seeds the tree from /proc and keeps it in sync in the background
+func (s *lineageService) Start() {
+	s.rescan()
+
+	s.mu.RLock()
+	log.Printf("Process lineage seeded with %d processes from /proc", len(s.live))
+	s.mu.RUnlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		// Rescan /proc to catch forks without exec and exits we did not observe
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.rescan()
+				s.prune()
+			}
+		}
+	}()
+}
+
+// Stop stops the background /proc rescans
+func (s *lineageService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// HandleExecs updates the tree with exec events from execsnoop
+func (s *lineageService) HandleExecs(events []models.ProcessEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range events {
+		pid, err := strconv.Atoi(e.PID)
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(e.PPID)
+		st, statErr := procfs.ReadStat(pid)
+
+		if cur, ok := s.live[pid]; ok {
+			// The start time tells an exec of the known process from a reused
+			// PID. A different parent alone does not, the process may have been
+			// reparented. Without start times, fall back to comparing parents.
+			sameProcess := cur.node.PPID == ppid
+			if statErr == nil && cur.startTicks != 0 {
+				sameProcess = st.StartTime == cur.startTicks
+			}
+			if sameProcess {
+				// exec replaces the image of an existing process, keep its identity
+				// and its original parent for the lineage
+				cur.reparent(ppid)
+				cur.node.Comm = e.Comm
+				cur.node.Args = e.Args
+				cur.node.Source = "exec"
+				continue
+			}
+			s.retire(cur, now)
+		}
+
+		entry := &lineageEntry{
+			node: models.ProcessNode{
+				PID:       pid,
+				PPID:      ppid,
+				Comm:      e.Comm,
+				Args:      e.Args,
+				StartTime: now,
+				Source:    "exec",
+			},
+		}
+		if statErr == nil && st.PPID == ppid {
+			entry.startTicks = st.StartTime
+			entry.node.StartTime = st.StartedAt()
+		}
+		s.live[pid] = entry
+
+		// Make sure the parent is known so the chain does not break here
+		if _, ok := s.live[ppid]; !ok && ppid > 0 {
+			s.addFromProc(ppid)
+		}
+	}
+}
+
+// HandleExits marks processes reported by exitsnoop as exited
+func (s *lineageService) HandleExits(events []models.ProcessExit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range events {
+		// Whatever process we know under this PID is gone now, even if
+		// we missed a PID reuse in between
+		if entry, ok := s.live[e.PID]; ok {
+			s.retire(entry, now)
+		}
+	}
+}
+
+// Ancestors returns the process followed by its parent, grandparent and so on up to the root
+func (s *lineageService) Ancestors(pid int) ([]models.ProcessNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry := s.lookup(pid, time.Time{})
+	if entry == nil {
+		return nil, ErrProcessNotFound
+	}
+
+	chain := []models.ProcessNode{entry.node}
+	seen := map[int]bool{pid: true}
+	for entry.node.PPID > 0 && !seen[entry.node.PPID] {
+		seen[entry.node.PPID] = true
+		parent := s.lookup(entry.node.PPID, entry.node.StartTime.Add(startTolerance))
+		if parent == nil {
+			break
+		}
+		chain = append(chain, parent.node)
+		entry = parent
+	}
+	return chain, nil
+}
+
+// Descendants returns the subtree rooted at pid
+func (s *lineageService) Descendants(pid int) (*models.ProcessTree, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry := s.lookup(pid, time.Time{})
+	if entry == nil {
+		return nil, ErrProcessNotFound
+	}
+
+	children := s.childIndex(s.all())
+	return buildTree(entry, children, map[*lineageEntry]bool{}), nil
+}
+
+// TreeBetween returns the forest of processes started within [since, until],
+// together with their ancestors so every process is shown in context
+func (s *lineageService) TreeBetween(since, until time.Time) []*models.ProcessTree {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	selected := make(map[*lineageEntry]bool)
+	for _, entry := range s.all() {
+		start := entry.node.StartTime
+		if start.Before(since) || (!until.IsZero() && start.After(until)) {
+			continue
+		}
+		selected[entry] = true
+
+		// Pull in the ancestors for context
+		cur := entry
+		for cur.node.PPID > 0 {
+			parent := s.lookup(cur.node.PPID, cur.node.StartTime.Add(startTolerance))
+			if parent == nil || selected[parent] {
+				break
+			}
+			selected[parent] = true
+			cur = parent
+		}
+	}
+
+	members := make([]*lineageEntry, 0, len(selected))
+	for entry := range selected {
+		members = append(members, entry)
+	}
+
+	children := s.childIndex(members)
+	visited := make(map[*lineageEntry]bool)
+	var forest []*models.ProcessTree
+	sortEntries(members)
+	for _, entry := range members {
+		parent := s.lookup(entry.node.PPID, entry.node.StartTime.Add(startTolerance))
+		if parent != nil && parent != entry && selected[parent] {
+			continue
+		}
+		forest = append(forest, buildTree(entry, children, visited))
+	}
+	return forest
+}
+
+// FormatChain renders an ancestor chain as "bash <- sshd <- systemd"
+func FormatChain(chain []models.ProcessNode) string {
+	names := make([]string, len(chain))
+	for i, node := range chain {
+		names[i] = node.Comm
+	}
+	return strings.Join(names, " <- ")
+}
+
+func buildTree(entry *lineageEntry, children map[int][]*lineageEntry, visited map[*lineageEntry]bool) *models.ProcessTree {
+	visited[entry] = true
+	tree := &models.ProcessTree{ProcessNode: entry.node, Children: []*models.ProcessTree{}}
+	for _, child := range children[entry.node.PID] {
+		if visited[child] || child.node.StartTime.Before(entry.node.StartTime.Add(-startTolerance)) {
+			continue
+		}
+		tree.Children = append(tree.Children, buildTree(child, children, visited))
+	}
+	return tree
+}
+
+// childIndex groups entries by parent PID
+func (s *lineageService) childIndex(entries []*lineageEntry) map[int][]*lineageEntry {
+	index := make(map[int][]*lineageEntry)
+	for _, entry := range entries {
+		if entry.node.PPID == entry.node.PID {
+			continue
+		}
+		index[entry.node.PPID] = append(index[entry.node.PPID], entry)
+	}
+	for _, list := range index {
+		sortEntries(list)
+	}
+	return index
+}
+
+func sortEntries(entries []*lineageEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].node.StartTime.Equal(entries[j].node.StartTime) {
+			return entries[i].node.StartTime.Before(entries[j].node.StartTime)
+		}
+		return entries[i].node.PID < entries[j].node.PID
+	})
+}
+
+func (s *lineageService) all() []*lineageEntry {
+	entries := make([]*lineageEntry, 0, len(s.live)+len(s.exited))
+	for _, entry := range s.live {
+		entries = append(entries, entry)
+	}
+	return append(entries, s.exited...)
+}
+
+// lookup finds the process with the given PID that was running at time at.
+// A zero time prefers the live process and falls back to the latest exited one.
+func (s *lineageService) lookup(pid int, at time.Time) *lineageEntry {
+	if entry, ok := s.live[pid]; ok && (at.IsZero() || !entry.node.StartTime.After(at)) {
+		return entry
+	}
+
+	var best *lineageEntry
+	for _, entry := range s.exitedBy[pid] {
+		if !at.IsZero() && entry.node.StartTime.After(at) {
+			continue
+		}
+		if best == nil || entry.node.StartTime.After(best.node.StartTime) {
+			best = entry
+		}
+	}
+	return best
+}
+
+// addFromProc inserts a live process read from /proc. Callers must hold s.mu.
+func (s *lineageService) addFromProc(pid int) {
+	st, err := procfs.ReadStat(pid)
+	if err != nil {
+		return
+	}
+
+	args, _ := procfs.ReadCmdline(pid)
+	if args == "" {
+		// Kernel threads have no command line
+		args = "[" + st.Comm + "]"
+	}
+
+	s.live[pid] = &lineageEntry{
+		node: models.ProcessNode{
+			PID:       pid,
+			PPID:      st.PPID,
+			Comm:      st.Comm,
+			Args:      args,
+			StartTime: st.StartedAt(),
+			Source:    "proc",
+		},
+		startTicks: st.StartTime,
+	}
+}
+
+// retire moves a live process to the exited list. Callers must hold s.mu.
+func (s *lineageService) retire(entry *lineageEntry, at time.Time) {
+	if entry.node.ExitTime == nil {
+		exitTime := at
+		entry.node.ExitTime = &exitTime
+	}
+	if s.live[entry.node.PID] == entry {
+		delete(s.live, entry.node.PID)
+	}
+	s.exited = append(s.exited, entry)
+	s.exitedBy[entry.node.PID] = append(s.exitedBy[entry.node.PID], entry)
+}
+
+// reparent records the current parent of a process, keeping the parent it
+// had when it was first seen as its lineage parent
+func (e *lineageEntry) reparent(ppid int) {
+	if ppid == e.node.PPID {
+		e.node.ReparentedTo = 0
+	} else {
+		e.node.ReparentedTo = ppid
+	}
+}
+
+// rescan reconciles the tree with the processes currently in /proc
+func (s *lineageService) rescan() {
+	pids, err := procfs.ListPIDs()
+	if err != nil {
+		log.Printf("Error listing /proc: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	present := make(map[int]bool, len(pids))
+	for _, pid := range pids {
+		present[pid] = true
+
+		entry, ok := s.live[pid]
+		if !ok {
+			s.addFromProc(pid)
+			continue
+		}
+
+		st, err := procfs.ReadStat(pid)
+		if err != nil {
+			continue
+		}
+		switch {
+		case entry.startTicks == 0 && st.PPID == entry.node.PPID:
+			entry.startTicks = st.StartTime
+		case entry.startTicks != st.StartTime:
+			// The PID now belongs to a different process
+			s.retire(entry, now)
+			s.addFromProc(pid)
+		default:
+			// Same process, possibly reparented since
+			entry.reparent(st.PPID)
+		}
+	}
+
+	for pid, entry := range s.live {
+		if !present[pid] {
+			s.retire(entry, now)
+		}
+	}
+}
+
+// prune drops exited processes that are older than the retention period
+func (s *lineageService) prune() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.retention)
+	kept := s.exited[:0]
+	for _, entry := range s.exited {
+		if entry.node.ExitTime != nil && entry.node.ExitTime.After(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) > maxExitedProcesses {
+		kept = kept[len(kept)-maxExitedProcesses:]
+	}
+	s.exited = kept
+
+	s.exitedBy = make(map[int][]*lineageEntry, len(kept))
+	for _, entry := range kept {
+		s.exitedBy[entry.node.PID] = append(s.exitedBy[entry.node.PID], entry)
+	}
+}
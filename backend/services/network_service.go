@@ -3,9 +3,11 @@ package services
 import (
 	"context"
 	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
 	"ebpf-dashboard/models"
 	"ebpf-dashboard/repository"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -13,24 +15,32 @@ import (
 type NetworkService interface {
 	StartCollecting()
 	StopCollecting()
-	GetRecentConnections(limit int) ([]models.NetworkConnection, error)
+	GetRecentConnections(limit int, filter models.Filter) ([]models.NetworkConnection, error)
+	Subscribe(fn func([]models.NetworkConnection))
 }
 
 type networkService struct {
-	repo      repository.NetworkRepository
-	collector *collector.NetworkCollector
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
+	repo       repository.NetworkRepository
+	collector  *collector.NetworkCollector
+	attributor enrich.Attributor
+	hostnames  *enrich.HostnameResolver
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	subMu       sync.RWMutex
+	subscribers []func([]models.NetworkConnection)
 }
 
-func NewNetworkService(repo repository.NetworkRepository) NetworkService {
+func NewNetworkService(repo repository.NetworkRepository, attributor enrich.Attributor, hostnames *enrich.HostnameResolver) NetworkService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &networkService{
-		repo:      repo,
-		collector: collector.NewNetworkCollector(),
-		ctx:       ctx,
-		cancel:    cancel,
+		repo:       repo,
+		collector:  collector.NewNetworkCollector(),
+		attributor: attributor,
+		hostnames:  hostnames,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -59,9 +69,11 @@ func (s *networkService) StartCollecting() {
 
 				// Save them to database using batch insert
 				if len(events) > 0 {
+					s.attribute(events)
 					if err := s.repo.SaveConnections(events); err != nil {
 						log.Printf("Error saving connections: %v", err)
 					}
+					s.publish(events)
 				}
 			}
 		}
@@ -73,6 +85,31 @@ func (s *networkService) StopCollecting() {
 	s.wg.Wait()
 }
 
-func (s *networkService) GetRecentConnections(limit int) ([]models.NetworkConnection, error) {
-	return s.repo.GetRecentConnections(limit)
+func (s *networkService) GetRecentConnections(limit int, filter models.Filter) ([]models.NetworkConnection, error) {
+	return s.repo.GetRecentConnections(limit, filter)
+}
+
+// attribute resolves the cgroup, unit and container of each connection, and
+// the hostname its process resolved the destination from
+func (s *networkService) attribute(events []models.NetworkConnection) {
+	for i := range events {
+		pid, _ := strconv.Atoi(events[i].PID)
+		events[i].Attribution = s.attributor.Attribute(pid, 0)
+	}
+	s.hostnames.ResolveConnections(events)
+}
+
+// Subscribe registers fn to receive every batch of collected connect events
+func (s *networkService) Subscribe(fn func([]models.NetworkConnection)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *networkService) publish(events []models.NetworkConnection) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(events)
+	}
 }
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+type OffCPUService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetRecentStacks(limit int, filter models.Filter) ([]models.OffCPUStack, error)
+}
+
+type offCPUService struct {
+	repo       repository.OffCPURepository
+	collector  *collector.OffCPUCollector
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewOffCPUService returns the service recording off-CPU stacks, summed up
+// by offcputime over interval
+func NewOffCPUService(repo repository.OffCPURepository, attributor enrich.Attributor, interval time.Duration) OffCPUService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &offCPUService{
+		repo:       repo,
+		collector:  collector.NewOffCPUCollector(interval),
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *offCPUService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.processEvents()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *offCPUService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+func (s *offCPUService) processEvents() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			stacks := s.collector.GetEvents()
+			if len(stacks) > 0 {
+				// Without a PID only the host is known
+				attribution := s.attributor.Attribute(0, 0)
+				for i := range stacks {
+					stacks[i].Attribution = attribution
+				}
+				if err := s.repo.SaveOffCPUStacks(stacks); err != nil {
+					log.Printf("Error saving off-CPU stacks: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// GetRecentStacks retrieves the most recent off-CPU stacks
+func (s *offCPUService) GetRecentStacks(limit int, filter models.Filter) ([]models.OffCPUStack, error) {
+	return s.repo.GetRecentOffCPUStacks(limit, filter)
+}
@@ -3,9 +3,11 @@ package services
 import (
 	"context"
 	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
 	"ebpf-dashboard/models"
 	"ebpf-dashboard/repository"
 	"log"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -13,24 +15,30 @@ import (
 type ProcessService interface {
 	StartCollecting()
 	StopCollecting()
-	GetRecentProcesses(limit int) ([]models.ProcessEvent, error)
+	GetRecentProcesses(limit int, filter models.Filter) ([]models.ProcessEvent, error)
+	Subscribe(fn func([]models.ProcessEvent))
 }
 
 type processService struct {
-	repo      repository.ProcessRepository
-	collector *collector.ProcessCollector
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
+	repo       repository.ProcessRepository
+	collector  *collector.ProcessCollector
+	attributor enrich.Attributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	subMu       sync.RWMutex
+	subscribers []func([]models.ProcessEvent)
 }
 
-func NewProcessService(repo repository.ProcessRepository) ProcessService {
+func NewProcessService(repo repository.ProcessRepository, attributor enrich.Attributor) ProcessService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &processService{
-		repo:      repo,
-		collector: collector.NewProcessCollector(),
-		ctx:       ctx,
-		cancel:    cancel,
+		repo:       repo,
+		collector:  collector.NewProcessCollector(),
+		attributor: attributor,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -59,9 +67,11 @@ func (s *processService) StartCollecting() {
 
 				// Save them to database using batch insert
 				if len(events) > 0 {
+					s.attribute(events)
 					if err := s.repo.SaveProcesses(events); err != nil {
 						log.Printf("Error saving processes: %v", err)
 					}
+					s.publish(events)
 				}
 			}
 		}
@@ -73,6 +83,30 @@ func (s *processService) StopCollecting() {
 	s.wg.Wait()
 }
 
-func (s *processService) GetRecentProcesses(limit int) ([]models.ProcessEvent, error) {
-	return s.repo.GetRecentProcesses(limit)
+func (s *processService) GetRecentProcesses(limit int, filter models.Filter) ([]models.ProcessEvent, error) {
+	return s.repo.GetRecentProcesses(limit, filter)
+}
+
+// attribute resolves the cgroup, unit and container of each event
+func (s *processService) attribute(events []models.ProcessEvent) {
+	for i := range events {
+		pid, _ := strconv.Atoi(events[i].PID)
+		ppid, _ := strconv.Atoi(events[i].PPID)
+		events[i].Attribution = s.attributor.Attribute(pid, ppid)
+	}
+}
+
+// Subscribe registers fn to receive every batch of collected exec events
+func (s *processService) Subscribe(fn func([]models.ProcessEvent)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *processService) publish(events []models.ProcessEvent) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(events)
+	}
 }
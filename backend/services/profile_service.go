@@ -0,0 +1,104 @@
+package services
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"time"
+)
+
+type ProfileService interface {
+	GetProfile(sel models.ProcessSelector, since, until time.Time, limit int) (*models.ProcessProfile, error)
+}
+
+type profileService struct {
+	repo    repository.ProfileRepository
+	lineage LineageService
+	host    string
+}
+
+func NewProfileService(repo repository.ProfileRepository, lineage LineageService, host string) ProfileService {
+	return &profileService{repo: repo, lineage: lineage, host: host}
+}
+
+// GetProfile joins execs, exits, OOM kills, received signals, connections,
+// TCP sessions, CPU stacks and syscall counts of the selected process within
+// the window. limit applies to each list separately. Note that a PID may have
+// been reused by different processes within a long window.
+func (s *profileService) GetProfile(sel models.ProcessSelector, since, until time.Time, limit int) (*models.ProcessProfile, error) {
+	profile := &models.ProcessProfile{
+		PID:   sel.PID,
+		Comm:  sel.Comm,
+		Since: since,
+		Until: until,
+	}
+
+	var err error
+	if profile.Execs, err = s.repo.GetExecs(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+	if profile.Exits, err = s.repo.GetExits(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+	if profile.OOMKills, err = s.repo.GetOOMKills(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+	if profile.Signals, err = s.repo.GetSignals(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+	if profile.Connections, err = s.repo.GetConnections(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+	if profile.TCPSessions, err = s.repo.GetTCPSessions(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+	if profile.TCP, err = s.repo.GetTCPSummary(sel, since, until); err != nil {
+		return nil, err
+	}
+	if profile.CPUStacks, profile.CPUSamples, err = s.repo.GetCPUStacks(sel, since, until, limit); err != nil {
+		return nil, err
+	}
+
+	series, err := s.repo.GetSyscallSeries(sel, since, until)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) > 0 {
+		profile.Syscalls = &models.SyscallSummary{Series: series}
+		for _, p := range series {
+			profile.Syscalls.Total += p.Count
+		}
+	}
+
+	if sel.PID > 0 {
+		// The process tree only holds processes of the local host
+		if sel.Filter.Host == "" || sel.Filter.Host == s.host {
+			if chain, err := s.lineage.Ancestors(sel.PID); err == nil {
+				profile.Ancestors = chain
+			}
+		}
+		if profile.Comm == "" {
+			profile.Comm = profileComm(profile)
+		}
+	}
+
+	return profile, nil
+}
+
+// profileComm names a PID after the most recent event that carries a comm
+func profileComm(profile *models.ProcessProfile) string {
+	switch {
+	case len(profile.Execs) > 0:
+		return profile.Execs[0].Comm
+	case len(profile.Exits) > 0:
+		return profile.Exits[0].Comm
+	case len(profile.OOMKills) > 0:
+		return profile.OOMKills[0].Comm
+	case len(profile.Connections) > 0:
+		return profile.Connections[0].Comm
+	case len(profile.TCPSessions) > 0:
+		return profile.TCPSessions[0].Comm
+	case len(profile.Ancestors) > 0:
+		return profile.Ancestors[0].Comm
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+type RunqService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetLatestRunq(limit int, filter models.Filter) ([]models.RunqLatency, error)
+	GetPercentiles(q models.RunqQuery, step time.Duration) ([]models.RunqPercentiles, error)
+	GetHeatmap(q models.RunqQuery, step time.Duration) (models.RunqHeatmap, error)
+}
+
+type runqService struct {
+	repo      repository.RunqRepository
+	collector *collector.RunqCollector
+	host      string
+	labels    models.Labels
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewRunqService returns the service collecting run queue latency, per PID
+// namespace if perNS is set and limited to cgroup if it is not empty
+func NewRunqService(repo repository.RunqRepository, host string, labels models.Labels, perNS bool, cgroup string) RunqService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &runqService{
+		repo:      repo,
+		collector: collector.NewRunqCollector(perNS, cgroup),
+		host:      host,
+		labels:    labels,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *runqService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				latencies := s.collector.GetEvents()
+				if len(latencies) > 0 {
+					for i := range latencies {
+						latencies[i].Host = s.host
+						latencies[i].HostLabels = s.labels
+					}
+					if err := s.repo.SaveRunqSnapshot(latencies); err != nil {
+						log.Printf("Error saving run queue latency: %v", err)
+					}
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *runqService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+// GetLatestRunq retrieves the most recent histogram buckets
+func (s *runqService) GetLatestRunq(limit int, filter models.Filter) ([]models.RunqLatency, error) {
+	return s.repo.GetLatestRunq(limit, filter)
+}
+
+// runqSeries is the histogram of one host and PID namespace over one step
+type runqSeries struct {
+	time    time.Time
+	host    string
+	pidns   int64
+	cgroup  string
+	buckets []models.RunqLatency
+}
+
+// GetPercentiles summarizes the histograms selected by q per step, host and
+// PID namespace, oldest first
+func (s *runqService) GetPercentiles(q models.RunqQuery, step time.Duration) ([]models.RunqPercentiles, error) {
+	buckets, err := s.repo.GetRunqBuckets(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type seriesKey struct {
+		time  time.Time
+		host  string
+		pidns int64
+	}
+
+	var series []*runqSeries
+	index := make(map[seriesKey]*runqSeries)
+	for _, b := range buckets {
+		key := seriesKey{b.Timestamp.Truncate(step), b.Host, b.PIDNS}
+		current, ok := index[key]
+		if !ok {
+			current = &runqSeries{time: key.time, host: b.Host, pidns: b.PIDNS, cgroup: b.Cgroup}
+			index[key] = current
+			series = append(series, current)
+		}
+		current.buckets = append(current.buckets, b)
+	}
+
+	results := make([]models.RunqPercentiles, 0, len(series))
+	for _, group := range series {
+		p := models.RunqPercentiles{
+			Time:   group.time,
+			Host:   group.host,
+			PIDNS:  group.pidns,
+			Cgroup: group.cgroup,
+			P50US:  models.LatencyPercentile(group.buckets, 0.5),
+			P90US:  models.LatencyPercentile(group.buckets, 0.9),
+			P99US:  models.LatencyPercentile(group.buckets, 0.99),
+		}
+		for _, b := range group.buckets {
+			p.Count += b.Count
+			if b.Count > 0 && b.RangeMax > p.MaxUS {
+				p.MaxUS = b.RangeMax
+			}
+		}
+		results = append(results, p)
+	}
+
+	// Buckets come oldest first, which keeps the steps in order; order the
+	// series of one step by host and namespace
+	sort.SliceStable(results, func(i, j int) bool {
+		if !results[i].Time.Equal(results[j].Time) {
+			return results[i].Time.Before(results[j].Time)
+		}
+		if results[i].Host != results[j].Host {
+			return results[i].Host < results[j].Host
+		}
+		return results[i].PIDNS < results[j].PIDNS
+	})
+
+	return results, nil
+}
+
+// GetHeatmap sums up the histograms selected by q per step, across hosts and
+// namespaces. Steps without any run queue latency are left out.
+func (s *runqService) GetHeatmap(q models.RunqQuery, step time.Duration) (models.RunqHeatmap, error) {
+	buckets, err := s.repo.GetRunqBuckets(q)
+	if err != nil {
+		return models.RunqHeatmap{}, err
+	}
+
+	// Rows are the distinct bucket ranges seen in the window
+	rows := make(map[int]int)
+	for _, b := range buckets {
+		rows[b.RangeMin] = b.RangeMax
+	}
+	heatmap := models.RunqHeatmap{
+		Step:    step.String(),
+		Buckets: make([]models.RunqHeatmapBucket, 0, len(rows)),
+		Columns: []models.RunqHeatmapColumn{},
+	}
+	for rangeMin, rangeMax := range rows {
+		heatmap.Buckets = append(heatmap.Buckets, models.RunqHeatmapBucket{RangeMin: rangeMin, RangeMax: rangeMax})
+	}
+	sort.Slice(heatmap.Buckets, func(i, j int) bool { return heatmap.Buckets[i].RangeMin < heatmap.Buckets[j].RangeMin })
+
+	row := make(map[int]int, len(heatmap.Buckets))
+	for i, b := range heatmap.Buckets {
+		row[b.RangeMin] = i
+	}
+
+	columns := make(map[time.Time]int)
+	for _, b := range buckets {
+		t := b.Timestamp.Truncate(step)
+		i, ok := columns[t]
+		if !ok {
+			i = len(heatmap.Columns)
+			columns[t] = i
+			heatmap.Columns = append(heatmap.Columns, models.RunqHeatmapColumn{Time: t, Counts: make([]int, len(heatmap.Buckets))})
+		}
+		heatmap.Columns[i].Counts[row[b.RangeMin]] += b.Count
+	}
+
+	return heatmap, nil
+}
@@ -13,24 +13,35 @@ import (
 type SyscallService interface {
 	Start()
 	Stop()
-	GetRecentStats(limit int) ([]models.SyscallStat, error)
+	GetRecentStats(limit int, filter models.Filter) ([]models.SyscallStat, error)
+	GetRecentProcessStats(limit int, filter models.Filter) ([]models.ProcessSyscallStat, error)
+	Subscribe(fn func([]models.SyscallStat))
 }
 
 type syscallService struct {
-	repo      *repository.SyscallRepository
-	collector *collector.SyscallCollector
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
+	repo             repository.SyscallRepository
+	collector        *collector.SyscallCollector
+	processCollector *collector.ProcessSyscallCollector
+	host             string
+	labels           models.Labels
+	ctx              context.Context
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+
+	subMu       sync.RWMutex
+	subscribers []func([]models.SyscallStat)
 }
 
-func NewSyscallService(repo *repository.SyscallRepository) SyscallService {
+func NewSyscallService(repo repository.SyscallRepository, host string, labels models.Labels) SyscallService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &syscallService{
-		repo:      repo,
-		collector: collector.NewSyscallCollector(),
-		ctx:       ctx,
-		cancel:    cancel,
+		repo:             repo,
+		collector:        collector.NewSyscallCollector(),
+		processCollector: collector.NewProcessSyscallCollector(),
+		host:             host,
+		labels:           labels,
+		ctx:              ctx,
+		cancel:           cancel,
 	}
 }
 
@@ -42,6 +53,11 @@ func (s *syscallService) Start() {
 		return
 	}
 
+	// Per-process counts are optional, the service works without them
+	if err := s.processCollector.Start(); err != nil {
+		log.Printf("Failed to start per-process syscall collector: %v", err)
+	}
+
 	s.wg.Add(1)
 	go s.collectPeriodically()
 	log.Println("Syscall stats service started")
@@ -51,6 +67,7 @@ func (s *syscallService) Start() {
 func (s *syscallService) Stop() {
 	s.cancel()
 	s.collector.Stop()
+	s.processCollector.Stop()
 	s.wg.Wait()
 	log.Println("Syscall stats service stopped")
 }
@@ -73,19 +90,49 @@ func (s *syscallService) collectPeriodically() {
 }
 
 func (s *syscallService) collectAndSave() {
-	stats := s.collector.GetEvents()
-
-	if len(stats) == 0 {
-		return
+	if stats := s.collector.GetEvents(); len(stats) > 0 {
+		for i := range stats {
+			stats[i].Host = s.host
+			stats[i].HostLabels = s.labels
+		}
+		if err := s.repo.SaveSyscallStats(stats); err != nil {
+			log.Printf("Error saving syscall stats: %v", err)
+		}
+		s.publish(stats)
 	}
 
-	if err := s.repo.SaveSyscallStats(stats); err != nil {
-		log.Printf("Error saving syscall stats: %v", err)
-		return
+	if stats := s.processCollector.GetEvents(); len(stats) > 0 {
+		for i := range stats {
+			stats[i].Host = s.host
+			stats[i].HostLabels = s.labels
+		}
+		if err := s.repo.SaveProcessSyscallStats(stats); err != nil {
+			log.Printf("Error saving per-process syscall stats: %v", err)
+		}
 	}
 }
 
 // GetRecentStats retrieves recent syscall statistics
-func (s *syscallService) GetRecentStats(limit int) ([]models.SyscallStat, error) {
-	return s.repo.GetRecentSyscallStats(limit)
+func (s *syscallService) GetRecentStats(limit int, filter models.Filter) ([]models.SyscallStat, error) {
+	return s.repo.GetRecentSyscallStats(limit, filter)
+}
+
+// GetRecentProcessStats retrieves recent per-process syscall counts
+func (s *syscallService) GetRecentProcessStats(limit int, filter models.Filter) ([]models.ProcessSyscallStat, error) {
+	return s.repo.GetRecentProcessSyscallStats(limit, filter)
+}
+
+// Subscribe registers fn to receive every batch of collected host-wide syscall counts
+func (s *syscallService) Subscribe(fn func([]models.SyscallStat)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *syscallService) publish(events []models.SyscallStat) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(events)
+	}
 }
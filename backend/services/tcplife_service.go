@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
 	"ebpf-dashboard/models"
 	"ebpf-dashboard/repository"
 	"log"
@@ -13,24 +14,32 @@ import (
 type TCPLifeService interface {
 	StartCollecting() error
 	StopCollecting()
-	GetRecentEvents(limit int) ([]models.TCPLifeEvent, error)
+	GetRecentEvents(limit int, filter models.Filter) ([]models.TCPLifeEvent, error)
+	Subscribe(fn func([]models.TCPLifeEvent))
 }
 
 type tcpLifeService struct {
-	collector *collector.TCPLifeCollector
-	repo      *repository.TCPLifeRepository
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
+	collector  *collector.TCPLifeCollector
+	repo       repository.TCPLifeRepository
+	attributor enrich.Attributor
+	hostnames  *enrich.HostnameResolver
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	subMu       sync.RWMutex
+	subscribers []func([]models.TCPLifeEvent)
 }
 
-func NewTCPLifeService(repo *repository.TCPLifeRepository) TCPLifeService {
+func NewTCPLifeService(repo repository.TCPLifeRepository, attributor enrich.Attributor, hostnames *enrich.HostnameResolver) TCPLifeService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &tcpLifeService{
-		collector: collector.NewTCPLifeCollector(),
-		repo:      repo,
-		ctx:       ctx,
-		cancel:    cancel,
+		collector:  collector.NewTCPLifeCollector(),
+		repo:       repo,
+		attributor: attributor,
+		hostnames:  hostnames,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 }
 
@@ -66,15 +75,35 @@ func (s *tcpLifeService) processEvents() {
 		case <-ticker.C:
 			events := s.collector.GetEvents()
 			if len(events) > 0 {
+				for i := range events {
+					events[i].Attribution = s.attributor.Attribute(events[i].PID, 0)
+				}
+				s.hostnames.ResolveSessions(events)
 				if err := s.repo.SaveTCPLifeEvents(events); err != nil {
 					log.Printf("Error saving tcplife events: %v", err)
 				}
+				s.publish(events)
 			}
 		}
 	}
 }
 
 // GetRecentEvents retrieves recent TCP lifecycle events
-func (s *tcpLifeService) GetRecentEvents(limit int) ([]models.TCPLifeEvent, error) {
-	return s.repo.GetRecentTCPLifeEvents(limit)
+func (s *tcpLifeService) GetRecentEvents(limit int, filter models.Filter) ([]models.TCPLifeEvent, error) {
+	return s.repo.GetRecentTCPLifeEvents(limit, filter)
+}
+
+// Subscribe registers fn to receive every batch of collected TCP lifecycle events
+func (s *tcpLifeService) Subscribe(fn func([]models.TCPLifeEvent)) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func (s *tcpLifeService) publish(events []models.TCPLifeEvent) {
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+	for _, fn := range s.subscribers {
+		fn(events)
+	}
 }
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sessionSlack widens the lifetime of a session when matching retransmits,
+// as both are timestamped when stored rather than when they happened
+const sessionSlack = time.Second
+
+// lossyCandidates is how many of the slowest sessions in a window are
+// checked for retransmits
+const lossyCandidates = 1000
+
+type TCPRetransService interface {
+	Start()
+	Stop()
+	GetRecentRetransmits(limit int, filter models.Filter) ([]models.TCPRetransmit, error)
+	GetRecentDrops(limit int, filter models.Filter) ([]models.TCPDrop, error)
+	GetRetransmitSeries(q models.RetransmitQuery, step time.Duration) ([]models.RetransmitPoint, error)
+	GetDropSummary(since, until time.Time, limit int, filter models.Filter) ([]models.DropSummary, error)
+	GetLossySessions(since, until time.Time, minDurationMS float64, minRetransmits, limit int, filter models.Filter) ([]models.LossySession, error)
+}
+
+type tcpRetransService struct {
+	repo        repository.TCPRetransRepository
+	sessions    repository.TCPLifeRepository
+	retransmits *collector.TCPRetransCollector
+	drops       *collector.TCPDropCollector
+	attributor  enrich.Attributor
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewTCPRetransService(repo repository.TCPRetransRepository, sessions repository.TCPLifeRepository, attributor enrich.Attributor) TCPRetransService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &tcpRetransService{
+		repo:        repo,
+		sessions:    sessions,
+		retransmits: collector.NewTCPRetransCollector(),
+		drops:       collector.NewTCPDropCollector(),
+		attributor:  attributor,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start starts tcpretrans and tcpdrop. Either works without the other.
+func (s *tcpRetransService) Start() {
+	if err := s.retransmits.Start(); err != nil {
+		log.Printf("Failed to start tcpretrans collector: %v", err)
+	}
+	if err := s.drops.Start(); err != nil {
+		log.Printf("Failed to start tcpdrop collector: %v", err)
+	}
+
+	s.wg.Add(1)
+	go s.collectPeriodically()
+}
+
+// Stop stops both collectors
+func (s *tcpRetransService) Stop() {
+	s.cancel()
+	s.retransmits.Stop()
+	s.drops.Stop()
+	s.wg.Wait()
+}
+
+func (s *tcpRetransService) collectPeriodically() {
+	defer s.wg.Done()
+
+	// Collect every second, while the sockets are likely still open and
+	// their owners can be found
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.collectAndSave()
+		}
+	}
+}
+
+func (s *tcpRetransService) collectAndSave() {
+	if events := s.retransmits.GetEvents(); len(events) > 0 {
+		enrich.AttributeRetransmits(s.attributor, events)
+		if err := s.repo.SaveRetransmits(events); err != nil {
+			log.Printf("Error saving TCP retransmits: %v", err)
+		}
+	}
+
+	if events := s.drops.GetEvents(); len(events) > 0 {
+		enrich.AttributeDrops(s.attributor, events)
+		if err := s.repo.SaveDrops(events); err != nil {
+			log.Printf("Error saving TCP drops: %v", err)
+		}
+	}
+}
+
+// GetRecentRetransmits retrieves the most recent retransmits
+func (s *tcpRetransService) GetRecentRetransmits(limit int, filter models.Filter) ([]models.TCPRetransmit, error) {
+	return s.repo.GetRecentRetransmits(limit, filter)
+}
+
+// GetRecentDrops retrieves the most recent drops
+func (s *tcpRetransService) GetRecentDrops(limit int, filter models.Filter) ([]models.TCPDrop, error) {
+	return s.repo.GetRecentDrops(limit, filter)
+}
+
+// GetRetransmitSeries counts the retransmits selected by q per step, remote
+// endpoint and command, oldest first
+func (s *tcpRetransService) GetRetransmitSeries(q models.RetransmitQuery, step time.Duration) ([]models.RetransmitPoint, error) {
+	counts, err := s.repo.GetRetransmitCounts(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type pointKey struct {
+		time       time.Time
+		remoteAddr string
+		remotePort int
+		comm       string
+	}
+
+	var points []models.RetransmitPoint
+	index := make(map[pointKey]int)
+	for _, c := range counts {
+		key := pointKey{c.Timestamp.Truncate(step), c.RemoteAddr, c.RemotePort, c.Comm}
+		i, ok := index[key]
+		if !ok {
+			i = len(points)
+			index[key] = i
+			points = append(points, models.RetransmitPoint{
+				Time:       key.time,
+				RemoteAddr: c.RemoteAddr,
+				RemotePort: c.RemotePort,
+				Comm:       c.Comm,
+			})
+		}
+		addRetransmits(&points[i].Retransmits, &points[i].RTO, &points[i].TLP, c)
+	}
+	return points, nil
+}
+
+// GetDropSummary counts the drops in the window by reason, state and stack
+func (s *tcpRetransService) GetDropSummary(since, until time.Time, limit int, filter models.Filter) ([]models.DropSummary, error) {
+	return s.repo.GetDropSummary(since, until, limit, filter)
+}
+
+// GetLossySessions returns the sessions that closed in the window after at
+// least minDurationMS and saw at least minRetransmits retransmits on their
+// 4-tuple while open, most retransmits first. Only the lossyCandidates
+// slowest sessions are considered.
+func (s *tcpRetransService) GetLossySessions(since, until time.Time, minDurationMS float64, minRetransmits, limit int, filter models.Filter) ([]models.LossySession, error) {
+	sessions, err := s.sessions.GetSlowSessions(since, until, minDurationMS, lossyCandidates, filter)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+
+	// Retransmits of sockets closed before their owner was found carry no
+	// attribution, so only the host part of the filter applies to them
+	longest := time.Duration(sessions[0].DurationMS * float64(time.Millisecond))
+	counts, err := s.repo.GetRetransmitCounts(models.RetransmitQuery{
+		Since:  since.Add(-longest - sessionSlack),
+		Until:  until.Add(sessionSlack),
+		Filter: models.Filter{Host: filter.Host, HostLabels: filter.HostLabels},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byTuple := make(map[enrich.SocketTuple][]models.RetransmitCount)
+	for _, c := range counts {
+		t := enrich.NewSocketTuple(c.LocalAddr, c.LocalPort, c.RemoteAddr, c.RemotePort)
+		byTuple[t] = append(byTuple[t], c)
+	}
+
+	var results []models.LossySession
+	for _, session := range sessions {
+		t := enrich.NewSocketTuple(session.LocalAddr, session.LocalPort, session.RemoteAddr, session.RemotePort)
+		matches := byTuple[t]
+		if len(matches) == 0 {
+			continue
+		}
+
+		lossy := models.LossySession{
+			TCPLifeEvent: session,
+			StartedAt:    session.Timestamp.Add(-time.Duration(session.DurationMS * float64(time.Millisecond))),
+		}
+		from, to := lossy.StartedAt.Add(-sessionSlack), session.Timestamp.Add(sessionSlack)
+		for _, c := range matches {
+			if !c.Timestamp.Before(from) && !c.Timestamp.After(to) {
+				addRetransmits(&lossy.Retransmits, &lossy.RTO, &lossy.TLP, c)
+			}
+		}
+		if lossy.Retransmits > 0 && lossy.Retransmits >= minRetransmits {
+			results = append(results, lossy)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Retransmits > results[j].Retransmits })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// addRetransmits adds a count to a total and to the total of its type
+func addRetransmits(total, rto, tlp *int, c models.RetransmitCount) {
+	*total += c.Count
+	if c.Type == models.RetransmitTLP {
+		*tlp += c.Count
+	} else {
+		*rto += c.Count
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+type TCPRTTService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetLatestRTT(limit int, filter models.Filter) ([]models.TCPRTT, error)
+	GetPercentiles(q models.RTTQuery) ([]models.RTTPercentiles, error)
+	GetSeries(q models.RTTQuery, step time.Duration) ([]models.RTTPoint, error)
+}
+
+type tcpRTTService struct {
+	repo      repository.TCPRTTRepository
+	collector *collector.TCPRTTCollector
+	host      string
+	labels    models.Labels
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewTCPRTTService(repo repository.TCPRTTRepository, host string, labels models.Labels) TCPRTTService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &tcpRTTService{
+		repo:      repo,
+		collector: collector.NewTCPRTTCollector(),
+		host:      host,
+		labels:    labels,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *tcpRTTService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				rtts := s.collector.GetEvents()
+				if len(rtts) > 0 {
+					for i := range rtts {
+						rtts[i].Host = s.host
+						rtts[i].HostLabels = s.labels
+					}
+					if err := s.repo.SaveRTTSnapshot(rtts); err != nil {
+						log.Printf("Error saving TCP round-trip times: %v", err)
+					}
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *tcpRTTService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+// GetLatestRTT retrieves the most recent histogram buckets
+func (s *tcpRTTService) GetLatestRTT(limit int, filter models.Filter) ([]models.TCPRTT, error) {
+	return s.repo.GetLatestRTT(limit, filter)
+}
+
+// rttKey identifies the round-trip times from one host to one peer
+type rttKey struct {
+	host       string
+	remoteAddr string
+}
+
+// GetPercentiles summarizes the histograms selected by q per host and peer
+// over the whole window, slowest peers first
+func (s *tcpRTTService) GetPercentiles(q models.RTTQuery) ([]models.RTTPercentiles, error) {
+	buckets, err := s.repo.GetRTTBuckets(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []rttKey
+	groups := make(map[rttKey][]models.TCPRTT)
+	for _, b := range buckets {
+		key := rttKey{b.Host, b.RemoteAddr}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], b)
+	}
+
+	results := make([]models.RTTPercentiles, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, rttPercentiles(key, groups[key]))
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].P99US > results[j].P99US })
+	return results, nil
+}
+
+// GetSeries summarizes the histograms selected by q per step, host and peer,
+// oldest first
+func (s *tcpRTTService) GetSeries(q models.RTTQuery, step time.Duration) ([]models.RTTPoint, error) {
+	buckets, err := s.repo.GetRTTBuckets(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type pointKey struct {
+		time time.Time
+		rttKey
+	}
+
+	var keys []pointKey
+	groups := make(map[pointKey][]models.TCPRTT)
+	for _, b := range buckets {
+		key := pointKey{b.Timestamp.Truncate(step), rttKey{b.Host, b.RemoteAddr}}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], b)
+	}
+
+	results := make([]models.RTTPoint, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, models.RTTPoint{Time: key.time, RTTPercentiles: rttPercentiles(key.rttKey, groups[key])})
+	}
+
+	// Buckets come oldest first, which keeps the steps in order; order the
+	// peers of one step by host and address
+	sort.SliceStable(results, func(i, j int) bool {
+		if !results[i].Time.Equal(results[j].Time) {
+			return results[i].Time.Before(results[j].Time)
+		}
+		if results[i].Host != results[j].Host {
+			return results[i].Host < results[j].Host
+		}
+		return results[i].RemoteAddr < results[j].RemoteAddr
+	})
+	return results, nil
+}
+
+func rttPercentiles(key rttKey, buckets []models.TCPRTT) models.RTTPercentiles {
+	p := models.RTTPercentiles{
+		Host:       key.host,
+		RemoteAddr: key.remoteAddr,
+		P50US:      models.LatencyPercentile(buckets, 0.5),
+		P90US:      models.LatencyPercentile(buckets, 0.9),
+		P99US:      models.LatencyPercentile(buckets, 0.99),
+	}
+	for _, b := range buckets {
+		p.Count += b.Count
+		if b.Count > 0 && b.RangeMax > p.MaxUS {
+			p.MaxUS = b.RangeMax
+		}
+	}
+	return p
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"ebpf-dashboard/collector"
+	"ebpf-dashboard/enrich"
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"log"
+	"sync"
+	"time"
+)
+
+// stuckStates are the states connections are reported stuck in by default,
+// which healthy connections only pass through. TIME_WAIT is left out, as the
+// kernel expires it on its own.
+var stuckStates = []string{"SYN_SENT", "SYN_RECV", "CLOSE_WAIT", "FIN_WAIT1", "FIN_WAIT2", "LAST_ACK", "CLOSING"}
+
+type TCPStateService interface {
+	StartCollecting() error
+	StopCollecting()
+	GetRecentStateChanges(limit int, filter models.Filter) ([]models.TCPStateChange, error)
+	GetStateSeries(q models.StateQuery, step time.Duration) ([]models.StatePoint, error)
+	GetStuckConnections(since time.Time, minAge time.Duration, states []string, limit int, filter models.Filter) ([]models.StuckConnection, error)
+}
+
+type tcpStateService struct {
+	repo       repository.TCPStateRepository
+	collector  *collector.TCPStatesCollector
+	attributor *enrich.StateAttributor
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewTCPStateService(repo repository.TCPStateRepository, attributor enrich.Attributor) TCPStateService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &tcpStateService{
+		repo:       repo,
+		collector:  collector.NewTCPStatesCollector(),
+		attributor: enrich.NewStateAttributor(attributor),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// StartCollecting starts the background collection process
+func (s *tcpStateService) StartCollecting() error {
+	if err := s.collector.Start(); err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go s.processEvents()
+
+	return nil
+}
+
+// StopCollecting stops the background collection process
+func (s *tcpStateService) StopCollecting() {
+	s.cancel()
+	s.collector.Stop()
+	s.wg.Wait()
+}
+
+func (s *tcpStateService) processEvents() {
+	defer s.wg.Done()
+
+	// Collect every second, while the sockets are likely still open and
+	// their owners can be found
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			events := s.collector.GetEvents()
+			if len(events) > 0 {
+				s.attributor.Attribute(events)
+				if err := s.repo.SaveStateChanges(events); err != nil {
+					log.Printf("Error saving TCP state changes: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// GetRecentStateChanges retrieves the most recent state transitions
+func (s *tcpStateService) GetRecentStateChanges(limit int, filter models.Filter) ([]models.TCPStateChange, error) {
+	return s.repo.GetRecentStateChanges(limit, filter)
+}
+
+// GetStateSeries counts the transitions selected by q per step, new state
+// and command, oldest first
+func (s *tcpStateService) GetStateSeries(q models.StateQuery, step time.Duration) ([]models.StatePoint, error) {
+	counts, err := s.repo.GetStateCounts(q)
+	if err != nil {
+		return nil, err
+	}
+
+	type pointKey struct {
+		time  time.Time
+		state string
+		comm  string
+	}
+
+	var points []models.StatePoint
+	index := make(map[pointKey]int)
+	for _, c := range counts {
+		key := pointKey{c.Timestamp.Truncate(step), c.State, c.Comm}
+		i, ok := index[key]
+		if !ok {
+			i = len(points)
+			index[key] = i
+			points = append(points, models.StatePoint{Time: key.time, State: c.State, Comm: c.Comm})
+		}
+		points[i].Count += c.Count
+	}
+	return points, nil
+}
+
+// GetStuckConnections returns the sockets that changed state since the
+// given time and have been in one of states, stuckStates if empty, for at
+// least minAge, longest stuck first
+func (s *tcpStateService) GetStuckConnections(since time.Time, minAge time.Duration, states []string, limit int, filter models.Filter) ([]models.StuckConnection, error) {
+	if len(states) == 0 {
+		states = stuckStates
+	}
+
+	now := time.Now()
+	events, err := s.repo.GetStuckConnections(models.StuckQuery{
+		Since:  since,
+		Before: now.Add(-minAge),
+		States: states,
+		Filter: filter,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stuck := make([]models.StuckConnection, 0, len(events))
+	for _, e := range events {
+		stuck = append(stuck, models.StuckConnection{
+			TCPStateChange: e,
+			StuckSeconds:   now.Sub(e.Timestamp).Seconds(),
+		})
+	}
+	return stuck, nil
+}
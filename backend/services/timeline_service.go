@@ -0,0 +1,101 @@
+package services
+
+import (
+	"ebpf-dashboard/models"
+	"ebpf-dashboard/repository"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnknownEventType is returned when a timeline query asks for an event
+// type no source produces
+var ErrUnknownEventType = errors.New("unknown event type")
+
+type TimelineService interface {
+	// Types returns the event types known to the timeline
+	Types() []string
+	GetTimeline(q models.TimelineQuery, types []string) ([]models.TimelineEvent, *models.TimelineCursor, error)
+}
+
+type timelineService struct {
+	sources []repository.TimelineSource
+}
+
+func NewTimelineService(sources []repository.TimelineSource) TimelineService {
+	return &timelineService{sources: sources}
+}
+
+// Types returns the event types known to the timeline
+func (s *timelineService) Types() []string {
+	types := make([]string, 0, len(s.sources))
+	for _, source := range s.sources {
+		types = append(types, source.Type())
+	}
+	sort.Strings(types)
+	return types
+}
+
+// GetTimeline merges the events of all selected sources into one page, newest
+// first. Without explicit types, host-wide events such as disk spikes are left
+// out when the query is narrowed down to a process or pod. The returned cursor
+// points at the last event of the page and is nil on the last page.
+func (s *timelineService) GetTimeline(q models.TimelineQuery, types []string) ([]models.TimelineEvent, *models.TimelineCursor, error) {
+	selected, err := s.selectSources(q, types)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Ask every source for one more event than needed to know whether
+	// another page exists
+	sourceQuery := q
+	sourceQuery.Limit = q.Limit + 1
+
+	var events []models.TimelineEvent
+	for _, source := range selected {
+		sourceEvents, err := source.Events(sourceQuery)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s events: %w", source.Type(), err)
+		}
+		events = append(events, sourceEvents...)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Cursor().Before(events[j].Cursor())
+	})
+
+	if len(events) <= q.Limit {
+		return events, nil, nil
+	}
+	events = events[:q.Limit]
+	next := events[len(events)-1].Cursor()
+	return events, &next, nil
+}
+
+func (s *timelineService) selectSources(q models.TimelineQuery, types []string) ([]repository.TimelineSource, error) {
+	if len(types) == 0 {
+		var selected []repository.TimelineSource
+		for _, source := range s.sources {
+			if source.HostWide() && q.TargetsProcess() {
+				continue
+			}
+			selected = append(selected, source)
+		}
+		return selected, nil
+	}
+
+	byType := make(map[string]repository.TimelineSource, len(s.sources))
+	for _, source := range s.sources {
+		byType[source.Type()] = source
+	}
+
+	selected := make([]repository.TimelineSource, 0, len(types))
+	for _, t := range types {
+		source, ok := byType[t]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownEventType, t)
+		}
+		selected = append(selected, source)
+	}
+	return selected, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"ebpf-dashboard/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// webhookAttempts is how often a delivery is tried before it is dropped
+const webhookAttempts = 3
+
+// webhookDrainTimeout bounds how long Stop waits for queued notifications
+const webhookDrainTimeout = 10 * time.Second
+
+// AlertNotifier delivers alert state changes
+type AlertNotifier interface {
+	Start()
+	Stop()
+	// Notify queues a notification for url. It never blocks; notifications
+	// are dropped if the queue is full.
+	Notify(url string, n models.AlertNotification)
+}
+
+type webhookDelivery struct {
+	url          string
+	notification models.AlertNotification
+}
+
+type webhookNotifier struct {
+	client *http.Client
+	queue  chan webhookDelivery
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookNotifier returns a notifier that POSTs notifications as JSON
+func NewWebhookNotifier(timeout time.Duration) AlertNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookNotifier{
+		client: &http.Client{Timeout: timeout},
+		queue:  make(chan webhookDelivery, 100),
+		stop:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (n *webhookNotifier) Start() {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		for {
+			select {
+			case <-n.stop:
+				// Deliver what is queued, e.g. the last resolved notifications
+				for {
+					select {
+					case d := <-n.queue:
+						n.deliver(d)
+					default:
+						return
+					}
+				}
+			case d := <-n.queue:
+				n.deliver(d)
+			}
+		}
+	}()
+}
+
+// Stop delivers the queued notifications, giving up on those still pending
+// after webhookDrainTimeout
+func (n *webhookNotifier) Stop() {
+	close(n.stop)
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(webhookDrainTimeout):
+		log.Printf("Webhook deliveries still pending after %s, dropping them", webhookDrainTimeout)
+		n.cancel()
+		<-done
+	}
+	n.cancel()
+}
+
+func (n *webhookNotifier) Notify(url string, notification models.AlertNotification) {
+	select {
+	case n.queue <- webhookDelivery{url: url, notification: notification}:
+	default:
+		log.Printf("Webhook queue full, dropping %s notification for alert %d", notification.Status, notification.Alert.ID)
+	}
+}
+
+// deliver posts the notification, retrying with exponential backoff
+func (n *webhookNotifier) deliver(d webhookDelivery) {
+	body, err := json.Marshal(d.notification)
+	if err != nil {
+		log.Printf("Error encoding webhook notification: %v", err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err = n.post(d.url, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			break
+		}
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	log.Printf("Error delivering webhook to %s after %d attempts: %v", d.url, webhookAttempts, err)
+}
+
+func (n *webhookNotifier) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}